@@ -0,0 +1,266 @@
+package llmkit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+)
+
+// ErrorDiagnostic is the raw failure evidence from a CLI run.
+// Providers fill in whatever they observed; empty fields are ignored.
+type ErrorDiagnostic struct {
+	Provider string // "claude" or "codex"
+	Op       string // Operation that failed ("complete", "stream")
+
+	// Message is error text from a result or error event.
+	Message string
+	// Stderr is the CLI's standard error output.
+	Stderr string
+	// ExitCode is the CLI exit status; 0 when unknown or successful.
+	ExitCode int
+
+	// ResultSubtype is the Claude result subtype (for example "error_max_turns").
+	ResultSubtype string
+	// EventType is the Codex event type that carried the failure
+	// ("turn.failed" or "error").
+	EventType string
+}
+
+// text returns the combined diagnostic text used for matching.
+func (d ErrorDiagnostic) text() string {
+	parts := make([]string, 0, 2)
+	if m := strings.TrimSpace(d.Message); m != "" {
+		parts = append(parts, m)
+	}
+	if s := strings.TrimSpace(d.Stderr); s != "" && s != strings.TrimSpace(d.Message) {
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// errorRule maps diagnostic text or an HTTP status onto a sentinel.
+type errorRule struct {
+	sentinel  error
+	retryable bool
+	needles   []string
+	statuses  []int
+}
+
+// errorRules are checked in order; the first rule with a matching needle or
+// status wins. Limits and auth come before transport failures because CLI
+// messages for them often mention HTTP status codes too.
+var errorRules = []errorRule{
+	{ErrMaxTurns, false, []string{"max turns", "maximum number of turns", "max_turns"}, nil},
+	{ErrBudgetExceeded, false, []string{"max budget", "budget exceeded", "exceeded budget", "max_budget_usd"}, nil},
+	{ErrStructuredOutputFailed, false, []string{
+		"structured output retries", "failed to produce structured output", "invalid structured output",
+		"error_max_structured_output_retries", "does not match the output schema",
+	}, nil},
+	{ErrContextTooLong, false, []string{
+		"prompt is too long", "context length", "context window", "context_length_exceeded",
+		"maximum context", "input is too long", "too many tokens", "exceeds the model's context",
+	}, nil},
+	{ErrCredentialsNotFound, false, []string{
+		"not logged in", "please log in", "no api key", "missing api key", "api key not found",
+		"no credentials", "credentials not found", "openai_api_key", "anthropic_api_key is not set",
+	}, nil},
+	{ErrCredentialsExpired, false, []string{
+		"token has expired", "token expired", "session expired", "please run /login",
+		"run `claude login`", "run codex login", "authentication_error", "invalid api key",
+		"invalid x-api-key", "unauthorized", "refresh token",
+	}, []int{401}},
+	{ErrRateLimited, true, []string{
+		"rate limit", "rate_limit", "ratelimit", "too many requests", "usage limit",
+		"quota exceeded", "insufficient_quota",
+	}, []int{429}},
+	{ErrOverloaded, true, []string{"overloaded"}, []int{529}},
+	{ErrTimeout, true, []string{"timed out", "timeout", "deadline exceeded"}, nil},
+	{ErrUnavailable, true, []string{
+		"service unavailable", "bad gateway", "gateway timeout", "internal server error",
+		"api_error", "connection refused", "connection reset",
+		"stream disconnected", "econnreset", "network error",
+	}, []int{500, 502, 503, 504}},
+	{ErrInvalidRequest, false, []string{"invalid_request_error", "invalid request", "bad request"}, []int{400}},
+}
+
+// statusPattern finds an HTTP status only where the text labels it as one:
+// the CLI's "API Error: 429", "status 503", "status_code=502", "code: 400"
+// or "HTTP 500". Bare numbers such as token counts or request IDs never match.
+var statusPattern = regexp.MustCompile(`(?i)(?:api error|status(?:[ _]code)?|http(?:/\d(?:\.\d)?)?|\bcode)["':=\s]+([1-5]\d\d)\b`)
+
+// httpStatus returns the first labelled HTTP status in text, or 0.
+func httpStatus(text string) int {
+	m := statusPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	status, _ := strconv.Atoi(m[1])
+	return status
+}
+
+// ClassifyError maps a CLI diagnostic onto a root sentinel wrapped in *Error.
+// Claude result subtypes take precedence over text matching. The returned
+// error always wraps a sentinel when one matches, so errors.Is works, and
+// carries the raw diagnostic and any retry delay the provider suggested.
+func ClassifyError(diag ErrorDiagnostic) *Error {
+	return classifyErrorAt(diag, time.Now())
+}
+
+func classifyErrorAt(diag ErrorDiagnostic, now time.Time) *Error {
+	raw := diag.text()
+	sentinel, retryable := classifyDiagnostic(diag, raw)
+
+	detail := raw
+	if detail == "" {
+		switch {
+		case diag.ResultSubtype != "":
+			detail = diag.ResultSubtype
+		case diag.ExitCode != 0:
+			detail = fmt.Sprintf("exit status %d", diag.ExitCode)
+		default:
+			detail = "unknown error"
+		}
+	}
+
+	var err error
+	switch {
+	case sentinel == nil:
+		err = errors.New(detail)
+	case detail == sentinel.Error():
+		err = sentinel
+	default:
+		err = fmt.Errorf("%w: %s", sentinel, detail)
+	}
+
+	return &Error{
+		Provider:   diag.Provider,
+		Op:         diag.Op,
+		Err:        err,
+		Retryable:  retryable,
+		RetryAfter: parseRetryAfter(raw, now),
+		Diagnostic: raw,
+	}
+}
+
+func classifyDiagnostic(diag ErrorDiagnostic, raw string) (error, bool) {
+	switch diag.ResultSubtype {
+	case claudecontract.ResultSubtypeErrorMaxTurns:
+		return ErrMaxTurns, false
+	case claudecontract.ResultSubtypeErrorMaxBudgetUSD:
+		return ErrBudgetExceeded, false
+	case claudecontract.ResultSubtypeErrorMaxStructuredOutputRetries:
+		return ErrStructuredOutputFailed, false
+	}
+
+	lower := strings.ToLower(raw)
+	status := httpStatus(raw)
+	for _, rule := range errorRules {
+		if status != 0 && slices.Contains(rule.statuses, status) {
+			return rule.sentinel, rule.retryable
+		}
+		for _, needle := range rule.needles {
+			if strings.Contains(lower, needle) {
+				return rule.sentinel, rule.retryable
+			}
+		}
+	}
+
+	switch diag.ExitCode {
+	case 126, 127:
+		return ErrCLINotFound, false
+	case 124:
+		return ErrTimeout, true
+	}
+
+	// A Codex error event with no recognizable text is most often a dropped
+	// upstream stream, which is worth retrying.
+	if diag.EventType == codexcontract.EventError && raw == "" {
+		return ErrUnavailable, true
+	}
+	return nil, false
+}
+
+var (
+	// "Claude AI usage limit reached|1760000000" carries the reset time as a Unix epoch.
+	usageLimitEpochPattern = regexp.MustCompile(`(?i)limit reached\|(\d{9,})`)
+	// "retry-after: 30", "Retry after 2.5 seconds".
+	retryAfterPattern = regexp.MustCompile(`(?i)retry[- _]after["':=\s]+(\d+(?:\.\d+)?)\s*([a-z]*)`)
+	// "try again in 1.5s", "try again in 2 hours 5 minutes", "try again in 6m0s".
+	tryAgainPattern     = regexp.MustCompile(`(?i)(?:try again|retry|resets?) in\s+((?:\d+(?:\.\d+)?\s*[a-z]+[\s,]*(?:and\s+)?)+)`)
+	durationPartPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(milliseconds?|ms|seconds?|secs?|s|minutes?|mins?|m|hours?|hrs?|h|days?|d)\b`)
+	goDurationPattern   = regexp.MustCompile(`^(?:\d+(?:\.\d+)?(?:ms|h|m|s))+$`)
+)
+
+// parseRetryAfter extracts a suggested retry delay from provider text.
+func parseRetryAfter(text string, now time.Time) time.Duration {
+	if text == "" {
+		return 0
+	}
+	if m := usageLimitEpochPattern.FindStringSubmatch(text); m != nil {
+		if epoch, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			if d := time.Unix(epoch, 0).Sub(now); d > 0 {
+				return d
+			}
+		}
+	}
+	if m := retryAfterPattern.FindStringSubmatch(text); m != nil {
+		value, err := strconv.ParseFloat(m[1], 64)
+		if err == nil {
+			unit := m[2]
+			if unit == "" {
+				unit = "s"
+			}
+			if d := durationFromUnit(value, unit); d > 0 {
+				return d
+			}
+		}
+	}
+	if m := tryAgainPattern.FindStringSubmatch(text); m != nil {
+		return sumDurationParts(m[1])
+	}
+	return 0
+}
+
+func sumDurationParts(text string) time.Duration {
+	compact := strings.TrimRight(strings.TrimSpace(text), ".,")
+	if goDurationPattern.MatchString(compact) {
+		if d, err := time.ParseDuration(compact); err == nil {
+			return d
+		}
+	}
+	var total time.Duration
+	for _, part := range durationPartPattern.FindAllStringSubmatch(text, -1) {
+		value, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			continue
+		}
+		total += durationFromUnit(value, part[2])
+	}
+	return total
+}
+
+func durationFromUnit(value float64, unit string) time.Duration {
+	var base time.Duration
+	switch strings.ToLower(unit) {
+	case "ms", "millisecond", "milliseconds":
+		base = time.Millisecond
+	case "s", "sec", "secs", "second", "seconds":
+		base = time.Second
+	case "m", "min", "mins", "minute", "minutes":
+		base = time.Minute
+	case "h", "hr", "hrs", "hour", "hours":
+		base = time.Hour
+	case "d", "day", "days":
+		base = 24 * time.Hour
+	default:
+		return 0
+	}
+	return time.Duration(value * float64(base))
+}
@@ -0,0 +1,161 @@
+package llmkit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+type errorFixture struct {
+	Name       string `json:"name"`
+	Diagnostic struct {
+		Provider      string `json:"provider"`
+		Op            string `json:"op"`
+		Message       string `json:"message"`
+		Stderr        string `json:"stderr"`
+		ExitCode      int    `json:"exit_code"`
+		ResultSubtype string `json:"result_subtype"`
+		EventType     string `json:"event_type"`
+	} `json:"diagnostic"`
+	Want       string `json:"want"`
+	Retryable  bool   `json:"retryable"`
+	RetryAfter string `json:"retry_after"`
+}
+
+var fixtureSentinels = map[string]error{
+	"max_turns":                ErrMaxTurns,
+	"budget_exceeded":          ErrBudgetExceeded,
+	"structured_output_failed": ErrStructuredOutputFailed,
+	"overloaded":               ErrOverloaded,
+	"rate_limited":             ErrRateLimited,
+	"context_too_long":         ErrContextTooLong,
+	"credentials_expired":      ErrCredentialsExpired,
+	"credentials_not_found":    ErrCredentialsNotFound,
+	"unavailable":              ErrUnavailable,
+	"timeout":                  ErrTimeout,
+	"cli_not_found":            ErrCLINotFound,
+}
+
+func TestClassifyErrorFixtures(t *testing.T) {
+	data, err := os.ReadFile("testdata/error_fixtures.json")
+	if err != nil {
+		t.Fatalf("read fixtures: %v", err)
+	}
+	var fixtures []errorFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("parse fixtures: %v", err)
+	}
+	now := time.Date(2025, 12, 31, 22, 0, 0, 0, time.UTC)
+
+	for _, fx := range fixtures {
+		t.Run(fx.Name, func(t *testing.T) {
+			got := classifyErrorAt(ErrorDiagnostic{
+				Provider:      fx.Diagnostic.Provider,
+				Op:            fx.Diagnostic.Op,
+				Message:       fx.Diagnostic.Message,
+				Stderr:        fx.Diagnostic.Stderr,
+				ExitCode:      fx.Diagnostic.ExitCode,
+				ResultSubtype: fx.Diagnostic.ResultSubtype,
+				EventType:     fx.Diagnostic.EventType,
+			}, now)
+
+			if fx.Want == "" {
+				for name, sentinel := range fixtureSentinels {
+					if errors.Is(got, sentinel) {
+						t.Fatalf("error %v unexpectedly matched %s", got, name)
+					}
+				}
+			} else {
+				sentinel, ok := fixtureSentinels[fx.Want]
+				if !ok {
+					t.Fatalf("unknown fixture sentinel %q", fx.Want)
+				}
+				if !errors.Is(got, sentinel) {
+					t.Fatalf("error %v does not wrap %v", got, sentinel)
+				}
+			}
+			if got.Retryable != fx.Retryable || IsRetryable(got) != fx.Retryable {
+				t.Fatalf("Retryable = %v, want %v", got.Retryable, fx.Retryable)
+			}
+			if got.Provider != fx.Diagnostic.Provider || got.Op != fx.Diagnostic.Op {
+				t.Fatalf("provider/op = %q/%q", got.Provider, got.Op)
+			}
+
+			var want time.Duration
+			if fx.RetryAfter != "" {
+				want, err = time.ParseDuration(fx.RetryAfter)
+				if err != nil {
+					t.Fatalf("bad fixture retry_after: %v", err)
+				}
+			}
+			if got.RetryAfter != want {
+				t.Fatalf("RetryAfter = %v, want %v", got.RetryAfter, want)
+			}
+			if d, ok := RetryAfter(got); ok != (want > 0) || d != want {
+				t.Fatalf("RetryAfter(err) = %v, %v", d, ok)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorKeepsRawDiagnostic(t *testing.T) {
+	err := ClassifyError(ErrorDiagnostic{
+		Provider: "codex",
+		Op:       "stream",
+		Message:  "stream disconnected before completion",
+		Stderr:   "WARN retrying request",
+	})
+	if err.Diagnostic != "stream disconnected before completion\nWARN retrying request" {
+		t.Fatalf("Diagnostic = %q", err.Diagnostic)
+	}
+	if err.Error() != "codex stream: LLM service unavailable: stream disconnected before completion\nWARN retrying request" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	if !IsLimitError(ClassifyError(ErrorDiagnostic{ResultSubtype: "error_max_turns"})) {
+		t.Fatal("max turns should be a limit error")
+	}
+}
+
+func TestClassifyErrorMatchesStatusCodesOnlyWhenLabelled(t *testing.T) {
+	for _, msg := range []string{
+		"request req_401abc failed to parse the tool input",
+		"used 4290 tokens before the tool crashed",
+		"line 503: unexpected token",
+		"wrote 1500 bytes, 502 remaining, then the tool crashed",
+		"error 529 in user script",
+		"Writing the JSON schema file failed",
+		"tool returned structured output we could not parse",
+	} {
+		if got := ClassifyError(ErrorDiagnostic{Message: msg}); classifiedSentinel(got) != nil {
+			t.Errorf("ClassifyError(%q) = %v, want no sentinel", msg, got)
+		}
+	}
+
+	for msg, want := range map[string]error{
+		"API Error: 429 slow down":       ErrRateLimited,
+		"upstream returned status 503":   ErrUnavailable,
+		`{"status_code": 502}`:           ErrUnavailable,
+		"HTTP 401 from the gateway":      ErrCredentialsExpired,
+		"API Error: 400 something wrong": ErrInvalidRequest,
+		"API Error: 529":                 ErrOverloaded,
+	} {
+		if got := ClassifyError(ErrorDiagnostic{Message: msg}); !errors.Is(got, want) {
+			t.Errorf("ClassifyError(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+// classifiedSentinel returns the root sentinel err wraps, or nil.
+func classifiedSentinel(err error) error {
+	for _, sentinel := range fixtureSentinels {
+		if errors.Is(err, sentinel) {
+			return sentinel
+		}
+	}
+	if errors.Is(err, ErrInvalidRequest) {
+		return ErrInvalidRequest
+	}
+	return nil
+}
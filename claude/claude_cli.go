@@ -2,8 +2,10 @@ package claude
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,6 +16,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/randalmurphal/llmkit/v2"
 	"github.com/randalmurphal/llmkit/v2/claudecontract"
 )
 
@@ -63,6 +66,7 @@ type ClaudeCLI struct {
 	// Permissions
 	dangerouslySkipPermissions bool
 	permissionMode             PermissionMode
+	permissionHandler          PermissionHandler
 	settingSources             []string
 
 	// Context
@@ -212,6 +216,15 @@ func WithPermissionMode(mode PermissionMode) ClaudeOption {
 	return func(c *ClaudeCLI) { c.permissionMode = mode }
 }
 
+// WithPermissionHandler decides tool calls in Go during StreamJSON and
+// Complete. The CLI is started with --permission-prompt-tool stdio instead
+// of --dangerously-skip-permissions, the prompt is written to stdin as
+// stream-json, and each decision is also emitted as a StreamEventPermission
+// event. See session.WithPermissionHandler.
+func WithPermissionHandler(h PermissionHandler) ClaudeOption {
+	return func(c *ClaudeCLI) { c.permissionHandler = h }
+}
+
 // WithSettingSources specifies which setting sources to use.
 // Valid values: "project", "local", "user"
 func WithSettingSources(sources []string) ClaudeOption {
@@ -510,10 +523,12 @@ func (c *ClaudeCLI) Complete(ctx context.Context, req CompletionRequest) (*Compl
 	}
 
 	resp, err := StreamToCompleteWithCallback(ctx, events, result, req.OnEvent)
+	if resp != nil {
+		resp.Duration = time.Since(start)
+	}
 	if err != nil {
-		return nil, err
+		return resp, err
 	}
-	resp.Duration = time.Since(start)
 
 	// Validate structured output when schema was requested.
 	// --json-schema uses constrained decoding which guarantees schema-compliant output,
@@ -562,6 +577,26 @@ func (c *ClaudeCLI) StreamJSON(ctx context.Context, req CompletionRequest) (<-ch
 	cmd := exec.CommandContext(ctx, c.resolvedPath(), args...)
 	c.setupCmd(cmd)
 	cmd.Stdin = nil // Use /dev/null to prevent TTY/raw mode errors in containers
+	var input []byte
+	if c.streamsInput(req.Messages) {
+		var err error
+		if input, err = buildStreamInput(req.Messages); err != nil {
+			return nil, nil, NewError("stream_json", fmt.Errorf("encode stream input: %w", err), false)
+		}
+		cmd.Stdin = bytes.NewReader(input)
+	}
+
+	// A permission handler answers control requests on stdin, so stdin stays
+	// open until the result arrives.
+	var control *stdioControl
+	if c.permissionHandler != nil {
+		cmd.Stdin = nil
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, nil, NewError("stream_json", fmt.Errorf("create stdin pipe: %w", err), false)
+		}
+		control = newStdioControl(ctx, c.permissionHandler, stdin)
+	}
 
 	// Run in separate process group so we can kill all child processes on cancel.
 	// Claude Code spawns subprocesses (test runners, build tools, MCP servers) that
@@ -572,6 +607,9 @@ func (c *ClaudeCLI) StreamJSON(ctx context.Context, req CompletionRequest) (<-ch
 	if err != nil {
 		return nil, nil, NewError("stream_json", fmt.Errorf("create stdout pipe: %w", err), false)
 	}
+	// Captured so a failed run can be classified (rate limit, expired login, ...).
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
 
 	if err := cmd.Start(); err != nil {
 		return nil, nil, NewError("stream_json", fmt.Errorf("start command: %w", err), false)
@@ -580,7 +618,10 @@ func (c *ClaudeCLI) StreamJSON(ctx context.Context, req CompletionRequest) (<-ch
 	events := make(chan StreamEvent, 100)
 	result := newStreamResult()
 
-	go c.processStreamJSON(ctx, stdout, cmd, events, result)
+	if control != nil {
+		go control.writeInput(input)
+	}
+	go c.processStreamJSON(ctx, stdout, stderr, cmd, control, events, result)
 
 	return events, result, nil
 }
@@ -647,11 +688,16 @@ func (c *ClaudeCLI) buildArgsForStreamJSON(req CompletionRequest) []string {
 func (c *ClaudeCLI) processStreamJSON(
 	ctx context.Context,
 	stdout io.ReadCloser,
+	stderr *stderrTail,
 	cmd *exec.Cmd,
+	control *stdioControl,
 	events chan<- StreamEvent,
 	result *StreamResult,
 ) {
 	defer close(events)
+	if control != nil {
+		defer control.finish()
+	}
 
 	scanner := bufio.NewScanner(stdout)
 	// Increase buffer size for large messages (100MB max).
@@ -683,6 +729,10 @@ func (c *ClaudeCLI) processStreamJSON(
 			}
 		}
 
+		if control != nil && control.handleLine(line, sessionID, events) {
+			continue
+		}
+
 		event, err := parseStreamEvent(line)
 		if err != nil {
 			// Log parse error but continue
@@ -699,6 +749,9 @@ func (c *ClaudeCLI) processStreamJSON(
 		// Capture result for the future
 		if event.Type == StreamEventResult && event.Result != nil {
 			finalResult = event.Result
+			if control != nil {
+				control.closeInput() // Lets the CLI exit after the turn
+			}
 		}
 
 		select {
@@ -723,7 +776,15 @@ func (c *ClaudeCLI) processStreamJSON(
 	// Wait for command to finish
 	if err := cmd.Wait(); err != nil {
 		if finalResult == nil {
-			result.complete(nil, NewError("stream_json", fmt.Errorf("command failed: %w", err), false))
+			diag := llmkit.ErrorDiagnostic{
+				Message: fmt.Sprintf("command failed: %v", err),
+				Stderr:  sanitizeStderr(stderr.String()),
+			}
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				diag.ExitCode = exitErr.ExitCode()
+			}
+			result.complete(nil, classifyError("stream_json", diag))
 			return
 		}
 		// If we have a result, the error might just be non-zero exit from tool use
@@ -978,7 +1039,9 @@ func (c *ClaudeCLI) appendToolArgs(args []string) []string {
 
 // appendPermissionArgs adds permission and settings arguments.
 func (c *ClaudeCLI) appendPermissionArgs(args []string) []string {
-	if c.dangerouslySkipPermissions {
+	if c.permissionHandler != nil {
+		args = append(args, claudecontract.FlagPermissionPromptTool, claudecontract.PermissionPromptToolStdio)
+	} else if c.dangerouslySkipPermissions {
 		args = append(args, claudecontract.FlagDangerouslySkipPermissions)
 	}
 	if c.permissionMode != "" {
@@ -1086,7 +1149,29 @@ func (c *ClaudeCLI) appendDebugArgs(args []string) []string {
 }
 
 // appendMessagePrompt converts messages to a CLI prompt and appends it.
+// Image and document blocks can only be sent as a stream-json user message,
+// and permission prompts need stdin for control messages, so those requests
+// switch to --input-format stream-json and the prompt is written to stdin by
+// StreamJSON instead.
 func (c *ClaudeCLI) appendMessagePrompt(args []string, messages []Message) []string {
+	if c.streamsInput(messages) {
+		if c.inputFormat == "" {
+			args = append(args, claudecontract.FlagInputFormat, claudecontract.FormatStreamJSON)
+		}
+		return args
+	}
+
+	// Prompt is a positional argument (not a flag)
+	// Note: -p/--print is for non-interactive mode, NOT for passing the prompt
+	if promptStr := buildMessagePrompt(messages); promptStr != "" {
+		args = append(args, promptStr)
+	}
+
+	return args
+}
+
+// buildMessagePrompt flattens messages into the single prompt the CLI expects.
+func buildMessagePrompt(messages []Message) string {
 	// Claude CLI expects a single prompt, so concatenate user messages
 	var prompt strings.Builder
 	for _, msg := range messages {
@@ -1103,15 +1188,47 @@ func (c *ClaudeCLI) appendMessagePrompt(args []string, messages []Message) []str
 			}
 		}
 	}
+	return strings.TrimSpace(prompt.String())
+}
 
-	// Prompt is a positional argument (not a flag)
-	// Note: -p/--print is for non-interactive mode, NOT for passing the prompt
-	promptStr := strings.TrimSpace(prompt.String())
-	if promptStr != "" {
-		args = append(args, promptStr)
+// streamsInput reports whether the prompt goes to stdin as stream-json.
+func (c *ClaudeCLI) streamsInput(messages []Message) bool {
+	return c.permissionHandler != nil || hasInputBlocks(messages)
+}
+
+func hasInputBlocks(messages []Message) bool {
+	for _, msg := range messages {
+		if msg.Role == RoleUser && len(msg.Blocks) > 0 {
+			return true
+		}
 	}
+	return false
+}
 
-	return args
+// buildStreamInput encodes messages as one stream-json user message: the
+// flattened text prompt followed by every user message's blocks in order.
+func buildStreamInput(messages []Message) ([]byte, error) {
+	var content []InputBlock
+	if prompt := buildMessagePrompt(messages); prompt != "" {
+		content = append(content, InputBlock{Type: "text", Text: prompt})
+	}
+	for _, msg := range messages {
+		if msg.Role == RoleUser {
+			content = append(content, msg.Blocks...)
+		}
+	}
+
+	line, err := json.Marshal(map[string]any{
+		"type": "user",
+		"message": map[string]any{
+			"role":    "user",
+			"content": content,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
 }
 
 // parseResponseWithSchema extracts response data from CLI output.
@@ -1167,7 +1284,7 @@ func (c *ClaudeCLI) parseResponseWithSchema(data []byte, schema string) (*Comple
 func (c *ClaudeCLI) parseJSONResponseWithSchema(cliResp *CLIResponse, schema string) (*CompletionResponse, error) {
 	var content string
 
-	if schema != "" {
+	if schema != "" && !cliResp.IsError {
 		// Schema was used - MUST have structured_output, no fallback
 		if len(cliResp.StructuredOutput) == 0 {
 			// Provide context about what we got instead
@@ -1179,7 +1296,7 @@ func (c *ClaudeCLI) parseJSONResponseWithSchema(cliResp *CLIResponse, schema str
 		}
 		content = string(cliResp.StructuredOutput)
 	} else {
-		// No schema - use result field
+		// No schema, or an error result - use result field
 		content = cliResp.Result
 	}
 
@@ -1197,13 +1314,6 @@ func (c *ClaudeCLI) parseJSONResponseWithSchema(cliResp *CLIResponse, schema str
 		},
 	}
 
-	// Determine finish reason from response type
-	if cliResp.IsError {
-		resp.FinishReason = "error"
-	} else {
-		resp.FinishReason = "stop"
-	}
-
 	// Get model from modelUsage if available
 	for model := range cliResp.ModelUsage {
 		resp.Model = model
@@ -1215,23 +1325,47 @@ func (c *ClaudeCLI) parseJSONResponseWithSchema(cliResp *CLIResponse, schema str
 		resp.Model = c.model
 	}
 
+	if cliResp.IsError {
+		resp.FinishReason = "error"
+		return resp, classifyError("result", llmkit.ErrorDiagnostic{
+			Message:       cliResp.Result,
+			ResultSubtype: cliResp.Subtype,
+		})
+	}
+	resp.FinishReason = "stop"
 	return resp, nil
 }
 
-// isRetryableError checks if an error message indicates a transient error.
-func isRetryableError(errMsg string) bool {
-	errLower := strings.ToLower(errMsg)
-	return strings.Contains(errLower, "rate limit") ||
-		strings.Contains(errLower, "timeout") ||
-		strings.Contains(errLower, "overloaded") ||
-		strings.Contains(errLower, "503") ||
-		strings.Contains(errLower, "529")
-}
-
 // maxStderrLength limits stderr output in error messages to prevent
 // leaking sensitive information and keeping errors readable.
 const maxStderrLength = 500
 
+// maxStderrCapture bounds the stderr kept from a streaming run. Long agentic
+// runs can log for hours; only the end matters for classifying a failure.
+const maxStderrCapture = 64 * 1024
+
+// stderrTail is an io.Writer that keeps the last maxStderrCapture bytes
+// written to it. exec.Cmd writes to it from a single goroutine, and it is
+// read only after Wait returns.
+type stderrTail struct {
+	buf []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	n := len(p)
+	if n >= maxStderrCapture {
+		t.buf = append(t.buf[:0], p[n-maxStderrCapture:]...)
+		return n, nil
+	}
+	if over := len(t.buf) + n - maxStderrCapture; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return n, nil
+}
+
+func (t *stderrTail) String() string { return string(t.buf) }
+
 // sanitizeStderr prepares stderr output for inclusion in error messages.
 // It truncates long output and redacts common sensitive patterns.
 func sanitizeStderr(stderr string) string {
@@ -1,26 +1,40 @@
 package claude
 
 import (
-	"errors"
 	"fmt"
+
+	"github.com/randalmurphal/llmkit/v2"
 )
 
 // Sentinel errors for LLM operations.
+// They alias the root llmkit sentinels so errors.Is matches either name.
 var (
 	// ErrUnavailable indicates the LLM service is unavailable.
-	ErrUnavailable = errors.New("LLM service unavailable")
+	ErrUnavailable = llmkit.ErrUnavailable
 
 	// ErrContextTooLong indicates the input exceeds the context window.
-	ErrContextTooLong = errors.New("context exceeds maximum length")
+	ErrContextTooLong = llmkit.ErrContextTooLong
 
 	// ErrRateLimited indicates the request was rate limited.
-	ErrRateLimited = errors.New("rate limited")
+	ErrRateLimited = llmkit.ErrRateLimited
 
 	// ErrInvalidRequest indicates the request is malformed.
-	ErrInvalidRequest = errors.New("invalid request")
+	ErrInvalidRequest = llmkit.ErrInvalidRequest
 
 	// ErrTimeout indicates the request timed out.
-	ErrTimeout = errors.New("request timed out")
+	ErrTimeout = llmkit.ErrTimeout
+
+	// ErrOverloaded indicates the Anthropic API is overloaded.
+	ErrOverloaded = llmkit.ErrOverloaded
+
+	// ErrMaxTurns indicates the run hit --max-turns.
+	ErrMaxTurns = llmkit.ErrMaxTurns
+
+	// ErrBudgetExceeded indicates the run hit --max-budget-usd.
+	ErrBudgetExceeded = llmkit.ErrBudgetExceeded
+
+	// ErrStructuredOutputFailed indicates --json-schema output could not be produced.
+	ErrStructuredOutputFailed = llmkit.ErrStructuredOutputFailed
 )
 
 // Error wraps LLM errors with context.
@@ -48,3 +62,14 @@ func NewError(op string, err error, retryable bool) *Error {
 		Retryable: retryable,
 	}
 }
+
+// classifyError wraps a classified CLI failure so callers can match root
+// sentinels with errors.Is and read RetryAfter via llmkit.RetryAfter.
+func classifyError(op string, diag llmkit.ErrorDiagnostic) *Error {
+	classified := llmkit.ClassifyError(diag)
+	return &Error{
+		Op:        op,
+		Err:       classified,
+		Retryable: classified.Retryable,
+	}
+}
@@ -2,10 +2,15 @@ package claude
 
 import (
 	"encoding/json"
+	"errors"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/randalmurphal/llmkit/v2"
 )
 
 // mustParseResponse is a test helper that calls parseResponseWithSchema with no schema.
@@ -157,30 +162,6 @@ func TestParseResponse(t *testing.T) {
 	}
 }
 
-func TestIsRetryableError(t *testing.T) {
-	tests := []struct {
-		errMsg    string
-		retryable bool
-	}{
-		{"rate limit exceeded", true},
-		{"Rate Limit", true},
-		{"request timeout", true},
-		{"server overloaded", true},
-		{"503 service unavailable", true},
-		{"error 529", true},
-		{"invalid request", false},
-		{"authentication failed", false},
-		{"", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.errMsg, func(t *testing.T) {
-			result := isRetryableError(tt.errMsg)
-			assert.Equal(t, tt.retryable, result)
-		})
-	}
-}
-
 func TestParseJSONResponse(t *testing.T) {
 	client := NewClaudeCLI(WithModel("fallback-model"))
 
@@ -231,30 +212,6 @@ func TestParseJSONResponse(t *testing.T) {
 				CacheReadInputTokens:     200,
 			},
 		},
-		{
-			name: "error response",
-			data: []byte(`{
-				"type": "result",
-				"subtype": "error",
-				"is_error": true,
-				"result": "Something went wrong",
-				"session_id": "err-session",
-				"total_cost_usd": 0.01,
-				"usage": {
-					"input_tokens": 10,
-					"output_tokens": 0
-				}
-			}`),
-			wantContent:   "Something went wrong",
-			wantSessionID: "err-session",
-			wantCostUSD:   0.01,
-			wantModel:     "fallback-model", // Falls back to client model
-			wantTokens: TokenUsage{
-				InputTokens:  10,
-				OutputTokens: 0,
-				TotalTokens:  10,
-			},
-		},
 		{
 			name:          "text response fallback",
 			data:          []byte("Plain text response"),
@@ -315,6 +272,30 @@ func TestParseJSONResponse(t *testing.T) {
 	}
 }
 
+func TestParseJSONResponseErrorResult(t *testing.T) {
+	client := NewClaudeCLI(WithModel("fallback-model"))
+	data := []byte(`{
+		"type": "result",
+		"subtype": "error_max_turns",
+		"is_error": true,
+		"result": "Reached max turns",
+		"session_id": "err-session",
+		"total_cost_usd": 0.01,
+		"usage": {"input_tokens": 10, "output_tokens": 0}
+	}`)
+
+	for _, schema := range []string{"", `{"type": "object"}`} {
+		resp, err := client.parseResponseWithSchema(data, schema)
+		if !errors.Is(err, llmkit.ErrMaxTurns) {
+			t.Fatalf("schema %q: err = %v, want ErrMaxTurns", schema, err)
+		}
+		require.NotNil(t, resp)
+		assert.Equal(t, "error", resp.FinishReason)
+		assert.Equal(t, "Reached max turns", resp.Content)
+		assert.Equal(t, 0.01, resp.CostUSD)
+	}
+}
+
 func TestBuildArgsNewOptions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -731,3 +712,50 @@ func TestResolvedPath(t *testing.T) {
 		assert.Equal(t, "claude", client.resolvedPath())
 	})
 }
+
+func TestStderrTailKeepsLastBytes(t *testing.T) {
+	var tail stderrTail
+	chunk := []byte(strings.Repeat("x", 1024))
+	for i := 0; i < 100; i++ {
+		_, err := tail.Write(chunk)
+		require.NoError(t, err)
+	}
+	_, _ = tail.Write([]byte("rate limit exceeded"))
+	assert.Len(t, tail.String(), maxStderrCapture)
+	assert.True(t, strings.HasSuffix(tail.String(), "rate limit exceeded"))
+
+	_, _ = tail.Write([]byte(strings.Repeat("y", 2*maxStderrCapture)))
+	assert.Len(t, tail.String(), maxStderrCapture)
+	assert.NotContains(t, tail.String(), "x")
+}
+
+func TestStreamInputForImageBlocks(t *testing.T) {
+	client := NewClaudeCLI()
+	req := CompletionRequest{Messages: []Message{{
+		Role:    RoleUser,
+		Content: "what is this?",
+		Blocks: []InputBlock{{
+			Type:   "image",
+			Source: &BlockSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0K"},
+		}},
+	}}}
+
+	args := client.buildArgsForStreamJSON(req)
+	assert.Contains(t, args, "--input-format")
+	assert.NotContains(t, args, "what is this?")
+
+	input, err := buildStreamInput(req.Messages)
+	assert.NoError(t, err)
+	var line struct {
+		Type    string `json:"type"`
+		Message struct {
+			Role    string       `json:"role"`
+			Content []InputBlock `json:"content"`
+		} `json:"message"`
+	}
+	assert.NoError(t, json.Unmarshal(input, &line))
+	assert.Equal(t, "user", line.Type)
+	assert.Len(t, line.Message.Content, 2)
+	assert.Equal(t, "what is this?", line.Message.Content[0].Text)
+	assert.Equal(t, "image/png", line.Message.Content[1].Source.MediaType)
+}
@@ -271,6 +271,19 @@ func ReadFile(path string) ([]session.JSONLMessage, error) {
 	return r.ReadAll()
 }
 
+// DefaultProjectsDir returns $CLAUDE_CONFIG_DIR/projects, or
+// ~/.claude/projects when CLAUDE_CONFIG_DIR is unset.
+func DefaultProjectsDir() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".claude", "projects"), nil
+}
+
 // FindSessionFiles returns all JSONL files in a Claude projects directory.
 // projectsDir should be ~/.claude/projects/
 func FindSessionFiles(projectsDir string) ([]string, error) {
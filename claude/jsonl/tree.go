@@ -0,0 +1,441 @@
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strings"
+
+	"github.com/randalmurphal/llmkit/v2/claude/session"
+)
+
+// Messages returns an iterator over the file's messages, read one line at a
+// time so large files are never held in memory. Malformed lines are
+// skipped, as in ReadAll; read errors are yielded once and end iteration.
+func (r *Reader) Messages() iter.Seq2[session.JSONLMessage, error] {
+	return func(yield func(session.JSONLMessage, error) bool) {
+		if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+			yield(session.JSONLMessage{}, fmt.Errorf("seek to start: %w", err))
+			return
+		}
+		err := scanLines(r.file, func(line []byte, _ int64) bool {
+			msg, err := session.ParseJSONLMessage(line)
+			if err != nil {
+				return true
+			}
+			return yield(*msg, nil)
+		})
+		if err != nil {
+			yield(session.JSONLMessage{}, err)
+		}
+	}
+}
+
+// Messages returns an iterator over the messages in the JSONL file at path.
+// The file is closed when iteration ends.
+func Messages(path string) iter.Seq2[session.JSONLMessage, error] {
+	return func(yield func(session.JSONLMessage, error) bool) {
+		r, err := NewReader(path)
+		if err != nil {
+			yield(session.JSONLMessage{}, err)
+			return
+		}
+		defer r.Close()
+		for msg, err := range r.Messages() {
+			if !yield(msg, err) {
+				return
+			}
+		}
+	}
+}
+
+// scanLines calls fn with each non-empty line (without its newline) and the
+// line's byte offset until fn returns false. Lines have no length limit.
+func scanLines(rd io.Reader, fn func(line []byte, offset int64) bool) error {
+	reader := bufio.NewReaderSize(rd, 64*1024)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		start := offset
+		offset += int64(len(line))
+		if trimmed := trimNewline(line); len(trimmed) > 0 {
+			if !fn(trimmed, start) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read jsonl: %w", err)
+		}
+	}
+}
+
+func trimNewline(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line
+}
+
+// Node is one message in a conversation tree. Nodes hold only structure and
+// the message's file offset; use Tree.Message or Tree.Messages to load
+// content.
+type Node struct {
+	UUID       string
+	ParentUUID string
+	Type       string // "user", "assistant", "system", ...
+	Timestamp  string
+	// Offset is the byte offset of the message's line in the file.
+	Offset int64
+	// IsSidechain marks messages of a subagent (Task tool) conversation.
+	IsSidechain bool
+
+	Parent   *Node
+	Children []*Node // In file order
+
+	// Sidechains holds the root messages of subagent conversations spawned
+	// by a Task tool call in this message.
+	Sidechains []*Node
+	// TaskToolUseID is the Task tool_use ID that spawned this sidechain root.
+	TaskToolUseID string
+}
+
+// IsLeaf reports whether the node has no children.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Tree is the message DAG of a Claude session file.
+type Tree struct {
+	path  string
+	nodes map[string]*Node
+
+	// Roots are main-conversation messages without a known parent, in file
+	// order. Compacted or truncated sessions can have several.
+	Roots []*Node
+	// Active is the leaf of the branch Claude continues from: the last
+	// main-conversation message written to the file.
+	Active *Node
+	// UnlinkedSidechains are sidechain roots that could not be matched to a
+	// Task tool call.
+	UnlinkedSidechains []*Node
+}
+
+// taskLink records a Task tool call for sidechain matching.
+type taskLink struct {
+	node   *Node
+	id     string
+	prompt string
+}
+
+// treeLine holds the fields BuildTree needs from each line.
+type treeLine struct {
+	UUID        string  `json:"uuid"`
+	ParentUUID  *string `json:"parentUuid"`
+	Type        string  `json:"type"`
+	Timestamp   string  `json:"timestamp"`
+	IsSidechain bool    `json:"isSidechain"`
+	Message     *struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// BuildTree reads a Claude session file and rebuilds its conversation tree.
+// Lines are streamed, and only structure and offsets are kept in memory.
+//
+// Subagent sidechains are matched to the Task tool call whose prompt equals
+// the sidechain's first user message, in order.
+func BuildTree(path string) (*Tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open jsonl file: %w", err)
+	}
+	defer f.Close()
+
+	t := &Tree{path: path, nodes: make(map[string]*Node)}
+	var order []*Node
+	var tasks []taskLink
+	sidechainPrompts := make(map[*Node]string)
+
+	err = scanLines(f, func(line []byte, offset int64) bool {
+		var tl treeLine
+		if json.Unmarshal(line, &tl) != nil || tl.UUID == "" {
+			return true // Malformed lines and summaries have no place in the tree
+		}
+		n := &Node{
+			UUID:        tl.UUID,
+			Type:        tl.Type,
+			Timestamp:   tl.Timestamp,
+			Offset:      offset,
+			IsSidechain: tl.IsSidechain,
+		}
+		if tl.ParentUUID != nil {
+			n.ParentUUID = *tl.ParentUUID
+		}
+		if _, dup := t.nodes[n.UUID]; dup {
+			return true
+		}
+		t.nodes[n.UUID] = n
+		order = append(order, n)
+
+		if tl.Message == nil {
+			return true
+		}
+		switch {
+		case tl.Type == "assistant":
+			for _, block := range contentBlocks(tl.Message.Content) {
+				if block.Type == "tool_use" && isTaskTool(block.Name) {
+					var input struct {
+						Prompt string `json:"prompt"`
+					}
+					_ = json.Unmarshal(block.Input, &input) // Unmatched without a prompt
+					tasks = append(tasks, taskLink{node: n, id: block.ID, prompt: strings.TrimSpace(input.Prompt)})
+				}
+			}
+		case tl.Type == "user" && tl.IsSidechain && n.ParentUUID == "":
+			sidechainPrompts[n] = strings.TrimSpace(promptText(tl.Message.Content))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range order {
+		parent := t.nodes[n.ParentUUID]
+		switch {
+		case parent != nil:
+			n.Parent = parent
+			parent.Children = append(parent.Children, n)
+		case n.IsSidechain:
+			t.linkSidechain(n, sidechainPrompts[n], tasks)
+		default:
+			t.Roots = append(t.Roots, n)
+		}
+		if !n.IsSidechain {
+			t.Active = n
+		}
+	}
+	return t, nil
+}
+
+// linkSidechain attaches a sidechain root to the first unclaimed Task call
+// with the same prompt.
+func (t *Tree) linkSidechain(root *Node, prompt string, tasks []taskLink) {
+	if prompt != "" {
+		for i := range tasks {
+			task := &tasks[i]
+			if task.node == nil || task.prompt != prompt {
+				continue
+			}
+			root.TaskToolUseID = task.id
+			task.node.Sidechains = append(task.node.Sidechains, root)
+			task.node = nil // Claimed
+			return
+		}
+	}
+	t.UnlinkedSidechains = append(t.UnlinkedSidechains, root)
+}
+
+// isTaskTool reports whether a tool spawns subagents. Newer Claude Code
+// versions name the Task tool "Agent".
+func isTaskTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+func contentBlocks(raw json.RawMessage) []session.JSONLContentBlock {
+	var blocks []session.JSONLContentBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return nil
+	}
+	return blocks
+}
+
+// promptText returns a user message's text, which is a plain string or a
+// list of text blocks.
+func promptText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []string
+	for _, block := range contentBlocks(raw) {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Path returns the session file the tree was built from.
+func (t *Tree) Path() string {
+	return t.path
+}
+
+// Len returns the number of messages in the tree, including sidechains.
+func (t *Tree) Len() int {
+	return len(t.nodes)
+}
+
+// Node returns the message with uuid, or nil.
+func (t *Tree) Node(uuid string) *Node {
+	return t.nodes[uuid]
+}
+
+// Leaves returns every main-conversation leaf, one per branch, in file order.
+func (t *Tree) Leaves() []*Node {
+	var leaves []*Node
+	for _, root := range t.Roots {
+		for n := range Descendants(root) {
+			if n.IsLeaf() {
+				leaves = append(leaves, n)
+			}
+		}
+	}
+	return leaves
+}
+
+// Branch returns the path from leaf's root down to leaf.
+func (t *Tree) Branch(leaf *Node) []*Node {
+	var branch []*Node
+	for n := leaf; n != nil; n = n.Parent {
+		branch = append(branch, n)
+	}
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch
+}
+
+// ActiveBranch returns the branch ending at Active.
+func (t *Tree) ActiveBranch() []*Node {
+	if t.Active == nil {
+		return nil
+	}
+	return t.Branch(t.Active)
+}
+
+// Descendants returns a depth-first, pre-order iterator over n and its
+// children. Sidechains are not included; walk Node.Sidechains separately.
+func Descendants(n *Node) iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		var walk func(*Node) bool
+		walk = func(n *Node) bool {
+			if !yield(n) {
+				return false
+			}
+			for _, c := range n.Children {
+				if !walk(c) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(n)
+	}
+}
+
+// Message loads the full message for n from the session file.
+func (t *Tree) Message(n *Node) (session.JSONLMessage, error) {
+	for _, msg := range t.Messages([]*Node{n}) {
+		return msg, nil
+	}
+	return session.JSONLMessage{}, fmt.Errorf("message %s not found in %s", n.UUID, t.path)
+}
+
+// Messages returns an iterator that loads each node's full message from
+// the session file, in the order given. An error ends iteration.
+func (t *Tree) Messages(nodes []*Node) iter.Seq2[*Node, session.JSONLMessage] {
+	return func(yield func(*Node, session.JSONLMessage) bool) {
+		f, err := os.Open(t.path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		reader := bufio.NewReaderSize(f, 64*1024)
+		for _, n := range nodes {
+			if _, err := f.Seek(n.Offset, io.SeekStart); err != nil {
+				return
+			}
+			reader.Reset(f)
+			line, err := reader.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return
+			}
+			msg, err := session.ParseJSONLMessage(trimNewline(line))
+			if err != nil || msg.UUID != n.UUID {
+				return // The file changed since the tree was built
+			}
+			if !yield(n, *msg) {
+				return
+			}
+		}
+	}
+}
+
+// Render writes the branch ending at leaf as an indented outline, with each
+// subagent sidechain nested under the message that spawned it.
+func (t *Tree) Render(w io.Writer, leaf *Node) error {
+	return t.render(w, t.Branch(leaf), 0)
+}
+
+func (t *Tree) render(w io.Writer, branch []*Node, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for n, msg := range t.Messages(branch) {
+		if _, err := fmt.Fprintf(w, "%s[%s] %s\n", indent, n.Type, summarizeMessage(&msg)); err != nil {
+			return err
+		}
+		for _, root := range n.Sidechains {
+			if _, err := fmt.Fprintf(w, "%s  └ subagent %s\n", indent, root.TaskToolUseID); err != nil {
+				return err
+			}
+			if err := t.render(w, t.mainPath(root), depth+2); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mainPath follows the last child from n, which is the branch a
+// sidechain ended on.
+func (t *Tree) mainPath(n *Node) []*Node {
+	path := []*Node{n}
+	for len(n.Children) > 0 {
+		n = n.Children[len(n.Children)-1]
+		path = append(path, n)
+	}
+	return path
+}
+
+// summarizeMessage returns a one-line description of a message.
+func summarizeMessage(msg *session.JSONLMessage) string {
+	var parts []string
+	if msg.Message != nil {
+		if text := promptText(msg.Message.Content); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	for _, call := range msg.GetToolCalls() {
+		parts = append(parts, "→ "+call.Name)
+	}
+	for _, block := range msg.GetContentBlocks() {
+		if block.Type == "tool_result" {
+			parts = append(parts, "← result")
+		}
+	}
+	s := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	if r := []rune(s); len(r) > 120 {
+		s = string(r[:117]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package jsonl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// treeJSONL has two branches from u1 (a1 was abandoned by an edit), a Task
+// call whose subagent sidechain follows it, and a sidechain with no Task call.
+const treeJSONL = `{"type":"summary","summary":"Tree test","leafUuid":"u2"}
+{"type":"user","uuid":"u1","parentUuid":null,"message":{"role":"user","content":"start"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":[{"type":"text","text":"first answer"}]}}
+{"type":"assistant","uuid":"a2","parentUuid":"u1","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_task","name":"Task","input":{"prompt":"Find usages","subagent_type":"general-purpose"}}]}}
+{"type":"user","uuid":"s1","parentUuid":null,"isSidechain":true,"message":{"role":"user","content":"Find usages"}}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"found 3"}]}}
+{"type":"user","uuid":"s3","parentUuid":null,"isSidechain":true,"message":{"role":"user","content":"orphan prompt"}}
+not json
+{"type":"user","uuid":"u2","parentUuid":"a2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_task","content":"3 usages"}]}}
+`
+
+func writeTreeFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.jsonl")
+	if err := os.WriteFile(path, []byte(treeJSONL), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func nodeIDs(nodes []*Node) string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.UUID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestBuildTree(t *testing.T) {
+	tree, err := BuildTree(writeTreeFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Len() != 7 {
+		t.Errorf("expected 7 nodes, got %d", tree.Len())
+	}
+	if got := nodeIDs(tree.Roots); got != "u1" {
+		t.Errorf("expected roots u1, got %s", got)
+	}
+	if got := nodeIDs(tree.ActiveBranch()); got != "u1,a2,u2" {
+		t.Errorf("expected active branch u1,a2,u2, got %s", got)
+	}
+	if got := nodeIDs(tree.Leaves()); got != "a1,u2" {
+		t.Errorf("expected leaves a1,u2, got %s", got)
+	}
+
+	a2 := tree.Node("a2")
+	if a2 == nil || len(a2.Sidechains) != 1 || a2.Sidechains[0].UUID != "s1" {
+		t.Fatalf("expected sidechain s1 under a2, got %+v", a2)
+	}
+	if a2.Sidechains[0].TaskToolUseID != "toolu_task" {
+		t.Errorf("expected TaskToolUseID toolu_task, got %s", a2.Sidechains[0].TaskToolUseID)
+	}
+	if got := nodeIDs(tree.UnlinkedSidechains); got != "s3" {
+		t.Errorf("expected unlinked sidechain s3, got %s", got)
+	}
+}
+
+func TestTree_Messages(t *testing.T) {
+	tree, err := BuildTree(writeTreeFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uuids []string
+	for n, msg := range tree.Messages(tree.ActiveBranch()) {
+		if msg.UUID != n.UUID {
+			t.Errorf("node %s loaded message %s", n.UUID, msg.UUID)
+		}
+		uuids = append(uuids, msg.UUID)
+	}
+	if got := strings.Join(uuids, ","); got != "u1,a2,u2" {
+		t.Errorf("expected messages u1,a2,u2, got %s", got)
+	}
+
+	msg, err := tree.Message(tree.Node("s2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.UUID != "s2" || !msg.IsAssistant() {
+		t.Errorf("expected assistant message s2, got %s %s", msg.Type, msg.UUID)
+	}
+}
+
+func TestTree_Render(t *testing.T) {
+	tree, err := BuildTree(writeTreeFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tree.Render(&buf, tree.Active); err != nil {
+		t.Fatal(err)
+	}
+	want := `[user] start
+[assistant] → Task
+  └ subagent toolu_task
+    [user] Find usages
+    [assistant] found 3
+[user] ← result
+`
+	if buf.String() != want {
+		t.Errorf("unexpected render:\n%s", buf.String())
+	}
+}
+
+func TestMessagesIterator(t *testing.T) {
+	var count int
+	for msg, err := range Messages(writeTreeFixture(t)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.UUID == "u2" {
+			break
+		}
+		count++
+	}
+	// The summary line parses; the malformed line is skipped.
+	if count != 7 {
+		t.Errorf("expected 7 messages before u2, got %d", count)
+	}
+}
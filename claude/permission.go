@@ -0,0 +1,208 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/randalmurphal/llmkit/v2/claude/session"
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// Permission types shared with the session package.
+type (
+	// PermissionHandler decides whether a tool call may run.
+	PermissionHandler = session.PermissionHandler
+
+	// ToolPermissionRequest is a tool call waiting for a permission decision.
+	ToolPermissionRequest = session.ToolPermissionRequest
+
+	// PermissionDecision is the answer to a ToolPermissionRequest.
+	PermissionDecision = session.PermissionDecision
+
+	// PermissionEvent records one permission decision.
+	PermissionEvent = session.PermissionEvent
+)
+
+// Permission decision helpers re-exported for convenience.
+var (
+	// AllowTool lets a tool call run unchanged.
+	AllowTool = session.Allow
+
+	// AllowToolWithInput lets a tool call run with a replacement input.
+	AllowToolWithInput = session.AllowWithInput
+
+	// DenyTool refuses a tool call.
+	DenyTool = session.Deny
+
+	// RulesPermissionHandler decides tool calls with claudeconfig rules.
+	RulesPermissionHandler = session.RulesPermissionHandler
+)
+
+// stdioControl answers the CLI's can_use_tool control requests for one
+// StreamJSON run.
+type stdioControl struct {
+	handler PermissionHandler
+	ctx     context.Context // Caller's context, for event delivery
+	stop    context.Context // Cancelled when the run ends
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	writeMu sync.Mutex // Serializes stdin lines and guards closed
+	stdin   io.WriteCloser
+	closed  bool
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc // Request ID -> handler cancel
+}
+
+// controlLine is a control message line in either direction.
+type controlLine struct {
+	Type      string                   `json:"type"`
+	RequestID string                   `json:"request_id,omitempty"`
+	Request   json.RawMessage          `json:"request,omitempty"`
+	Response  *session.ControlResponse `json:"response,omitempty"`
+}
+
+func newStdioControl(ctx context.Context, h PermissionHandler, stdin io.WriteCloser) *stdioControl {
+	stop, cancel := context.WithCancel(ctx)
+	return &stdioControl{
+		handler:  h,
+		ctx:      ctx,
+		stop:     stop,
+		cancel:   cancel,
+		stdin:    stdin,
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// writeInput writes the prompt message to stdin.
+func (c *stdioControl) writeInput(input []byte) {
+	if err := c.write(input); err != nil {
+		c.closeInput() // The CLI exits and the run reports its failure
+	}
+}
+
+// handleLine processes a control message from stdout. It reports false for
+// lines that are not control messages.
+func (c *stdioControl) handleLine(line []byte, sessionID string, events chan<- StreamEvent) bool {
+	if !bytes.Contains(line, []byte(`"control_`)) {
+		return false // Skip decoding conversation lines twice
+	}
+	var msg controlLine
+	if json.Unmarshal(line, &msg) != nil {
+		return false
+	}
+	switch msg.Type {
+	case claudecontract.EventTypeControlRequest:
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.answer(msg, sessionID, events)
+		}()
+	case claudecontract.EventTypeControlCancelRequest:
+		c.mu.Lock()
+		cancel, ok := c.inflight[msg.RequestID]
+		delete(c.inflight, msg.RequestID)
+		c.mu.Unlock()
+		if ok {
+			cancel()
+		}
+	case claudecontract.EventTypeControlResponse:
+		// Nothing is sent to the CLI that expects an answer
+	default:
+		return false
+	}
+	return true
+}
+
+// answer decides a control request and writes the response.
+func (c *stdioControl) answer(msg controlLine, sessionID string, events chan<- StreamEvent) {
+	var body struct {
+		Subtype string `json:"subtype"`
+	}
+	_ = json.Unmarshal(msg.Request, &body) // An empty subtype is refused below
+	if body.Subtype != claudecontract.ControlSubtypeCanUseTool {
+		c.respond(msg.RequestID, nil, fmt.Sprintf("unsupported control request: %q", body.Subtype))
+		return
+	}
+	req, err := session.ParsePermissionRequest(msg.RequestID, msg.Request)
+	if err != nil {
+		c.respond(msg.RequestID, nil, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.stop)
+	defer cancel()
+	c.mu.Lock()
+	c.inflight[msg.RequestID] = cancel
+	c.mu.Unlock()
+
+	decision := c.handler.Decide(ctx, req)
+
+	c.mu.Lock()
+	_, pending := c.inflight[msg.RequestID]
+	delete(c.inflight, msg.RequestID)
+	c.mu.Unlock()
+	if !pending {
+		return // Withdrawn by control_cancel_request; the CLI expects no answer
+	}
+
+	ev := PermissionEvent{Request: req, Decision: decision}
+	select {
+	case events <- StreamEvent{Type: StreamEventPermission, SessionID: sessionID, Permission: &ev}:
+	case <-c.ctx.Done():
+		return
+	}
+	c.respond(msg.RequestID, decision.ControlResponse(req), "")
+}
+
+// respond writes a control_response; errMsg non-empty makes it an error.
+func (c *stdioControl) respond(requestID string, response any, errMsg string) {
+	resp := &session.ControlResponse{Subtype: claudecontract.ControlResponseSuccess, RequestID: requestID}
+	if errMsg != "" {
+		resp.Subtype = claudecontract.ControlResponseError
+		resp.Error = errMsg
+	} else if data, err := json.Marshal(response); err == nil {
+		resp.Response = data
+	}
+	line, err := json.Marshal(controlLine{Type: claudecontract.EventTypeControlResponse, Response: resp})
+	if err != nil {
+		return
+	}
+	_ = c.write(line) // The CLI is gone if the write fails
+}
+
+// write writes one line to stdin unless input is closed.
+func (c *stdioControl) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return io.ErrClosedPipe
+	}
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		data = append(data[:len(data):len(data)], '\n')
+	}
+	_, err := c.stdin.Write(data)
+	return err
+}
+
+// closeInput closes stdin so the CLI exits once the turn is over.
+func (c *stdioControl) closeInput() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		_ = c.stdin.Close()
+	}
+}
+
+// finish cancels outstanding handlers and waits for them.
+func (c *stdioControl) finish() {
+	c.cancel()
+	c.closeInput()
+	c.wg.Wait()
+}
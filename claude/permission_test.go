@@ -0,0 +1,79 @@
+package claude
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// permissionCLIStub reads the prompt, asks to run Bash, logs the answer to
+// $STUB_LOG and finishes the turn. It exits only when stdin is closed.
+const permissionCLIStub = `#!/bin/sh
+IFS= read -r prompt
+printf '%s\n' "$prompt" >> "$STUB_LOG"
+printf '{"type":"system","subtype":"init","session_id":"s1","model":"sonnet"}\n'
+printf '{"type":"control_request","request_id":"perm-1","request":{"subtype":"can_use_tool","tool_name":"Bash","input":{"command":"rm -rf /"}}}\n'
+IFS= read -r answer
+printf '%s\n' "$answer" >> "$STUB_LOG"
+printf '{"type":"result","subtype":"success","session_id":"s1","result":"done"}\n'
+while IFS= read -r line; do :; done
+`
+
+func TestStreamJSONPermissionHandler(t *testing.T) {
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "claude")
+	require.NoError(t, os.WriteFile(stub, []byte(permissionCLIStub), 0o755))
+	logPath := filepath.Join(dir, "stdin.log")
+
+	client := NewClaudeCLI(
+		WithClaudePath(stub),
+		WithEnvVar("STUB_LOG", logPath),
+		WithDangerouslySkipPermissions(),
+		WithPermissionHandler(RulesPermissionHandler(&claudeconfig.ToolPermissions{Deny: []string{"Bash(rm:*)"}})),
+	)
+	req := CompletionRequest{Messages: []Message{{Role: RoleUser, Content: "clean up"}}}
+
+	args := client.buildArgsForStreamJSON(req)
+	assert.Contains(t, args, "--permission-prompt-tool")
+	assert.Contains(t, args, "--input-format")
+	assert.NotContains(t, args, "--dangerously-skip-permissions")
+	assert.NotContains(t, args, "clean up")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, result, err := client.StreamJSON(ctx, req)
+	require.NoError(t, err)
+
+	var permission *PermissionEvent
+	for ev := range events {
+		if ev.Type == StreamEventPermission {
+			permission = ev.Permission
+			assert.Equal(t, "s1", ev.SessionID)
+		}
+	}
+	final, err := result.Wait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "done", final.Result)
+
+	require.NotNil(t, permission)
+	assert.Equal(t, "Bash", permission.Request.ToolName)
+	assert.Equal(t, claudecontract.PermissionBehaviorDeny, permission.Decision.Behavior)
+	assert.Contains(t, permission.Decision.Message, "Bash(rm:*)")
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"text":"clean up"`)
+	assert.Contains(t, lines[1], `"request_id":"perm-1"`)
+	assert.Contains(t, lines[1], `"behavior":"deny"`)
+}
@@ -26,6 +26,7 @@ func init() {
 			MaxTurns:           true,
 			Env:                true,
 			AddDirs:            true,
+			Hooks:              true,
 		},
 		Environment: llmkit.EnvironmentSupport{
 			Hooks:        true,
@@ -34,6 +35,19 @@ func init() {
 			Instructions: true,
 			CustomAgents: true,
 		},
+		// The CLI takes a single prompt: only user and assistant turns survive.
+		// Images and documents are sent as stream-json input blocks.
+		Request: llmkit.RequestSupport{
+			SystemPrompt: true,
+			JSONSchema:   true,
+			Roles:        []llmkit.Role{llmkit.RoleUser, llmkit.RoleAssistant},
+			ContentParts: []llmkit.ContentPartSupport{
+				{Type: llmkit.ContentPartText, Text: true},
+				{Type: llmkit.ContentPartImage, ImageURL: true, ImageBase64: true, FilePath: true},
+				{Type: llmkit.ContentPartDocument, ImageURL: true, ImageBase64: true, FilePath: true},
+				{Type: llmkit.ContentPartFile, FilePath: true},
+			},
+		},
 	})
 }
 
@@ -102,6 +116,18 @@ func newFromProviderConfig(cfg llmkit.Config) (llmkit.Client, error) {
 	if len(cfg.AddDirs) > 0 {
 		opts = append(opts, WithAddDirs(cfg.AddDirs))
 	}
+	if cfg.Launch.ClaudeSettings != "" {
+		opts = append(opts, WithSettings(cfg.Launch.ClaudeSettings))
+	}
+	if cfg.Launch.ClaudeMCPConfig != "" {
+		opts = append(opts, WithMCPConfig(cfg.Launch.ClaudeMCPConfig))
+	}
+	for _, dir := range cfg.Launch.ClaudePluginDirs {
+		opts = append(opts, WithPluginDir(dir))
+	}
+	if len(cfg.Launch.Env) > 0 {
+		opts = append(opts, WithEnv(cfg.Launch.Env))
+	}
 	if sessionID := sessionIDFromMetadata(cfg.Session); sessionID != "" {
 		if cfg.ResumeSession {
 			opts = append(opts, WithResume(sessionID))
@@ -135,14 +161,20 @@ func newFromProviderConfig(cfg llmkit.Config) (llmkit.Client, error) {
 		}
 	}
 
-	return &claudeProviderAdapter{cli: NewClaudeCLI(opts...)}, nil
+	return &claudeProviderAdapter{cli: NewClaudeCLI(opts...), validation: cfg.RequestValidation}, nil
 }
 
 type claudeProviderAdapter struct {
-	cli *ClaudeCLI
+	cli        *ClaudeCLI
+	validation llmkit.RequestValidationMode
 }
 
 func (a *claudeProviderAdapter) Complete(ctx context.Context, req llmkit.Request) (*llmkit.Response, error) {
+	warnings, err := llmkit.PreflightRequest("claude", req, a.validationMode())
+	if err != nil {
+		return nil, err
+	}
+
 	claudeReq := CompletionRequest{
 		SystemPrompt: req.SystemPrompt,
 		Model:        req.Model,
@@ -153,9 +185,9 @@ func (a *claudeProviderAdapter) Complete(ctx context.Context, req llmkit.Request
 		claudeReq.JSONSchema = string(req.JSONSchema)
 	}
 
-	claudeReq.Messages = make([]Message, len(req.Messages))
-	for i, m := range req.Messages {
-		claudeReq.Messages[i] = convertMessageToClaude(m)
+	claudeReq.Messages, err = convertMessagesToClaude(ctx, req.Messages)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(req.Tools) > 0 {
@@ -173,10 +205,17 @@ func (a *claudeProviderAdapter) Complete(ctx context.Context, req llmkit.Request
 	if err != nil {
 		return nil, err
 	}
-	return a.convertResponse(resp), nil
+	out := a.convertResponse(resp)
+	out.Metadata = llmkit.AttachRequestWarnings(out.Metadata, warnings)
+	return out, nil
 }
 
 func (a *claudeProviderAdapter) Stream(ctx context.Context, req llmkit.Request) (<-chan llmkit.StreamChunk, error) {
+	warnings, err := llmkit.PreflightRequest("claude", req, a.validationMode())
+	if err != nil {
+		return nil, err
+	}
+
 	claudeReq := CompletionRequest{
 		SystemPrompt: req.SystemPrompt,
 		Model:        req.Model,
@@ -187,9 +226,9 @@ func (a *claudeProviderAdapter) Stream(ctx context.Context, req llmkit.Request)
 		claudeReq.JSONSchema = string(req.JSONSchema)
 	}
 
-	claudeReq.Messages = make([]Message, len(req.Messages))
-	for i, m := range req.Messages {
-		claudeReq.Messages[i] = convertMessageToClaude(m)
+	claudeReq.Messages, err = convertMessagesToClaude(ctx, req.Messages)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(req.Tools) > 0 {
@@ -330,10 +369,11 @@ func (a *claudeProviderAdapter) Stream(ctx context.Context, req llmkit.Request)
 			FinalContent: finalContent,
 			CostUSD:      final.TotalCostUSD,
 			NumTurns:     final.NumTurns,
+			Metadata:     llmkit.AttachRequestWarnings(nil, warnings),
 		}
 
-		if final.IsError {
-			out <- llmkit.StreamChunk{Type: "error", Error: fmt.Errorf("streaming failed: %s", final.Result), SessionID: final.SessionID, Session: claudeSession(final.SessionID)}
+		if resultErr := final.Err(); resultErr != nil {
+			out <- llmkit.StreamChunk{Type: "error", Error: fmt.Errorf("streaming failed: %w", resultErr), SessionID: final.SessionID, Session: claudeSession(final.SessionID)}
 		}
 	}()
 
@@ -371,6 +411,13 @@ func assistantChunkFromEvent(event StreamEvent, session *llmkit.SessionMetadata)
 	return chunk, true
 }
 
+func (a *claudeProviderAdapter) validationMode() llmkit.RequestValidationMode {
+	if a.validation == "" {
+		return llmkit.RequestValidationStrict
+	}
+	return a.validation
+}
+
 func (a *claudeProviderAdapter) Provider() string {
 	return "claude"
 }
@@ -470,44 +517,75 @@ func mapHeadersToSlice(in map[string]string) []string {
 	return out
 }
 
-func convertMessageToClaude(m llmkit.Message) Message {
+func convertMessagesToClaude(ctx context.Context, messages []llmkit.Message) ([]Message, error) {
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		msg, err := convertMessageToClaude(ctx, m)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		out[i] = msg
+	}
+	return out, nil
+}
+
+func convertMessageToClaude(ctx context.Context, m llmkit.Message) (Message, error) {
 	msg := Message{
 		Role: Role(m.Role),
 		Name: m.Name,
 	}
+	if !m.IsMultimodal() {
+		msg.Content = m.Content
+		return msg, nil
+	}
 
-	if m.IsMultimodal() {
-		var parts []string
-		var imagePaths []string
-
-		for _, part := range m.ContentParts {
-			switch part.Type {
-			case "text":
-				if part.Text != "" {
-					parts = append(parts, part.Text)
-				}
-			case "image":
-				if part.FilePath != "" {
-					imagePaths = append(imagePaths, part.FilePath)
-				}
-			case "file":
-				if part.FilePath != "" {
-					parts = append(parts, "[File: "+part.FilePath+"]")
-				}
+	var parts []string
+	for _, part := range m.ContentParts {
+		switch part.Type {
+		case llmkit.ContentPartText:
+			if part.Text != "" {
+				parts = append(parts, part.Text)
 			}
-		}
-
-		if len(parts) > 0 {
-			msg.Content = strings.Join(parts, "\n")
-		}
-		if len(imagePaths) > 0 && msg.Content != "" {
-			for _, path := range imagePaths {
-				msg.Content += "\n[Image: " + path + "]"
+		case llmkit.ContentPartFile:
+			if part.FilePath != "" {
+				parts = append(parts, "[File: "+part.FilePath+"]")
+			}
+		case llmkit.ContentPartImage, llmkit.ContentPartDocument:
+			block, err := inputBlockFromPart(ctx, part)
+			if err != nil {
+				return Message{}, err
 			}
+			msg.Blocks = append(msg.Blocks, block)
 		}
-	} else {
-		msg.Content = m.Content
 	}
+	msg.Content = strings.Join(parts, "\n")
+	return msg, nil
+}
 
-	return msg
+// inputBlockFromPart converts an image or document part to a stream-json
+// block. Remote URLs are passed through for the API to fetch; everything else
+// is loaded, checked against size and media-type limits, and sent inline.
+func inputBlockFromPart(ctx context.Context, part llmkit.ContentPart) (InputBlock, error) {
+	if part.ImageURL != "" && !strings.HasPrefix(part.ImageURL, "data:") {
+		return InputBlock{Type: part.Type, Source: &BlockSource{Type: "url", URL: part.ImageURL}}, nil
+	}
+	data, err := llmkit.ReadContentPart(ctx, part)
+	if err != nil {
+		return InputBlock{}, fmt.Errorf("load %s: %w", part.Type, err)
+	}
+	// The API takes base64 documents only as PDFs; plain text goes inline.
+	if data.MediaType == "text/plain" {
+		return InputBlock{
+			Type:   part.Type,
+			Source: &BlockSource{Type: "text", MediaType: data.MediaType, Data: string(data.Data)},
+		}, nil
+	}
+	return InputBlock{
+		Type: part.Type,
+		Source: &BlockSource{
+			Type:      "base64",
+			MediaType: data.MediaType,
+			Data:      data.Base64(),
+		},
+	}, nil
 }
@@ -1,6 +1,8 @@
 package claude
 
 import (
+	"context"
+	"encoding/base64"
 	"testing"
 
 	"github.com/randalmurphal/llmkit/v2"
@@ -51,3 +53,23 @@ func TestAssistantChunkFromEvent_RejectsEmptyAssistantPayload(t *testing.T) {
 		t.Fatal("expected empty assistant payload to be skipped")
 	}
 }
+
+func TestInputBlockFromPartSendsTextDocumentsInline(t *testing.T) {
+	text := llmkit.ContentPart{Type: llmkit.ContentPartDocument, ImageBase64: base64.StdEncoding.EncodeToString([]byte("hello")), MediaType: "text/plain"}
+	block, err := inputBlockFromPart(context.Background(), text)
+	if err != nil {
+		t.Fatalf("inputBlockFromPart: %v", err)
+	}
+	if want := (BlockSource{Type: "text", MediaType: "text/plain", Data: "hello"}); block.Source == nil || *block.Source != want {
+		t.Fatalf("text source = %+v, want %+v", block.Source, want)
+	}
+
+	pdf := llmkit.ContentPart{Type: llmkit.ContentPartDocument, ImageBase64: "JVBERi0=", MediaType: "application/pdf"}
+	block, err = inputBlockFromPart(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("inputBlockFromPart: %v", err)
+	}
+	if block.Source == nil || block.Source.Type != "base64" || block.Source.Data != "JVBERi0=" {
+		t.Fatalf("pdf source = %+v", block.Source)
+	}
+}
@@ -46,6 +46,26 @@ type Message struct {
 	Role    Role   `json:"role"`
 	Content string `json:"content"`
 	Name    string `json:"name,omitempty"` // For tool results
+
+	// Blocks are image or document blocks sent after Content. When any user
+	// message has blocks, the prompt is written to stdin with
+	// --input-format stream-json instead of being passed as an argument.
+	Blocks []InputBlock `json:"blocks,omitempty"`
+}
+
+// InputBlock is a content block in a stream-json user message.
+type InputBlock struct {
+	Type   string       `json:"type"` // "text", "image", or "document"
+	Text   string       `json:"text,omitempty"`
+	Source *BlockSource `json:"source,omitempty"`
+}
+
+// BlockSource carries the data for an image or document block.
+type BlockSource struct {
+	Type      string `json:"type"` // "base64", "url" or "text"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 // Role identifies the message sender.
@@ -0,0 +1,280 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// ErrControlTimeout is returned when the CLI does not answer a control
+// request within the control timeout.
+var ErrControlTimeout = errors.New("control request timed out")
+
+// ControlError is a control request the CLI answered with an error.
+type ControlError struct {
+	RequestID string
+	Subtype   string // Subtype of the failed request
+	Message   string
+}
+
+// Error implements the error interface.
+func (e *ControlError) Error() string {
+	return fmt.Sprintf("control request %s (%s) failed: %s", e.Subtype, e.RequestID, e.Message)
+}
+
+// ControlResponse is the CLI's answer to a control request.
+type ControlResponse struct {
+	Subtype   string          `json:"subtype"` // "success" or "error"
+	RequestID string          `json:"request_id"`
+	Response  json.RawMessage `json:"response,omitempty"` // Subtype-specific payload
+	Error     string          `json:"error,omitempty"`
+}
+
+// controlMessage is a control_request or control_response line.
+type controlMessage struct {
+	Type      string           `json:"type"`
+	RequestID string           `json:"request_id,omitempty"`
+	Request   json.RawMessage  `json:"request,omitempty"`
+	Response  *ControlResponse `json:"response,omitempty"`
+}
+
+// Interrupt implements Session.
+func (s *session) Interrupt(ctx context.Context) error {
+	_, err := s.Control(ctx, claudecontract.ControlSubtypeInterrupt, nil)
+	return err
+}
+
+// SetModel implements Session.
+func (s *session) SetModel(ctx context.Context, model string) error {
+	params := map[string]any{"model": nil} // null restores the default model
+	if model != "" {
+		params["model"] = model
+	}
+	if _, err := s.Control(ctx, claudecontract.ControlSubtypeSetModel, params); err != nil {
+		return err
+	}
+	s.metaMu.Lock()
+	s.config.model = model
+	if s.initMsg != nil {
+		s.initMsg.Model = model
+	}
+	s.metaMu.Unlock()
+	return nil
+}
+
+// SetPermissionMode implements Session.
+func (s *session) SetPermissionMode(ctx context.Context, mode claudecontract.PermissionMode) error {
+	if !mode.IsValid() {
+		return fmt.Errorf("invalid permission mode: %q", mode)
+	}
+	_, err := s.Control(ctx, claudecontract.ControlSubtypeSetPermissionMode, map[string]any{"mode": string(mode)})
+	return err
+}
+
+// Control implements Session.
+func (s *session) Control(ctx context.Context, subtype string, params map[string]any) (*ControlResponse, error) {
+	if s.Status() != StatusActive {
+		return nil, fmt.Errorf("session not active: %s", s.Status())
+	}
+
+	request := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		request[k] = v
+	}
+	request["subtype"] = subtype
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshal control request: %w", err)
+	}
+	id := s.newRequestID()
+	data, err := json.Marshal(controlMessage{
+		Type:      claudecontract.EventTypeControlRequest,
+		RequestID: id,
+		Request:   body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal control request: %w", err)
+	}
+
+	// Register the waiter before writing; the answer can arrive first.
+	waiter := make(chan *ControlResponse, 1)
+	s.controlMu.Lock()
+	s.controlPending[id] = waiter
+	s.controlMu.Unlock()
+	defer func() {
+		s.controlMu.Lock()
+		delete(s.controlPending, id)
+		s.controlMu.Unlock()
+	}()
+
+	timer := time.NewTimer(s.config.controlTimeout)
+	defer timer.Stop()
+
+	if err := s.writeLine(ctx, data); err != nil {
+		return nil, fmt.Errorf("write control request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %s (%s) after %s", ErrControlTimeout, subtype, id, s.config.controlTimeout)
+	case <-s.done:
+		return nil, fmt.Errorf("session closed before control response received")
+	case resp := <-waiter:
+		if resp == nil {
+			return nil, fmt.Errorf("session closed before control response received")
+		}
+		if resp.Subtype == claudecontract.ControlResponseError {
+			return resp, &ControlError{RequestID: id, Subtype: subtype, Message: resp.Error}
+		}
+		return resp, nil
+	}
+}
+
+// newRequestID returns a unique control request ID in the SDKs' format.
+func (s *session) newRequestID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:]) // Uniqueness comes from the counter
+	return fmt.Sprintf("req_%d_%s", s.controlSeq.Add(1), hex.EncodeToString(b[:]))
+}
+
+// handleControlLine processes a control message from stdout. It reports
+// false for lines that are not control messages.
+func (s *session) handleControlLine(msg *OutputMessage) bool {
+	switch msg.Type {
+	case claudecontract.EventTypeControlResponse:
+		var ctrl controlMessage
+		if json.Unmarshal(msg.Raw, &ctrl) == nil && ctrl.Response != nil {
+			s.deliverControl(ctrl.Response)
+		}
+		return true
+	case claudecontract.EventTypeControlRequest:
+		var ctrl controlMessage
+		if json.Unmarshal(msg.Raw, &ctrl) == nil {
+			go s.answerControlRequest(ctrl)
+		}
+		return true
+	case claudecontract.EventTypeControlCancelRequest:
+		var ctrl controlMessage
+		if json.Unmarshal(msg.Raw, &ctrl) == nil {
+			s.cancelControlRequest(ctrl.RequestID)
+		}
+		return true
+	}
+	return false
+}
+
+// deliverControl routes a control response to its pending waiter.
+func (s *session) deliverControl(resp *ControlResponse) {
+	s.controlMu.Lock()
+	waiter, ok := s.controlPending[resp.RequestID]
+	if ok {
+		delete(s.controlPending, resp.RequestID)
+	}
+	s.controlMu.Unlock()
+
+	if ok {
+		waiter <- resp
+	}
+}
+
+// failPendingControls unblocks all control waiters when the process exits.
+func (s *session) failPendingControls() {
+	s.controlMu.Lock()
+	defer s.controlMu.Unlock()
+
+	for id, waiter := range s.controlPending {
+		select {
+		case waiter <- nil:
+		default:
+		}
+		delete(s.controlPending, id)
+	}
+}
+
+// answerControlRequest answers a control request from the CLI. Requests
+// the session has no handler for are refused rather than left waiting.
+func (s *session) answerControlRequest(req controlMessage) {
+	var body struct {
+		Subtype string `json:"subtype"`
+	}
+	_ = json.Unmarshal(req.Request, &body) // An empty subtype is refused below
+	switch {
+	case body.Subtype == claudecontract.ControlSubtypeCanUseTool && s.config.permissionHandler != nil:
+		s.answerPermission(req.RequestID, req.Request)
+	case body.Subtype == claudecontract.ControlSubtypeHookCallback && len(s.config.hooks) > 0:
+		s.answerHookCallback(req.RequestID, req.Request)
+	default:
+		s.respondControl(req.RequestID, nil, fmt.Errorf("unsupported control request: %q", body.Subtype))
+	}
+}
+
+// inflightContext returns the context for answering a CLI control request.
+// It is cancelled when the CLI withdraws the request or the session ends.
+func (s *session) inflightContext(requestID string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.controlMu.Lock()
+	s.controlInflight[requestID] = cancel
+	s.controlMu.Unlock()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// finishInflight unregisters an answered control request. It reports false
+// if the CLI withdrew the request, which then expects no answer.
+func (s *session) finishInflight(requestID string) bool {
+	s.controlMu.Lock()
+	defer s.controlMu.Unlock()
+	_, pending := s.controlInflight[requestID]
+	delete(s.controlInflight, requestID)
+	return pending
+}
+
+// cancelControlRequest withdraws an inbound control request still being
+// answered.
+func (s *session) cancelControlRequest(requestID string) {
+	s.controlMu.Lock()
+	cancel, ok := s.controlInflight[requestID]
+	delete(s.controlInflight, requestID)
+	s.controlMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// respondControl writes the answer to a CLI control request.
+func (s *session) respondControl(requestID string, response any, err error) {
+	resp := &ControlResponse{Subtype: claudecontract.ControlResponseSuccess, RequestID: requestID}
+	if err != nil {
+		resp.Subtype = claudecontract.ControlResponseError
+		resp.Error = err.Error()
+	} else if response != nil {
+		data, marshalErr := json.Marshal(response)
+		if marshalErr != nil {
+			resp.Subtype = claudecontract.ControlResponseError
+			resp.Error = fmt.Sprintf("marshal control response: %v", marshalErr)
+		} else {
+			resp.Response = data
+		}
+	}
+	data, marshalErr := json.Marshal(controlMessage{Type: claudecontract.EventTypeControlResponse, Response: resp})
+	if marshalErr != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.controlTimeout)
+	defer cancel()
+	_ = s.writeLine(ctx, data) // The CLI is gone if the write fails
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// controlStub answers control requests like the CLI: set_model "bogus" is
+// refused, set_permission_mode is never answered, and other lines are
+// logged to $STUB_LOG.
+const controlStub = `#!/bin/sh
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+    *'"subtype":"set_permission_mode"'*) ;;
+    *'"model":"bogus"'*) printf '{"type":"control_response","response":{"subtype":"error","request_id":"%s","error":"unknown model"}}\n' "$id" ;;
+    *control_request*)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{"ok":true}}}\n' "$id" ;;
+    *) printf '%s\n' "$line" >> "$STUB_LOG" ;;
+  esac
+done
+`
+
+func startControlStub(t *testing.T, opts ...SessionOption) (*session, string) {
+	t.Helper()
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "claude")
+	if err := os.WriteFile(stub, []byte(controlStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "stdin.log")
+	opts = append([]SessionOption{WithClaudePath(stub), WithEnv(map[string]string{"STUB_LOG": logPath})}, opts...)
+	s, err := newSession(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, logPath
+}
+
+func TestSessionControlRequests(t *testing.T) {
+	s, logPath := startControlStub(t, WithModel("sonnet"))
+	ctx := context.Background()
+
+	if err := s.Interrupt(ctx); err != nil {
+		t.Fatalf("Interrupt returned error: %v", err)
+	}
+	resp, err := s.Control(ctx, "mcp_status", map[string]any{"verbose": true})
+	if err != nil {
+		t.Fatalf("Control returned error: %v", err)
+	}
+	if !strings.HasPrefix(resp.RequestID, "req_") || string(resp.Response) != `{"ok":true}` {
+		t.Fatalf("control response = %+v", resp)
+	}
+
+	if err := s.SetModel(ctx, "opus"); err != nil {
+		t.Fatalf("SetModel returned error: %v", err)
+	}
+	if got := s.Info().Model; got != "opus" {
+		t.Fatalf("model after SetModel = %q, want opus", got)
+	}
+
+	err = s.SetModel(ctx, "bogus")
+	var ctrlErr *ControlError
+	if !errors.As(err, &ctrlErr) || ctrlErr.Message != "unknown model" || ctrlErr.Subtype != claudecontract.ControlSubtypeSetModel {
+		t.Fatalf("SetModel(bogus) error = %v, want ControlError", err)
+	}
+	if got := s.Info().Model; got != "opus" {
+		t.Fatalf("model after refused SetModel = %q, want opus", got)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"subtype":"interrupt"`) || !strings.Contains(lines[2], `"model":"opus"`) {
+		t.Fatalf("stdin lines = %q", lines)
+	}
+}
+
+func TestSessionControlTimeout(t *testing.T) {
+	s, _ := startControlStub(t, WithControlTimeout(50*time.Millisecond))
+
+	err := s.SetPermissionMode(context.Background(), claudecontract.PermissionPlan)
+	if !errors.Is(err, ErrControlTimeout) {
+		t.Fatalf("SetPermissionMode error = %v, want ErrControlTimeout", err)
+	}
+	if err := s.SetPermissionMode(context.Background(), "sideways"); err == nil {
+		t.Fatal("expected an invalid permission mode to be rejected")
+	}
+	if n := len(s.controlPending); n != 0 {
+		t.Fatalf("%d control waiters left registered", n)
+	}
+}
@@ -36,6 +36,71 @@
 //	    }
 //	}
 //
+// # Persistence and Warm Pools
+//
+// WithSessionStore records managed sessions in a sessionstore.Store so a
+// restarted process can call Restorer.Restore and resume them lazily. A
+// Pool keeps pre-started sessions ready to hide CLI startup latency:
+//
+//	pool := session.NewPool(mgr, session.WithPoolSize(2),
+//	    session.WithPoolSessionOptions(session.WithModel("sonnet")))
+//	defer pool.Close()
+//	sess, err := pool.Acquire(ctx)
+//
+// # Control Protocol
+//
+// Sessions speak the CLI's SDK control protocol on the same stdin and
+// stdout, so a running session can be steered without a restart:
+//
+//	err := sess.Interrupt(ctx) // The turn ends with a result message
+//	err = sess.SetModel(ctx, "opus")
+//	err = sess.SetPermissionMode(ctx, claudecontract.PermissionAcceptEdits)
+//
+// Each call carries a unique request ID and waits for the matching
+// control_response. It fails with ErrControlTimeout after the
+// WithControlTimeout duration (30 seconds by default) and with a
+// *ControlError when the CLI refuses the request. Control sends any other
+// request subtype.
+//
+// # Tool Permissions
+//
+// WithPermissionHandler decides tool calls in Go instead of skipping
+// permission checks. The CLI sends each call it would prompt for as a
+// can_use_tool control request, and the handler allows it, allows it with a
+// modified input, or denies it with a message for the model:
+//
+//	sess, err := mgr.Create(ctx, session.WithPermissionHandler(
+//	    func(ctx context.Context, req session.ToolPermissionRequest) (session.PermissionDecision, error) {
+//	        if req.ToolName == "Bash" {
+//	            return session.Deny("no shell access"), nil
+//	        }
+//	        return session.Allow(), nil
+//	    }))
+//
+// RulesPermissionHandler evaluates claudeconfig.ToolPermissions allow and
+// deny rules. Every decision is also emitted on Output as a
+// system/permission_decision message carrying a PermissionEvent.
+//
+// # Hook Callbacks
+//
+// WithHook runs Go functions for hook events instead of shell commands in
+// settings files. Callbacks get a typed HookInput and can block, add
+// context or rewrite a tool's input:
+//
+//	session.WithHook(claudecontract.HookPreToolUse, "Bash",
+//	    func(ctx context.Context, in session.HookInput) (session.HookOutput, error) {
+//	        if strings.Contains(string(in.ToolInput), "rm -rf") {
+//	            return session.Block("destructive command"), nil
+//	        }
+//	        return session.HookOutput{}, nil
+//	    })
+//
+// Callbacks are registered with an initialize control request. If the CLI
+// refuses it, the session restarts with command hooks in --settings JSON
+// that re-run the program as a shim talking to a Unix socket in a temp
+// directory; that fallback requires calling HookShimMain first thing in
+// main. Neither transport writes to the project.
+//
 // # Session Lifecycle
 //
 // Sessions go through the following states:
@@ -57,6 +122,7 @@
 //   - assistant: Claude's responses
 //   - result: Final result with token usage and cost
 //   - system/hook_response: Hook execution output (filtered by default)
+//   - system/permission_decision: A PermissionHandler decision
 //
 // Use the Is*() methods and type-specific fields to handle each type.
 package session
@@ -0,0 +1,463 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// HookCallback handles a hook event in-process. Returning an error is a
+// non-blocking hook failure: the CLI reports it and carries on.
+type HookCallback func(ctx context.Context, in HookInput) (HookOutput, error)
+
+// HookTransport selects how the CLI reaches hook callbacks.
+type HookTransport string
+
+const (
+	// HookTransportAuto registers callbacks over the control protocol and
+	// restarts the CLI with the socket shim if it refuses them.
+	HookTransportAuto HookTransport = ""
+
+	// HookTransportControl registers callbacks with an initialize control
+	// request; the CLI calls them with hook_callback requests.
+	HookTransportControl HookTransport = "control"
+
+	// HookTransportSocket passes command hooks in --settings JSON that
+	// re-run this executable as a shim connected to a Unix socket. The
+	// program must call HookShimMain first thing in main.
+	HookTransportSocket HookTransport = "socket"
+)
+
+// ErrHookShimUnavailable is returned when hooks need the socket shim but
+// the program never called HookShimMain.
+var ErrHookShimUnavailable = errors.New("hook socket shim unavailable: call session.HookShimMain at the start of main")
+
+// Environment of a hook shim process.
+const (
+	envHookSocket = "LLMKIT_HOOK_SOCKET"
+	envHookID     = "LLMKIT_HOOK_ID"
+)
+
+// hookShimInstalled records that the program calls HookShimMain.
+var hookShimInstalled atomic.Bool
+
+// HookInput is the input of a hook event. Fields beyond the common ones are
+// set only for the events noted; Raw holds the full payload.
+type HookInput struct {
+	HookEventName  claudecontract.HookEvent `json:"hook_event_name"`
+	SessionID      string                   `json:"session_id"`
+	TranscriptPath string                   `json:"transcript_path,omitempty"`
+	CWD            string                   `json:"cwd,omitempty"`
+	PermissionMode string                   `json:"permission_mode,omitempty"`
+
+	// PreToolUse, PostToolUse, PostToolUseFailure and PermissionRequest
+	ToolName     string          `json:"tool_name,omitempty"`
+	ToolInput    json.RawMessage `json:"tool_input,omitempty"`
+	ToolResponse json.RawMessage `json:"tool_response,omitempty"` // PostToolUse
+	ToolUseID    string          `json:"tool_use_id,omitempty"`
+	Error        string          `json:"error,omitempty"` // PostToolUseFailure and StopFailure
+
+	// UserPromptSubmit
+	Prompt string `json:"prompt,omitempty"`
+
+	// Stop and SubagentStop
+	StopHookActive bool `json:"stop_hook_active,omitempty"`
+
+	// SubagentStart and SubagentStop
+	AgentID   string `json:"agent_id,omitempty"`
+	AgentType string `json:"agent_type,omitempty"`
+
+	// SessionStart ("startup", "resume", "clear" or "compact")
+	Source string `json:"source,omitempty"`
+
+	// SessionEnd
+	Reason string `json:"reason,omitempty"`
+
+	// PreCompact and PostCompact ("manual" or "auto")
+	Trigger            string `json:"trigger,omitempty"`
+	CustomInstructions string `json:"custom_instructions,omitempty"`
+
+	// Notification
+	Message string `json:"message,omitempty"`
+	Title   string `json:"title,omitempty"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// HookOutput is a hook callback's answer. The zero value lets the CLI
+// continue unchanged.
+type HookOutput struct {
+	// Block denies a PreToolUse call, or blocks the prompt, tool result or
+	// stop for UserPromptSubmit, PostToolUse, Stop and SubagentStop.
+	// Reason is shown to the model.
+	Block  bool
+	Reason string
+
+	// Decision is a PreToolUse permission decision (allow, deny or ask)
+	// without blocking; Block overrides it with deny.
+	Decision claudecontract.PermissionBehavior
+
+	// UpdatedInput replaces a PreToolUse call's tool input.
+	UpdatedInput json.RawMessage
+
+	// AdditionalContext is added to the conversation for PreToolUse,
+	// PostToolUse, UserPromptSubmit and SessionStart.
+	AdditionalContext string
+
+	// SystemMessage is shown to the user.
+	SystemMessage string
+
+	// SuppressOutput hides the hook's output from the transcript.
+	SuppressOutput bool
+
+	// Stop ends the session after the hook, with StopReason shown to the user.
+	Stop       bool
+	StopReason string
+}
+
+// Block blocks the action the hook fired for; reason is shown to the model.
+func Block(reason string) HookOutput {
+	return HookOutput{Block: true, Reason: reason}
+}
+
+// AddContext adds text to the conversation.
+func AddContext(text string) HookOutput {
+	return HookOutput{AdditionalContext: text}
+}
+
+// ModifyToolInput runs a PreToolUse call with a replacement input,
+// approving it without a permission prompt.
+func ModifyToolInput(input json.RawMessage) HookOutput {
+	return HookOutput{Decision: claudecontract.PermissionBehaviorAllow, UpdatedInput: input}
+}
+
+// wire encodes the output in the CLI's hook output JSON for event.
+func (o HookOutput) wire(event claudecontract.HookEvent) map[string]any {
+	out := map[string]any{}
+	if o.Stop {
+		out["continue"] = false
+		if o.StopReason != "" {
+			out["stopReason"] = o.StopReason
+		}
+	}
+	if o.SuppressOutput {
+		out["suppressOutput"] = true
+	}
+	if o.SystemMessage != "" {
+		out["systemMessage"] = o.SystemMessage
+	}
+
+	specific := map[string]any{}
+	if event == claudecontract.HookPreToolUse {
+		decision := o.Decision
+		if o.Block {
+			decision = claudecontract.PermissionBehaviorDeny
+		}
+		if decision != "" {
+			specific["permissionDecision"] = string(decision)
+			if o.Reason != "" {
+				specific["permissionDecisionReason"] = o.Reason
+			}
+		}
+		if len(o.UpdatedInput) > 0 {
+			specific["updatedInput"] = o.UpdatedInput
+		}
+	} else if o.Block {
+		out["decision"] = "block"
+		out["reason"] = o.Reason
+	}
+	if o.AdditionalContext != "" {
+		specific["additionalContext"] = o.AdditionalContext
+	}
+	if len(specific) > 0 {
+		specific["hookEventName"] = string(event)
+		out["hookSpecificOutput"] = specific
+	}
+	return out
+}
+
+// hookRegistration is a callback registered with WithHook.
+type hookRegistration struct {
+	event    claudecontract.HookEvent
+	matcher  string
+	callback HookCallback
+}
+
+// hookID is the callback ID of the i-th registered hook.
+func hookID(i int) string {
+	return fmt.Sprintf("hook_%d", i)
+}
+
+// hookConfig groups registered callbacks by event, in the shape entry
+// builds for each matcher.
+func (s *session) hookConfig(entry func(id string, h hookRegistration) map[string]any) map[string][]map[string]any {
+	config := map[string][]map[string]any{}
+	for i, h := range s.config.hooks {
+		config[string(h.event)] = append(config[string(h.event)], entry(hookID(i), h))
+	}
+	return config
+}
+
+// initializeHooks registers the hook callbacks over the control protocol.
+func (s *session) initializeHooks(ctx context.Context) error {
+	hooks := s.hookConfig(func(id string, h hookRegistration) map[string]any {
+		return map[string]any{
+			"matcher":         h.matcher,
+			"hookCallbackIds": []string{id},
+			"timeout":         s.config.hookTimeout.Seconds(),
+		}
+	})
+	_, err := s.Control(ctx, claudecontract.ControlSubtypeInitialize, map[string]any{"hooks": hooks})
+	return err
+}
+
+// runHook runs the callback with the given ID on a raw hook input.
+func (s *session) runHook(ctx context.Context, id string, input json.RawMessage) (map[string]any, error) {
+	var idx int
+	if _, err := fmt.Sscanf(id, "hook_%d", &idx); err != nil || idx < 0 || idx >= len(s.config.hooks) {
+		return nil, fmt.Errorf("unknown hook callback %q", id)
+	}
+	h := s.config.hooks[idx]
+
+	var in HookInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, fmt.Errorf("parse %s hook input: %w", h.event, err)
+	}
+	in.Raw = input
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.hookTimeout)
+	defer cancel()
+	out, err := h.callback(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s hook: %w", h.event, err)
+	}
+	return out.wire(h.event), nil
+}
+
+// answerHookCallback runs a hook_callback control request.
+func (s *session) answerHookCallback(requestID string, body json.RawMessage) {
+	var req struct {
+		CallbackID string          `json:"callback_id"`
+		Input      json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.respondControl(requestID, nil, fmt.Errorf("parse hook_callback request: %w", err))
+		return
+	}
+
+	ctx, cancel := s.inflightContext(requestID)
+	defer cancel()
+	out, err := s.runHook(ctx, req.CallbackID, req.Input)
+	if !s.finishInflight(requestID) {
+		return // Withdrawn by control_cancel_request
+	}
+	s.respondControl(requestID, out, err)
+}
+
+// shimRequest and shimResponse are the socket shim's messages.
+type shimRequest struct {
+	ID    string          `json:"id"`
+	Input json.RawMessage `json:"input"`
+}
+
+type shimResponse struct {
+	Output map[string]any `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// startHookServer listens on a Unix socket in a private temp directory and
+// sets the --settings JSON whose command hooks run the shim against it.
+func (s *session) startHookServer() error {
+	if !hookShimInstalled.Load() {
+		return ErrHookShimUnavailable
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate hook shim: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "llmkit-hooks-")
+	if err != nil {
+		return fmt.Errorf("create hook socket dir: %w", err)
+	}
+	sock := filepath.Join(dir, "hooks.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("listen on hook socket: %w", err)
+	}
+
+	hooks := s.hookConfig(func(id string, h hookRegistration) map[string]any {
+		command := fmt.Sprintf("%s=%s %s=%s %s", envHookSocket, shellQuote(sock), envHookID, id, shellQuote(exe))
+		return map[string]any{
+			"matcher": h.matcher,
+			"hooks": []map[string]any{{
+				"type":    "command",
+				"command": command,
+				"timeout": int(s.config.hookTimeout.Seconds()),
+			}},
+		}
+	})
+	settings, err := shimSettings(s.config.settings, hooks)
+	if err != nil {
+		_ = ln.Close()
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("build hook settings: %w", err)
+	}
+
+	s.hookDir, s.hookListener, s.hookSettings = dir, ln, string(settings)
+	go s.serveHooks(ln)
+	return nil
+}
+
+// shimSettings adds the shim's command hooks to the WithSettings settings,
+// since the CLI takes a single --settings.
+func shimSettings(base string, hooks map[string][]map[string]any) ([]byte, error) {
+	merged := map[string]any{}
+	if base != "" {
+		data := []byte(base)
+		if !json.Valid(data) {
+			var err error
+			if data, err = os.ReadFile(base); err != nil {
+				return nil, fmt.Errorf("read settings: %w", err)
+			}
+		}
+		if err := json.Unmarshal(data, &merged); err != nil {
+			return nil, fmt.Errorf("parse settings: %w", err)
+		}
+	}
+	all, _ := merged["hooks"].(map[string]any)
+	if all == nil {
+		all = map[string]any{}
+	}
+	for event, groups := range hooks {
+		existing, _ := all[event].([]any)
+		for _, group := range groups {
+			existing = append(existing, group)
+		}
+		all[event] = existing
+	}
+	merged["hooks"] = all
+	return json.Marshal(merged)
+}
+
+// serveHooks answers shim connections until the listener closes.
+func (s *session) serveHooks(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveHookConn(conn)
+	}
+}
+
+// serveHookConn runs the hook one shim asks for.
+func (s *session) serveHookConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.config.hookTimeout + 5*time.Second))
+
+	var req shimRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var resp shimResponse
+	out, err := s.runHook(ctx, req.ID, req.Input)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Output = out
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// stopHookServer closes the shim socket and removes its directory.
+func (s *session) stopHookServer() {
+	s.hookStop.Do(func() {
+		if s.hookListener != nil {
+			_ = s.hookListener.Close()
+			_ = os.RemoveAll(s.hookDir)
+		}
+	})
+}
+
+// HookShimMain turns the process into a hook shim when the CLI runs it as
+// one, and returns otherwise. Programs using HookTransportAuto or
+// HookTransportSocket call it first thing in main:
+//
+//	func main() {
+//	    session.HookShimMain()
+//	    ...
+//	}
+func HookShimMain() {
+	hookShimInstalled.Store(true)
+	sock := os.Getenv(envHookSocket)
+	if sock == "" {
+		return
+	}
+	os.Exit(runHookShim(sock, os.Getenv(envHookID), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// runHookShim forwards one hook input to the session's socket and prints
+// the answer the way command hooks report it: JSON on stdout and exit 0,
+// or a message on stderr and exit 1 for a non-blocking error.
+func runHookShim(sock, id string, stdin io.Reader, stdout, stderr io.Writer) int {
+	input, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "read hook input: %v\n", err)
+		return 1
+	}
+	if len(bytes.TrimSpace(input)) == 0 {
+		input = []byte("{}")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		fmt.Fprintf(stderr, "connect to hook socket: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(shimRequest{ID: id, Input: input}); err != nil {
+		fmt.Fprintf(stderr, "send hook input: %v\n", err)
+		return 1
+	}
+	var resp shimResponse
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		fmt.Fprintf(stderr, "read hook output: %v\n", err)
+		return 1
+	}
+	if resp.Error != "" {
+		fmt.Fprintln(stderr, resp.Error)
+		return 1
+	}
+	if err := json.NewEncoder(stdout).Encode(resp.Output); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,212 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// hookStub registers hooks like the CLI. With STUB_MODE=refuse it rejects
+// the initialize request, and when started with --settings it runs the
+// first command hook itself. Either way the first user message fires a
+// PreToolUse hook for Bash, and the hook's answer is logged to $STUB_LOG.
+const hookStub = `#!/bin/sh
+settings=""
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--settings" ]; then settings=$2; fi
+  shift
+done
+cmd=$(printf '%s' "$settings" | sed -n 's/.*"command":"\([^"]*\)".*/\1/p')
+input='{"hook_event_name":"PreToolUse","session_id":"s1","tool_name":"Bash","tool_input":{"command":"rm -rf /"}}'
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+    *'"subtype":"initialize"'*)
+      if [ "$STUB_MODE" = "refuse" ]; then
+        printf '{"type":"control_response","response":{"subtype":"error","request_id":"%s","error":"unsupported"}}\n' "$id"
+      else
+        printf '%s\n' "$line" >> "$STUB_LOG"
+        printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+      fi ;;
+    *control_response*)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      printf '{"type":"result","subtype":"success","session_id":"s1","result":"done"}\n' ;;
+    *'"type":"user"'*)
+      if [ -n "$cmd" ]; then
+        printf '%s' "$input" | sh -c "$cmd" >> "$STUB_LOG"
+        printf '{"type":"result","subtype":"success","session_id":"s1","result":"done"}\n'
+      else
+        printf '{"type":"control_request","request_id":"hook-1","request":{"subtype":"hook_callback","callback_id":"hook_0","input":%s}}\n' "$input"
+      fi ;;
+  esac
+done
+`
+
+// runHookTurn starts a session against hookStub, runs one turn and returns
+// the stub's log lines once the session is closed.
+func runHookTurn(t *testing.T, workdir string, env map[string]string, opts ...SessionOption) []string {
+	t.Helper()
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "claude")
+	if err := os.WriteFile(stub, []byte(hookStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "stdin.log")
+	env["STUB_LOG"] = logPath
+
+	opts = append([]SessionOption{WithClaudePath(stub), WithWorkdir(workdir), WithEnv(env)}, opts...)
+	s, err := newSession(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	if err := s.Send(context.Background(), NewUserMessage("clean up")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case msg := <-s.Output():
+			done = msg.IsResult()
+		case <-timeout:
+			t.Fatal("timed out waiting for the turn to end")
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("%s has %d entries left, want none", dir, len(entries))
+	}
+}
+
+func TestSessionHookCallbacks(t *testing.T) {
+	workdir := t.TempDir()
+	var got HookInput
+	lines := runHookTurn(t, workdir, map[string]string{},
+		WithHook(claudecontract.HookPreToolUse, "Bash", func(_ context.Context, in HookInput) (HookOutput, error) {
+			got = in
+			return Block("no rm"), nil
+		}),
+		WithHook(claudecontract.HookUserPromptSubmit, "", func(context.Context, HookInput) (HookOutput, error) {
+			return AddContext("unused"), nil
+		}),
+	)
+
+	if got.HookEventName != claudecontract.HookPreToolUse || got.ToolName != "Bash" || !strings.Contains(string(got.ToolInput), "rm -rf") {
+		t.Fatalf("hook input = %+v", got)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("stub log = %q, want initialize and one hook answer", lines)
+	}
+	for _, want := range []string{`"subtype":"initialize"`, `"PreToolUse":[{"hookCallbackIds":["hook_0"],"matcher":"Bash"`, `"UserPromptSubmit":[{"hookCallbackIds":["hook_1"]`} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("initialize request %s lacks %s", lines[0], want)
+		}
+	}
+	for _, want := range []string{`"request_id":"hook-1"`, `"permissionDecision":"deny"`, `"permissionDecisionReason":"no rm"`} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("hook answer %s lacks %s", lines[1], want)
+		}
+	}
+	assertEmptyDir(t, workdir)
+}
+
+func TestSessionHookSocketFallback(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp) // Where the hook socket lives
+	workdir := t.TempDir()
+
+	lines := runHookTurn(t, workdir, map[string]string{"STUB_MODE": "refuse"},
+		WithHook(claudecontract.HookPreToolUse, "Bash", func(_ context.Context, in HookInput) (HookOutput, error) {
+			return ModifyToolInput(json.RawMessage(`{"command":"ls"}`)), nil
+		}),
+	)
+
+	if len(lines) != 1 {
+		t.Fatalf("stub log = %q, want one shim answer", lines)
+	}
+	var out struct {
+		HookSpecificOutput map[string]any `json:"hookSpecificOutput"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &out); err != nil {
+		t.Fatalf("shim output %q: %v", lines[0], err)
+	}
+	if out.HookSpecificOutput["permissionDecision"] != "allow" ||
+		!reflect.DeepEqual(out.HookSpecificOutput["updatedInput"], map[string]any{"command": "ls"}) {
+		t.Fatalf("shim output = %s", lines[0])
+	}
+	assertEmptyDir(t, workdir)
+	assertEmptyDir(t, tmp)
+}
+
+func TestShimSettingsMergesBase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	base := `{"env":{"FOO":"bar"},"hooks":{"Stop":[{"hooks":[{"type":"command","command":"mine"}]}]}}`
+	if err := os.WriteFile(path, []byte(base), 0o644); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+	shim := map[string][]map[string]any{"Stop": {{"matcher": "", "hooks": []any{}}}}
+
+	for _, pathOrJSON := range []string{path, base} {
+		data, err := shimSettings(pathOrJSON, shim)
+		if err != nil {
+			t.Fatalf("shimSettings(%s): %v", pathOrJSON, err)
+		}
+		var got struct {
+			Env   map[string]string            `json:"env"`
+			Hooks map[string][]json.RawMessage `json:"hooks"`
+		}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("parse %s: %v", data, err)
+		}
+		if got.Env["FOO"] != "bar" || len(got.Hooks["Stop"]) != 2 {
+			t.Fatalf("shimSettings(%s) = %s", pathOrJSON, data)
+		}
+	}
+	if _, err := shimSettings(filepath.Join(t.TempDir(), "missing.json"), shim); err == nil {
+		t.Fatal("shimSettings accepted a missing settings file")
+	}
+}
+
+func TestHookOutputWire(t *testing.T) {
+	tests := []struct {
+		event claudecontract.HookEvent
+		out   HookOutput
+		want  string
+	}{
+		{claudecontract.HookPostToolUse, Block("tests failed"), `{"decision":"block","reason":"tests failed"}`},
+		{claudecontract.HookSessionStart, AddContext("on branch main"), `{"hookSpecificOutput":{"additionalContext":"on branch main","hookEventName":"SessionStart"}}`},
+		{claudecontract.HookStop, HookOutput{Stop: true, StopReason: "budget", SystemMessage: "stopping"}, `{"continue":false,"stopReason":"budget","systemMessage":"stopping"}`},
+		{claudecontract.HookNotification, HookOutput{}, `{}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.out.wire(tt.event))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s output = %s, want %s", tt.event, data, tt.want)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
 )
 
 // SessionManager manages multiple Claude CLI sessions.
@@ -35,11 +37,24 @@ type SessionManager interface {
 	Info(sessionID string) (*SessionInfo, bool)
 }
 
+// Restorer is implemented by the manager NewManager returns. It is kept off
+// SessionManager so existing implementations and mocks stay valid.
+type Restorer interface {
+	// Restore loads the active and suspended Claude sessions recorded in the
+	// manager's session store. Restored sessions are not started; Get or
+	// Resume restarts one with its stored options and WithResume on first use.
+	// Go callbacks and environment values are not stored; pass them again
+	// with WithDefaultSessionOptions or as Resume options.
+	Restore() error
+}
+
 // manager implements SessionManager.
 type manager struct {
 	config     managerConfig
 	sessions   map[string]*session
 	aliases    map[string]string
+	restored   map[string]sessionstore.Record
+	closing    map[*session]bool
 	mu         sync.RWMutex
 	closed     bool
 	closedOnce sync.Once
@@ -58,6 +73,8 @@ func NewManager(opts ...ManagerOption) SessionManager {
 		config:    cfg,
 		sessions:  make(map[string]*session),
 		aliases:   make(map[string]string),
+		restored:  make(map[string]sessionstore.Record),
+		closing:   make(map[*session]bool),
 		stopClean: make(chan struct{}),
 	}
 
@@ -84,22 +101,42 @@ func (m *manager) Create(ctx context.Context, opts ...SessionOption) (Session, e
 	m.mu.Unlock()
 
 	// Apply default options first, then user options
-	allOpts := make([]SessionOption, 0, len(m.config.defaultOpts)+len(opts))
+	allOpts := make([]SessionOption, 0, len(m.config.defaultOpts)+len(opts)+1)
 	allOpts = append(allOpts, m.config.defaultOpts...)
 	allOpts = append(allOpts, opts...)
+	if m.config.store != nil {
+		allOpts = append(allOpts, withUpdateHook(m.persistActive))
+	}
 
 	s, err := newSession(ctx, allOpts...)
 	if err != nil {
 		return nil, err
 	}
 
+	key, err := m.register(s)
+	if err != nil {
+		_ = s.Close() // Best effort cleanup
+		return nil, err
+	}
+	// Sessions created with a known ID are recorded now; pending ones are
+	// recorded by trackSessionID once init arrives.
+	m.persistActive(s)
+
+	// Start goroutines to track init/close lifecycle.
+	go m.trackSessionID(key, s)
+	go m.watchSession(key, s)
+
+	return s, nil
+}
+
+// register adds a newly started session to the manager's maps.
+func (m *manager) register(s *session) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Double-check limits after session creation
 	if len(m.sessions) >= m.config.maxSessions {
-		_ = s.Close() // Best effort cleanup
-		return nil, fmt.Errorf("max sessions reached (%d)", m.config.maxSessions)
+		return "", fmt.Errorf("max sessions reached (%d)", m.config.maxSessions)
 	}
 
 	key := m.sessionKeyForCreate(s)
@@ -107,17 +144,12 @@ func (m *manager) Create(ctx context.Context, opts ...SessionOption) (Session, e
 	if sessionID := s.ID(); sessionID != "" {
 		if _, exists := m.aliases[sessionID]; exists {
 			delete(m.sessions, key)
-			_ = s.Close()
-			return nil, fmt.Errorf("session already tracked: %s", sessionID)
+			return "", fmt.Errorf("session already tracked: %s", sessionID)
 		}
 		m.aliases[sessionID] = key
+		delete(m.restored, sessionID)
 	}
-
-	// Start goroutines to track init/close lifecycle.
-	go m.trackSessionID(key, s)
-	go m.watchSession(key, s)
-
-	return s, nil
+	return key, nil
 }
 
 func (m *manager) sessionKeyForCreate(s *session) string {
@@ -128,7 +160,8 @@ func (m *manager) sessionKeyForCreate(s *session) string {
 }
 
 func (m *manager) trackSessionID(key string, s *session) {
-	if s.ID() == "" {
+	pending := s.ID() == ""
+	if pending {
 		if err := s.WaitForInit(context.Background()); err != nil {
 			return
 		}
@@ -140,16 +173,22 @@ func (m *manager) trackSessionID(key string, s *session) {
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	current, ok := m.sessions[key]
 	if !ok || current != s {
+		m.mu.Unlock()
 		return
 	}
 	if existingKey, exists := m.aliases[sessionID]; exists && existingKey != key {
+		m.mu.Unlock()
 		return
 	}
 	m.aliases[sessionID] = key
+	delete(m.restored, sessionID)
+	m.mu.Unlock()
+
+	if pending {
+		m.persistActive(s)
+	}
 }
 
 // watchSession removes a session from the map when it closes.
@@ -161,11 +200,32 @@ func (m *manager) watchSession(key string, s *session) {
 	if sessionID != "" && m.aliases[sessionID] == key {
 		delete(m.aliases, sessionID)
 	}
+	status := sessionstore.StatusSuspended
+	if m.closing[s] {
+		status = sessionstore.StatusClosed
+		delete(m.closing, s)
+	}
 	m.mu.Unlock()
+
+	m.persist(s, status)
 }
 
-// Get implements SessionManager.
-func (m *manager) Get(sessionID string) (Session, bool) {
+// persistActive records s as active in the session store.
+func (m *manager) persistActive(s *session) {
+	m.persist(s, sessionstore.StatusActive)
+}
+
+// persist writes s to the session store. Store errors are dropped: the
+// session keeps running and the next update writes the record again.
+func (m *manager) persist(s *session, status string) {
+	if m.config.store == nil || s.ID() == "" {
+		return
+	}
+	_ = m.config.store.Save(s.record(status))
+}
+
+// getActive returns the running session for sessionID.
+func (m *manager) getActive(sessionID string) (Session, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -180,31 +240,101 @@ func (m *manager) Get(sessionID string) (Session, bool) {
 	return s, true
 }
 
-// Resume implements SessionManager.
+// Get implements SessionManager. A restored session is resumed on first Get.
+func (m *manager) Get(sessionID string) (Session, bool) {
+	if s, ok := m.getActive(sessionID); ok {
+		return s, true
+	}
+
+	m.mu.RLock()
+	_, restored := m.restored[sessionID]
+	m.mu.RUnlock()
+	if !restored {
+		return nil, false
+	}
+	s, err := m.Resume(context.Background(), sessionID)
+	if err != nil {
+		return nil, false
+	}
+	return s, true
+}
+
+// Resume implements SessionManager. When the session store has a record for
+// sessionID, its stored options are applied before opts.
 func (m *manager) Resume(ctx context.Context, sessionID string, opts ...SessionOption) (Session, error) {
 	// Check if session is already active
-	if s, ok := m.Get(sessionID); ok {
+	if s, ok := m.getActive(sessionID); ok {
 		return s, nil
 	}
 
 	// Add resume option
-	resumeOpts := append([]SessionOption{WithResume(sessionID)}, opts...)
-	return m.Create(ctx, resumeOpts...)
+	resumeOpts := []SessionOption{WithResume(sessionID)}
+	if rec, ok := m.storedRecord(sessionID); ok {
+		resumeOpts = restoreOptions(rec)
+	}
+	return m.Create(ctx, append(resumeOpts, opts...)...)
+}
+
+// storedRecord returns the restored or stored record for sessionID.
+func (m *manager) storedRecord(sessionID string) (sessionstore.Record, bool) {
+	m.mu.RLock()
+	rec, ok := m.restored[sessionID]
+	m.mu.RUnlock()
+	if ok {
+		return rec, true
+	}
+	if m.config.store == nil {
+		return sessionstore.Record{}, false
+	}
+	rec, err := m.config.store.Load(sessionID)
+	return rec, err == nil && rec.Provider == storeProvider
+}
+
+// Restore implements Restorer.
+func (m *manager) Restore() error {
+	if m.config.store == nil {
+		return fmt.Errorf("no session store configured")
+	}
+	records, err := m.config.store.List()
+	if err != nil {
+		return fmt.Errorf("restore sessions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range records {
+		if rec.Provider != storeProvider || rec.Status == sessionstore.StatusClosed {
+			continue
+		}
+		if _, live := m.aliases[rec.ID]; live {
+			continue
+		}
+		m.restored[rec.ID] = rec
+	}
+	return nil
 }
 
-// Close implements SessionManager.
+// Close implements SessionManager. The session's store record is marked
+// closed so Restore skips it.
 func (m *manager) Close(sessionID string) error {
 	m.mu.Lock()
 	key, ok := m.aliases[sessionID]
 	if !ok {
+		rec, restored := m.restored[sessionID]
+		delete(m.restored, sessionID)
 		m.mu.Unlock()
-		return fmt.Errorf("session not found: %s", sessionID)
+		if !restored {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+		rec.Status = sessionstore.StatusClosed
+		return m.config.store.Save(rec)
 	}
 	s, ok := m.sessions[key]
 	if !ok {
 		m.mu.Unlock()
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
+	m.closing[s] = true
 	m.mu.Unlock()
 
 	return s.Close()
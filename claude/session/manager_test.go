@@ -2,10 +2,15 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
 )
 
 func TestManagerConfig_Defaults(t *testing.T) {
@@ -369,12 +374,137 @@ func TestManagerTracksPendingSessionUnderResolvedID(t *testing.T) {
 	}
 }
 
+func TestManagerRestoreResumesWithStoredOptions(t *testing.T) {
+	stub := writeStubCLI(t)
+	workdir := t.TempDir()
+	options, _ := json.Marshal(storedOptions{ClaudePath: stub, Model: "opus", Workdir: workdir, MaxTurns: 3})
+
+	store := sessionstore.NewMemoryStore()
+	for _, rec := range []sessionstore.Record{
+		{ID: "sess-a", Provider: "claude", Options: options, Status: sessionstore.StatusSuspended,
+			Usage: sessionstore.Usage{Turns: 4, CostUSD: 1.5}, Metadata: map[string]string{"task": "T-1"}},
+		{ID: "sess-b", Provider: "claude", Options: options, Status: sessionstore.StatusClosed},
+		{ID: "thread-c", Provider: "codex", Status: sessionstore.StatusActive},
+	} {
+		if err := store.Save(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr := NewManager(WithSessionStore(store), WithSessionTTL(0))
+	defer mgr.CloseAll()
+	if err := mgr.(Restorer).Restore(); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if mgr.Count() != 0 {
+		t.Fatalf("Restore started %d sessions, want lazy resume", mgr.Count())
+	}
+	if _, ok := mgr.Get("sess-b"); ok {
+		t.Fatal("closed record should not be restored")
+	}
+	if _, ok := mgr.Get("thread-c"); ok {
+		t.Fatal("codex record should not be restored by the claude manager")
+	}
+
+	got, ok := mgr.Get("sess-a")
+	if !ok {
+		t.Fatal("Get did not resume restored session")
+	}
+	s := got.(*session)
+	if !s.config.resume || s.config.model != "opus" || s.config.workdir != workdir || s.config.maxTurns != 3 {
+		t.Fatalf("resumed config = %+v", s.config)
+	}
+
+	rec, err := store.Load("sess-a")
+	if err != nil || rec.Status != sessionstore.StatusActive || rec.Usage.Turns != 4 || rec.Metadata["task"] != "T-1" {
+		t.Fatalf("record after resume = %+v, %v", rec, err)
+	}
+
+	if err := mgr.Close("sess-a"); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	waitForRecordStatus(t, store, "sess-a", sessionstore.StatusClosed)
+}
+
+func TestSessionRecordRoundTripsLaunchOptions(t *testing.T) {
+	cfg := defaultConfig()
+	WithSettings("/tmp/overlay/settings.json")(&cfg)
+	WithMCPConfig("/tmp/overlay/mcp.json")(&cfg)
+	WithPluginDir("/tmp/plugins")(&cfg)
+	WithAgentsJSON(`{"reviewer":{}}`)(&cfg)
+	WithControlTimeout(45 * time.Second)(&cfg)
+	WithHookTransport(HookTransportSocket)(&cfg)
+	WithHookTimeout(20 * time.Second)(&cfg)
+	WithEnv(map[string]string{"ANTHROPIC_API_KEY": "secret"})(&cfg)
+	WithPermissionHandler(func(context.Context, ToolPermissionRequest) (PermissionDecision, error) {
+		return PermissionDecision{}, nil
+	})(&cfg)
+	WithHook(claudecontract.HookPreToolUse, "Bash", func(context.Context, HookInput) (HookOutput, error) {
+		return HookOutput{}, nil
+	})(&cfg)
+
+	s := &session{id: "sess-1", config: cfg, createdAt: time.Now()}
+	s.lastActivity.Store(time.Now())
+	s.totalCost.Store(float64(0))
+	rec := s.record(sessionstore.StatusActive)
+	if strings.Contains(string(rec.Options), "secret") {
+		t.Fatalf("stored options = %s, want options without env", rec.Options)
+	}
+
+	restored := defaultConfig()
+	for _, opt := range restoreOptions(rec) {
+		opt(&restored)
+	}
+	if restored.settings != cfg.settings || len(restored.mcpConfigs) != 1 || len(restored.pluginDirs) != 1 ||
+		restored.agentsJSON != cfg.agentsJSON || restored.controlTimeout != 45*time.Second ||
+		restored.hookTransport != HookTransportSocket || restored.hookTimeout != 20*time.Second {
+		t.Fatalf("restored config = %+v", restored)
+	}
+	if restored.permissionHandler != nil || restored.hooks != nil || restored.extraEnv != nil {
+		t.Fatal("restored config should not carry the permission handler, hooks or env")
+	}
+}
+
+func TestManagerRecordsSuspendedOnCloseAll(t *testing.T) {
+	stub := writeStubCLI(t)
+	store := sessionstore.NewMemoryStore()
+	mgr := NewManager(WithSessionStore(store), WithSessionTTL(0))
+
+	if _, err := mgr.Create(context.Background(), WithClaudePath(stub), WithSessionID("sess-new"), WithMetadata(map[string]string{"task": "T-2"})); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	waitForRecordStatus(t, store, "sess-new", sessionstore.StatusActive)
+	if err := mgr.CloseAll(); err != nil {
+		t.Fatalf("CloseAll returned error: %v", err)
+	}
+	rec := waitForRecordStatus(t, store, "sess-new", sessionstore.StatusSuspended)
+	if rec.Metadata["task"] != "T-2" || rec.Provider != "claude" {
+		t.Fatalf("suspended record = %+v", rec)
+	}
+}
+
+func waitForRecordStatus(t *testing.T, store sessionstore.Store, id, status string) sessionstore.Record {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec, err := store.Load(id)
+		if err == nil && rec.Status == status {
+			return rec
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("record %s = %+v, %v; want status %s", id, rec, err, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestManager_Interface(t *testing.T) {
 	// Ensure mockSessionManager implements SessionManager
 	var _ SessionManager = (*mockSessionManager)(nil)
 
 	// Ensure manager implements SessionManager
 	var _ SessionManager = (*manager)(nil)
+	var _ Restorer = (*manager)(nil)
 }
 
 func TestSession_Interface(t *testing.T) {
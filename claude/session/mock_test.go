@@ -7,10 +7,15 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/fanout"
 )
 
-// TestMain sets up the test helper command for mocking Claude CLI.
+// TestMain sets up the test helper command for mocking Claude CLI. The
+// test binary doubles as the hook socket shim.
 func TestMain(m *testing.M) {
+	HookShimMain()
 	os.Exit(m.Run())
 }
 
@@ -47,6 +52,20 @@ func (m *mockSession) Info() SessionInfo                   { return m.info }
 func (m *mockSession) Wait() error                         { return nil }
 func (m *mockSession) WaitForInit(_ context.Context) error { return nil }
 func (m *mockSession) JSONLPath() string                   { return "" }
+func (m *mockSession) Interrupt(_ context.Context) error   { return nil }
+func (m *mockSession) SetModel(_ context.Context, _ string) error {
+	return nil
+}
+func (m *mockSession) SetPermissionMode(_ context.Context, _ claudecontract.PermissionMode) error {
+	return nil
+}
+func (m *mockSession) Control(_ context.Context, subtype string, _ map[string]any) (*ControlResponse, error) {
+	return &ControlResponse{Subtype: claudecontract.ControlResponseSuccess, RequestID: "req_mock"}, nil
+}
+
+func (m *mockSession) Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage] {
+	return fanout.New[OutputMessage](nil).Subscribe(opts...)
+}
 
 func (m *mockSession) Send(_ context.Context, msg UserMessage) error {
 	m.closeMu.Lock()
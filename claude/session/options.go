@@ -1,6 +1,11 @@
 package session
 
-import "time"
+import (
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
+)
 
 // SessionOption configures a Session.
 type SessionOption func(*sessionConfig)
@@ -32,10 +37,15 @@ type sessionConfig struct {
 	// Permissions
 	dangerouslySkipPermissions bool
 	permissionMode             string
+	permissionHandler          PermissionHandler
 	settingSources             []string
+	settings                   string
 
 	// Context
 	addDirs            []string
+	mcpConfigs         []string
+	pluginDirs         []string
+	agentsJSON         string
 	systemPrompt       string
 	appendSystemPrompt string
 
@@ -46,6 +56,7 @@ type sessionConfig struct {
 	// Timeouts
 	startupTimeout time.Duration
 	idleTimeout    time.Duration
+	controlTimeout time.Duration
 
 	// Environment
 	homeDir   string
@@ -54,6 +65,16 @@ type sessionConfig struct {
 
 	// Output filtering
 	includeHookOutput bool
+
+	// In-process hooks
+	hooks         []hookRegistration
+	hookTransport HookTransport
+	hookTimeout   time.Duration
+
+	// Persistence
+	metadata map[string]string
+	restored *sessionstore.Record
+	onUpdate func(*session)
 }
 
 // defaultConfig returns the default session configuration.
@@ -63,6 +84,8 @@ func defaultConfig() sessionConfig {
 		dangerouslySkipPermissions: true, // Required for non-interactive use
 		startupTimeout:             30 * time.Second,
 		idleTimeout:                10 * time.Minute,
+		controlTimeout:             30 * time.Second,
+		hookTimeout:                60 * time.Second,
 		includeHookOutput:          false,
 	}
 }
@@ -146,12 +169,66 @@ func WithPermissionMode(mode string) SessionOption {
 	return func(c *sessionConfig) { c.permissionMode = mode }
 }
 
+// WithPermissionHandler decides tool calls in Go. The CLI is started with
+// --permission-prompt-tool stdio instead of --dangerously-skip-permissions
+// and asks the handler about every call its own settings, permission mode
+// and allowed tools do not already decide. Each decision is also emitted as
+// a permission_decision system message (see OutputMessage.Permission).
+// RulesPermissionHandler decides with claudeconfig permission rules.
+func WithPermissionHandler(h PermissionHandler) SessionOption {
+	return func(c *sessionConfig) { c.permissionHandler = h }
+}
+
+// WithHook registers a Go callback for a hook event. matcher selects tools
+// for tool events like a settings.json matcher ("" or "*" matches all).
+// Callbacks reach the CLI as configured by WithHookTransport; nothing is
+// written to the project.
+func WithHook(event claudecontract.HookEvent, matcher string, cb HookCallback) SessionOption {
+	return func(c *sessionConfig) {
+		c.hooks = append(c.hooks, hookRegistration{event: event, matcher: matcher, callback: cb})
+	}
+}
+
+// WithHookTransport selects how the CLI reaches WithHook callbacks.
+// Default: HookTransportAuto.
+func WithHookTransport(t HookTransport) SessionOption {
+	return func(c *sessionConfig) { c.hookTransport = t }
+}
+
+// WithHookTimeout bounds each hook callback. Default: 60 seconds.
+func WithHookTimeout(d time.Duration) SessionOption {
+	return func(c *sessionConfig) { c.hookTimeout = d }
+}
+
 // WithSettingSources specifies which setting sources to use.
 // Valid values: "project", "local", "user"
 func WithSettingSources(sources []string) SessionOption {
 	return func(c *sessionConfig) { c.settingSources = sources }
 }
 
+// WithSettings loads additional settings from a file path or JSON string.
+// Hooks registered with WithHook are merged into it.
+func WithSettings(pathOrJSON string) SessionOption {
+	return func(c *sessionConfig) { c.settings = pathOrJSON }
+}
+
+// WithMCPConfig adds an MCP configuration file path or JSON string.
+// Can be called multiple times.
+func WithMCPConfig(pathOrJSON string) SessionOption {
+	return func(c *sessionConfig) { c.mcpConfigs = append(c.mcpConfigs, pathOrJSON) }
+}
+
+// WithPluginDir adds a plugin directory to load for this session.
+// Can be called multiple times.
+func WithPluginDir(dir string) SessionOption {
+	return func(c *sessionConfig) { c.pluginDirs = append(c.pluginDirs, dir) }
+}
+
+// WithAgentsJSON defines custom agents inline, in the --agents JSON format.
+func WithAgentsJSON(json string) SessionOption {
+	return func(c *sessionConfig) { c.agentsJSON = json }
+}
+
 // WithAddDirs adds directories to Claude's file access scope.
 func WithAddDirs(dirs []string) SessionOption {
 	return func(c *sessionConfig) { c.addDirs = dirs }
@@ -191,6 +268,12 @@ func WithIdleTimeout(d time.Duration) SessionOption {
 	return func(c *sessionConfig) { c.idleTimeout = d }
 }
 
+// WithControlTimeout sets how long control requests such as Interrupt and
+// SetModel wait for the CLI's answer. The default is 30 seconds.
+func WithControlTimeout(d time.Duration) SessionOption {
+	return func(c *sessionConfig) { c.controlTimeout = d }
+}
+
 // WithHomeDir sets the HOME environment variable for credential discovery.
 func WithHomeDir(dir string) SessionOption {
 	return func(c *sessionConfig) { c.homeDir = dir }
@@ -219,6 +302,19 @@ func WithIncludeHookOutput(include bool) SessionOption {
 	return func(c *sessionConfig) { c.includeHookOutput = include }
 }
 
+// WithMetadata attaches caller-defined keys, such as a task ID, to the
+// session's store record.
+func WithMetadata(metadata map[string]string) SessionOption {
+	return func(c *sessionConfig) {
+		if c.metadata == nil {
+			c.metadata = make(map[string]string)
+		}
+		for k, v := range metadata {
+			c.metadata[k] = v
+		}
+	}
+}
+
 // ManagerOption configures a SessionManager.
 type ManagerOption func(*managerConfig)
 
@@ -235,6 +331,9 @@ type managerConfig struct {
 
 	// Cleanup interval for expired sessions
 	cleanupInterval time.Duration
+
+	// Durable record of managed sessions (nil = memory only)
+	store sessionstore.Store
 }
 
 // defaultManagerConfig returns the default manager configuration.
@@ -266,3 +365,10 @@ func WithSessionTTL(d time.Duration) ManagerOption {
 func WithCleanupInterval(d time.Duration) ManagerOption {
 	return func(c *managerConfig) { c.cleanupInterval = d }
 }
+
+// WithSessionStore persists every managed session to store on create, after
+// each turn, and when it ends. Call SessionManager.Restore to pick up the
+// stored sessions after a restart.
+func WithSessionStore(store sessionstore.Store) ManagerOption {
+	return func(c *managerConfig) { c.store = store }
+}
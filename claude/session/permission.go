@@ -0,0 +1,163 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// SubtypePermissionDecision is the subtype of the system messages this
+// package emits after each permission decision. The CLI never sends it.
+const SubtypePermissionDecision = "permission_decision"
+
+// PermissionHandler decides whether a tool call may run. It is called
+// concurrently for parallel tool calls, and ctx is cancelled when the CLI
+// withdraws the request or the session ends. Returning an error denies the
+// call with the error text.
+type PermissionHandler func(ctx context.Context, req ToolPermissionRequest) (PermissionDecision, error)
+
+// ToolPermissionRequest is a tool call waiting for a permission decision.
+type ToolPermissionRequest struct {
+	RequestID   string          `json:"request_id"`
+	ToolName    string          `json:"tool_name"`
+	ToolUseID   string          `json:"tool_use_id,omitempty"`
+	Input       json.RawMessage `json:"input,omitempty"`
+	Suggestions json.RawMessage `json:"permission_suggestions,omitempty"` // Rule updates the CLI would offer a user
+	BlockedPath string          `json:"blocked_path,omitempty"`           // Path outside the allowed directories, if any
+}
+
+// PermissionDecision is the answer to a ToolPermissionRequest. The zero
+// value denies the call.
+type PermissionDecision struct {
+	Behavior     claudecontract.PermissionBehavior `json:"behavior"`                // allow or deny
+	Message      string                            `json:"message,omitempty"`       // Reason shown to the model on deny
+	UpdatedInput json.RawMessage                   `json:"updated_input,omitempty"` // Replaces the tool input on allow
+	Interrupt    bool                              `json:"interrupt,omitempty"`     // Also stop the turn on deny
+}
+
+// Allow lets the tool call run unchanged.
+func Allow() PermissionDecision {
+	return PermissionDecision{Behavior: claudecontract.PermissionBehaviorAllow}
+}
+
+// AllowWithInput lets the tool call run with a replacement input.
+func AllowWithInput(input json.RawMessage) PermissionDecision {
+	return PermissionDecision{Behavior: claudecontract.PermissionBehaviorAllow, UpdatedInput: input}
+}
+
+// Deny refuses the tool call; message tells the model why.
+func Deny(message string) PermissionDecision {
+	return PermissionDecision{Behavior: claudecontract.PermissionBehaviorDeny, Message: message}
+}
+
+// PermissionEvent records one permission decision. It is delivered on
+// OutputMessage.Permission.
+type PermissionEvent struct {
+	Request  ToolPermissionRequest `json:"request"`
+	Decision PermissionDecision    `json:"decision"`
+}
+
+// RulesPermissionHandler returns a handler that decides tool calls with
+// Claude Code's settings rules (see claudeconfig.ToolPermissions.Evaluate).
+func RulesPermissionHandler(perms *claudeconfig.ToolPermissions) PermissionHandler {
+	return func(_ context.Context, req ToolPermissionRequest) (PermissionDecision, error) {
+		allowed, rule := perms.Evaluate(req.ToolName, req.Input)
+		switch {
+		case allowed:
+			return Allow(), nil
+		case rule != "":
+			return Deny(fmt.Sprintf("%s denied by permission rule %q", req.ToolName, rule)), nil
+		default:
+			return Deny(fmt.Sprintf("%s is not in the allowed tools", req.ToolName)), nil
+		}
+	}
+}
+
+// ParsePermissionRequest decodes the body of a can_use_tool control request.
+func ParsePermissionRequest(requestID string, body json.RawMessage) (ToolPermissionRequest, error) {
+	var req ToolPermissionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return req, fmt.Errorf("parse can_use_tool request: %w", err)
+	}
+	if req.ToolName == "" {
+		return req, fmt.Errorf("parse can_use_tool request: missing tool_name")
+	}
+	req.RequestID = requestID
+	return req, nil
+}
+
+// Decide runs the handler. Errors and decisions that are neither allow nor
+// deny become a deny, so a tool call is never left undecided.
+func (h PermissionHandler) Decide(ctx context.Context, req ToolPermissionRequest) PermissionDecision {
+	decision, err := h(ctx, req)
+	switch {
+	case err != nil:
+		return Deny(fmt.Sprintf("permission handler failed: %v", err))
+	case decision.Behavior == claudecontract.PermissionBehaviorAllow:
+		return decision
+	case decision.Behavior == claudecontract.PermissionBehaviorDeny:
+		if decision.Message == "" {
+			decision.Message = fmt.Sprintf("%s denied by permission handler", req.ToolName)
+		}
+		return decision
+	default:
+		return Deny(fmt.Sprintf("permission handler returned no decision for %s", req.ToolName))
+	}
+}
+
+// ControlResponse returns the can_use_tool response payload for the
+// decision. An allow without UpdatedInput echoes the original input, which
+// the CLI requires.
+func (d PermissionDecision) ControlResponse(req ToolPermissionRequest) map[string]any {
+	if d.Behavior == claudecontract.PermissionBehaviorAllow {
+		input := d.UpdatedInput
+		if len(input) == 0 {
+			input = req.Input
+		}
+		if len(input) == 0 {
+			input = json.RawMessage(`{}`)
+		}
+		return map[string]any{"behavior": string(d.Behavior), "updatedInput": input}
+	}
+	return map[string]any{"behavior": string(claudecontract.PermissionBehaviorDeny), "message": d.Message, "interrupt": d.Interrupt}
+}
+
+// newPermissionMessage wraps a permission event as a system output message.
+func newPermissionMessage(sessionID string, ev PermissionEvent) OutputMessage {
+	msg := OutputMessage{
+		Type:       claudecontract.EventTypeSystem,
+		Subtype:    SubtypePermissionDecision,
+		SessionID:  sessionID,
+		Permission: &ev,
+	}
+	msg.Raw, _ = json.Marshal(struct {
+		Type      string `json:"type"`
+		Subtype   string `json:"subtype"`
+		SessionID string `json:"session_id"`
+		PermissionEvent
+	}{msg.Type, msg.Subtype, sessionID, ev})
+	return msg
+}
+
+// answerPermission decides a can_use_tool request with the configured
+// handler, reports the decision on the output channel and answers the CLI.
+func (s *session) answerPermission(requestID string, body json.RawMessage) {
+	req, err := ParsePermissionRequest(requestID, body)
+	if err != nil {
+		s.respondControl(requestID, nil, err)
+		return
+	}
+
+	ctx, cancel := s.inflightContext(requestID)
+	defer cancel()
+	decision := s.config.permissionHandler.Decide(ctx, req)
+	if !s.finishInflight(requestID) {
+		return // Withdrawn by control_cancel_request; the CLI expects no answer
+	}
+
+	s.publish(newPermissionMessage(s.ID(), PermissionEvent{Request: req, Decision: decision}))
+	s.respondControl(requestID, decision.ControlResponse(req), nil)
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// permissionStub asks for permission to run three tools after the first
+// user message, logs the answers to $STUB_LOG and ends the turn once all
+// three are answered.
+const permissionStub = `#!/bin/sh
+answered=0
+while IFS= read -r line; do
+  case "$line" in
+    *control_response*)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      answered=$((answered + 1))
+      if [ "$answered" -eq 3 ]; then
+        printf '{"type":"result","subtype":"success","session_id":"s1","result":"done"}\n'
+      fi ;;
+    *'"type":"user"'*)
+      printf '{"type":"control_request","request_id":"perm-1","request":{"subtype":"can_use_tool","tool_name":"Bash","input":{"command":"rm -rf /"},"tool_use_id":"toolu_1"}}\n'
+      printf '{"type":"control_request","request_id":"perm-2","request":{"subtype":"can_use_tool","tool_name":"Read","input":{"file_path":"/repo/main.go"}}}\n'
+      printf '{"type":"control_request","request_id":"perm-3","request":{"subtype":"can_use_tool","tool_name":"Write","input":{"file_path":"/repo/out.txt","content":"x"}}}\n' ;;
+  esac
+done
+`
+
+func TestSessionPermissionHandler(t *testing.T) {
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "claude")
+	if err := os.WriteFile(stub, []byte(permissionStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "stdin.log")
+
+	rules := RulesPermissionHandler(&claudeconfig.ToolPermissions{Allow: []string{"Read", "Write"}})
+	handler := func(ctx context.Context, req ToolPermissionRequest) (PermissionDecision, error) {
+		switch req.ToolName {
+		case "Write":
+			return AllowWithInput(json.RawMessage(`{"file_path":"/tmp/out.txt","content":"x"}`)), nil
+		case "Bash":
+			return PermissionDecision{}, errors.New("no shell")
+		}
+		return rules(ctx, req)
+	}
+
+	s, err := newSession(context.Background(),
+		WithClaudePath(stub),
+		WithEnv(map[string]string{"STUB_LOG": logPath}),
+		WithPermissionHandler(handler),
+	)
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Send(context.Background(), NewUserMessage("go")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	decisions := map[string]PermissionDecision{}
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case msg := <-s.Output():
+			if msg.IsPermissionDecision() {
+				decisions[msg.Permission.Request.ToolName] = msg.Permission.Decision
+			}
+			done = msg.IsResult()
+		case <-timeout:
+			t.Fatal("timed out waiting for the turn to end")
+		}
+	}
+	if len(decisions) != 3 {
+		t.Fatalf("permission events = %+v, want 3", decisions)
+	}
+	if d := decisions["Bash"]; d.Behavior != claudecontract.PermissionBehaviorDeny || !strings.Contains(d.Message, "no shell") {
+		t.Fatalf("Bash decision = %+v, want deny with the handler error", d)
+	}
+	if d := decisions["Read"]; d.Behavior != claudecontract.PermissionBehaviorAllow {
+		t.Fatalf("Read decision = %+v, want allow", d)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string]map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var msg controlMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.Response == nil {
+			t.Fatalf("bad control response line %q: %v", line, err)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(msg.Response.Response, &body); err != nil {
+			t.Fatal(err)
+		}
+		responses[msg.Response.RequestID] = body
+	}
+	if r := responses["perm-1"]; r["behavior"] != "deny" || !strings.Contains(r["message"].(string), "no shell") {
+		t.Fatalf("perm-1 response = %v", r)
+	}
+	if r := responses["perm-2"]; r["behavior"] != "allow" || r["updatedInput"].(map[string]any)["file_path"] != "/repo/main.go" {
+		t.Fatalf("perm-2 response = %v, want the original input echoed", r)
+	}
+	if r := responses["perm-3"]; r["updatedInput"].(map[string]any)["file_path"] != "/tmp/out.txt" {
+		t.Fatalf("perm-3 response = %v, want the updated input", r)
+	}
+}
+
+func TestSession_BuildArgs_PermissionHandler(t *testing.T) {
+	cfg := defaultConfig()
+	WithPermissionHandler(RulesPermissionHandler(nil))(&cfg)
+
+	args := (&session{config: cfg}).buildArgs()
+
+	assertContains(t, args, "--permission-prompt-tool")
+	assertContains(t, args, "stdio")
+	assertNotContains(t, args, "--dangerously-skip-permissions")
+}
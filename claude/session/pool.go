@@ -0,0 +1,64 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/pool"
+)
+
+// PoolOption configures a Pool.
+type PoolOption func(*poolConfig)
+
+// poolConfig holds pool configuration.
+type poolConfig struct {
+	// Options for every pooled session
+	sessionOpts []SessionOption
+
+	// Options for the underlying pool
+	poolOpts []pool.Option
+}
+
+// WithPoolSize sets how many idle sessions the pool keeps ready.
+func WithPoolSize(n int) PoolOption {
+	return func(c *poolConfig) { c.poolOpts = append(c.poolOpts, pool.WithSize(n)) }
+}
+
+// WithPoolSessionOptions sets the options every pooled session is created with.
+func WithPoolSessionOptions(opts ...SessionOption) PoolOption {
+	return func(c *poolConfig) { c.sessionOpts = opts }
+}
+
+// WithPoolMaxIdleAge sets how long a session may sit in the pool before it is
+// closed and replaced. Keep it below the sessions' idle timeout.
+func WithPoolMaxIdleAge(d time.Duration) PoolOption {
+	return func(c *poolConfig) { c.poolOpts = append(c.poolOpts, pool.WithMaxIdleAge(d)) }
+}
+
+// WithPoolCheckInterval sets how often the pool evicts stale or crashed
+// sessions and retries failed refills.
+func WithPoolCheckInterval(d time.Duration) PoolOption {
+	return func(c *poolConfig) { c.poolOpts = append(c.poolOpts, pool.WithCheckInterval(d)) }
+}
+
+// PoolStats reports pool activity.
+type PoolStats = pool.Stats
+
+// Pool keeps pre-started sessions with one option set so Acquire does not
+// pay CLI startup latency. Sessions are created through the manager, so
+// pooled sessions count toward WithMaxSessions; the pool stops refilling
+// while the manager is full.
+type Pool = pool.Pool[Session]
+
+// NewPool creates a pool that fills itself in the background.
+func NewPool(mgr SessionManager, opts ...PoolOption) *Pool {
+	var cfg poolConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	create := func(ctx context.Context) (Session, error) {
+		return mgr.Create(ctx, cfg.sessionOpts...)
+	}
+	active := func(s Session) bool { return s.Status() == StatusActive }
+	return pool.New(create, active, cfg.poolOpts...)
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoolAcquireHitsAndRespectsMaxSessions(t *testing.T) {
+	stub := writeStubCLI(t)
+	mgr := NewManager(WithMaxSessions(2), WithSessionTTL(0))
+	defer mgr.CloseAll()
+
+	pool := NewPool(mgr,
+		WithPoolSize(2),
+		WithPoolSessionOptions(WithClaudePath(stub), WithModel("haiku")),
+		WithPoolCheckInterval(20*time.Millisecond),
+	)
+	defer pool.Close()
+	waitForIdle(t, pool, 2)
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	if got := first.(*session).config.model; got != "haiku" {
+		t.Fatalf("pooled session model = %q, want haiku", got)
+	}
+
+	// The manager is full (one acquired, one idle), so the pool cannot refill.
+	time.Sleep(60 * time.Millisecond)
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Hits != 1 || stats.LastError == "" {
+		t.Fatalf("stats after first acquire = %+v", stats)
+	}
+
+	if _, err := pool.Acquire(context.Background()); err != nil {
+		t.Fatalf("second Acquire returned error: %v", err)
+	}
+	if _, err := pool.Acquire(context.Background()); err == nil {
+		t.Fatal("third Acquire should fail while the manager is full")
+	}
+	if stats := pool.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v, want 2 hits and 1 miss", stats)
+	}
+
+	// Releasing a session frees a slot for the background refill.
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	waitForIdle(t, pool, 1)
+}
+
+// writeStubCLI writes a fake claude binary that idles until stdin closes.
+func writeStubCLI(t *testing.T) string {
+	t.Helper()
+	stub := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(stub, []byte("#!/bin/sh\ncat >/dev/null\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return stub
+}
+
+func waitForIdle(t *testing.T, pool *Pool, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Stats().Idle != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool stats = %+v, want %d idle", pool.Stats(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
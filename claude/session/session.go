@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"strings"
@@ -15,6 +17,7 @@ import (
 	"time"
 
 	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/fanout"
 )
 
 // Session manages a long-running Claude CLI process with stream-json I/O.
@@ -27,10 +30,33 @@ type Session interface {
 	// Messages are delivered via the Output channel.
 	Send(ctx context.Context, msg UserMessage) error
 
+	// Interrupt stops the running turn through the SDK control protocol.
+	// The interrupted turn still ends with a result message.
+	Interrupt(ctx context.Context) error
+
+	// SetModel switches the model for following turns without restarting
+	// the process. An empty model restores the default.
+	SetModel(ctx context.Context, model string) error
+
+	// SetPermissionMode changes how tool permissions are decided for
+	// following tool calls.
+	SetPermissionMode(ctx context.Context, mode claudecontract.PermissionMode) error
+
+	// Control sends a control request of the given subtype, with params as
+	// the request's other fields, and waits for the CLI's answer. It fails
+	// with ErrControlTimeout when no answer arrives within the control
+	// timeout, and with a *ControlError when the CLI refuses the request.
+	Control(ctx context.Context, subtype string, params map[string]any) (*ControlResponse, error)
+
 	// Output returns a channel of parsed output messages from Claude.
 	// The channel is closed when the session ends.
 	Output() <-chan OutputMessage
 
+	// Subscribe returns an independent stream of output messages with its
+	// own buffer and slow-consumer policy. Late subscribers first receive
+	// the current turn's messages. Subscriptions close when the session ends.
+	Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage]
+
 	// Close terminates the session and releases resources.
 	Close() error
 
@@ -61,17 +87,34 @@ type session struct {
 	config sessionConfig
 
 	// Process management
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout io.ReadCloser
-	cancel context.CancelFunc
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+	cancel  context.CancelFunc
+	writeMu sync.Mutex // Serializes stdin lines
+
+	// Control protocol: request ID -> response channel, and request ID ->
+	// cancel func for CLI requests still being answered
+	controlSeq      atomic.Int64
+	controlPending  map[string]chan *ControlResponse
+	controlInflight map[string]context.CancelFunc
+	controlMu       sync.Mutex
 
 	// Output handling
-	outputCh chan OutputMessage
-	initMsg  *InitMessage
-	metaMu   sync.RWMutex
-	initDone chan struct{}
-	initOnce sync.Once
+	outputCh  chan OutputMessage
+	broadcast *fanout.Broadcaster[OutputMessage]
+	initMsg   *InitMessage
+	metaMu    sync.RWMutex
+	initDone  chan struct{}
+	initOnce  sync.Once
+	outMu     sync.Mutex // Guards outClosed; permission events publish concurrently
+	outClosed bool
+
+	// Hook socket shim (HookTransportSocket only)
+	hookDir      string
+	hookListener net.Listener
+	hookSettings string // --settings JSON with the shim's command hooks
+	hookStop     sync.Once
 
 	// State
 	status       atomic.Value // SessionStatus
@@ -79,11 +122,14 @@ type session struct {
 	lastActivity atomic.Value // time.Time
 	turnCount    atomic.Int32
 	totalCost    atomic.Value // float64
+	inputTokens  atomic.Int64
+	outputTokens atomic.Int64
 
 	// Lifecycle
 	done     chan struct{}
 	closeErr error
-	closeMu  sync.Mutex
+	closeMu  sync.Mutex // Serializes Close
+	errMu    sync.Mutex // Guards closeErr; readOutput sets it while Close waits
 }
 
 // newSession creates a new session with the given configuration.
@@ -92,14 +138,39 @@ func newSession(ctx context.Context, opts ...SessionOption) (*session, error) {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	for _, h := range cfg.hooks {
+		if !h.event.IsValid() {
+			return nil, fmt.Errorf("invalid hook event: %q", h.event)
+		}
+	}
+
+	s, err := launchSession(ctx, cfg)
+	var ctrlErr *ControlError
+	if err != nil && cfg.hookTransport == HookTransportAuto && len(cfg.hooks) > 0 &&
+		(errors.As(err, &ctrlErr) || errors.Is(err, ErrControlTimeout)) {
+		// The CLI refused hook callbacks; start over with the socket shim
+		if !hookShimInstalled.Load() {
+			return nil, fmt.Errorf("%w (control protocol: %v)", ErrHookShimUnavailable, err)
+		}
+		cfg.hookTransport = HookTransportSocket
+		s, err = launchSession(ctx, cfg)
+	}
+	return s, err
+}
 
+// launchSession starts a session process for cfg.
+func launchSession(ctx context.Context, cfg sessionConfig) (*session, error) {
 	s := &session{
 		config:    cfg,
 		id:        cfg.sessionID, // Use provided session ID immediately (if any)
 		outputCh:  make(chan OutputMessage, 100),
-		initDone:  make(chan struct{}),
-		done:      make(chan struct{}),
-		createdAt: time.Now(),
+		broadcast: fanout.New(isTurnEnd),
+
+		controlPending:  make(map[string]chan *ControlResponse),
+		controlInflight: make(map[string]context.CancelFunc),
+		initDone:        make(chan struct{}),
+		done:            make(chan struct{}),
+		createdAt:       time.Now(),
 	}
 	s.status.Store(StatusCreating)
 	s.lastActivity.Store(time.Now())
@@ -123,9 +194,19 @@ func newSession(ctx context.Context, opts ...SessionOption) (*session, error) {
 // Send() triggers a response. Session metadata (ID, model) may be empty until
 // the first message exchange.
 func (s *session) start(ctx context.Context) error {
+	if len(s.config.hooks) > 0 && s.config.hookTransport == HookTransportSocket {
+		if err := s.startHookServer(); err != nil {
+			return err
+		}
+	}
+
 	// Create cancellable context for process lifetime
 	procCtx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
+	abort := func() {
+		cancel()
+		s.stopHookServer()
+	}
 
 	// Build command arguments
 	args := s.buildArgs()
@@ -142,13 +223,13 @@ func (s *session) start(ctx context.Context) error {
 	var err error
 	s.stdin, err = s.cmd.StdinPipe()
 	if err != nil {
-		cancel()
+		abort()
 		return fmt.Errorf("create stdin pipe: %w", err)
 	}
 
 	s.stdout, err = s.cmd.StdoutPipe()
 	if err != nil {
-		cancel()
+		abort()
 		return fmt.Errorf("create stdout pipe: %w", err)
 	}
 
@@ -157,7 +238,7 @@ func (s *session) start(ctx context.Context) error {
 
 	// Start the process
 	if err := s.cmd.Start(); err != nil {
-		cancel()
+		abort()
 		return fmt.Errorf("start claude: %w", err)
 	}
 
@@ -169,6 +250,13 @@ func (s *session) start(ctx context.Context) error {
 	// it will be captured by updateFromMessage() when the first response
 	// comes through.
 	s.status.Store(StatusActive)
+
+	if len(s.config.hooks) > 0 && s.config.hookTransport != HookTransportSocket {
+		if err := s.initializeHooks(ctx); err != nil {
+			_ = s.Close()
+			return fmt.Errorf("register hooks: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -223,8 +311,10 @@ func (s *session) buildArgs() []string {
 		args = append(args, claudecontract.FlagTools, strings.Join(s.config.tools, ","))
 	}
 
-	// Permissions
-	if s.config.dangerouslySkipPermissions {
+	// Permissions; a permission handler replaces skipping them
+	if s.config.permissionHandler != nil {
+		args = append(args, claudecontract.FlagPermissionPromptTool, claudecontract.PermissionPromptToolStdio)
+	} else if s.config.dangerouslySkipPermissions {
 		args = append(args, claudecontract.FlagDangerouslySkipPermissions)
 	}
 	if s.config.permissionMode != "" {
@@ -233,12 +323,28 @@ func (s *session) buildArgs() []string {
 	if len(s.config.settingSources) > 0 {
 		args = append(args, claudecontract.FlagSettingSources, strings.Join(s.config.settingSources, ","))
 	}
+	if s.hookSettings != "" {
+		args = append(args, claudecontract.FlagSettings, s.hookSettings)
+	} else if s.config.settings != "" {
+		args = append(args, claudecontract.FlagSettings, s.config.settings)
+	}
 
 	// Directories
 	for _, dir := range s.config.addDirs {
 		args = append(args, claudecontract.FlagAddDir, dir)
 	}
 
+	// MCP, plugins and agents
+	for _, cfg := range s.config.mcpConfigs {
+		args = append(args, claudecontract.FlagMCPConfig, cfg)
+	}
+	for _, dir := range s.config.pluginDirs {
+		args = append(args, claudecontract.FlagPluginDir, dir)
+	}
+	if s.config.agentsJSON != "" {
+		args = append(args, claudecontract.FlagAgents, s.config.agentsJSON)
+	}
+
 	// Limits
 	if s.config.maxBudgetUSD > 0 {
 		args = append(args, claudecontract.FlagMaxBudgetUSD, fmt.Sprintf("%.6f", s.config.maxBudgetUSD))
@@ -287,7 +393,7 @@ func setEnvVar(env []string, key, value string) []string {
 
 // readOutput reads and parses JSON lines from stdout.
 func (s *session) readOutput() {
-	defer close(s.outputCh)
+	defer s.closeOutput()
 	defer close(s.done)
 
 	scanner := bufio.NewScanner(s.stdout)
@@ -307,6 +413,11 @@ func (s *session) readOutput() {
 			continue
 		}
 
+		// Control protocol traffic is not part of the conversation
+		if s.handleControlLine(msg) {
+			continue
+		}
+
 		// Update session state from messages
 		s.updateFromMessage(msg)
 
@@ -315,17 +426,7 @@ func (s *session) readOutput() {
 			continue
 		}
 
-		// Send to output channel (non-blocking with select)
-		select {
-		case s.outputCh <- *msg:
-		default:
-			// Channel full, drop oldest
-			select {
-			case <-s.outputCh:
-				s.outputCh <- *msg
-			default:
-			}
-		}
+		s.publish(*msg)
 	}
 
 	// Process ended
@@ -338,9 +439,49 @@ func (s *session) readOutput() {
 		s.setCloseError(fmt.Errorf("process exited: %w", err))
 	}
 
+	// Fail any pending control requests so they don't hang forever
+	s.failPendingControls()
+	s.stopHookServer()
+
 	s.status.Store(StatusClosed)
 }
 
+// publish delivers a message to subscribers and the output channel. It is
+// a no-op once the output is closed.
+func (s *session) publish(msg OutputMessage) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.outClosed {
+		return
+	}
+
+	s.broadcast.Publish(msg)
+
+	// Send to output channel (non-blocking with select)
+	select {
+	case s.outputCh <- msg:
+	default:
+		// Channel full, drop oldest
+		select {
+		case <-s.outputCh:
+		default:
+		}
+		select {
+		case s.outputCh <- msg:
+		default:
+		}
+	}
+}
+
+// closeOutput closes subscriptions and the output channel.
+func (s *session) closeOutput() {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.outClosed = true
+	s.broadcast.Close()
+	close(s.outputCh)
+}
+
 // updateFromMessage updates session state based on message content.
 func (s *session) updateFromMessage(msg *OutputMessage) {
 	s.lastActivity.Store(time.Now())
@@ -359,6 +500,12 @@ func (s *session) updateFromMessage(msg *OutputMessage) {
 		s.turnCount.Add(1)
 		cost := s.totalCost.Load().(float64)
 		s.totalCost.Store(cost + msg.Result.TotalCostUSD)
+		s.inputTokens.Add(int64(msg.Result.Usage.InputTokens))
+		s.outputTokens.Add(int64(msg.Result.Usage.OutputTokens))
+	}
+
+	if s.config.onUpdate != nil && msg.IsResult() {
+		s.config.onUpdate(s)
 	}
 }
 
@@ -399,13 +546,25 @@ func (s *session) Send(ctx context.Context, msg UserMessage) error {
 		return fmt.Errorf("marshal message: %w", err)
 	}
 
-	// Add newline delimiter
-	data = append(data, '\n')
+	if err := s.writeLine(ctx, data); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	s.lastActivity.Store(time.Now())
+	return nil
+}
+
+// writeLine writes data and a newline delimiter to stdin. Lines from
+// concurrent callers are never interleaved.
+func (s *session) writeLine(ctx context.Context, data []byte) error {
+	line := append(data[:len(data):len(data)], '\n')
 
 	// Write with context timeout
 	done := make(chan error, 1)
 	go func() {
-		_, err := s.stdin.Write(data)
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		_, err := s.stdin.Write(line)
 		done <- err
 	}()
 
@@ -413,13 +572,8 @@ func (s *session) Send(ctx context.Context, msg UserMessage) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("write message: %w", err)
-		}
+		return err
 	}
-
-	s.lastActivity.Store(time.Now())
-	return nil
 }
 
 // Output implements Session.
@@ -427,6 +581,16 @@ func (s *session) Output() <-chan OutputMessage {
 	return s.outputCh
 }
 
+// Subscribe implements Session.
+func (s *session) Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage] {
+	return s.broadcast.Subscribe(opts...)
+}
+
+// isTurnEnd reports whether msg ends a turn, resetting the replay buffer.
+func isTurnEnd(msg OutputMessage) bool {
+	return msg.IsResult()
+}
+
 // Close implements Session.
 func (s *session) Close() error {
 	s.closeMu.Lock()
@@ -434,7 +598,7 @@ func (s *session) Close() error {
 
 	status := s.Status()
 	if status == StatusClosed || status == StatusClosing {
-		return s.closeErr
+		return s.getCloseError()
 	}
 
 	s.status.Store(StatusClosing)
@@ -478,7 +642,7 @@ func (s *session) Close() error {
 	}
 
 	s.status.Store(StatusClosed)
-	return s.closeErr
+	return s.getCloseError()
 }
 
 // Status implements Session.
@@ -513,18 +677,25 @@ func (s *session) getModel() string {
 // Wait implements Session.
 func (s *session) Wait() error {
 	<-s.done
-	return s.closeErr
+	return s.getCloseError()
 }
 
 // setCloseError sets the close error if not already set.
 func (s *session) setCloseError(err error) {
-	s.closeMu.Lock()
-	defer s.closeMu.Unlock()
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
 	if s.closeErr == nil {
 		s.closeErr = err
 	}
 }
 
+// getCloseError returns the first error recorded by setCloseError.
+func (s *session) getCloseError() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.closeErr
+}
+
 // JSONLPath implements Session.
 // Returns the path to Claude Code's session JSONL file.
 func (s *session) JSONLPath() string {
@@ -3,8 +3,13 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/randalmurphal/llmkit/v2/fanout"
 )
 
 func TestSessionConfig_Defaults(t *testing.T) {
@@ -283,6 +288,32 @@ func TestSession_BuildArgs_Resume(t *testing.T) {
 	assertNotContains(t, args, "--session-id")
 }
 
+func TestSession_BuildArgs_Launch(t *testing.T) {
+	s := &session{config: sessionConfig{
+		settings:   "/tmp/settings.json",
+		mcpConfigs: []string{"/tmp/mcp.json"},
+		pluginDirs: []string{"/tmp/plugin"},
+		agentsJSON: `{"reviewer":{"description":"d","prompt":"p"}}`,
+	}}
+	args := strings.Join(s.buildArgs(), " ")
+	for _, want := range []string{
+		"--settings /tmp/settings.json",
+		"--mcp-config /tmp/mcp.json",
+		"--plugin-dir /tmp/plugin",
+		`--agents {"reviewer":{"description":"d","prompt":"p"}}`,
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+
+	// Shim hooks replace the settings flag, so they carry the settings too.
+	s.hookSettings = `{"hooks":{}}`
+	if args := strings.Join(s.buildArgs(), " "); strings.Contains(args, "/tmp/settings.json") {
+		t.Errorf("args %q pass --settings twice", args)
+	}
+}
+
 func TestSessionWaitForInitReturnsWhenInitArrives(t *testing.T) {
 	s := &session{
 		outputCh:  make(chan OutputMessage, 1),
@@ -486,3 +517,91 @@ func assertNotContains(t *testing.T, slice []string, item string) {
 		}
 	}
 }
+
+func TestSessionSubscribeReplaysTurnToEachSubscriber(t *testing.T) {
+	stub := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\n" +
+		`echo '{"type":"system","subtype":"init","session_id":"s1"}'` + "\n" +
+		`echo '{"type":"result","subtype":"success","session_id":"s1","result":"done"}'` + "\n" +
+		"cat >/dev/null\n"
+	if err := os.WriteFile(stub, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newSession(context.Background(), WithClaudePath(stub))
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	first := s.Subscribe()
+	if msg := <-first.C(); !msg.IsInit() {
+		t.Fatalf("first message = %+v, want init", msg)
+	}
+	if msg := <-first.C(); !msg.IsResult() {
+		t.Fatalf("second message = %+v, want result", msg)
+	}
+
+	// A subscriber attached after the turn ended still receives it.
+	late := s.Subscribe(fanout.WithPolicy(fanout.Disconnect))
+	_ = s.Close()
+	var types []string
+	for msg := range late.C() {
+		types = append(types, msg.Type)
+	}
+	if len(types) != 2 || types[0] != "system" || types[1] != "result" {
+		t.Fatalf("late subscriber got %v", types)
+	}
+	if _, ok := <-first.C(); ok {
+		t.Fatal("subscription should close with the session")
+	}
+}
+
+func TestSessionIdleSubscriberDoesNotStallSession(t *testing.T) {
+	stub := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+while IFS= read -r line; do
+  case "$line" in
+    *control_request*)
+      id=$(printf '%s' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+      printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id" ;;
+    *)
+      i=0
+      while [ $i -lt 300 ]; do
+        printf '{"type":"assistant","session_id":"s1","message":{"content":[{"type":"text","text":"chunk"}]}}\n'
+        i=$((i+1))
+      done ;;
+  esac
+done
+`
+	if err := os.WriteFile(stub, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newSession(context.Background(), WithClaudePath(stub))
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	idle := s.Subscribe(fanout.WithBuffer(1))
+	defer idle.Close()
+
+	if err := s.Send(context.Background(), NewUserMessage("go")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	// The control response arrives after all 300 messages, so it is only
+	// read if publishing never waits on the idle subscriber.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Interrupt(ctx); err != nil {
+		t.Fatalf("Interrupt returned error: %v", err)
+	}
+	if idle.Dropped() == 0 {
+		t.Fatal("idle subscriber dropped nothing")
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- s.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Close did not return")
+	}
+}
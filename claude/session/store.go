@@ -0,0 +1,194 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
+)
+
+// storeProvider is the Record.Provider value written by this package.
+const storeProvider = "claude"
+
+// storedOptions is the serialized form of sessionConfig kept in a
+// sessionstore.Record. Environment variables are not stored because they
+// often carry credentials, and Go callbacks (WithPermissionHandler,
+// WithHook) cannot be serialized; supply them again with
+// WithDefaultSessionOptions or as Resume options. Settings, MCP config and
+// plugin paths are stored as given, so files from a closed overlay must be
+// prepared again before the session resumes.
+type storedOptions struct {
+	ClaudePath                 string   `json:"claude_path,omitempty"`
+	Model                      string   `json:"model,omitempty"`
+	FallbackModel              string   `json:"fallback_model,omitempty"`
+	Effort                     string   `json:"effort,omitempty"`
+	Workdir                    string   `json:"workdir,omitempty"`
+	AllowedTools               []string `json:"allowed_tools,omitempty"`
+	DisallowedTools            []string `json:"disallowed_tools,omitempty"`
+	Tools                      []string `json:"tools,omitempty"`
+	ToolsSet                   bool     `json:"tools_set,omitempty"`
+	DangerouslySkipPermissions bool     `json:"dangerously_skip_permissions"`
+	PermissionMode             string   `json:"permission_mode,omitempty"`
+	SettingSources             []string `json:"setting_sources,omitempty"`
+	Settings                   string   `json:"settings,omitempty"`
+	AddDirs                    []string `json:"add_dirs,omitempty"`
+	MCPConfigs                 []string `json:"mcp_configs,omitempty"`
+	PluginDirs                 []string `json:"plugin_dirs,omitempty"`
+	AgentsJSON                 string   `json:"agents_json,omitempty"`
+	SystemPrompt               string   `json:"system_prompt,omitempty"`
+	AppendSystemPrompt         string   `json:"append_system_prompt,omitempty"`
+	MaxBudgetUSD               float64  `json:"max_budget_usd,omitempty"`
+	MaxTurns                   int      `json:"max_turns,omitempty"`
+	StartupTimeout             string   `json:"startup_timeout,omitempty"`
+	IdleTimeout                string   `json:"idle_timeout,omitempty"`
+	ControlTimeout             string   `json:"control_timeout,omitempty"`
+	HomeDir                    string   `json:"home_dir,omitempty"`
+	ConfigDir                  string   `json:"config_dir,omitempty"`
+	IncludeHookOutput          bool     `json:"include_hook_output,omitempty"`
+	HookTransport              string   `json:"hook_transport,omitempty"`
+	HookTimeout                string   `json:"hook_timeout,omitempty"`
+}
+
+func (c sessionConfig) storedOptions() storedOptions {
+	return storedOptions{
+		ClaudePath:                 c.claudePath,
+		Model:                      c.model,
+		FallbackModel:              c.fallbackModel,
+		Effort:                     c.effort,
+		Workdir:                    c.workdir,
+		AllowedTools:               c.allowedTools,
+		DisallowedTools:            c.disallowedTools,
+		Tools:                      c.tools,
+		ToolsSet:                   c.toolsSet,
+		DangerouslySkipPermissions: c.dangerouslySkipPermissions,
+		PermissionMode:             c.permissionMode,
+		SettingSources:             c.settingSources,
+		Settings:                   c.settings,
+		AddDirs:                    c.addDirs,
+		MCPConfigs:                 c.mcpConfigs,
+		PluginDirs:                 c.pluginDirs,
+		AgentsJSON:                 c.agentsJSON,
+		SystemPrompt:               c.systemPrompt,
+		AppendSystemPrompt:         c.appendSystemPrompt,
+		MaxBudgetUSD:               c.maxBudgetUSD,
+		MaxTurns:                   c.maxTurns,
+		StartupTimeout:             formatDuration(c.startupTimeout),
+		IdleTimeout:                formatDuration(c.idleTimeout),
+		ControlTimeout:             formatDuration(c.controlTimeout),
+		HomeDir:                    c.homeDir,
+		ConfigDir:                  c.configDir,
+		IncludeHookOutput:          c.includeHookOutput,
+		HookTransport:              string(c.hookTransport),
+		HookTimeout:                formatDuration(c.hookTimeout),
+	}
+}
+
+// option returns a SessionOption that applies the stored values.
+func (o storedOptions) option() SessionOption {
+	return func(c *sessionConfig) {
+		if o.ClaudePath != "" {
+			c.claudePath = o.ClaudePath
+		}
+		c.model = o.Model
+		c.fallbackModel = o.FallbackModel
+		c.effort = o.Effort
+		c.workdir = o.Workdir
+		c.allowedTools = o.AllowedTools
+		c.disallowedTools = o.DisallowedTools
+		c.tools = o.Tools
+		c.toolsSet = o.ToolsSet
+		c.dangerouslySkipPermissions = o.DangerouslySkipPermissions
+		c.permissionMode = o.PermissionMode
+		c.settingSources = o.SettingSources
+		c.settings = o.Settings
+		c.addDirs = o.AddDirs
+		c.mcpConfigs = o.MCPConfigs
+		c.pluginDirs = o.PluginDirs
+		c.agentsJSON = o.AgentsJSON
+		c.systemPrompt = o.SystemPrompt
+		c.appendSystemPrompt = o.AppendSystemPrompt
+		c.maxBudgetUSD = o.MaxBudgetUSD
+		c.maxTurns = o.MaxTurns
+		if d, err := time.ParseDuration(o.StartupTimeout); err == nil {
+			c.startupTimeout = d
+		}
+		if d, err := time.ParseDuration(o.IdleTimeout); err == nil {
+			c.idleTimeout = d
+		}
+		if d, err := time.ParseDuration(o.ControlTimeout); err == nil {
+			c.controlTimeout = d
+		}
+		if d, err := time.ParseDuration(o.HookTimeout); err == nil {
+			c.hookTimeout = d
+		}
+		c.homeDir = o.HomeDir
+		c.configDir = o.ConfigDir
+		c.includeHookOutput = o.IncludeHookOutput
+		c.hookTransport = HookTransport(o.HookTransport)
+	}
+}
+
+// restoreOptions returns the options that resume rec: its stored options,
+// its usage baseline, and WithResume.
+func restoreOptions(rec sessionstore.Record) []SessionOption {
+	var opts []SessionOption
+	var stored storedOptions
+	if len(rec.Options) > 0 && json.Unmarshal(rec.Options, &stored) == nil {
+		opts = append(opts, stored.option())
+	}
+	return append(opts, withRestoredRecord(rec), WithResume(rec.ID))
+}
+
+// withRestoredRecord carries a stored record into the resumed session so
+// usage keeps accumulating across restarts.
+func withRestoredRecord(rec sessionstore.Record) SessionOption {
+	return func(c *sessionConfig) {
+		c.restored = &rec
+		if c.metadata == nil {
+			c.metadata = rec.Metadata
+		}
+	}
+}
+
+// withUpdateHook registers fn to run after each result.
+func withUpdateHook(fn func(*session)) SessionOption {
+	return func(c *sessionConfig) { c.onUpdate = fn }
+}
+
+// record builds the store record for s.
+func (s *session) record(status string) sessionstore.Record {
+	options, _ := json.Marshal(s.config.storedOptions())
+	rec := sessionstore.Record{
+		ID:       s.ID(),
+		Provider: storeProvider,
+		Workdir:  s.config.workdir,
+		Options:  options,
+		Status:   status,
+		Usage: sessionstore.Usage{
+			Turns:        int(s.turnCount.Load()),
+			InputTokens:  int(s.inputTokens.Load()),
+			OutputTokens: int(s.outputTokens.Load()),
+			CostUSD:      s.totalCost.Load().(float64),
+		},
+		Metadata:     s.config.metadata,
+		CreatedAt:    s.createdAt,
+		LastActivity: s.lastActivity.Load().(time.Time),
+	}
+	if base := s.config.restored; base != nil {
+		if !base.CreatedAt.IsZero() {
+			rec.CreatedAt = base.CreatedAt
+		}
+		rec.Usage.Turns += base.Usage.Turns
+		rec.Usage.InputTokens += base.Usage.InputTokens
+		rec.Usage.OutputTokens += base.Usage.OutputTokens
+		rec.Usage.CostUSD += base.Usage.CostUSD
+	}
+	return rec
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -46,6 +46,9 @@ type OutputMessage struct {
 
 	// Populated for type="user" (echoed back in some modes)
 	User *UserMessage `json:"-"`
+
+	// Populated for type="system", subtype="permission_decision"
+	Permission *PermissionEvent `json:"-"`
 }
 
 // InitMessage contains session initialization data.
@@ -248,6 +251,12 @@ func (m *OutputMessage) IsHook() bool {
 	return m.Type == claudecontract.EventTypeSystem && m.Subtype == claudecontract.SubtypeHookResponse
 }
 
+// IsPermissionDecision returns true if this is a permission decision
+// emitted by a session with a PermissionHandler.
+func (m *OutputMessage) IsPermissionDecision() bool {
+	return m.Type == claudecontract.EventTypeSystem && m.Subtype == SubtypePermissionDecision
+}
+
 // IsSuccess returns true if this is a successful result.
 func (m *OutputMessage) IsSuccess() bool {
 	return m.Type == claudecontract.EventTypeResult && m.Subtype == claudecontract.ResultSubtypeSuccess
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/randalmurphal/llmkit/v2"
 	"github.com/randalmurphal/llmkit/v2/claude/session"
 )
 
@@ -166,6 +167,12 @@ collectLoop:
 			resp.Model = model
 			break
 		}
+		if result.IsError {
+			return resp, classifyError("result", llmkit.ErrorDiagnostic{
+				Message:       result.Result,
+				ResultSubtype: result.Subtype,
+			})
+		}
 	} else {
 		// No result message - session may have ended unexpectedly
 		resp.FinishReason = "error"
@@ -39,6 +39,15 @@ type (
 
 	// ManagerOption configures session manager creation.
 	ManagerOption = session.ManagerOption
+
+	// HookCallback handles a hook event in-process.
+	HookCallback = session.HookCallback
+
+	// HookInput is the input of a hook event.
+	HookInput = session.HookInput
+
+	// HookOutput is a hook callback's answer.
+	HookOutput = session.HookOutput
 )
 
 // Session status constants.
@@ -106,6 +115,12 @@ var (
 	// SessionWithNoPersistence disables session persistence.
 	SessionWithNoPersistence = session.WithNoSessionPersistence
 
+	// SessionWithPermissionHandler decides tool calls in Go.
+	SessionWithPermissionHandler = session.WithPermissionHandler
+
+	// SessionWithHook registers a Go callback for a hook event.
+	SessionWithHook = session.WithHook
+
 	// SessionWithIncludeHookOutput includes hook output in the output channel.
 	SessionWithIncludeHookOutput = session.WithIncludeHookOutput
 )
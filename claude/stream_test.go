@@ -1,7 +1,11 @@
 package claude
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/randalmurphal/llmkit/v2"
 )
 
 func TestStreamAccumulator_Append(t *testing.T) {
@@ -355,3 +359,45 @@ func BenchmarkStreamAccumulator_Append(b *testing.B) {
 		acc.Append(event)
 	}
 }
+
+func TestResultEvent_Err(t *testing.T) {
+	success := &ResultEvent{Subtype: "success", Result: "done"}
+	if err := success.Err(); err != nil {
+		t.Errorf("success Err() = %v, want nil", err)
+	}
+
+	maxTurns := &ResultEvent{Subtype: "error_max_turns"}
+	if err := maxTurns.Err(); !errors.Is(err, llmkit.ErrMaxTurns) || !errors.Is(err, ErrMaxTurns) {
+		t.Errorf("max turns Err() = %v, want ErrMaxTurns", err)
+	}
+
+	limited := &ResultEvent{Subtype: "error_during_execution", IsError: true, Result: "API Error: 429 rate limit, retry after 30 seconds"}
+	err := limited.Err()
+	var claudeErr *Error
+	if !errors.As(err, &claudeErr) || !claudeErr.Retryable || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("rate limit Err() = %v", err)
+	}
+	if d, ok := llmkit.RetryAfter(err); !ok || d.Seconds() != 30 {
+		t.Errorf("RetryAfter() = %v, %v", d, ok)
+	}
+}
+
+func TestStreamToCompleteReturnsClassifiedResultError(t *testing.T) {
+	events := make(chan StreamEvent)
+	close(events)
+	result := newStreamResult()
+	result.TestComplete(&ResultEvent{
+		Subtype:      "error_max_budget_usd",
+		IsError:      true,
+		Result:       "Exceeded budget",
+		TotalCostUSD: 1.5,
+	}, nil)
+
+	resp, err := StreamToComplete(context.Background(), events, result)
+	if !errors.Is(err, llmkit.ErrBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrBudgetExceeded", err)
+	}
+	if resp == nil || resp.FinishReason != "error" || resp.CostUSD != 1.5 {
+		t.Fatalf("resp = %+v", resp)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"strings"
 	"sync"
+
+	"github.com/randalmurphal/llmkit/v2"
 )
 
 // StreamEventType identifies the type of streaming event.
@@ -17,6 +19,10 @@ const (
 	StreamEventResult    StreamEventType = "result"
 	StreamEventHook      StreamEventType = "hook"
 	StreamEventError     StreamEventType = "error"
+
+	// StreamEventPermission reports a decision made by the client's
+	// PermissionHandler; the CLI does not emit it.
+	StreamEventPermission StreamEventType = "permission"
 )
 
 // StreamEvent represents a single event from Claude CLI stream-json output.
@@ -47,6 +53,9 @@ type StreamEvent struct {
 	// Error is populated when Type == StreamEventError.
 	Error error
 
+	// Permission is populated when Type == StreamEventPermission.
+	Permission *PermissionEvent
+
 	// Raw contains the original JSON for advanced parsing.
 	Raw json.RawMessage
 }
@@ -154,6 +163,19 @@ type ResultEvent struct {
 	ModelUsage map[string]ModelUsageDetail `json:"modelUsage,omitempty"`
 }
 
+// Err returns the classified failure for an error result, or nil on success.
+// The error wraps a root llmkit sentinel (ErrMaxTurns, ErrBudgetExceeded,
+// ErrRateLimited, ...) chosen from the subtype and result text.
+func (r *ResultEvent) Err() error {
+	if r == nil || (!r.IsError && !strings.HasPrefix(r.Subtype, "error")) {
+		return nil
+	}
+	return classifyError("result", llmkit.ErrorDiagnostic{
+		Message:       r.Result,
+		ResultSubtype: r.Subtype,
+	})
+}
+
 // ResultUsage contains aggregate token usage from a result.
 type ResultUsage struct {
 	InputTokens              int           `json:"input_tokens"`
@@ -381,6 +403,8 @@ func StreamToComplete(ctx context.Context, events <-chan StreamEvent, result *St
 
 // StreamToCompleteWithCallback converts streaming events to a CompletionResponse,
 // calling the optional onEvent callback for each event as it arrives.
+// An error result returns the response, with its usage and cost, together
+// with the classified error from ResultEvent.Err.
 // Use this to capture transcripts in real-time, track progress, or log activity.
 func StreamToCompleteWithCallback(ctx context.Context, events <-chan StreamEvent, result *StreamResult, onEvent func(StreamEvent)) (*CompletionResponse, error) {
 	var content strings.Builder
@@ -438,9 +462,8 @@ func StreamToCompleteWithCallback(ctx context.Context, events <-chan StreamEvent
 
 	if final.IsError {
 		resp.FinishReason = "error"
-	} else {
-		resp.FinishReason = "stop"
+		return resp, final.Err()
 	}
-
+	resp.FinishReason = "stop"
 	return resp, nil
 }
@@ -0,0 +1,273 @@
+package claudeconfig
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Evaluate decides a tool call against the permission rules the way Claude
+// Code's settings do: a matching Deny rule always wins, and a non-empty
+// Allow list admits only calls matching one of its rules. Nil or empty
+// permissions allow everything. The matching rule is returned when there
+// is one. Relative path patterns resolve against Dir.
+func (p *ToolPermissions) Evaluate(toolName string, input json.RawMessage) (allowed bool, rule string) {
+	if p == nil {
+		return true, ""
+	}
+	for _, r := range p.Deny {
+		if matchRule(r, toolName, input, p.Dir, true) {
+			return false, r
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true, ""
+	}
+	for _, r := range p.Allow {
+		if matchRule(r, toolName, input, p.Dir, false) {
+			return true, r
+		}
+	}
+	return false, ""
+}
+
+// MatchRule reports whether an allow rule matches a tool call. Relative
+// path patterns resolve against the working directory.
+//
+// Rules use Claude Code's settings syntax:
+//   - "Tool" or "Tool(*)" matches every call to Tool.
+//   - "Bash(npm test)" matches that exact command, "Bash(npm run:*)" any
+//     command starting with "npm run", and "*" elsewhere in the specifier
+//     matches any text. A compound command ("a && b", "a; b", "a | b")
+//     matches a wildcard rule only if every subcommand does, and one using
+//     command substitution never does.
+//   - "Read(/src/**)", "Edit(~/notes/*.md)" and "Write(docs/**)" match the
+//     call's file_path, path or notebook_path. Absolute and ~ patterns match
+//     from the root; relative ones match below the directory they resolve
+//     against, and a relative pattern without a slash, such as "*.go",
+//     matches at any depth below it.
+//   - "WebFetch(domain:example.com)" matches URLs on that host.
+//   - "mcp__server" matches every tool of an MCP server.
+func MatchRule(rule, toolName string, input json.RawMessage) bool {
+	return matchRule(rule, toolName, input, "", false)
+}
+
+// MatchDenyRule reports whether a deny rule matches a tool call. It errs
+// toward matching where MatchRule errs toward not: a compound Bash command
+// matches if any subcommand does, commands inside $(...), backticks,
+// process substitution or a subshell count as subcommands, and relative
+// path patterns match at any depth.
+func MatchDenyRule(rule, toolName string, input json.RawMessage) bool {
+	return matchRule(rule, toolName, input, "", true)
+}
+
+// matchRule matches rule with relative paths resolved against dir (the
+// working directory when empty), or with deny-rule matching when deny is
+// set.
+func matchRule(rule, toolName string, input json.RawMessage, dir string, deny bool) bool {
+	name, spec, hasSpec := splitRule(rule)
+	if !hasSpec || spec == "*" {
+		return name == toolName || (isMCPServerRule(name) && strings.HasPrefix(toolName, name+"__")) ||
+			(strings.Contains(name, "*") && globMatch(name, toolName, false))
+	}
+	if name != toolName {
+		return false
+	}
+
+	var fields struct {
+		Command      string `json:"command"`
+		FilePath     string `json:"file_path"`
+		Path         string `json:"path"`
+		NotebookPath string `json:"notebook_path"`
+		URL          string `json:"url"`
+	}
+	_ = json.Unmarshal(input, &fields) // Missing fields match nothing below
+
+	switch {
+	case fields.Command != "":
+		match := func(sub string) bool { return globMatch(spec, sub, true) }
+		if prefix, ok := strings.CutSuffix(spec, ":*"); ok {
+			match = func(sub string) bool { return strings.HasPrefix(sub, prefix) }
+		}
+		switch {
+		case deny:
+			return anySubcommand(fields.Command, match)
+		case strings.Contains(spec, "*"):
+			return everySubcommand(fields.Command, match)
+		default:
+			return match(fields.Command)
+		}
+	case strings.HasPrefix(spec, "domain:"):
+		u, err := url.Parse(fields.URL)
+		return err == nil && strings.EqualFold(u.Hostname(), strings.TrimPrefix(spec, "domain:"))
+	}
+
+	path := fields.FilePath
+	if path == "" {
+		path = fields.Path
+	}
+	if path == "" {
+		path = fields.NotebookPath
+	}
+	if path == "" {
+		return false
+	}
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return false
+		}
+		dir = wd
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if deny {
+		dir = ""
+	}
+	return matchPath(spec, filepath.ToSlash(filepath.Clean(path)), dir)
+}
+
+// everySubcommand reports whether match accepts every subcommand of a
+// shell command. Commands using command or process substitution are
+// refused, since the substituted command runs before the outer one.
+func everySubcommand(command string, match func(string) bool) bool {
+	if strings.Contains(command, "`") || strings.Contains(command, "$(") ||
+		strings.Contains(command, "<(") || strings.Contains(command, ">(") {
+		return false
+	}
+	subs := splitCommand(command)
+	if len(subs) == 0 {
+		return false
+	}
+	for _, sub := range subs {
+		if !match(sub) {
+			return false
+		}
+	}
+	return true
+}
+
+// substitutionMarks turns the openings and closings of command and process
+// substitution, subshells and brace groups into separators.
+var substitutionMarks = strings.NewReplacer("$(", "\n", "<(", "\n", ">(", "\n", "(", "\n", ")", "\n", "`", "\n", "{", "\n", "}", "\n")
+
+// anySubcommand reports whether match accepts the whole command or any of
+// its subcommands, including substituted ones.
+func anySubcommand(command string, match func(string) bool) bool {
+	if match(strings.TrimSpace(command)) {
+		return true
+	}
+	return slices.ContainsFunc(splitCommand(substitutionMarks.Replace(command)), match)
+}
+
+// splitCommand splits a shell command on ;, &&, ||, |, background & and
+// newlines. Quotes are not parsed, so a separator inside a quoted argument
+// also splits; that can only make a rule match less.
+func splitCommand(command string) []string {
+	var subs []string
+	start := 0
+	add := func(end int) {
+		if sub := strings.TrimSpace(command[start:end]); sub != "" {
+			subs = append(subs, sub)
+		}
+	}
+	for i := 0; i < len(command); i++ {
+		switch c := command[i]; c {
+		case ';', '\n', '|':
+			add(i)
+			if c == '|' && i+1 < len(command) && (command[i+1] == '|' || command[i+1] == '&') {
+				i++
+			}
+			start = i + 1
+		case '&':
+			// Leave redirections such as 2>&1 and &>file alone.
+			if i > 0 && (command[i-1] == '>' || command[i-1] == '<') || i+1 < len(command) && command[i+1] == '>' {
+				continue
+			}
+			add(i)
+			if i+1 < len(command) && command[i+1] == '&' {
+				i++
+			}
+			start = i + 1
+		}
+	}
+	add(len(command))
+	return subs
+}
+
+// splitRule splits "Tool(spec)" into its tool name and specifier.
+func splitRule(rule string) (name, spec string, ok bool) {
+	rule = strings.TrimSpace(rule)
+	open := strings.IndexByte(rule, '(')
+	if open < 0 || !strings.HasSuffix(rule, ")") {
+		return rule, "", false
+	}
+	return rule[:open], rule[open+1 : len(rule)-1], true
+}
+
+// isMCPServerRule reports whether a rule names a whole MCP server
+// ("mcp__server") rather than one of its tools.
+func isMCPServerRule(name string) bool {
+	rest, ok := strings.CutPrefix(name, "mcp__")
+	return ok && rest != "" && !strings.Contains(rest, "__")
+}
+
+// matchPath matches a file path against a path pattern, resolving relative
+// patterns against dir, or matching them at any depth when dir is empty.
+func matchPath(pattern, path, dir string) bool {
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = filepath.ToSlash(home) + pattern[1:]
+	case strings.HasPrefix(pattern, "//"):
+		pattern = pattern[1:] // Explicitly absolute
+	case strings.HasPrefix(pattern, "/"):
+	default:
+		pattern = strings.TrimPrefix(pattern, "./")
+		if dir == "" || !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		if dir != "" {
+			pattern = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(dir)), "/") + "/" + pattern
+		}
+	}
+	return globMatch(pattern, path, false)
+}
+
+// globMatch matches s against a glob. "**" matches across path separators;
+// "*" and "?" do too when anySep is set.
+func globMatch(pattern, s string, anySep bool) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*' && anySep:
+			re.WriteString(".*")
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?' && anySep:
+			re.WriteString(".")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	matched, err := regexp.MatchString(re.String(), s)
+	return err == nil && matched
+}
@@ -68,6 +68,11 @@ func ValidHookEvents() []HookEvent {
 type ToolPermissions struct {
 	Allow []string `json:"allow,omitempty"` // Whitelist: only these tools allowed
 	Deny  []string `json:"deny,omitempty"`  // Blacklist: these tools blocked
+
+	// Dir is the directory relative path patterns resolve against, set to
+	// the project root by LoadProjectSettings. Empty means the working
+	// directory.
+	Dir string `json:"-"`
 }
 
 // IsEmpty returns true if no permissions are configured.
@@ -126,6 +131,7 @@ func (s *Settings) Clone() *Settings {
 		clone.Permissions = &ToolPermissions{
 			Allow: append([]string(nil), s.Permissions.Allow...),
 			Deny:  append([]string(nil), s.Permissions.Deny...),
+			Dir:   s.Permissions.Dir,
 		}
 	}
 
@@ -174,6 +180,7 @@ func (s *Settings) Merge(project *Settings) *Settings {
 		result.Permissions = &ToolPermissions{
 			Allow: append([]string(nil), project.Permissions.Allow...),
 			Deny:  append([]string(nil), project.Permissions.Deny...),
+			Dir:   project.Permissions.Dir,
 		}
 	}
 
@@ -277,7 +284,16 @@ func LoadGlobalSettings() (*Settings, error) {
 // LoadProjectSettings loads settings from {projectRoot}/.claude/settings.json.
 func LoadProjectSettings(projectRoot string) (*Settings, error) {
 	path := filepath.Join(projectRoot, claudecontract.DirClaude, claudecontract.FileSettings)
-	return loadSettingsFile(path)
+	settings, err := loadSettingsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Permissions != nil {
+		if dir, err := filepath.Abs(projectRoot); err == nil {
+			settings.Permissions.Dir = dir
+		}
+	}
+	return settings, nil
 }
 
 // loadSettingsFile loads settings from a specific file path.
@@ -19,6 +19,54 @@ const (
 	EventTypeStreamEvent = "stream_event"
 )
 
+// SDK control protocol message types. Control messages travel on the same
+// stream-json stdin and stdout as conversation messages, in both directions.
+const (
+	// EventTypeControlRequest asks the other side to perform a control action.
+	EventTypeControlRequest = "control_request"
+
+	// EventTypeControlResponse answers a control request by request_id.
+	EventTypeControlResponse = "control_response"
+
+	// EventTypeControlCancelRequest withdraws a pending control request.
+	EventTypeControlCancelRequest = "control_cancel_request"
+)
+
+// Control request subtypes sent to the CLI.
+const (
+	// ControlSubtypeInterrupt stops the running turn.
+	ControlSubtypeInterrupt = "interrupt"
+
+	// ControlSubtypeSetModel switches the model for following turns.
+	ControlSubtypeSetModel = "set_model"
+
+	// ControlSubtypeSetPermissionMode changes the permission mode.
+	ControlSubtypeSetPermissionMode = "set_permission_mode"
+
+	// ControlSubtypeInitialize registers SDK hook callbacks at startup.
+	ControlSubtypeInitialize = "initialize"
+)
+
+// Control request subtypes sent by the CLI.
+const (
+	// ControlSubtypeCanUseTool asks whether a tool call may run. The CLI
+	// sends it when started with --permission-prompt-tool stdio.
+	ControlSubtypeCanUseTool = "can_use_tool"
+
+	// ControlSubtypeHookCallback runs a hook callback registered with
+	// ControlSubtypeInitialize.
+	ControlSubtypeHookCallback = "hook_callback"
+)
+
+// Control response subtypes.
+const (
+	// ControlResponseSuccess indicates the control request succeeded.
+	ControlResponseSuccess = "success"
+
+	// ControlResponseError indicates the control request failed.
+	ControlResponseError = "error"
+)
+
 // System event subtypes.
 const (
 	// SubtypeInit is the initialization event at session start.
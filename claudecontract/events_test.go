@@ -17,6 +17,9 @@ func TestEventTypeConstants(t *testing.T) {
 		{"EventTypeUser", EventTypeUser, "user"},
 		{"EventTypeResult", EventTypeResult, "result"},
 		{"EventTypeStreamEvent", EventTypeStreamEvent, "stream_event"},
+		{"EventTypeControlRequest", EventTypeControlRequest, "control_request"},
+		{"EventTypeControlResponse", EventTypeControlResponse, "control_response"},
+		{"EventTypeControlCancelRequest", EventTypeControlCancelRequest, "control_cancel_request"},
 	}
 
 	for _, tt := range tests {
@@ -40,6 +43,16 @@ func TestSubtypeConstants(t *testing.T) {
 		{"SubtypeHookResponse", SubtypeHookResponse, "hook_response"},
 		{"SubtypeCompactBoundary", SubtypeCompactBoundary, "compact_boundary"},
 
+		// Control subtypes
+		{"ControlSubtypeInterrupt", ControlSubtypeInterrupt, "interrupt"},
+		{"ControlSubtypeSetModel", ControlSubtypeSetModel, "set_model"},
+		{"ControlSubtypeSetPermissionMode", ControlSubtypeSetPermissionMode, "set_permission_mode"},
+		{"ControlSubtypeInitialize", ControlSubtypeInitialize, "initialize"},
+		{"ControlSubtypeCanUseTool", ControlSubtypeCanUseTool, "can_use_tool"},
+		{"ControlSubtypeHookCallback", ControlSubtypeHookCallback, "hook_callback"},
+		{"ControlResponseSuccess", ControlResponseSuccess, "success"},
+		{"ControlResponseError", ControlResponseError, "error"},
+
 		// Result subtypes
 		{"ResultSubtypeSuccess", ResultSubtypeSuccess, "success"},
 		{"ResultSubtypeErrorMaxTurns", ResultSubtypeErrorMaxTurns, "error_max_turns"},
@@ -14,6 +14,10 @@ const (
 	FormatStreamJSON = "stream-json"
 )
 
+// PermissionPromptToolStdio is the --permission-prompt-tool value that sends
+// permission prompts to the SDK host as can_use_tool control requests.
+const PermissionPromptToolStdio = "stdio"
+
 // Transport types for MCP servers.
 const (
 	// TransportStdio is a stdio-based MCP server.
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/randalmurphal/llmkit/v2"
 	"github.com/randalmurphal/llmkit/v2/codexcontract"
 )
 
@@ -513,7 +515,7 @@ func (c *CodexCLI) Stream(ctx context.Context, req CompletionRequest) (<-chan St
 			}
 			if event.ErrMsg != "" {
 				sawTerminal = true
-				if !sendChunk(ctx, ch, StreamChunk{Error: NewError("stream", fmt.Errorf("%s", event.ErrMsg), false), SessionID: sessionID}) {
+				if !sendChunk(ctx, ch, StreamChunk{Error: event.err("stream"), SessionID: sessionID}) {
 					return
 				}
 				break scanLoop
@@ -556,7 +558,12 @@ func (c *CodexCLI) Stream(ctx context.Context, req CompletionRequest) (<-chan St
 				if errMsg == "" {
 					errMsg = waitErr.Error()
 				}
-				_ = sendChunk(ctx, ch, StreamChunk{Error: NewError("stream", fmt.Errorf("%s", errMsg), isRetryableError(errMsg)), SessionID: sessionID})
+				diag := llmkit.ErrorDiagnostic{Message: errMsg}
+				var exitErr *exec.ExitError
+				if errors.As(waitErr, &exitErr) {
+					diag.ExitCode = exitErr.ExitCode()
+				}
+				_ = sendChunk(ctx, ch, StreamChunk{Error: classifyError("stream", diag), SessionID: sessionID})
 			}
 			return
 		}
@@ -580,14 +587,28 @@ func sendChunk(ctx context.Context, ch chan<- StreamChunk, chunk StreamChunk) bo
 
 // buildArgsWithCleanup constructs CLI arguments and returns a cleanup function.
 func (c *CodexCLI) buildArgsWithCleanup(req CompletionRequest) ([]string, func(), error) {
-	cleanup := func() {}
+	var cleanups []func()
+	cleanup := func() {
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
 	if len(req.JSONSchema) > 0 {
 		path, err := writeSchemaFile(req.JSONSchema)
 		if err != nil {
 			return nil, cleanup, fmt.Errorf("write output schema: %w", err)
 		}
 		req.OutputSchemaPath = path
-		cleanup = func() { _ = os.Remove(path) }
+		cleanups = append(cleanups, func() { _ = os.Remove(path) })
+	}
+	if len(req.ImageData) > 0 {
+		paths, removeImages, err := writeImageFiles(req.ImageData)
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("write images: %w", err)
+		}
+		req.Images = append(append([]string(nil), req.Images...), paths...)
+		cleanups = append(cleanups, removeImages)
 	}
 
 	args := c.buildExecArgs(req)
@@ -693,6 +714,9 @@ func (c *CodexCLI) buildExecArgs(req CompletionRequest) []string {
 	for _, img := range c.images {
 		args = append(args, codexcontract.FlagImage, img)
 	}
+	for _, img := range req.Images {
+		args = append(args, codexcontract.FlagImage, img)
+	}
 
 	webSearchMode := c.webSearchMode
 	if req.WebSearchMode != "" {
@@ -932,6 +956,20 @@ type parsedLineEvent struct {
 	Usage              *TokenUsage
 	Done               bool
 	ErrMsg             string
+	// ErrEventType is the event that carried ErrMsg (turn.failed or error).
+	ErrEventType string
+}
+
+// defaultTurnFailedMsg is used when a failure event carries no error text.
+const defaultTurnFailedMsg = "codex turn failed"
+
+// err classifies the failure carried by a turn.failed or error event.
+func (p *parsedLineEvent) err(op string) error {
+	diag := llmkit.ErrorDiagnostic{EventType: p.ErrEventType}
+	if p.ErrMsg != defaultTurnFailedMsg {
+		diag.Message = p.ErrMsg
+	}
+	return classifyError(op, diag)
 }
 
 func parseEventLine(line []byte) (*parsedLineEvent, error) {
@@ -1012,9 +1050,10 @@ func parseEventLine(line []byte) (*parsedLineEvent, error) {
 
 	case codexcontract.EventTurnFailed, codexcontract.EventError:
 		parsed.Done = true
+		parsed.ErrEventType = eventType
 		parsed.ErrMsg = firstNonEmpty(getString(event, "error"), getString(event, "message"), parseResultText(event["result"]))
 		if parsed.ErrMsg == "" {
-			parsed.ErrMsg = "codex turn failed"
+			parsed.ErrMsg = defaultTurnFailedMsg
 		}
 
 	default:
@@ -1327,17 +1366,6 @@ func firstNonZeroInt(values ...int) int {
 	return 0
 }
 
-// isRetryableError checks if an error message indicates a transient error.
-func isRetryableError(errMsg string) bool {
-	errLower := strings.ToLower(errMsg)
-	return strings.Contains(errLower, "rate limit") ||
-		strings.Contains(errLower, "timeout") ||
-		strings.Contains(errLower, "overloaded") ||
-		strings.Contains(errLower, "503") ||
-		strings.Contains(errLower, "529") ||
-		strings.Contains(errLower, "429")
-}
-
 // maxStderrLength limits stderr output in error messages.
 const maxStderrLength = 500
 
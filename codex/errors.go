@@ -3,24 +3,27 @@ package codex
 import (
 	"errors"
 	"fmt"
+
+	"github.com/randalmurphal/llmkit/v2"
 )
 
 // Sentinel errors for LLM operations.
+// Shared failures alias the root llmkit sentinels so errors.Is matches either name.
 var (
 	// ErrUnavailable indicates the LLM service is unavailable.
-	ErrUnavailable = errors.New("LLM service unavailable")
+	ErrUnavailable = llmkit.ErrUnavailable
 
 	// ErrContextTooLong indicates the input exceeds the context window.
-	ErrContextTooLong = errors.New("context exceeds maximum length")
+	ErrContextTooLong = llmkit.ErrContextTooLong
 
 	// ErrRateLimited indicates the request was rate limited.
-	ErrRateLimited = errors.New("rate limited")
+	ErrRateLimited = llmkit.ErrRateLimited
 
 	// ErrInvalidRequest indicates the request is malformed.
-	ErrInvalidRequest = errors.New("invalid request")
+	ErrInvalidRequest = llmkit.ErrInvalidRequest
 
 	// ErrTimeout indicates the request timed out.
-	ErrTimeout = errors.New("request timed out")
+	ErrTimeout = llmkit.ErrTimeout
 
 	// ErrSessionNotFound indicates the session ID was not found.
 	ErrSessionNotFound = errors.New("session not found")
@@ -51,3 +54,14 @@ func NewError(op string, err error, retryable bool) *Error {
 		Retryable: retryable,
 	}
 }
+
+// classifyError wraps a classified CLI failure so callers can match root
+// sentinels with errors.Is and read RetryAfter via llmkit.RetryAfter.
+func classifyError(op string, diag llmkit.ErrorDiagnostic) *Error {
+	classified := llmkit.ClassifyError(diag)
+	return &Error{
+		Op:        op,
+		Err:       classified,
+		Retryable: classified.Retryable,
+	}
+}
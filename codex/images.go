@@ -0,0 +1,42 @@
+package codex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// imageExtensions maps supported image media types to file extensions.
+// Codex infers the image format from the --image path.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// writeImageFiles writes in-memory images to a private temp directory and
+// returns their paths plus a cleanup func that removes the directory.
+func writeImageFiles(images []ImageData) ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "llmkit-codex-images-*")
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("create image dir: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	paths := make([]string, 0, len(images))
+	for i, img := range images {
+		ext, ok := imageExtensions[img.MediaType]
+		if !ok {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("%w: unsupported image media type %q", ErrInvalidRequest, img.MediaType)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("image-%d%s", i+1, ext))
+		if err := os.WriteFile(path, img.Data, 0o600); err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("write image %d: %w", i+1, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, cleanup, nil
+}
@@ -25,6 +25,7 @@ func init() {
 			MaxTurns:           false,
 			Env:                true,
 			AddDirs:            true,
+			Hooks:              true,
 		},
 		Environment: llmkit.EnvironmentSupport{
 			Hooks:        true,
@@ -33,6 +34,18 @@ func init() {
 			Instructions: true,
 			CustomAgents: true,
 		},
+		// Messages are flattened into one prompt; images go through --image
+		// and there is no document input.
+		Request: llmkit.RequestSupport{
+			SystemPrompt: true,
+			JSONSchema:   true,
+			MessageNames: true,
+			Roles:        []llmkit.Role{llmkit.RoleUser, llmkit.RoleAssistant, llmkit.RoleTool},
+			ContentParts: []llmkit.ContentPartSupport{
+				{Type: llmkit.ContentPartText, Text: true},
+				{Type: llmkit.ContentPartImage, ImageURL: true, ImageBase64: true, FilePath: true},
+			},
+		},
 	})
 }
 
@@ -60,6 +73,12 @@ func newFromProviderConfig(cfg llmkit.Config) (llmkit.Client, error) {
 	if len(cfg.AddDirs) > 0 {
 		opts = append(opts, WithAddDirs(cfg.AddDirs))
 	}
+	if len(cfg.Launch.CodexOverrides) > 0 {
+		opts = append(opts, WithConfigOverrides(cfg.Launch.CodexOverrides))
+	}
+	if len(cfg.Launch.Env) > 0 {
+		opts = append(opts, WithEnv(cfg.Launch.Env))
+	}
 	if sessionID := sessionIDFromMetadata(cfg.Session); sessionID != "" {
 		if !cfg.ResumeSession {
 			return nil, fmt.Errorf("%w: codex client only supports resuming existing sessions", llmkit.ErrUnsupportedFeature)
@@ -97,26 +116,46 @@ func newFromProviderConfig(cfg llmkit.Config) (llmkit.Client, error) {
 	return &codexProviderAdapter{
 		cli:                 NewCodexCLI(opts...),
 		defaultSystemPrompt: cfg.SystemPrompt,
+		validation:          cfg.RequestValidation,
 	}, nil
 }
 
 type codexProviderAdapter struct {
 	cli                 *CodexCLI
 	defaultSystemPrompt string
+	validation          llmkit.RequestValidationMode
 }
 
 func (a *codexProviderAdapter) Complete(ctx context.Context, req llmkit.Request) (*llmkit.Response, error) {
+	warnings, err := llmkit.PreflightRequest("codex", req, a.validationMode())
+	if err != nil {
+		return nil, err
+	}
+
 	codexReq := a.buildCompletionRequest(req)
+	if err := attachImages(ctx, &codexReq, req.Messages); err != nil {
+		return nil, err
+	}
 	resp, err := a.cli.Complete(ctx, codexReq)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.convertResponse(resp), nil
+	out := a.convertResponse(resp)
+	out.Metadata = llmkit.AttachRequestWarnings(out.Metadata, warnings)
+	return out, nil
 }
 
 func (a *codexProviderAdapter) Stream(ctx context.Context, req llmkit.Request) (<-chan llmkit.StreamChunk, error) {
+	warnings, err := llmkit.PreflightRequest("codex", req, a.validationMode())
+	if err != nil {
+		return nil, err
+	}
+
 	codexReq := a.buildCompletionRequest(req)
+	if err := attachImages(ctx, &codexReq, req.Messages); err != nil {
+		return nil, err
+	}
 	codexStream, err := a.cli.Stream(ctx, codexReq)
 	if err != nil {
 		return nil, err
@@ -191,6 +230,9 @@ func (a *codexProviderAdapter) Stream(ctx context.Context, req llmkit.Request) (
 					Done:         chunk.Done,
 					Error:        chunk.Error,
 				}
+				if chunk.Done {
+					converted.Metadata = llmkit.AttachRequestWarnings(nil, warnings)
+				}
 				if chunk.Usage != nil {
 					converted.Usage = &llmkit.TokenUsage{
 						InputTokens:              chunk.Usage.InputTokens,
@@ -248,7 +290,7 @@ func (a *codexProviderAdapter) buildCompletionRequest(req llmkit.Request) Comple
 	for i, m := range req.Messages {
 		codexReq.Messages[i] = Message{
 			Role:    Role(m.Role),
-			Content: m.Content,
+			Content: m.GetText(),
 			Name:    m.Name,
 		}
 	}
@@ -267,6 +309,36 @@ func (a *codexProviderAdapter) buildCompletionRequest(req llmkit.Request) Comple
 	return codexReq
 }
 
+// attachImages resolves image parts for --image. Local files are passed
+// through; base64 and URL images are loaded so the CLI can write them to a
+// temp directory it removes when the run ends.
+func attachImages(ctx context.Context, codexReq *CompletionRequest, messages []llmkit.Message) error {
+	for _, m := range messages {
+		for _, part := range m.ContentParts {
+			if part.Type != llmkit.ContentPartImage {
+				continue
+			}
+			if part.FilePath != "" {
+				codexReq.Images = append(codexReq.Images, part.FilePath)
+				continue
+			}
+			data, err := llmkit.ReadContentPart(ctx, part)
+			if err != nil {
+				return fmt.Errorf("load image: %w", err)
+			}
+			codexReq.ImageData = append(codexReq.ImageData, ImageData{MediaType: data.MediaType, Data: data.Data})
+		}
+	}
+	return nil
+}
+
+func (a *codexProviderAdapter) validationMode() llmkit.RequestValidationMode {
+	if a.validation == "" {
+		return llmkit.RequestValidationStrict
+	}
+	return a.validation
+}
+
 func (a *codexProviderAdapter) Provider() string {
 	return "codex"
 }
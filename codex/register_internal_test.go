@@ -2,6 +2,9 @@ package codex
 
 import (
 	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/randalmurphal/llmkit/v2"
@@ -27,6 +30,26 @@ func TestNewFromProviderConfig_MapsSharedFields(t *testing.T) {
 	assertArgPair(t, args, "--cd", "/tmp/work")
 }
 
+func TestNewFromProviderConfig_AppliesLaunch(t *testing.T) {
+	client, err := newFromProviderConfig(llmkit.Config{
+		Provider: "codex",
+		Launch: llmkit.LaunchConfig{
+			CodexOverrides: map[string]any{"mcp_servers.docs.command": "docs-mcp"},
+			Env:            map[string]string{"CODEX_HOME": "/tmp/overlay"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("newFromProviderConfig returned error: %v", err)
+	}
+	adapter := client.(*codexProviderAdapter)
+
+	args := adapter.cli.buildExecArgs(CompletionRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	assertArgPair(t, args, "-c", `mcp_servers.docs.command="docs-mcp"`)
+	if got := adapter.cli.extraEnv["CODEX_HOME"]; got != "/tmp/overlay" {
+		t.Fatalf("CODEX_HOME = %q", got)
+	}
+}
+
 func TestCodexProviderAdapter_BuildCompletionRequest_UsesDefaultSystemPrompt(t *testing.T) {
 	adapter := &codexProviderAdapter{defaultSystemPrompt: "default system"}
 
@@ -61,3 +84,42 @@ func TestEmitStreamChunk_StopsWhenContextCancelled(t *testing.T) {
 		t.Fatal("emitStreamChunk should stop when the stream context is cancelled")
 	}
 }
+
+func TestAttachImages_MaterializesAndCleansUp(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	adapter := &codexProviderAdapter{cli: NewCodexCLI()}
+	req := llmkit.Request{Messages: []llmkit.Message{
+		llmkit.NewImageBase64Message(llmkit.RoleUser, "describe", base64.StdEncoding.EncodeToString(png), "image/png"),
+		{Role: llmkit.RoleUser, ContentParts: []llmkit.ContentPart{{Type: llmkit.ContentPartImage, FilePath: "/tmp/local.png"}}},
+	}}
+
+	codexReq := adapter.buildCompletionRequest(req)
+	if codexReq.Messages[0].Content != "describe" {
+		t.Fatalf("content = %q, want text part", codexReq.Messages[0].Content)
+	}
+	if err := attachImages(context.Background(), &codexReq, req.Messages); err != nil {
+		t.Fatalf("attachImages returned error: %v", err)
+	}
+
+	args, cleanup, err := adapter.cli.buildArgsWithCleanup(codexReq)
+	if err != nil {
+		t.Fatalf("buildArgsWithCleanup returned error: %v", err)
+	}
+	var images []string
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--image" {
+			images = append(images, args[i+1])
+		}
+	}
+	if len(images) != 2 || images[0] != "/tmp/local.png" || filepath.Ext(images[1]) != ".png" {
+		t.Fatalf("--image args = %v", images)
+	}
+	if data, err := os.ReadFile(images[1]); err != nil || string(data) != string(png) {
+		t.Fatalf("materialized image = %q, %v", data, err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(images[1])); !os.IsNotExist(err) {
+		t.Fatalf("image dir still exists after cleanup: %v", err)
+	}
+}
@@ -46,11 +46,27 @@ type CompletionRequest struct {
 	// Request-level overrides take precedence over client-level overrides.
 	ConfigOverrides map[string]any `json:"config_overrides,omitempty"`
 
+	// Images are local image files attached with --image for this request,
+	// after any client-level WithImage files.
+	Images []string `json:"images,omitempty"`
+
+	// ImageData holds in-memory images. The client writes them to a private
+	// temp directory, attaches them with --image, and removes the directory
+	// when the run ends.
+	ImageData []ImageData `json:"-"`
+
 	// OnEvent is called synchronously for each streaming chunk during execution.
 	// Use to capture events in real-time.
 	OnEvent func(StreamChunk) `json:"-"`
 }
 
+// ImageData is an in-memory image attached to a request.
+type ImageData struct {
+	// MediaType is the image MIME type (for example "image/png").
+	MediaType string
+	Data      []byte
+}
+
 // Message is a conversation turn.
 type Message struct {
 	Role    Role   `json:"role"`
@@ -0,0 +1,479 @@
+// Package rollout provides reading and tailing of Codex session rollout files.
+//
+// Codex writes each thread's history to a JSONL rollout file at:
+//
+//	$CODEX_HOME/sessions/YYYY/MM/DD/rollout-YYYY-MM-DDThh-mm-ss-{threadId}.jsonl
+//
+// Each line is a JSON object with a timestamp, a record type (session_meta,
+// turn_context, response_item, event_msg, compacted) and a payload. This
+// package discovers those files, parses records into typed messages, tails
+// active sessions, and mirrors the claude/jsonl helpers.
+package rollout
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrNotFound is returned when no rollout file matches a thread ID.
+var ErrNotFound = errors.New("rollout file not found")
+
+// Reader reads rollout files from Codex.
+type Reader struct {
+	path string
+	file *os.File
+}
+
+// NewReader creates a new rollout reader for the given file path.
+func NewReader(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open rollout file: %w", err)
+	}
+	return &Reader{path: path, file: file}, nil
+}
+
+// Path returns the file path being read.
+func (r *Reader) Path() string {
+	return r.path
+}
+
+// Close closes the underlying file.
+func (r *Reader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// ReadAll reads all messages from the rollout file.
+func (r *Reader) ReadAll() ([]Message, error) {
+	messages, _, err := r.ReadFrom(0)
+	return messages, err
+}
+
+// ReadFrom reads all messages starting from a specific byte offset.
+// Returns the new offset after reading.
+func (r *Reader) ReadFrom(offset int64) ([]Message, int64, error) {
+	if _, err := r.file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("seek to offset: %w", err)
+	}
+
+	var messages []Message
+	scanner := bufio.NewScanner(r.file)
+	// Tool outputs can be large
+	buf := make([]byte, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		offset += int64(len(line)) + 1 // +1 for newline
+		if len(line) == 0 {
+			continue
+		}
+
+		msg, err := ParseMessage(line)
+		if err != nil {
+			// Skip malformed lines
+			continue
+		}
+		messages = append(messages, *msg)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, offset, fmt.Errorf("scan rollout: %w", err)
+	}
+
+	return messages, offset, nil
+}
+
+// Tail follows the rollout file and sends new messages to the returned channel.
+// The channel is closed when the context is cancelled or an unrecoverable error occurs.
+// Uses fsnotify for efficient file watching with polling fallback.
+func (r *Reader) Tail(ctx context.Context) <-chan Message {
+	ch := make(chan Message, 100)
+
+	go func() {
+		defer close(ch)
+
+		// Seek to end to only show new content
+		offset, err := r.file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			r.tailPolling(ctx, ch, offset)
+			return
+		}
+		defer watcher.Close()
+
+		// Watch the directory (more reliable than watching file directly)
+		if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+			r.tailPolling(ctx, ch, offset)
+			return
+		}
+
+		r.tailWithWatcher(ctx, ch, watcher, offset)
+	}()
+
+	return ch
+}
+
+// tailWithWatcher uses fsnotify for efficient file watching.
+func (r *Reader) tailWithWatcher(ctx context.Context, ch chan<- Message, watcher *fsnotify.Watcher, offset int64) {
+	baseName := filepath.Base(r.path)
+	reader := bufio.NewReader(r.file)
+	var partial []byte
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Base(event.Name) != baseName || !event.Has(fsnotify.Write) {
+				continue
+			}
+			offset, partial = r.readAvailable(ctx, reader, ch, offset, partial)
+
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			// Usually recoverable; keep watching
+		}
+	}
+}
+
+// tailPolling uses polling as a fallback when fsnotify isn't available.
+func (r *Reader) tailPolling(ctx context.Context, ch chan<- Message, offset int64) {
+	reader := bufio.NewReader(r.file)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	var partial []byte
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			offset, partial = r.readAvailable(ctx, reader, ch, offset, partial)
+		}
+	}
+}
+
+// readAvailable handles truncation and sends every complete line after
+// offset. A trailing line without its newline is kept in partial until the
+// rest is written.
+func (r *Reader) readAvailable(ctx context.Context, reader *bufio.Reader, ch chan<- Message, offset int64, partial []byte) (int64, []byte) {
+	info, err := r.file.Stat()
+	if err != nil {
+		return offset, partial
+	}
+	if info.Size() < offset {
+		// File truncated, start over
+		if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+			return offset, partial
+		}
+		offset = 0
+		partial = nil
+		reader.Reset(r.file)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		offset += int64(len(line))
+		if err != nil {
+			return offset, append(partial, line...)
+		}
+		if len(partial) > 0 {
+			line = append(partial, line...)
+			partial = nil
+		}
+		line = line[:len(line)-1]
+		if len(line) == 0 {
+			continue
+		}
+		msg, err := ParseMessage(line)
+		if err != nil {
+			continue
+		}
+		select {
+		case ch <- *msg:
+		case <-ctx.Done():
+			return offset, nil
+		}
+	}
+}
+
+// ReadFile reads all messages from a rollout file path.
+// Convenience function that opens, reads, and closes the file.
+func ReadFile(path string) ([]Message, error) {
+	r, err := NewReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return r.ReadAll()
+}
+
+// DefaultSessionsDir returns $CODEX_HOME/sessions, or ~/.codex/sessions when
+// CODEX_HOME is unset.
+func DefaultSessionsDir() (string, error) {
+	if home := os.Getenv("CODEX_HOME"); home != "" {
+		return filepath.Join(home, "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".codex", "sessions"), nil
+}
+
+// FileInfo is the metadata encoded in a rollout file name.
+type FileInfo struct {
+	Path      string
+	ThreadID  string
+	StartedAt time.Time // Local time, as Codex names the file
+}
+
+// fileTimeLayout is the timestamp format inside rollout file names.
+const fileTimeLayout = "2006-01-02T15-04-05"
+
+// ParseFileName extracts the start time and thread ID from a rollout file
+// path. It reports false for files that are not rollouts.
+func ParseFileName(path string) (FileInfo, bool) {
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, "rollout-") || !strings.HasSuffix(name, ".jsonl") {
+		return FileInfo{}, false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, "rollout-"), ".jsonl")
+	if len(rest) < len(fileTimeLayout)+2 || rest[len(fileTimeLayout)] != '-' {
+		return FileInfo{}, false
+	}
+	started, err := time.ParseInLocation(fileTimeLayout, rest[:len(fileTimeLayout)], time.Local)
+	if err != nil {
+		return FileInfo{}, false
+	}
+	return FileInfo{Path: path, ThreadID: rest[len(fileTimeLayout)+1:], StartedAt: started}, true
+}
+
+// FindRolloutFiles returns all rollout files under a sessions directory,
+// oldest first. sessionsDir is usually DefaultSessionsDir().
+func FindRolloutFiles(sessionsDir string) ([]string, error) {
+	var files []FileInfo
+
+	err := filepath.WalkDir(sessionsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if info, ok := ParseFileName(path); ok {
+			files = append(files, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk sessions dir: %w", err)
+	}
+
+	return sortedPaths(files), nil
+}
+
+// FindByDate returns the rollout files for threads started on day's
+// calendar date, oldest first. Codex files sessions by local date.
+func FindByDate(sessionsDir string, day time.Time) ([]string, error) {
+	dir := filepath.Join(sessionsDir, day.Format("2006"), day.Format("01"), day.Format("02"))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sessions dir: %w", err)
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if info, ok := ParseFileName(filepath.Join(dir, entry.Name())); ok {
+			files = append(files, info)
+		}
+	}
+	return sortedPaths(files), nil
+}
+
+// FindByThreadID returns the rollout file for a thread. If a thread was
+// written to several files, the newest is returned.
+func FindByThreadID(sessionsDir, threadID string) (string, error) {
+	files, err := FindRolloutFiles(sessionsDir)
+	if err != nil {
+		return "", err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if info, _ := ParseFileName(files[i]); info.ThreadID == threadID {
+			return files[i], nil
+		}
+	}
+	return "", fmt.Errorf("thread %s: %w", threadID, ErrNotFound)
+}
+
+func sortedPaths(files []FileInfo) []string {
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].StartedAt.Equal(files[j].StartedAt) {
+			return files[i].StartedAt.Before(files[j].StartedAt)
+		}
+		return files[i].Path < files[j].Path
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// Summary contains aggregate statistics from a rollout file.
+type Summary struct {
+	ThreadID               string
+	CWD                    string
+	CLIVersion             string
+	MessageCount           int
+	UserMessages           int // Prompts, excluding injected context
+	AssistantMessages      int
+	TotalInputTokens       int
+	TotalCachedInputTokens int
+	TotalOutputTokens      int
+	TotalReasoningTokens   int
+	Models                 map[string]int // Model name -> turn count
+	ToolCalls              int
+	FirstTimestamp         string
+	LastTimestamp          string
+}
+
+// Summarize reads a rollout file and returns aggregate statistics. Token
+// totals come from the last token_count event, which Codex reports
+// cumulatively.
+func Summarize(path string) (*Summary, error) {
+	messages, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Models: make(map[string]int),
+	}
+
+	for _, msg := range messages {
+		summary.MessageCount++
+
+		if summary.FirstTimestamp == "" {
+			summary.FirstTimestamp = msg.Timestamp
+		}
+		summary.LastTimestamp = msg.Timestamp
+
+		switch {
+		case msg.SessionMeta != nil:
+			if summary.ThreadID == "" {
+				summary.ThreadID = msg.SessionMeta.ID
+				summary.CWD = msg.SessionMeta.CWD
+				summary.CLIVersion = msg.SessionMeta.CLIVersion
+			}
+		case msg.TurnContext != nil:
+			if model := msg.GetModel(); model != "" {
+				summary.Models[model]++
+			}
+		case msg.IsUser():
+			if !msg.IsInjectedContext() {
+				summary.UserMessages++
+			}
+		case msg.IsAssistant():
+			summary.AssistantMessages++
+		case msg.IsToolCall():
+			summary.ToolCalls++
+		case msg.Event != nil && msg.Event.Info != nil:
+			total := msg.Event.Info.TotalTokenUsage
+			summary.TotalInputTokens = total.InputTokens
+			summary.TotalCachedInputTokens = total.CachedInputTokens
+			summary.TotalOutputTokens = total.OutputTokens
+			summary.TotalReasoningTokens = total.ReasoningOutputTokens
+		}
+	}
+
+	return summary, nil
+}
+
+// ExtractPlan extracts all update_plan snapshots from a rollout file.
+// Returns a slice of plans in chronological order.
+func ExtractPlan(path string) ([][]PlanStep, error) {
+	messages, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans [][]PlanStep
+
+	for _, msg := range messages {
+		if plan := msg.GetPlan(); len(plan) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+
+	return plans, nil
+}
+
+// ExtractToolCalls extracts all tool calls from a rollout file.
+func ExtractToolCalls(path string) ([]ToolCall, error) {
+	messages, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []ToolCall
+
+	for _, msg := range messages {
+		if call := msg.GetToolCall(); call != nil {
+			tools = append(tools, *call)
+		}
+	}
+
+	return tools, nil
+}
+
+// FilterByType returns only messages with the given record type.
+func FilterByType(messages []Message, recordType string) []Message {
+	var filtered []Message
+	for _, msg := range messages {
+		if msg.Type == recordType {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+// ToJSON converts messages to a JSON array of their raw records for export.
+func ToJSON(messages []Message) ([]byte, error) {
+	raw := make([]json.RawMessage, len(messages))
+	for i, msg := range messages {
+		raw[i] = msg.Raw
+	}
+	return json.MarshalIndent(raw, "", "  ")
+}
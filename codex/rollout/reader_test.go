@@ -0,0 +1,218 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const threadID = "0199a213-81c0-7800-8aa1-bbab2a035a53"
+
+// Sample rollout content matching Codex's format
+const sampleRollout = `{"timestamp":"2025-06-02T09:00:00.000Z","type":"session_meta","payload":{"id":"` + threadID + `","timestamp":"2025-06-02T09:00:00.000Z","cwd":"/repo","originator":"codex_cli_rs","cli_version":"0.40.0"}}
+{"timestamp":"2025-06-02T09:00:00.100Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>\n  <cwd>/repo</cwd>\n</environment_context>"}]}}
+{"timestamp":"2025-06-02T09:00:00.200Z","type":"turn_context","payload":{"cwd":"/repo","approval_policy":"on-request","model":"gpt-5-codex","effort":"medium"}}
+{"timestamp":"2025-06-02T09:00:00.300Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"List the files"}]}}
+{"timestamp":"2025-06-02T09:00:01.000Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"Use ls."}],"encrypted_content":"xyz"}}
+{"timestamp":"2025-06-02T09:00:01.100Z","type":"response_item","payload":{"type":"function_call","name":"update_plan","arguments":"{\"plan\":[{\"step\":\"List files\",\"status\":\"in_progress\"}]}","call_id":"call_plan"}}
+{"timestamp":"2025-06-02T09:00:01.300Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\"]}","call_id":"call_1"}}
+{"timestamp":"2025-06-02T09:00:01.500Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"missing\\n\",\"metadata\":{\"exit_code\":2}}"}}
+{"timestamp":"2025-06-02T09:00:01.600Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch","call_id":"call_2"}}
+{"timestamp":"2025-06-02T09:00:02.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Done."}]}}
+{"timestamp":"2025-06-02T09:00:02.100Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":500,"cached_input_tokens":100,"output_tokens":40,"reasoning_output_tokens":8},"last_token_usage":{"input_tokens":300,"cached_input_tokens":0,"output_tokens":20}}}}
+{"timestamp":"2025-06-02T09:00:02.200Z","type":"response_item","payload":{"type":"function_call","name":"update_plan","arguments":"{\"plan\":[{\"step\":\"List files\",\"status\":\"completed\"}]}","call_id":"call_plan2"}}
+not json
+`
+
+func writeRollout(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFileParsesTypedMessages(t *testing.T) {
+	path := writeRollout(t, t.TempDir(), "rollout.jsonl", sampleRollout)
+
+	messages, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 12 {
+		t.Fatalf("expected 12 messages, got %d", len(messages))
+	}
+	if messages[0].SessionMeta == nil || messages[0].SessionMeta.ID != threadID {
+		t.Errorf("session_meta = %+v", messages[0].SessionMeta)
+	}
+	if !messages[1].IsInjectedContext() || messages[3].IsInjectedContext() {
+		t.Error("only the environment context should be injected")
+	}
+	if got := messages[2].GetModel(); got != "gpt-5-codex" {
+		t.Errorf("expected model gpt-5-codex, got %s", got)
+	}
+	if got := messages[4].GetText(); got != "Use ls." {
+		t.Errorf("expected reasoning summary, got %q", got)
+	}
+	if out, isErr := messages[7].GetToolOutput(); out != "missing\n" || !isErr {
+		t.Errorf("shell output = %q, error %v", out, isErr)
+	}
+	if call := messages[8].GetToolCall(); call == nil || string(call.Arguments) != `"*** Begin Patch"` {
+		t.Errorf("custom tool call = %+v", call)
+	}
+	if usage := messages[10].GetUsage(); usage == nil || usage.InputTokens != 300 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestReader_ReadFrom(t *testing.T) {
+	path := writeRollout(t, t.TempDir(), "rollout.jsonl", sampleRollout)
+	r, err := NewReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	_, offset, err := r.ReadFrom(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, newOffset, err := r.ReadFrom(offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 0 || newOffset != offset {
+		t.Errorf("reading from end returned %d messages, offset %d -> %d", len(messages), offset, newOffset)
+	}
+}
+
+func TestReader_Tail(t *testing.T) {
+	path := writeRollout(t, t.TempDir(), "rollout.jsonl", "")
+	r, err := NewReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ch := r.Tail(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Write one record in two pieces; only the complete line is delivered.
+	line := `{"timestamp":"2025-06-02T09:00:02.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Hi"}]}}`
+	if _, err := f.WriteString(line[:40]); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := f.WriteString(line[40:] + "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-ch:
+		if !msg.IsAssistant() || msg.GetText() != "Hi" {
+			t.Errorf("tailed message = %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for tailed message")
+	}
+}
+
+func TestFindRolloutFiles(t *testing.T) {
+	sessions := t.TempDir()
+	day1 := filepath.Join(sessions, "2025", "06", "01")
+	day2 := filepath.Join(sessions, "2025", "06", "02")
+	older := writeRollout(t, day1, "rollout-2025-06-01T23-59-00-aaaaaaaa-0000-0000-0000-000000000001.jsonl", "")
+	first := writeRollout(t, day2, "rollout-2025-06-02T08-00-00-"+threadID+".jsonl", "")
+	second := writeRollout(t, day2, "rollout-2025-06-02T09-00-00-bbbbbbbb-0000-0000-0000-000000000002.jsonl", "")
+	writeRollout(t, day2, "notes.txt", "")
+
+	files, err := FindRolloutFiles(sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[0] != older || files[1] != first || files[2] != second {
+		t.Errorf("FindRolloutFiles = %v", files)
+	}
+
+	byDate, err := FindByDate(sessions, time.Date(2025, 6, 2, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDate) != 2 || byDate[0] != first {
+		t.Errorf("FindByDate = %v", byDate)
+	}
+	if none, err := FindByDate(sessions, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)); err != nil || len(none) != 0 {
+		t.Errorf("FindByDate for an empty day = %v, %v", none, err)
+	}
+
+	found, err := FindByThreadID(sessions, threadID)
+	if err != nil || found != first {
+		t.Errorf("FindByThreadID = %q, %v", found, err)
+	}
+	if _, err := FindByThreadID(sessions, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	info, ok := ParseFileName(first)
+	if !ok || info.ThreadID != threadID || info.StartedAt.Hour() != 8 {
+		t.Errorf("ParseFileName = %+v, %v", info, ok)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	path := writeRollout(t, t.TempDir(), "rollout.jsonl", sampleRollout)
+
+	summary, err := Summarize(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ThreadID != threadID || summary.CWD != "/repo" || summary.CLIVersion != "0.40.0" {
+		t.Errorf("summary header = %+v", summary)
+	}
+	if summary.UserMessages != 1 || summary.AssistantMessages != 1 || summary.ToolCalls != 4 {
+		t.Errorf("counts = %+v", summary)
+	}
+	if summary.TotalInputTokens != 500 || summary.TotalCachedInputTokens != 100 || summary.TotalOutputTokens != 40 || summary.TotalReasoningTokens != 8 {
+		t.Errorf("tokens = %+v", summary)
+	}
+	if summary.Models["gpt-5-codex"] != 1 {
+		t.Errorf("models = %v", summary.Models)
+	}
+	if summary.FirstTimestamp != "2025-06-02T09:00:00.000Z" || summary.LastTimestamp != "2025-06-02T09:00:02.200Z" {
+		t.Errorf("timestamps = %s .. %s", summary.FirstTimestamp, summary.LastTimestamp)
+	}
+}
+
+func TestExtractToolCallsAndPlan(t *testing.T) {
+	path := writeRollout(t, t.TempDir(), "rollout.jsonl", sampleRollout)
+
+	tools, err := ExtractToolCalls(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 4 || tools[1].Name != "shell" || tools[1].CallID != "call_1" {
+		t.Errorf("tool calls = %+v", tools)
+	}
+
+	plans, err := ExtractPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 2 || plans[0][0].Status != "in_progress" || plans[1][0].Status != "completed" {
+		t.Errorf("plans = %+v", plans)
+	}
+}
@@ -0,0 +1,320 @@
+package rollout
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Record types written to rollout files.
+const (
+	TypeSessionMeta  = "session_meta"
+	TypeResponseItem = "response_item"
+	TypeEventMsg     = "event_msg"
+	TypeTurnContext  = "turn_context"
+	TypeCompacted    = "compacted"
+)
+
+// Response item types.
+const (
+	ItemMessage              = "message"
+	ItemReasoning            = "reasoning"
+	ItemFunctionCall         = "function_call"
+	ItemFunctionCallOutput   = "function_call_output"
+	ItemCustomToolCall       = "custom_tool_call"
+	ItemCustomToolCallOutput = "custom_tool_call_output"
+	ItemLocalShellCall       = "local_shell_call"
+	ItemWebSearchCall        = "web_search_call"
+)
+
+// Message is one record of a rollout file. Exactly one of the typed payload
+// fields is set, chosen by Type; unknown record types leave them all nil.
+type Message struct {
+	Timestamp string          `json:"timestamp"` // ISO 8601 format
+	Type      string          `json:"type"`      // "session_meta", "response_item", "event_msg", "turn_context", "compacted"
+	Payload   json.RawMessage `json:"payload"`
+
+	SessionMeta *SessionMeta  `json:"-"`
+	TurnContext *TurnContext  `json:"-"`
+	Item        *ResponseItem `json:"-"`
+	Event       *EventMsg     `json:"-"`
+	Compacted   *Compacted    `json:"-"`
+
+	// Raw JSON for advanced parsing
+	Raw json.RawMessage `json:"-"`
+}
+
+// SessionMeta is the first record of a rollout and identifies the thread.
+type SessionMeta struct {
+	ID            string `json:"id"` // Thread ID (UUID)
+	Timestamp     string `json:"timestamp"`
+	CWD           string `json:"cwd"`
+	Originator    string `json:"originator,omitempty"`
+	CLIVersion    string `json:"cli_version,omitempty"`
+	Instructions  string `json:"instructions,omitempty"`
+	ModelProvider string `json:"model_provider,omitempty"`
+	Git           *struct {
+		CommitHash    string `json:"commit_hash,omitempty"`
+		Branch        string `json:"branch,omitempty"`
+		RepositoryURL string `json:"repository_url,omitempty"`
+	} `json:"git,omitempty"`
+}
+
+// TurnContext records the settings in effect for the following turn.
+type TurnContext struct {
+	CWD            string          `json:"cwd"`
+	ApprovalPolicy string          `json:"approval_policy,omitempty"`
+	SandboxPolicy  json.RawMessage `json:"sandbox_policy,omitempty"`
+	Model          string          `json:"model"`
+	Effort         string          `json:"effort,omitempty"`
+	Summary        string          `json:"summary,omitempty"`
+}
+
+// ResponseItem is a model input or output item.
+type ResponseItem struct {
+	Type string `json:"type"` // "message", "reasoning", "function_call", ...
+
+	// message
+	Role    string        `json:"role,omitempty"` // "user", "assistant", "developer"
+	Content []ContentItem `json:"content,omitempty"`
+
+	// reasoning
+	Summary []ContentItem `json:"summary,omitempty"`
+
+	// function_call, custom_tool_call, local_shell_call and their outputs
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"` // JSON-encoded (function_call)
+	Input     string          `json:"input,omitempty"`     // Free-form input (custom_tool_call)
+	CallID    string          `json:"call_id,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Action    json.RawMessage `json:"action,omitempty"` // local_shell_call and web_search_call
+	Status    string          `json:"status,omitempty"`
+}
+
+// ContentItem is a message content or reasoning summary part.
+type ContentItem struct {
+	Type string `json:"type"` // "input_text", "output_text", "summary_text", "input_image"
+	Text string `json:"text,omitempty"`
+}
+
+// EventMsg is a protocol event recorded alongside the response items.
+type EventMsg struct {
+	Type    string          `json:"type"` // "user_message", "agent_message", "token_count", ...
+	Message string          `json:"message,omitempty"`
+	Info    *TokenCountInfo `json:"info,omitempty"`
+}
+
+// TokenCountInfo is the payload of a token_count event.
+type TokenCountInfo struct {
+	TotalTokenUsage    TokenUsage `json:"total_token_usage"`
+	LastTokenUsage     TokenUsage `json:"last_token_usage"`
+	ModelContextWindow int        `json:"model_context_window,omitempty"`
+}
+
+// TokenUsage is token usage for a request or a whole thread.
+type TokenUsage struct {
+	InputTokens           int `json:"input_tokens"`
+	CachedInputTokens     int `json:"cached_input_tokens,omitempty"`
+	OutputTokens          int `json:"output_tokens"`
+	ReasoningOutputTokens int `json:"reasoning_output_tokens,omitempty"`
+	TotalTokens           int `json:"total_tokens,omitempty"`
+}
+
+// Compacted marks a history compaction.
+type Compacted struct {
+	Message string `json:"message"`
+}
+
+// ToolCall is a tool invocation extracted from a response item.
+type ToolCall struct {
+	CallID    string          `json:"call_id"`
+	Name      string          `json:"name"`                // "shell" for local_shell_call
+	Arguments json.RawMessage `json:"arguments,omitempty"` // JSON arguments, free-form input as a JSON string, or the shell action
+	Timestamp string          `json:"timestamp,omitempty"`
+}
+
+// PlanStep is one step of an update_plan call.
+type PlanStep struct {
+	Step   string `json:"step"`
+	Status string `json:"status"` // "pending", "in_progress", "completed"
+}
+
+// ParseMessage parses a single line from a rollout file.
+func ParseMessage(data []byte) (*Message, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	msg.Raw = data
+
+	var target any
+	switch msg.Type {
+	case TypeSessionMeta:
+		msg.SessionMeta = &SessionMeta{}
+		target = msg.SessionMeta
+	case TypeTurnContext:
+		msg.TurnContext = &TurnContext{}
+		target = msg.TurnContext
+	case TypeResponseItem:
+		msg.Item = &ResponseItem{}
+		target = msg.Item
+	case TypeEventMsg:
+		msg.Event = &EventMsg{}
+		target = msg.Event
+	case TypeCompacted:
+		msg.Compacted = &Compacted{}
+		target = msg.Compacted
+	default:
+		return &msg, nil
+	}
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, target); err != nil {
+			return nil, err
+		}
+	}
+	return &msg, nil
+}
+
+// IsUser returns true if this is a user message item, including context
+// Codex injects itself (see IsInjectedContext).
+// Returns false for nil receivers.
+func (m *Message) IsUser() bool {
+	return m != nil && m.Item != nil && m.Item.Type == ItemMessage && m.Item.Role == "user"
+}
+
+// IsAssistant returns true if this is an assistant message item.
+// Returns false for nil receivers.
+func (m *Message) IsAssistant() bool {
+	return m != nil && m.Item != nil && m.Item.Type == ItemMessage && m.Item.Role == "assistant"
+}
+
+// IsToolCall returns true if this item invokes a tool.
+func (m *Message) IsToolCall() bool {
+	if m == nil || m.Item == nil {
+		return false
+	}
+	switch m.Item.Type {
+	case ItemFunctionCall, ItemCustomToolCall, ItemLocalShellCall:
+		return true
+	}
+	return false
+}
+
+// IsToolOutput returns true if this item carries a tool result.
+func (m *Message) IsToolOutput() bool {
+	return m != nil && m.Item != nil &&
+		(m.Item.Type == ItemFunctionCallOutput || m.Item.Type == ItemCustomToolCallOutput)
+}
+
+// IsInjectedContext returns true for user messages Codex adds itself, such
+// as environment context and AGENTS.md instructions, rather than prompts.
+func (m *Message) IsInjectedContext() bool {
+	if !m.IsUser() {
+		return false
+	}
+	text := strings.TrimSpace(m.GetText())
+	return strings.HasPrefix(text, "<environment_context>") ||
+		strings.HasPrefix(text, "<user_instructions>") ||
+		strings.HasPrefix(text, "# AGENTS.md instructions")
+}
+
+// GetText extracts concatenated text from a message item's content, or a
+// reasoning item's summary.
+func (m *Message) GetText() string {
+	if m == nil || m.Item == nil {
+		return ""
+	}
+	parts := m.Item.Content
+	if m.Item.Type == ItemReasoning {
+		parts = m.Item.Summary
+	}
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 && sb.Len() > 0 && part.Text != "" {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+// GetModel returns the model from a turn_context record.
+func (m *Message) GetModel() string {
+	if m != nil && m.TurnContext != nil {
+		return m.TurnContext.Model
+	}
+	return ""
+}
+
+// GetUsage returns the usage of the last request from a token_count event.
+func (m *Message) GetUsage() *TokenUsage {
+	if m != nil && m.Event != nil && m.Event.Info != nil {
+		return &m.Event.Info.LastTokenUsage
+	}
+	return nil
+}
+
+// GetToolCall returns the tool call carried by this item, or nil.
+func (m *Message) GetToolCall() *ToolCall {
+	if !m.IsToolCall() {
+		return nil
+	}
+	call := &ToolCall{CallID: m.Item.CallID, Name: m.Item.Name, Timestamp: m.Timestamp}
+	switch {
+	case m.Item.Arguments != "" && json.Valid([]byte(m.Item.Arguments)):
+		call.Arguments = json.RawMessage(m.Item.Arguments)
+	case m.Item.Input != "":
+		call.Arguments, _ = json.Marshal(m.Item.Input)
+	case len(m.Item.Action) > 0:
+		call.Arguments = m.Item.Action
+	}
+	if call.Name == "" && m.Item.Type == ItemLocalShellCall {
+		call.Name = "shell"
+	}
+	return call
+}
+
+// GetToolOutput decodes a tool output item into its text and whether the
+// tool failed. Shell outputs are JSON-encoded with an exit code; other
+// outputs are plain strings or {"content", "success"} objects.
+func (m *Message) GetToolOutput() (output string, isError bool) {
+	if !m.IsToolOutput() {
+		return "", false
+	}
+	raw := m.Item.Output
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		var obj struct {
+			Content string `json:"content"`
+			Success *bool  `json:"success"`
+		}
+		if json.Unmarshal(raw, &obj) == nil {
+			return obj.Content, obj.Success != nil && !*obj.Success
+		}
+		return string(raw), false
+	}
+	var shell struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal([]byte(s), &shell) == nil && shell.Metadata.ExitCode != nil {
+		return shell.Output, *shell.Metadata.ExitCode != 0
+	}
+	return s, false
+}
+
+// GetPlan returns the steps of an update_plan call, or nil.
+func (m *Message) GetPlan() []PlanStep {
+	call := m.GetToolCall()
+	if call == nil || call.Name != "update_plan" {
+		return nil
+	}
+	var args struct {
+		Plan []PlanStep `json:"plan"`
+	}
+	if json.Unmarshal(call.Arguments, &args) != nil {
+		return nil
+	}
+	return args.Plan
+}
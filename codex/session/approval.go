@@ -0,0 +1,411 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Approval requests the app-server sends to the client. The item/* methods
+// belong to the v2 thread API; the other two are the legacy conversation
+// API equivalents, which some app-server versions still send.
+const (
+	MethodCommandApproval     = "item/commandExecution/requestApproval"
+	MethodFileChangeApproval  = "item/fileChange/requestApproval"
+	MethodExecCommandApproval = "execCommandApproval"
+	MethodApplyPatchApproval  = "applyPatchApproval"
+)
+
+// EventApprovalDecision is the type of the output messages this package
+// emits after each approval decision. The app-server never sends it.
+const EventApprovalDecision = "approval.decision"
+
+// defaultApprovalTimeout bounds how long an approval handler may take. It
+// is generous because handlers often wait for a person.
+const defaultApprovalTimeout = 5 * time.Minute
+
+// JSON-RPC error codes for server requests this package cannot answer.
+const (
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+)
+
+// Reasons an approval falls back to ApprovalDeny. They are reported on
+// ApprovalEvent.Error.
+var (
+	ErrNoApprovalHandler = errors.New("no approval handler configured")
+	ErrApprovalTimeout   = errors.New("approval handler timed out")
+)
+
+// ApprovalKind says what an approval request is for.
+type ApprovalKind string
+
+// Approval kinds.
+const (
+	ApprovalCommand ApprovalKind = "command" // Run a shell command
+	ApprovalPatch   ApprovalKind = "patch"   // Apply file changes
+)
+
+// ApprovalDecision is the answer to an ApprovalRequest. The zero value
+// denies.
+type ApprovalDecision string
+
+// Approval decisions.
+const (
+	ApprovalApprove           ApprovalDecision = "approve"
+	ApprovalApproveForSession ApprovalDecision = "approve_for_session" // Also approve identical requests for the rest of the session
+	ApprovalDeny              ApprovalDecision = "deny"
+)
+
+// ApprovalHandler decides whether Codex may run a command or apply a patch.
+// It is called concurrently for parallel requests, and ctx is cancelled
+// when the approval timeout passes or the session ends. Returning an error
+// denies the request.
+type ApprovalHandler func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+
+// ApprovalRequest is a command or patch waiting for approval.
+type ApprovalRequest struct {
+	Kind     ApprovalKind `json:"kind"`
+	Method   string       `json:"method"` // JSON-RPC method the request arrived on
+	ThreadID string       `json:"thread_id,omitempty"`
+	TurnID   string       `json:"turn_id,omitempty"`
+	ItemID   string       `json:"item_id,omitempty"` // Item ID (v2) or call ID (legacy)
+	Reason   string       `json:"reason,omitempty"`  // Why Codex wants to escape the sandbox, if given
+
+	// Command requests.
+	Command string `json:"command,omitempty"`
+	CWD     string `json:"cwd,omitempty"`
+
+	// Patch requests.
+	Changes   []FileChange `json:"changes,omitempty"`
+	GrantRoot string       `json:"grant_root,omitempty"` // Directory Codex asks write access to for the session
+
+	// Raw holds the request params as received.
+	Raw json.RawMessage `json:"-"`
+}
+
+// FileChange is one file in a patch approval request.
+type FileChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind,omitempty"` // add, delete or update
+	Diff string `json:"diff,omitempty"` // Unified diff, or the full content for adds and deletes
+}
+
+// Diff returns the diffs of all changes concatenated in order.
+func (r ApprovalRequest) Diff() string {
+	var b strings.Builder
+	for _, c := range r.Changes {
+		b.WriteString(c.Diff)
+		if c.Diff != "" && !strings.HasSuffix(c.Diff, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// ApprovalEvent records one approval decision. It is delivered on
+// OutputMessage.Approval.
+type ApprovalEvent struct {
+	Request  ApprovalRequest  `json:"request"`
+	Decision ApprovalDecision `json:"decision"`
+	Error    string           `json:"error,omitempty"` // Why the request was denied without a handler decision
+}
+
+// Decide runs the handler under ctx. It always returns a valid decision:
+// a nil handler, a handler error, an unknown decision or ctx ending first
+// all deny, and the returned error says which happened.
+func (h ApprovalHandler) Decide(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	if h == nil {
+		return ApprovalDeny, ErrNoApprovalHandler
+	}
+
+	type result struct {
+		decision ApprovalDecision
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		d, err := h(ctx, req)
+		done <- result{d, err}
+	}()
+
+	select {
+	case r := <-done:
+		switch {
+		case r.err != nil:
+			return ApprovalDeny, fmt.Errorf("approval handler failed: %w", r.err)
+		case r.decision == ApprovalApprove, r.decision == ApprovalApproveForSession, r.decision == ApprovalDeny:
+			return r.decision, nil
+		default:
+			return ApprovalDeny, fmt.Errorf("approval handler returned unknown decision %q", r.decision)
+		}
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ApprovalDeny, ErrApprovalTimeout
+		}
+		return ApprovalDeny, ctx.Err()
+	}
+}
+
+// isApprovalMethod reports whether method is an approval request.
+func isApprovalMethod(method string) bool {
+	switch method {
+	case MethodCommandApproval, MethodFileChangeApproval, MethodExecCommandApproval, MethodApplyPatchApproval:
+		return true
+	}
+	return false
+}
+
+// ParseApprovalRequest decodes the params of an approval request. Patch
+// requests from the v2 API carry no diff; pass the changes of the matching
+// fileChange item as known, or nil.
+func ParseApprovalRequest(method string, params json.RawMessage, known []FileChange) (ApprovalRequest, error) {
+	var p struct {
+		ThreadID       string          `json:"threadId"`
+		TurnID         string          `json:"turnId"`
+		ItemID         string          `json:"itemId"`
+		ConversationID string          `json:"conversationId"`
+		CallID         string          `json:"callId"`
+		Reason         string          `json:"reason"`
+		Command        json.RawMessage `json:"command"`
+		CWD            string          `json:"cwd"`
+		GrantRoot      string          `json:"grantRoot"`
+		Changes        json.RawMessage `json:"changes"`
+		FileChanges    json.RawMessage `json:"fileChanges"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return ApprovalRequest{}, fmt.Errorf("parse %s params: %w", method, err)
+		}
+	}
+
+	req := ApprovalRequest{
+		Method:    method,
+		ThreadID:  firstNonEmpty(p.ThreadID, p.ConversationID),
+		TurnID:    p.TurnID,
+		ItemID:    firstNonEmpty(p.ItemID, p.CallID),
+		Reason:    p.Reason,
+		CWD:       p.CWD,
+		GrantRoot: p.GrantRoot,
+		Raw:       params,
+	}
+
+	switch method {
+	case MethodCommandApproval, MethodExecCommandApproval:
+		req.Kind = ApprovalCommand
+		req.Command = commandString(p.Command)
+	case MethodFileChangeApproval, MethodApplyPatchApproval:
+		req.Kind = ApprovalPatch
+		req.Changes = parseFileChanges(p.Changes)
+		if req.Changes == nil {
+			req.Changes = parseLegacyFileChanges(p.FileChanges)
+		}
+		if req.Changes == nil {
+			req.Changes = known
+		}
+	default:
+		return ApprovalRequest{}, fmt.Errorf("%s is not an approval request", method)
+	}
+	return req, nil
+}
+
+// approvalResult returns the JSON-RPC result answering an approval request
+// with decision. The v2 and legacy APIs spell decisions differently.
+func approvalResult(method string, decision ApprovalDecision) map[string]string {
+	legacy := method == MethodExecCommandApproval || method == MethodApplyPatchApproval
+	var wire string
+	switch {
+	case decision == ApprovalApprove && legacy:
+		wire = "approved"
+	case decision == ApprovalApprove:
+		wire = "accept"
+	case decision == ApprovalApproveForSession && legacy:
+		wire = "approved_for_session"
+	case decision == ApprovalApproveForSession:
+		wire = "acceptForSession"
+	case legacy:
+		wire = "denied"
+	default:
+		wire = "decline"
+	}
+	return map[string]string{"decision": wire}
+}
+
+// commandString flattens a command given as a string or an argv array.
+func commandString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var argv []string
+	if json.Unmarshal(raw, &argv) == nil {
+		return strings.Join(argv, " ")
+	}
+	return ""
+}
+
+// parseFileChanges decodes v2 file changes ([{path, kind, diff}]), where
+// kind is either a string or an object with a type field.
+func parseFileChanges(raw json.RawMessage) []FileChange {
+	var entries []struct {
+		Path string          `json:"path"`
+		Kind json.RawMessage `json:"kind"`
+		Diff string          `json:"diff"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &entries) != nil || len(entries) == 0 {
+		return nil
+	}
+	changes := make([]FileChange, 0, len(entries))
+	for _, e := range entries {
+		changes = append(changes, FileChange{Path: e.Path, Kind: changeKind(e.Kind), Diff: e.Diff})
+	}
+	return changes
+}
+
+// parseLegacyFileChanges decodes the legacy path -> change map, where each
+// change is {"type":"add","content":..}, {"type":"delete","content":..} or
+// {"type":"update","unified_diff":..}.
+func parseLegacyFileChanges(raw json.RawMessage) []FileChange {
+	var byPath map[string]struct {
+		Type        string `json:"type"`
+		Content     string `json:"content"`
+		UnifiedDiff string `json:"unified_diff"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &byPath) != nil || len(byPath) == 0 {
+		return nil
+	}
+	changes := make([]FileChange, 0, len(byPath))
+	for path, c := range byPath {
+		changes = append(changes, FileChange{Path: path, Kind: c.Type, Diff: firstNonEmpty(c.UnifiedDiff, c.Content)})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// changeKind reads a patch change kind given as "add" or {"type":"add"}.
+func changeKind(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(raw, &obj)
+	return obj.Type
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// newApprovalMessage wraps an approval event as an output message.
+func newApprovalMessage(ev ApprovalEvent) OutputMessage {
+	msg := OutputMessage{
+		Type:     EventApprovalDecision,
+		ThreadID: ev.Request.ThreadID,
+		TurnID:   ev.Request.TurnID,
+		ItemID:   ev.Request.ItemID,
+		Approval: &ev,
+	}
+	msg.Raw, _ = json.Marshal(struct {
+		Type string `json:"type"`
+		ApprovalEvent
+	}{msg.Type, ev})
+	return msg
+}
+
+// rememberFileChanges records the changes of a fileChange item so a later
+// v2 patch approval for that item can show its diff.
+func (s *session) rememberFileChanges(msg *OutputMessage) {
+	if !msg.IsItemStarted() || msg.ItemType != "fileChange" || msg.ItemID == "" {
+		return
+	}
+	var n struct {
+		Params struct {
+			Item struct {
+				Changes json.RawMessage `json:"changes"`
+			} `json:"item"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(msg.Raw, &n) != nil {
+		return
+	}
+	if changes := parseFileChanges(n.Params.Item.Changes); changes != nil {
+		s.fileChangesMu.Lock()
+		s.fileChanges[msg.ItemID] = changes
+		s.fileChangesMu.Unlock()
+	}
+}
+
+// answerServerRequest handles a request the app-server sent to the client.
+// Approval requests go to the configured handler; anything else gets a
+// method-not-found error so the server does not wait forever.
+func (s *session) answerServerRequest(req *serverRequest) {
+	if !isApprovalMethod(req.Method) {
+		s.respondServer(req.ID, nil, &JSONRPCError{
+			Code:    jsonRPCMethodNotFound,
+			Message: fmt.Sprintf("client does not handle %s requests", req.Method),
+		})
+		return
+	}
+
+	var known []FileChange
+	if req.Method == MethodFileChangeApproval {
+		var ids struct {
+			ItemID string `json:"itemId"`
+		}
+		_ = json.Unmarshal(req.Params, &ids)
+		s.fileChangesMu.Lock()
+		known = s.fileChanges[ids.ItemID]
+		delete(s.fileChanges, ids.ItemID)
+		s.fileChangesMu.Unlock()
+	}
+	approval, err := ParseApprovalRequest(req.Method, req.Params, known)
+	if err != nil {
+		s.respondServer(req.ID, nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.approvalTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	decision, err := s.config.approvalHandler.Decide(ctx, approval)
+	ev := ApprovalEvent{Request: approval, Decision: decision}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	s.publish(newApprovalMessage(ev))
+	s.respondServer(req.ID, approvalResult(req.Method, decision), nil)
+}
+
+// respondServer answers a server request with a result or an error.
+func (s *session) respondServer(id json.RawMessage, result any, rpcErr *JSONRPCError) {
+	resp := struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  any             `json:"result,omitempty"`
+		Error   *JSONRPCError   `json:"error,omitempty"`
+	}{JSONRPCVersion, id, result, rpcErr}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	_ = s.writeLine(append(data, '\n'))
+}
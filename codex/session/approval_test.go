@@ -0,0 +1,170 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// approvalStub completes the handshake, then answers turn/start with a
+// fileChange item and four server requests: a v2 command approval, a v2
+// patch approval, a legacy exec approval and a method the client does not
+// know. It logs each answer to $STUB_LOG and ends the turn after the last.
+const approvalStub = `#!/bin/sh
+answered=0
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"method":"initialize"'*)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{}}\n' "$id" ;;
+    *'"method":"thread/start"'*)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"thread":{"id":"t1"}}}\n' "$id" ;;
+    *'"method":"turn/start"'*)
+      printf '{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"t1","turn":{"id":"turn1"}}}\n'
+      printf '{"jsonrpc":"2.0","method":"item/started","params":{"threadId":"t1","turnId":"turn1","item":{"id":"fc1","type":"fileChange","changes":[{"path":"main.go","kind":{"type":"update"},"diff":"-a\\n+b\\n"}]}}}\n'
+      printf '{"jsonrpc":"2.0","id":101,"method":"item/commandExecution/requestApproval","params":{"threadId":"t1","turnId":"turn1","itemId":"cmd1","command":"make test","cwd":"/repo","reason":"needs network"}}\n'
+      printf '{"jsonrpc":"2.0","id":102,"method":"item/fileChange/requestApproval","params":{"threadId":"t1","turnId":"turn1","itemId":"fc1"}}\n'
+      printf '{"jsonrpc":"2.0","id":103,"method":"execCommandApproval","params":{"conversationId":"t1","callId":"call1","command":["rm","-rf","/"],"cwd":"/repo"}}\n'
+      printf '{"jsonrpc":"2.0","id":104,"method":"item/tool/requestUserInput","params":{"threadId":"t1"}}\n' ;;
+    *'"result"'*|*'"error"'*)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      answered=$((answered + 1))
+      if [ "$answered" -eq 4 ]; then
+        printf '{"jsonrpc":"2.0","method":"turn/completed","params":{"threadId":"t1","turn":{"id":"turn1"}}}\n'
+      fi ;;
+  esac
+done
+`
+
+func TestSessionApprovalHandler(t *testing.T) {
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "codex")
+	if err := os.WriteFile(stub, []byte(approvalStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "stdin.log")
+
+	stuck := make(chan struct{})
+	t.Cleanup(func() { close(stuck) })
+	handler := func(_ context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		switch {
+		case req.Kind == ApprovalPatch:
+			return ApprovalApprove, nil
+		case req.Command == "make test":
+			return ApprovalApproveForSession, nil
+		}
+		<-stuck // Ignores ctx; the timeout must still answer
+		return ApprovalApprove, nil
+	}
+
+	s, err := newSession(context.Background(),
+		WithCodexPath(stub),
+		WithEnv(map[string]string{"STUB_LOG": logPath}),
+		WithApprovalHandler(handler),
+		WithApprovalTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("newSession returned error: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Send(context.Background(), NewUserMessage("go")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	events := map[string]ApprovalEvent{}
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case msg := <-s.Output():
+			if msg.IsApprovalDecision() {
+				events[msg.Approval.Request.ItemID] = *msg.Approval
+			}
+			done = msg.IsTurnComplete()
+		case <-timeout:
+			t.Fatal("timed out waiting for the turn to end")
+		}
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("approval events = %+v, want 3", events)
+	}
+	if ev := events["cmd1"]; ev.Request.Kind != ApprovalCommand || ev.Request.CWD != "/repo" || ev.Request.Reason != "needs network" || ev.Decision != ApprovalApproveForSession {
+		t.Fatalf("command approval = %+v", ev)
+	}
+	if ev := events["fc1"]; ev.Request.Diff() != "-a\n+b\n" || ev.Request.Changes[0].Kind != "update" || ev.Decision != ApprovalApprove {
+		t.Fatalf("patch approval = %+v, want the item's diff", ev)
+	}
+	if ev := events["call1"]; ev.Request.Command != "rm -rf /" || ev.Decision != ApprovalDeny || ev.Error != ErrApprovalTimeout.Error() {
+		t.Fatalf("legacy approval = %+v, want a timeout deny", ev)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers := map[int64]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var resp JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.ID == nil {
+			t.Fatalf("bad answer line %q: %v", line, err)
+		}
+		answers[*resp.ID] = line
+	}
+	for id, want := range map[int64]string{
+		101: `"result":{"decision":"acceptForSession"}`,
+		102: `"result":{"decision":"accept"}`,
+		103: `"result":{"decision":"denied"}`,
+		104: `"error":{"code":-32601`,
+	} {
+		if !strings.Contains(answers[id], want) {
+			t.Errorf("answer to %d = %q, want %s", id, answers[id], want)
+		}
+	}
+}
+
+func TestApprovalHandlerDecide(t *testing.T) {
+	req := ApprovalRequest{Kind: ApprovalCommand, Command: "ls"}
+	tests := []struct {
+		name    string
+		handler ApprovalHandler
+		want    ApprovalDecision
+		wantErr bool
+	}{
+		{"nil handler denies", nil, ApprovalDeny, true},
+		{"approve", func(context.Context, ApprovalRequest) (ApprovalDecision, error) { return ApprovalApprove, nil }, ApprovalApprove, false},
+		{"error denies", func(context.Context, ApprovalRequest) (ApprovalDecision, error) {
+			return ApprovalApprove, errors.New("boom")
+		}, ApprovalDeny, true},
+		{"zero value denies", func(context.Context, ApprovalRequest) (ApprovalDecision, error) { return "", nil }, ApprovalDeny, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.handler.Decide(context.Background(), req)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: Decide = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseApprovalRequest_LegacyPatch(t *testing.T) {
+	params := json.RawMessage(`{"conversationId":"t1","callId":"c1","fileChanges":{"b.go":{"type":"update","unified_diff":"-x\n+y\n"},"a.go":{"type":"add","content":"package a\n"}},"grantRoot":"/repo"}`)
+
+	req, err := ParseApprovalRequest(MethodApplyPatchApproval, params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Kind != ApprovalPatch || req.ThreadID != "t1" || req.ItemID != "c1" || req.GrantRoot != "/repo" {
+		t.Fatalf("request = %+v", req)
+	}
+	if got := req.Diff(); got != "package a\n-x\n+y\n" {
+		t.Fatalf("Diff() = %q", got)
+	}
+	if _, err := ParseApprovalRequest("item/tool/call", nil, nil); err == nil {
+		t.Fatal("expected an error for a non-approval method")
+	}
+}
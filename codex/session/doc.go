@@ -44,8 +44,55 @@
 //   - thread/resume: Resume an existing thread
 //   - turn/start: Send a new user message on a thread
 //   - turn/steer: Inject input into an actively running turn
+//   - turn/interrupt: Stop the active turn (Session.Interrupt)
+//   - thread/fork: Start a new thread from an existing one (WithFork)
 //   - shutdown: Gracefully terminate the server
 //
+// Session.Call sends any other method. Methods the installed app-server
+// does not implement fail with ErrUnknownMethod.
+//
+// # Threads, Models and Account
+//
+// SessionManager also exposes app-server queries that need no session:
+// ListThreads, ReadThread, Archive, Models, Account and RateLimits. They
+// share one thread-less app-server connection, started with the default
+// session options on first use and stopped by CloseAll:
+//
+//	page, err := mgr.ListThreads(ctx, session.ThreadListParams{Limit: 20})
+//	forked, err := mgr.Fork(ctx, page.Data[0].ID, session.WithModel("o4-mini"))
+//	limits, err := mgr.RateLimits(ctx)
+//
+// # Approvals
+//
+// When the approval policy lets Codex ask (WithApprovalMode "on-request"
+// or "untrusted"), the app-server sends command-execution and patch-apply
+// approval requests to the client. WithApprovalHandler answers them in Go:
+//
+//	sess, err := mgr.Create(ctx,
+//	    session.WithApprovalMode("on-request"),
+//	    session.WithApprovalHandler(func(ctx context.Context, req session.ApprovalRequest) (session.ApprovalDecision, error) {
+//	        if req.Kind == session.ApprovalCommand && strings.HasPrefix(req.Command, "go test") {
+//	            return session.ApprovalApproveForSession, nil
+//	        }
+//	        return session.ApprovalDeny, nil
+//	    }),
+//	)
+//
+// Without a handler, and whenever the handler errors or exceeds
+// WithApprovalTimeout, the request is denied. Each decision is also
+// delivered as an approval.decision output message (IsApprovalDecision).
+//
+// # Persistence and Warm Pools
+//
+// WithSessionStore records managed sessions in a sessionstore.Store so a
+// restarted process can call Restorer.Restore and resume them lazily. A
+// Pool keeps pre-started sessions ready to hide CLI startup latency:
+//
+//	pool := session.NewPool(mgr, session.WithPoolSize(2),
+//	    session.WithPoolSessionOptions(session.WithModel("o4-mini")))
+//	defer pool.Close()
+//	sess, err := pool.Acquire(ctx)
+//
 // # Session Lifecycle
 //
 // Sessions go through the following states:
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
 )
 
 // SessionManager manages multiple Codex app-server sessions.
@@ -32,16 +34,58 @@ type SessionManager interface {
 
 	// Info returns information about a session.
 	Info(sessionID string) (*SessionInfo, bool)
+
+	// ListThreads returns one page of the threads persisted under CODEX_HOME.
+	ListThreads(ctx context.Context, params ThreadListParams) (*ThreadListResult, error)
+
+	// ReadThread returns a persisted thread, with its turns if includeTurns.
+	ReadThread(ctx context.Context, threadID string, includeTurns bool) (*Thread, error)
+
+	// Fork starts a session on a new thread that copies threadID's history.
+	Fork(ctx context.Context, threadID string, opts ...SessionOption) (Session, error)
+
+	// Archive closes the thread's session, if any, and archives the thread
+	// so it no longer appears in ListThreads.
+	Archive(ctx context.Context, threadID string) error
+
+	// Interrupt stops the active turn of a session.
+	Interrupt(ctx context.Context, sessionID string) error
+
+	// Models lists the models available to the signed-in account.
+	Models(ctx context.Context) ([]Model, error)
+
+	// Account reports the signed-in account.
+	Account(ctx context.Context) (*AccountReadResult, error)
+
+	// RateLimits reports the account's usage against its rate limits.
+	RateLimits(ctx context.Context) (*RateLimitSnapshot, error)
+}
+
+// Restorer is implemented by the manager NewManager returns. It is kept off
+// SessionManager so existing implementations and mocks stay valid.
+type Restorer interface {
+	// Restore loads the active and suspended Codex threads recorded in the
+	// manager's session store. Restored threads are not started; Get or
+	// Resume restarts one with its stored options and WithResume on first use.
+	// Go callbacks, environment values and config overrides are not stored;
+	// pass them again with WithDefaultSessionOptions or as Resume options.
+	Restore() error
 }
 
 // manager implements SessionManager.
 type manager struct {
 	config     managerConfig
 	sessions   map[string]*session
+	restored   map[string]sessionstore.Record
+	closing    map[*session]bool
 	mu         sync.RWMutex
 	closed     bool
 	closedOnce sync.Once
 	stopClean  chan struct{}
+
+	// Thread-less app-server connection for queries such as ListThreads.
+	ctl   *session
+	ctlMu sync.Mutex
 }
 
 // NewManager creates a new session manager.
@@ -54,6 +98,8 @@ func NewManager(opts ...ManagerOption) SessionManager {
 	m := &manager{
 		config:    cfg,
 		sessions:  make(map[string]*session),
+		restored:  make(map[string]sessionstore.Record),
+		closing:   make(map[*session]bool),
 		stopClean: make(chan struct{}),
 	}
 
@@ -80,9 +126,12 @@ func (m *manager) Create(ctx context.Context, opts ...SessionOption) (Session, e
 	m.mu.Unlock()
 
 	// Apply default options first, then caller options.
-	allOpts := make([]SessionOption, 0, len(m.config.defaultOpts)+len(opts))
+	allOpts := make([]SessionOption, 0, len(m.config.defaultOpts)+len(opts)+1)
 	allOpts = append(allOpts, m.config.defaultOpts...)
 	allOpts = append(allOpts, opts...)
+	if m.config.store != nil {
+		allOpts = append(allOpts, withUpdateHook(m.persistActive))
+	}
 
 	s, err := newSession(ctx, allOpts...)
 	if err != nil {
@@ -95,15 +144,18 @@ func (m *manager) Create(ctx context.Context, opts ...SessionOption) (Session, e
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	// Double-check limits after session creation.
 	if len(m.sessions) >= m.config.maxSessions {
+		m.mu.Unlock()
 		_ = s.Close()
 		return nil, fmt.Errorf("max sessions reached (%d)", m.config.maxSessions)
 	}
 
 	m.sessions[s.ID()] = s
+	delete(m.restored, s.ID())
+	m.mu.Unlock()
+
+	m.persistActive(s)
 
 	// Remove session from map when it closes.
 	go m.watchSession(s)
@@ -116,11 +168,51 @@ func (m *manager) watchSession(s *session) {
 	<-s.done
 	m.mu.Lock()
 	delete(m.sessions, s.ID())
+	status := sessionstore.StatusSuspended
+	if m.closing[s] {
+		status = sessionstore.StatusClosed
+		delete(m.closing, s)
+	}
 	m.mu.Unlock()
+
+	m.persist(s, status)
+}
+
+// persistActive records s as active in the session store.
+func (m *manager) persistActive(s *session) {
+	m.persist(s, sessionstore.StatusActive)
+}
+
+// persist writes s to the session store. Store errors are dropped: the
+// session keeps running and the next update writes the record again.
+func (m *manager) persist(s *session, status string) {
+	if m.config.store == nil || s.ID() == "" {
+		return
+	}
+	_ = m.config.store.Save(s.record(status))
 }
 
-// Get implements SessionManager.
+// Get implements SessionManager. A restored thread is resumed on first Get.
 func (m *manager) Get(sessionID string) (Session, bool) {
+	if s, ok := m.getActive(sessionID); ok {
+		return s, true
+	}
+
+	m.mu.RLock()
+	_, restored := m.restored[sessionID]
+	m.mu.RUnlock()
+	if !restored {
+		return nil, false
+	}
+	s, err := m.Resume(context.Background(), sessionID)
+	if err != nil {
+		return nil, false
+	}
+	return s, true
+}
+
+// getActive returns the running session for sessionID.
+func (m *manager) getActive(sessionID string) (Session, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -131,25 +223,76 @@ func (m *manager) Get(sessionID string) (Session, bool) {
 	return s, true
 }
 
-// Resume implements SessionManager.
+// Resume implements SessionManager. When the session store has a record for
+// threadID, its stored options are applied before opts.
 func (m *manager) Resume(ctx context.Context, threadID string, opts ...SessionOption) (Session, error) {
 	// Check if session is already active.
-	if s, ok := m.Get(threadID); ok {
+	if s, ok := m.getActive(threadID); ok {
 		return s, nil
 	}
 
-	resumeOpts := append([]SessionOption{WithResume(threadID)}, opts...)
-	return m.Create(ctx, resumeOpts...)
+	resumeOpts := []SessionOption{WithResume(threadID)}
+	if rec, ok := m.storedRecord(threadID); ok {
+		resumeOpts = restoreOptions(rec)
+	}
+	return m.Create(ctx, append(resumeOpts, opts...)...)
+}
+
+// storedRecord returns the restored or stored record for threadID.
+func (m *manager) storedRecord(threadID string) (sessionstore.Record, bool) {
+	m.mu.RLock()
+	rec, ok := m.restored[threadID]
+	m.mu.RUnlock()
+	if ok {
+		return rec, true
+	}
+	if m.config.store == nil {
+		return sessionstore.Record{}, false
+	}
+	rec, err := m.config.store.Load(threadID)
+	return rec, err == nil && rec.Provider == storeProvider
+}
+
+// Restore implements Restorer.
+func (m *manager) Restore() error {
+	if m.config.store == nil {
+		return fmt.Errorf("no session store configured")
+	}
+	records, err := m.config.store.List()
+	if err != nil {
+		return fmt.Errorf("restore sessions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range records {
+		if rec.Provider != storeProvider || rec.Status == sessionstore.StatusClosed {
+			continue
+		}
+		if _, live := m.sessions[rec.ID]; live {
+			continue
+		}
+		m.restored[rec.ID] = rec
+	}
+	return nil
 }
 
-// Close implements SessionManager.
+// Close implements SessionManager. The session's store record is marked
+// closed so Restore skips it.
 func (m *manager) Close(sessionID string) error {
 	m.mu.Lock()
 	s, ok := m.sessions[sessionID]
 	if !ok {
+		rec, restored := m.restored[sessionID]
+		delete(m.restored, sessionID)
 		m.mu.Unlock()
-		return fmt.Errorf("session not found: %s", sessionID)
+		if !restored {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+		rec.Status = sessionstore.StatusClosed
+		return m.config.store.Save(rec)
 	}
+	m.closing[s] = true
 	m.mu.Unlock()
 
 	return s.Close()
@@ -169,7 +312,7 @@ func (m *manager) CloseAll() error {
 	}
 	m.mu.Unlock()
 
-	var lastErr error
+	lastErr := m.closeControl()
 	for _, s := range sessions {
 		if err := s.Close(); err != nil {
 			lastErr = err
@@ -1,6 +1,10 @@
 package session
 
-import "time"
+import (
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
+)
 
 // SessionOption configures a Session.
 type SessionOption func(*sessionConfig)
@@ -21,9 +25,15 @@ type sessionConfig struct {
 	approvalMode string // "untrusted", "on-failure", "on-request", "never"
 	fullAuto     bool
 
+	// Approval requests from the app-server
+	approvalHandler ApprovalHandler
+	approvalTimeout time.Duration
+
 	// Thread management
 	threadID string
 	resume   bool
+	forkFrom string // Source thread for thread/fork
+	noThread bool   // Stop after initialize (manager control connection)
 
 	// System prompt
 	systemPrompt string
@@ -35,20 +45,29 @@ type sessionConfig struct {
 	enabledFeatures  []string
 	disabledFeatures []string
 
+	// -c key=value overrides
+	configOverrides map[string]any
+
 	// Timeouts
 	startupTimeout time.Duration
 	idleTimeout    time.Duration
 
 	// Environment
 	extraEnv map[string]string
+
+	// Persistence
+	metadata map[string]string
+	restored *sessionstore.Record
+	onUpdate func(*session)
 }
 
 // defaultConfig returns the default session configuration.
 func defaultConfig() sessionConfig {
 	return sessionConfig{
-		codexPath:      "codex",
-		startupTimeout: 30 * time.Second,
-		idleTimeout:    10 * time.Minute,
+		codexPath:       "codex",
+		startupTimeout:  30 * time.Second,
+		approvalTimeout: defaultApprovalTimeout,
+		idleTimeout:     10 * time.Minute,
 	}
 }
 
@@ -85,6 +104,21 @@ func WithFullAuto() SessionOption {
 	return func(c *sessionConfig) { c.fullAuto = true }
 }
 
+// WithApprovalHandler answers the app-server's command and patch approval
+// requests with h. Without a handler every request is denied, so sessions
+// that neither set one nor run WithFullAuto cannot escalate past their
+// sandbox. Pair it with WithApprovalMode("on-request") or "untrusted" so
+// that Codex asks.
+func WithApprovalHandler(h ApprovalHandler) SessionOption {
+	return func(c *sessionConfig) { c.approvalHandler = h }
+}
+
+// WithApprovalTimeout bounds how long the approval handler may take before
+// the request is denied. Default: 5 minutes.
+func WithApprovalTimeout(d time.Duration) SessionOption {
+	return func(c *sessionConfig) { c.approvalTimeout = d }
+}
+
 // WithThreadID sets a specific thread ID for resuming.
 func WithThreadID(id string) SessionOption {
 	return func(c *sessionConfig) { c.threadID = id }
@@ -120,6 +154,20 @@ func WithDisabledFeatures(features []string) SessionOption {
 	return func(c *sessionConfig) { c.disabledFeatures = features }
 }
 
+// WithConfigOverrides adds -c key=value overrides to the app-server.
+// Strings are quoted; slices and maps are written as JSON, which Codex
+// reads as TOML arrays and inline values.
+func WithConfigOverrides(overrides map[string]any) SessionOption {
+	return func(c *sessionConfig) {
+		if c.configOverrides == nil {
+			c.configOverrides = make(map[string]any, len(overrides))
+		}
+		for k, v := range overrides {
+			c.configOverrides[k] = v
+		}
+	}
+}
+
 // WithStartupTimeout sets the timeout for session startup operations,
 // including the thread/start or thread/resume handshake.
 func WithStartupTimeout(d time.Duration) SessionOption {
@@ -143,6 +191,19 @@ func WithEnv(env map[string]string) SessionOption {
 	}
 }
 
+// WithMetadata attaches caller-defined keys, such as a task ID, to the
+// session's store record.
+func WithMetadata(metadata map[string]string) SessionOption {
+	return func(c *sessionConfig) {
+		if c.metadata == nil {
+			c.metadata = make(map[string]string)
+		}
+		for k, v := range metadata {
+			c.metadata[k] = v
+		}
+	}
+}
+
 // ManagerOption configures a SessionManager.
 type ManagerOption func(*managerConfig)
 
@@ -159,6 +220,9 @@ type managerConfig struct {
 
 	// Cleanup interval for expired sessions
 	cleanupInterval time.Duration
+
+	// Durable record of managed sessions (nil = memory only)
+	store sessionstore.Store
 }
 
 // defaultManagerConfig returns the default manager configuration.
@@ -190,3 +254,10 @@ func WithSessionTTL(d time.Duration) ManagerOption {
 func WithCleanupInterval(d time.Duration) ManagerOption {
 	return func(c *managerConfig) { c.cleanupInterval = d }
 }
+
+// WithSessionStore persists every managed session to store on create, after
+// each turn, and when it ends. Call SessionManager.Restore to pick up the
+// stored sessions after a restart.
+func WithSessionStore(store sessionstore.Store) ManagerOption {
+	return func(c *managerConfig) { c.store = store }
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/fanout"
+)
+
+// poolTestSession is a minimal Session whose status the test controls.
+type poolTestSession struct {
+	id     string
+	status atomic.Value // SessionStatus
+}
+
+func newPoolTestSession(id string) *poolTestSession {
+	s := &poolTestSession{id: id}
+	s.status.Store(StatusActive)
+	return s
+}
+
+func (s *poolTestSession) ID() string                                   { return s.id }
+func (s *poolTestSession) ThreadID() string                             { return s.id }
+func (s *poolTestSession) Send(context.Context, UserMessage) error      { return nil }
+func (s *poolTestSession) Steer(context.Context, UserMessage) error     { return nil }
+func (s *poolTestSession) Output() <-chan OutputMessage                 { return nil }
+func (s *poolTestSession) Status() SessionStatus                        { return s.status.Load().(SessionStatus) }
+func (s *poolTestSession) Info() SessionInfo                            { return SessionInfo{ID: s.id} }
+func (s *poolTestSession) Wait() error                                  { return nil }
+func (s *poolTestSession) WaitForInit(context.Context) error            { return nil }
+func (s *poolTestSession) Interrupt(context.Context) error              { return nil }
+func (s *poolTestSession) Call(context.Context, string, any, any) error { return nil }
+func (s *poolTestSession) Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage] {
+	return fanout.New[OutputMessage](nil).Subscribe(opts...)
+}
+func (s *poolTestSession) Close() error { s.status.Store(StatusClosed); return nil }
+
+// poolTestManager creates poolTestSessions up to max.
+type poolTestManager struct {
+	SessionManager
+	mu      sync.Mutex
+	max     int
+	created []*poolTestSession
+}
+
+func (m *poolTestManager) Create(context.Context, ...SessionOption) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	live := 0
+	for _, s := range m.created {
+		if s.Status() == StatusActive {
+			live++
+		}
+	}
+	if live >= m.max {
+		return nil, fmt.Errorf("max sessions reached (%d)", m.max)
+	}
+	s := newPoolTestSession(fmt.Sprintf("thread-%d", len(m.created)+1))
+	m.created = append(m.created, s)
+	return s, nil
+}
+
+func TestPoolCreatesThroughManagerAndEvictsCrashedSessions(t *testing.T) {
+	mgr := &poolTestManager{max: 2}
+	pool := NewPool(mgr, WithPoolSize(1), WithPoolCheckInterval(10*time.Millisecond))
+	defer pool.Close()
+
+	waitForPoolIdle(t, pool, 1)
+	first, err := pool.Acquire(context.Background())
+	if err != nil || first.ID() != "thread-1" {
+		t.Fatalf("Acquire = %v, %v; want pooled thread-1", first, err)
+	}
+	waitForPoolIdle(t, pool, 1)
+
+	// Crash the idle session; the pool should evict and replace it.
+	mgr.mu.Lock()
+	mgr.created[1].status.Store(StatusError)
+	mgr.mu.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Stats().Evicted != 1 || pool.Stats().Idle != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, want crashed session replaced", pool.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitForPoolIdle(t *testing.T, pool *Pool, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Stats().Idle != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool stats = %+v, want %d idle", pool.Stats(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,6 +17,7 @@ import (
 	"time"
 
 	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/fanout"
 )
 
 // Session manages a long-running Codex app-server process with JSON-RPC 2.0 I/O.
@@ -38,6 +41,11 @@ type Session interface {
 	// The channel is closed when the session ends.
 	Output() <-chan OutputMessage
 
+	// Subscribe returns an independent stream of output messages with its
+	// own buffer and slow-consumer policy. Late subscribers first receive
+	// the current turn's messages. Subscriptions close when the session ends.
+	Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage]
+
 	// Close terminates the session and releases resources.
 	Close() error
 
@@ -53,6 +61,15 @@ type Session interface {
 	// WaitForInit blocks until the thread/start handshake completes and
 	// the thread ID is available.
 	WaitForInit(ctx context.Context) error
+
+	// Interrupt stops the active turn via turn/interrupt. Returns an error
+	// if there is no active turn.
+	Interrupt(ctx context.Context) error
+
+	// Call sends any JSON-RPC method to the app-server and decodes the
+	// result into result (which may be nil). Methods the app-server does
+	// not implement fail with ErrUnknownMethod.
+	Call(ctx context.Context, method string, params, result any) error
 }
 
 // session implements Session.
@@ -66,6 +83,10 @@ type session struct {
 	stdout io.ReadCloser
 	cancel context.CancelFunc
 
+	// Serializes writes to stdin: client requests and answers to server
+	// requests are written from different goroutines.
+	writeMu sync.Mutex
+
 	// JSON-RPC request ID counter
 	nextID atomic.Int64
 
@@ -74,8 +95,17 @@ type session struct {
 	pending   map[int64]chan *JSONRPCResponse
 	pendingMu sync.Mutex
 
-	// Output handling
-	outputCh chan OutputMessage
+	// Output handling. Approval decisions are published from their own
+	// goroutines, so sends and the final close go through outMu.
+	outputCh  chan OutputMessage
+	broadcast *fanout.Broadcaster[OutputMessage]
+	outMu     sync.Mutex
+	outClosed bool
+
+	// Changes of started fileChange items, keyed by item ID, so v2 patch
+	// approvals can carry their diff.
+	fileChanges   map[string][]FileChange
+	fileChangesMu sync.Mutex
 
 	// State
 	status       atomic.Value // SessionStatus
@@ -91,7 +121,8 @@ type session struct {
 	initDone chan struct{} // Closed when thread handshake completes
 	done     chan struct{} // Closed when readOutput exits
 	closeErr error
-	closeMu  sync.Mutex
+	errMu    sync.Mutex // Guards closeErr
+	closeMu  sync.Mutex // Serializes Close
 }
 
 // newSession creates a new session with the given configuration.
@@ -102,12 +133,14 @@ func newSession(ctx context.Context, opts ...SessionOption) (*session, error) {
 	}
 
 	s := &session{
-		config:    cfg,
-		pending:   make(map[int64]chan *JSONRPCResponse),
-		outputCh:  make(chan OutputMessage, 100),
-		initDone:  make(chan struct{}),
-		done:      make(chan struct{}),
-		createdAt: time.Now(),
+		config:      cfg,
+		pending:     make(map[int64]chan *JSONRPCResponse),
+		outputCh:    make(chan OutputMessage, 100),
+		broadcast:   fanout.New(isTurnEnd),
+		fileChanges: make(map[string][]FileChange),
+		initDone:    make(chan struct{}),
+		done:        make(chan struct{}),
+		createdAt:   time.Now(),
 	}
 	s.status.Store(StatusCreating)
 	s.lastActivity.Store(time.Now())
@@ -193,14 +226,23 @@ func (s *session) initializeHandshake(ctx context.Context) error {
 		return fmt.Errorf("initialize error: %s", initResp.Error.Message)
 	}
 
-	// Step 2: thread/start or thread/resume with configuration.
+	if s.config.noThread {
+		close(s.initDone)
+		return nil
+	}
+
+	// Step 2: thread/start, thread/resume or thread/fork with configuration.
 	var method string
 	var params any
 
-	if s.config.resume && s.config.threadID != "" {
+	switch {
+	case s.config.resume && s.config.threadID != "":
 		method = MethodThreadResume
 		params = ThreadResumeParams{ThreadID: s.config.threadID}
-	} else {
+	case s.config.forkFrom != "":
+		method = MethodThreadFork
+		params = s.buildThreadForkParams()
+	default:
 		method = MethodThreadStart
 		params = s.buildThreadStartParams()
 	}
@@ -210,7 +252,7 @@ func (s *session) initializeHandshake(ctx context.Context) error {
 		return fmt.Errorf("send %s: %w", method, err)
 	}
 	if resp.Error != nil {
-		return fmt.Errorf("%s error: %s", method, resp.Error.Message)
+		return rpcError(method, resp.Error)
 	}
 
 	var result ThreadStartResult
@@ -277,14 +319,34 @@ func (s *session) buildArgs() []string {
 			`model_reasoning_effort="`+s.config.reasoningEffort+`"`)
 	}
 
-	if s.config.reasoningEffort != "" {
-		args = append(args, codexcontract.FlagConfig,
-			`model_reasoning_effort="`+s.config.reasoningEffort+`"`)
+	keys := make([]string, 0, len(s.config.configOverrides))
+	for key := range s.config.configOverrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, codexcontract.FlagConfig, key+"="+formatConfigValue(s.config.configOverrides[key]))
 	}
 
 	return args
 }
 
+// formatConfigValue renders v as a -c override value.
+func formatConfigValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strconv.Quote(t)
+	case bool, int, int64, float64:
+		return fmt.Sprint(t)
+	default:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return strconv.Quote(fmt.Sprint(t))
+		}
+		return string(data)
+	}
+}
+
 // setupEnv configures environment variables for the process.
 func (s *session) setupEnv() {
 	if len(s.config.extraEnv) == 0 && s.config.workdir == "" {
@@ -349,8 +411,7 @@ func (s *session) sendRequest(ctx context.Context, method string, params any) (*
 	// Write the request.
 	writeDone := make(chan error, 1)
 	go func() {
-		_, writeErr := s.stdin.Write(data)
-		writeDone <- writeErr
+		writeDone <- s.writeLine(data)
 	}()
 
 	select {
@@ -376,11 +437,20 @@ func (s *session) sendRequest(ctx context.Context, method string, params any) (*
 	}
 }
 
-// readOutput reads lines from stdout, classifies them as responses or
-// notifications, and dispatches accordingly.
+// writeLine writes one newline-terminated JSON-RPC message to stdin.
+func (s *session) writeLine(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.stdin.Write(data)
+	return err
+}
+
+// readOutput reads lines from stdout, classifies them as responses, server
+// requests or notifications, and dispatches accordingly.
 func (s *session) readOutput() {
-	defer close(s.outputCh)
+	defer s.closeOutput()
 	defer close(s.done)
+	defer s.broadcast.Close()
 
 	scanner := bufio.NewScanner(s.stdout)
 	const maxScanTokenSize = 10 * 1024 * 1024 // 10MB
@@ -396,7 +466,14 @@ func (s *session) readOutput() {
 		lineCopy := make([]byte, len(line))
 		copy(lineCopy, line)
 
-		// Try to classify as a response first.
+		// Server requests (approvals) are answered off the read loop so a
+		// slow handler does not stall notifications.
+		if req, isReq := parseServerRequest(lineCopy); isReq {
+			go s.answerServerRequest(req)
+			continue
+		}
+
+		// Try to classify as a response next.
 		if resp, isResp := parseJSONRPCLine(lineCopy); isResp {
 			s.deliverResponse(resp)
 			continue
@@ -410,17 +487,8 @@ func (s *session) readOutput() {
 		}
 
 		s.updateFromMessage(msg)
-
-		// Send to output channel (non-blocking with drop-oldest on full).
-		select {
-		case s.outputCh <- *msg:
-		default:
-			select {
-			case <-s.outputCh:
-				s.outputCh <- *msg
-			default:
-			}
-		}
+		s.rememberFileChanges(msg)
+		s.publish(*msg)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -438,9 +506,53 @@ func (s *session) readOutput() {
 	s.status.Store(StatusClosed)
 }
 
-// deliverResponse routes a JSON-RPC response to its pending waiter.
+// publish sends msg to subscribers and the output channel (non-blocking
+// with drop-oldest on full). Messages published after the output closes
+// are dropped.
+func (s *session) publish(msg OutputMessage) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.outClosed {
+		return
+	}
+
+	s.broadcast.Publish(msg)
+	select {
+	case s.outputCh <- msg:
+	default:
+		select {
+		case <-s.outputCh:
+		default:
+		}
+		select {
+		case s.outputCh <- msg:
+		default:
+		}
+	}
+}
+
+// closeOutput closes the output channel and subscriptions.
+func (s *session) closeOutput() {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.outClosed = true
+	close(s.outputCh)
+	s.broadcast.Close()
+}
+
+// EventRPCError is the type of the output messages this package emits for
+// JSON-RPC error responses that carry no ID and so answer no known request.
+// The app-server never sends it.
+const EventRPCError = "rpc.error"
+
+// deliverResponse routes a JSON-RPC response to its pending waiter. An
+// error response without an ID cannot be matched to a request and is
+// published as an EventRPCError message instead.
 func (s *session) deliverResponse(resp *JSONRPCResponse) {
 	if resp.ID == nil {
+		if resp.Error != nil {
+			s.publish(OutputMessage{Type: EventRPCError, Error: resp.Error.Error()})
+		}
 		return
 	}
 
@@ -483,7 +595,13 @@ func (s *session) updateFromMessage(msg *OutputMessage) {
 
 	if msg.IsTurnComplete() || msg.IsTurnFailed() {
 		s.activeTurnID.Store("")
+		s.fileChangesMu.Lock()
+		clear(s.fileChanges)
+		s.fileChangesMu.Unlock()
 		s.turnCount.Add(1)
+		if s.config.onUpdate != nil {
+			s.config.onUpdate(s)
+		}
 	}
 }
 
@@ -545,8 +663,7 @@ func (s *session) Send(ctx context.Context, msg UserMessage) error {
 
 	writeDone := make(chan error, 1)
 	go func() {
-		_, writeErr := s.stdin.Write(data)
-		writeDone <- writeErr
+		writeDone <- s.writeLine(data)
 	}()
 
 	select {
@@ -600,8 +717,7 @@ func (s *session) Steer(ctx context.Context, msg UserMessage) error {
 
 	writeDone := make(chan error, 1)
 	go func() {
-		_, writeErr := s.stdin.Write(data)
-		writeDone <- writeErr
+		writeDone <- s.writeLine(data)
 	}()
 
 	select {
@@ -622,6 +738,16 @@ func (s *session) Output() <-chan OutputMessage {
 	return s.outputCh
 }
 
+// Subscribe implements Session.
+func (s *session) Subscribe(opts ...fanout.Option) *fanout.Subscription[OutputMessage] {
+	return s.broadcast.Subscribe(opts...)
+}
+
+// isTurnEnd reports whether msg ends a turn, resetting the replay buffer.
+func isTurnEnd(msg OutputMessage) bool {
+	return msg.IsTurnComplete() || msg.IsTurnFailed()
+}
+
 // Close implements Session.
 func (s *session) Close() error {
 	s.closeMu.Lock()
@@ -629,7 +755,7 @@ func (s *session) Close() error {
 
 	status := s.Status()
 	if status == StatusClosed || status == StatusClosing {
-		return s.closeErr
+		return s.getCloseError()
 	}
 
 	s.status.Store(StatusClosing)
@@ -645,7 +771,7 @@ func (s *session) Close() error {
 	select {
 	case <-s.done:
 		s.status.Store(StatusClosed)
-		return s.closeErr
+		return s.getCloseError()
 	case <-time.After(3 * time.Second):
 	}
 
@@ -660,7 +786,7 @@ func (s *session) Close() error {
 	select {
 	case <-s.done:
 		s.status.Store(StatusClosed)
-		return s.closeErr
+		return s.getCloseError()
 	case <-time.After(2 * time.Second):
 	}
 
@@ -681,7 +807,7 @@ func (s *session) Close() error {
 	}
 
 	s.status.Store(StatusClosed)
-	return s.closeErr
+	return s.getCloseError()
 }
 
 // sendShutdown sends a shutdown JSON-RPC request. Best effort - errors are ignored
@@ -704,7 +830,7 @@ func (s *session) sendShutdown() {
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
-		_, _ = s.stdin.Write(data)
+		_ = s.writeLine(data)
 	}()
 
 	select {
@@ -736,14 +862,23 @@ func (s *session) Info() SessionInfo {
 // Wait implements Session.
 func (s *session) Wait() error {
 	<-s.done
-	return s.closeErr
+	return s.getCloseError()
 }
 
-// setCloseError sets the close error if not already set.
+// setCloseError sets the close error if not already set. It uses errMu
+// rather than closeMu: Close holds closeMu while waiting for readOutput,
+// which also records errors.
 func (s *session) setCloseError(err error) {
-	s.closeMu.Lock()
-	defer s.closeMu.Unlock()
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
 	if s.closeErr == nil {
 		s.closeErr = err
 	}
 }
+
+// getCloseError returns the recorded close error.
+func (s *session) getCloseError() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.closeErr
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/randalmurphal/llmkit/v2/codexcontract"
@@ -23,6 +24,29 @@ func TestSessionBuildArgsIncludesReasoningEffort(t *testing.T) {
 	assertContains(t, args, `model_reasoning_effort="xhigh"`)
 }
 
+func TestSessionBuildArgsIncludesConfigOverrides(t *testing.T) {
+	s := &session{
+		config: sessionConfig{
+			reasoningEffort: "high",
+			configOverrides: map[string]any{
+				"mcp_servers.docs.command": "docs-mcp",
+				"mcp_servers.docs.args":    []string{"--stdio"},
+			},
+		},
+	}
+
+	got := s.buildArgs()
+	want := []string{
+		codexcontract.CommandAppServer,
+		codexcontract.FlagConfig, `model_reasoning_effort="high"`,
+		codexcontract.FlagConfig, `mcp_servers.docs.args=["--stdio"]`,
+		codexcontract.FlagConfig, `mcp_servers.docs.command="docs-mcp"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("buildArgs() = %v, want %v", got, want)
+	}
+}
+
 func assertContains(t *testing.T, args []string, want string) {
 	t.Helper()
 	for _, arg := range args {
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
+)
+
+// storeProvider is the Record.Provider value written by this package.
+const storeProvider = "codex"
+
+// storedOptions is the serialized form of sessionConfig kept in a
+// sessionstore.Record. Environment variables and config overrides are not
+// stored because they often carry credentials, and the approval handler is a
+// Go callback; supply them again with WithDefaultSessionOptions or as Resume
+// options.
+type storedOptions struct {
+	CodexPath        string   `json:"codex_path,omitempty"`
+	Model            string   `json:"model,omitempty"`
+	Workdir          string   `json:"workdir,omitempty"`
+	SandboxMode      string   `json:"sandbox_mode,omitempty"`
+	ApprovalMode     string   `json:"approval_mode,omitempty"`
+	FullAuto         bool     `json:"full_auto,omitempty"`
+	ApprovalTimeout  string   `json:"approval_timeout,omitempty"`
+	SystemPrompt     string   `json:"system_prompt,omitempty"`
+	ReasoningEffort  string   `json:"reasoning_effort,omitempty"`
+	EnabledFeatures  []string `json:"enabled_features,omitempty"`
+	DisabledFeatures []string `json:"disabled_features,omitempty"`
+	StartupTimeout   string   `json:"startup_timeout,omitempty"`
+	IdleTimeout      string   `json:"idle_timeout,omitempty"`
+}
+
+func (c sessionConfig) storedOptions() storedOptions {
+	return storedOptions{
+		CodexPath:        c.codexPath,
+		Model:            c.model,
+		Workdir:          c.workdir,
+		SandboxMode:      c.sandboxMode,
+		ApprovalMode:     c.approvalMode,
+		FullAuto:         c.fullAuto,
+		ApprovalTimeout:  formatDuration(c.approvalTimeout),
+		SystemPrompt:     c.systemPrompt,
+		ReasoningEffort:  c.reasoningEffort,
+		EnabledFeatures:  c.enabledFeatures,
+		DisabledFeatures: c.disabledFeatures,
+		StartupTimeout:   formatDuration(c.startupTimeout),
+		IdleTimeout:      formatDuration(c.idleTimeout),
+	}
+}
+
+// option returns a SessionOption that applies the stored values.
+func (o storedOptions) option() SessionOption {
+	return func(c *sessionConfig) {
+		if o.CodexPath != "" {
+			c.codexPath = o.CodexPath
+		}
+		c.model = o.Model
+		c.workdir = o.Workdir
+		c.sandboxMode = o.SandboxMode
+		c.approvalMode = o.ApprovalMode
+		c.fullAuto = o.FullAuto
+		c.systemPrompt = o.SystemPrompt
+		c.reasoningEffort = o.ReasoningEffort
+		c.enabledFeatures = o.EnabledFeatures
+		c.disabledFeatures = o.DisabledFeatures
+		if d, err := time.ParseDuration(o.StartupTimeout); err == nil {
+			c.startupTimeout = d
+		}
+		if d, err := time.ParseDuration(o.IdleTimeout); err == nil {
+			c.idleTimeout = d
+		}
+		if d, err := time.ParseDuration(o.ApprovalTimeout); err == nil {
+			c.approvalTimeout = d
+		}
+	}
+}
+
+// restoreOptions returns the options that resume rec: its stored options,
+// its usage baseline, and WithResume.
+func restoreOptions(rec sessionstore.Record) []SessionOption {
+	var opts []SessionOption
+	var stored storedOptions
+	if len(rec.Options) > 0 && json.Unmarshal(rec.Options, &stored) == nil {
+		opts = append(opts, stored.option())
+	}
+	return append(opts, withRestoredRecord(rec), WithResume(rec.ID))
+}
+
+// withRestoredRecord carries a stored record into the resumed session so
+// usage keeps accumulating across restarts.
+func withRestoredRecord(rec sessionstore.Record) SessionOption {
+	return func(c *sessionConfig) {
+		c.restored = &rec
+		if c.metadata == nil {
+			c.metadata = rec.Metadata
+		}
+	}
+}
+
+// withUpdateHook registers fn to run after each completed or failed turn.
+func withUpdateHook(fn func(*session)) SessionOption {
+	return func(c *sessionConfig) { c.onUpdate = fn }
+}
+
+// record builds the store record for s.
+func (s *session) record(status string) sessionstore.Record {
+	options, _ := json.Marshal(s.config.storedOptions())
+	rec := sessionstore.Record{
+		ID:           s.ID(),
+		Provider:     storeProvider,
+		Workdir:      s.config.workdir,
+		Options:      options,
+		Status:       status,
+		Usage:        sessionstore.Usage{Turns: int(s.turnCount.Load())},
+		Metadata:     s.config.metadata,
+		CreatedAt:    s.createdAt,
+		LastActivity: s.lastActivity.Load().(time.Time),
+	}
+	if base := s.config.restored; base != nil {
+		if !base.CreatedAt.IsZero() {
+			rec.CreatedAt = base.CreatedAt
+		}
+		rec.Usage.Turns += base.Usage.Turns
+		rec.Usage.InputTokens += base.Usage.InputTokens
+		rec.Usage.OutputTokens += base.Usage.OutputTokens
+		rec.Usage.CostUSD += base.Usage.CostUSD
+	}
+	return rec
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/sessionstore"
+)
+
+func TestSessionRecordRoundTripsOptions(t *testing.T) {
+	cfg := defaultConfig()
+	WithModel("gpt-5-codex")(&cfg)
+	WithSandboxMode("workspace-write")(&cfg)
+	WithEnabledFeatures([]string{"codex_hooks"})(&cfg)
+	WithEnv(map[string]string{"OPENAI_API_KEY": "secret"})(&cfg)
+	WithConfigOverrides(map[string]any{"mcp_servers.x.env.TOKEN": "override-secret"})(&cfg)
+	WithApprovalHandler(func(context.Context, ApprovalRequest) (ApprovalDecision, error) {
+		return "", nil
+	})(&cfg)
+	WithApprovalTimeout(90 * time.Second)(&cfg)
+	WithMetadata(map[string]string{"task": "T-9"})(&cfg)
+	withRestoredRecord(sessionstore.Record{Usage: sessionstore.Usage{Turns: 2}})(&cfg)
+
+	s := &session{id: "thread-1", config: cfg, createdAt: time.Now()}
+	s.lastActivity.Store(time.Now())
+	s.turnCount.Add(1)
+
+	rec := s.record(sessionstore.StatusActive)
+	if rec.Provider != "codex" || rec.Usage.Turns != 3 || rec.Metadata["task"] != "T-9" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if string(rec.Options) == "" || strings.Contains(string(rec.Options), "secret") {
+		t.Fatalf("stored options = %s, want options without env or overrides", rec.Options)
+	}
+
+	restored := defaultConfig()
+	for _, opt := range restoreOptions(rec) {
+		opt(&restored)
+	}
+	if restored.model != "gpt-5-codex" || restored.sandboxMode != "workspace-write" || len(restored.enabledFeatures) != 1 ||
+		!restored.resume || restored.threadID != "thread-1" || restored.metadata["task"] != "T-9" {
+		t.Fatalf("restored config = %+v", restored)
+	}
+	if restored.approvalTimeout != 90*time.Second {
+		t.Fatalf("restored approvalTimeout = %v, want 90s", restored.approvalTimeout)
+	}
+	if restored.approvalHandler != nil || restored.configOverrides != nil || restored.extraEnv != nil {
+		t.Fatal("restored config should not carry the approval handler, config overrides or env")
+	}
+}
+
+func TestManagerRestoreAndCloseRestoredThread(t *testing.T) {
+	store := sessionstore.NewMemoryStore()
+	_ = store.Save(sessionstore.Record{ID: "thread-1", Provider: "codex", Status: sessionstore.StatusSuspended})
+	_ = store.Save(sessionstore.Record{ID: "thread-2", Provider: "codex", Status: sessionstore.StatusClosed})
+
+	mgr := NewManager(WithSessionStore(store), WithSessionTTL(0)).(*manager)
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if _, ok := mgr.restored["thread-1"]; !ok || len(mgr.restored) != 1 {
+		t.Fatalf("restored = %v, want only thread-1", mgr.restored)
+	}
+
+	if err := mgr.Close("thread-1"); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if rec, err := store.Load("thread-1"); err != nil || rec.Status != sessionstore.StatusClosed {
+		t.Fatalf("record after Close = %+v, %v", rec, err)
+	}
+	if err := NewManager().(Restorer).Restore(); err == nil {
+		t.Fatal("Restore without a store should fail")
+	}
+}
@@ -0,0 +1,352 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnknownMethod is returned when the app-server does not implement a
+// JSON-RPC method, usually because the installed codex is older than the
+// method.
+var ErrUnknownMethod = errors.New("method not supported by this codex app-server")
+
+// JSON-RPC error code for a request the server could not decode. Codex
+// reports unknown methods this way ("unknown variant ...").
+const jsonRPCInvalidRequest = -32600
+
+// Thread is a persisted Codex thread as returned by thread/list,
+// thread/read, thread/start and thread/fork.
+type Thread struct {
+	ID            string          `json:"id"`
+	Preview       string          `json:"preview,omitempty"` // First user message
+	ModelProvider string          `json:"modelProvider,omitempty"`
+	CreatedAt     int64           `json:"createdAt,omitempty"` // Unix seconds
+	UpdatedAt     int64           `json:"updatedAt,omitempty"` // Unix seconds
+	Path          string          `json:"path,omitempty"`      // Rollout file
+	CWD           string          `json:"cwd,omitempty"`
+	CLIVersion    string          `json:"cliVersion,omitempty"`
+	Source        json.RawMessage `json:"source,omitempty"` // cli, vscode, exec, appServer or a sub-agent object
+	Turns         []ThreadTurn    `json:"turns,omitempty"`  // Only with ThreadReadParams.IncludeTurns
+}
+
+// ThreadTurn is one turn of a thread read with its turns.
+type ThreadTurn struct {
+	ID     string            `json:"id"`
+	Status string            `json:"status,omitempty"` // completed, interrupted, failed or inProgress
+	Items  []json.RawMessage `json:"items,omitempty"`
+	Error  json.RawMessage   `json:"error,omitempty"`
+}
+
+// ThreadListParams are the parameters for thread/list.
+type ThreadListParams struct {
+	Cursor         string   `json:"cursor,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+	SortKey        string   `json:"sortKey,omitempty"` // created_at or updated_at
+	ModelProviders []string `json:"modelProviders,omitempty"`
+	Archived       bool     `json:"archived,omitempty"` // List archived threads instead of active ones
+}
+
+// ThreadListResult is one page of thread/list. NextCursor is empty on the
+// last page.
+type ThreadListResult struct {
+	Data       []Thread `json:"data"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// ThreadReadParams are the parameters for thread/read.
+type ThreadReadParams struct {
+	ThreadID     string `json:"threadId"`
+	IncludeTurns bool   `json:"includeTurns,omitempty"`
+}
+
+// ThreadReadResult is the result of thread/read.
+type ThreadReadResult struct {
+	Thread Thread `json:"thread"`
+}
+
+// ThreadForkParams are the parameters for thread/fork. The new thread copies
+// the history of ThreadID; the other fields override its configuration.
+type ThreadForkParams struct {
+	ThreadID         string `json:"threadId"`
+	Model            string `json:"model,omitempty"`
+	CWD              string `json:"cwd,omitempty"`
+	ApprovalPolicy   string `json:"approvalPolicy,omitempty"`
+	Sandbox          string `json:"sandbox,omitempty"`
+	BaseInstructions string `json:"baseInstructions,omitempty"`
+}
+
+// ThreadArchiveParams are the parameters for thread/archive.
+type ThreadArchiveParams struct {
+	ThreadID string `json:"threadId"`
+}
+
+// TurnInterruptParams are the parameters for turn/interrupt.
+type TurnInterruptParams struct {
+	ThreadID string `json:"threadId"`
+	TurnID   string `json:"turnId"`
+}
+
+// ModelListParams are the parameters for model/list.
+type ModelListParams struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// ModelListResult is one page of model/list.
+type ModelListResult struct {
+	Data       []Model `json:"data"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+// Model is a model the signed-in account can use.
+type Model struct {
+	ID                        string                  `json:"id"`
+	Model                     string                  `json:"model"` // Value for WithModel
+	DisplayName               string                  `json:"displayName,omitempty"`
+	Description               string                  `json:"description,omitempty"`
+	SupportedReasoningEfforts []ReasoningEffortOption `json:"supportedReasoningEfforts,omitempty"`
+	DefaultReasoningEffort    string                  `json:"defaultReasoningEffort,omitempty"`
+	IsDefault                 bool                    `json:"isDefault,omitempty"`
+}
+
+// ReasoningEffortOption is a reasoning effort a model supports.
+type ReasoningEffortOption struct {
+	ReasoningEffort string `json:"reasoningEffort"`
+	Description     string `json:"description,omitempty"`
+}
+
+// AccountReadParams are the parameters for account/read.
+type AccountReadParams struct {
+	RefreshToken bool `json:"refreshToken,omitempty"`
+}
+
+// AccountReadResult is the result of account/read. Account is nil when no
+// one is signed in.
+type AccountReadResult struct {
+	Account            *Account `json:"account"`
+	RequiresOpenAIAuth bool     `json:"requiresOpenaiAuth"`
+}
+
+// Account is the credential the app-server runs with.
+type Account struct {
+	Type     string `json:"type"` // apiKey or chatgpt
+	Email    string `json:"email,omitempty"`
+	PlanType string `json:"planType,omitempty"` // ChatGPT plan, e.g. plus or pro
+}
+
+// RateLimitsResult is the result of account/rateLimits/read.
+type RateLimitsResult struct {
+	RateLimits RateLimitSnapshot `json:"rateLimits"`
+}
+
+// RateLimitSnapshot is the account's current usage against its limits.
+type RateLimitSnapshot struct {
+	Primary   *RateLimitWindow `json:"primary,omitempty"`   // Short window, typically 5 hours
+	Secondary *RateLimitWindow `json:"secondary,omitempty"` // Long window, typically weekly
+}
+
+// RateLimitWindow is usage within one rate-limit window.
+type RateLimitWindow struct {
+	UsedPercent        float64 `json:"usedPercent"`
+	WindowDurationMins int64   `json:"windowDurationMins,omitempty"`
+	ResetsAt           int64   `json:"resetsAt,omitempty"` // Unix seconds
+}
+
+// Call implements Session.
+func (s *session) Call(ctx context.Context, method string, params, result any) error {
+	if status := s.Status(); status == StatusClosing || status == StatusClosed {
+		return fmt.Errorf("%s: session not active: %s", method, status)
+	}
+	resp, err := s.sendRequest(ctx, method, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return rpcError(method, resp.Error)
+	}
+	s.lastActivity.Store(time.Now())
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("parse %s result: %w", method, err)
+	}
+	return nil
+}
+
+// Interrupt implements Session.
+func (s *session) Interrupt(ctx context.Context) error {
+	turnID := s.activeTurnID.Load().(string)
+	if turnID == "" {
+		return fmt.Errorf("no active turn to interrupt")
+	}
+	return s.Call(ctx, MethodTurnInterrupt, TurnInterruptParams{ThreadID: s.id, TurnID: turnID}, nil)
+}
+
+// rpcError wraps a JSON-RPC error response, recognizing unknown methods.
+func rpcError(method string, e *JSONRPCError) error {
+	if e.Code == jsonRPCMethodNotFound ||
+		(e.Code == jsonRPCInvalidRequest && strings.Contains(e.Message, "unknown variant")) {
+		return fmt.Errorf("%s: %w (%s)", method, ErrUnknownMethod, e.Message)
+	}
+	return fmt.Errorf("%s: %w", method, e)
+}
+
+// WithFork starts the session on a new thread forked from threadID instead
+// of a fresh one. The session's model, workdir, approval and sandbox
+// options override the source thread's.
+func WithFork(threadID string) SessionOption {
+	return func(c *sessionConfig) { c.forkFrom = threadID }
+}
+
+// withoutThread stops the handshake after initialize, for connections that
+// only query the app-server.
+func withoutThread() SessionOption {
+	return func(c *sessionConfig) { c.noThread = true }
+}
+
+// buildThreadForkParams constructs the thread/fork params from session config.
+func (s *session) buildThreadForkParams() ThreadForkParams {
+	start := s.buildThreadStartParams()
+	return ThreadForkParams{
+		ThreadID:         s.config.forkFrom,
+		Model:            start.Model,
+		CWD:              start.CWD,
+		ApprovalPolicy:   start.ApprovalPolicy,
+		Sandbox:          start.Sandbox,
+		BaseInstructions: start.BaseInstructions,
+	}
+}
+
+// control returns the manager's thread-less app-server connection used for
+// queries that need no session, starting it with the default session
+// options on first use or after it exits.
+func (m *manager) control(ctx context.Context) (*session, error) {
+	m.ctlMu.Lock()
+	defer m.ctlMu.Unlock()
+
+	m.mu.RLock()
+	closed := m.closed
+	m.mu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("manager is closed")
+	}
+
+	if m.ctl != nil && m.ctl.Status() == StatusActive {
+		return m.ctl, nil
+	}
+	opts := append(append([]SessionOption{}, m.config.defaultOpts...), withoutThread())
+	s, err := newSession(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("start control connection: %w", err)
+	}
+	m.ctl = s
+	return s, nil
+}
+
+// call sends method on the control connection.
+func (m *manager) call(ctx context.Context, method string, params, result any) error {
+	s, err := m.control(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Call(ctx, method, params, result)
+}
+
+// closeControl stops the control connection, if any.
+func (m *manager) closeControl() error {
+	m.ctlMu.Lock()
+	defer m.ctlMu.Unlock()
+	if m.ctl == nil {
+		return nil
+	}
+	err := m.ctl.Close()
+	m.ctl = nil
+	return err
+}
+
+// ListThreads implements SessionManager.
+func (m *manager) ListThreads(ctx context.Context, params ThreadListParams) (*ThreadListResult, error) {
+	var result ThreadListResult
+	if err := m.call(ctx, MethodThreadList, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReadThread implements SessionManager.
+func (m *manager) ReadThread(ctx context.Context, threadID string, includeTurns bool) (*Thread, error) {
+	var result ThreadReadResult
+	params := ThreadReadParams{ThreadID: threadID, IncludeTurns: includeTurns}
+	if err := m.call(ctx, MethodThreadRead, params, &result); err != nil {
+		return nil, err
+	}
+	return &result.Thread, nil
+}
+
+// Fork implements SessionManager.
+func (m *manager) Fork(ctx context.Context, threadID string, opts ...SessionOption) (Session, error) {
+	return m.Create(ctx, append([]SessionOption{WithFork(threadID)}, opts...)...)
+}
+
+// Archive implements SessionManager.
+func (m *manager) Archive(ctx context.Context, threadID string) error {
+	m.mu.RLock()
+	_, live := m.sessions[threadID]
+	_, restored := m.restored[threadID]
+	m.mu.RUnlock()
+	if live || restored {
+		if err := m.Close(threadID); err != nil {
+			return fmt.Errorf("close %s before archiving: %w", threadID, err)
+		}
+	}
+	return m.call(ctx, MethodThreadArchive, ThreadArchiveParams{ThreadID: threadID}, nil)
+}
+
+// Interrupt implements SessionManager.
+func (m *manager) Interrupt(ctx context.Context, sessionID string) error {
+	s, ok := m.getActive(sessionID)
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return s.Interrupt(ctx)
+}
+
+// Models implements SessionManager.
+func (m *manager) Models(ctx context.Context) ([]Model, error) {
+	var models []Model
+	params := ModelListParams{}
+	for {
+		var page ModelListResult
+		if err := m.call(ctx, MethodModelList, params, &page); err != nil {
+			return nil, err
+		}
+		models = append(models, page.Data...)
+		if page.NextCursor == "" || page.NextCursor == params.Cursor {
+			return models, nil
+		}
+		params.Cursor = page.NextCursor
+	}
+}
+
+// Account implements SessionManager.
+func (m *manager) Account(ctx context.Context) (*AccountReadResult, error) {
+	var result AccountReadResult
+	if err := m.call(ctx, MethodAccountRead, AccountReadParams{}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RateLimits implements SessionManager.
+func (m *manager) RateLimits(ctx context.Context) (*RateLimitSnapshot, error) {
+	var result RateLimitsResult
+	if err := m.call(ctx, MethodAccountRateLimits, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.RateLimits, nil
+}
@@ -0,0 +1,211 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// threadsStub answers the thread lifecycle, model and account methods with
+// canned results and logs fork and interrupt requests to $STUB_LOG.
+// thread/rollback is rejected the way codex rejects methods it does not
+// know, and bogus/nullId gets only an error response without an ID.
+const threadsStub = `#!/bin/sh
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  method=$(printf '%s' "$line" | sed -n 's/.*"method":"\([^"]*\)".*/\1/p')
+  case "$method" in
+    initialize|thread/archive)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{}}\n' "$id" ;;
+    thread/start)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"thread":{"id":"t1"}}}\n' "$id" ;;
+    thread/fork)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"thread":{"id":"t2","preview":"fix the bug"}}}\n' "$id" ;;
+    thread/list)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"data":[{"id":"t1","preview":"fix the bug","createdAt":1760000000,"cwd":"/repo","source":"cli"}],"nextCursor":"c2"}}\n' "$id" ;;
+    thread/read)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"thread":{"id":"t1","turns":[{"id":"turn1","status":"completed","items":[{"type":"userMessage"}]}]}}}\n' "$id" ;;
+    model/list)
+      case "$line" in
+        *'"cursor":"p2"'*) printf '{"jsonrpc":"2.0","id":%s,"result":{"data":[{"id":"o4-mini","model":"o4-mini"}]}}\n' "$id" ;;
+        *) printf '{"jsonrpc":"2.0","id":%s,"result":{"data":[{"id":"gpt-5","model":"gpt-5","isDefault":true,"supportedReasoningEfforts":[{"reasoningEffort":"high"}]}],"nextCursor":"p2"}}\n' "$id" ;;
+      esac ;;
+    account/read)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"account":{"type":"chatgpt","email":"dev@example.com","planType":"pro"},"requiresOpenaiAuth":true}}\n' "$id" ;;
+    account/rateLimits/read)
+      printf '{"jsonrpc":"2.0","id":%s,"result":{"rateLimits":{"primary":{"usedPercent":42.5,"windowDurationMins":300,"resetsAt":1760003600}}}}\n' "$id" ;;
+    turn/start)
+      printf '{"jsonrpc":"2.0","method":"turn/started","params":{"threadId":"t1","turn":{"id":"turn1"}}}\n' ;;
+    turn/interrupt)
+      printf '%s\n' "$line" >> "$STUB_LOG"
+      printf '{"jsonrpc":"2.0","id":%s,"result":{}}\n' "$id"
+      printf '{"jsonrpc":"2.0","method":"turn/completed","params":{"threadId":"t1","turn":{"id":"turn1","status":"interrupted"}}}\n' ;;
+    bogus/nullId)
+      printf '{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}\n' ;;
+    *)
+      printf '{"jsonrpc":"2.0","id":%s,"error":{"code":-32600,"message":"Invalid request: unknown variant %s"}}\n' "$id" "$method" ;;
+  esac
+done
+`
+
+func newThreadsManager(t *testing.T) (SessionManager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "codex")
+	if err := os.WriteFile(stub, []byte(threadsStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "stdin.log")
+	mgr := NewManager(WithDefaultSessionOptions(
+		WithCodexPath(stub),
+		WithEnv(map[string]string{"STUB_LOG": logPath}),
+	))
+	t.Cleanup(func() { _ = mgr.CloseAll() })
+	return mgr, logPath
+}
+
+func TestManagerThreadQueries(t *testing.T) {
+	mgr, _ := newThreadsManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := mgr.ListThreads(ctx, ThreadListParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListThreads returned error: %v", err)
+	}
+	if len(page.Data) != 1 || page.Data[0].Preview != "fix the bug" || page.Data[0].CWD != "/repo" || page.NextCursor != "c2" {
+		t.Fatalf("ListThreads = %+v", page)
+	}
+
+	thread, err := mgr.ReadThread(ctx, "t1", true)
+	if err != nil {
+		t.Fatalf("ReadThread returned error: %v", err)
+	}
+	if len(thread.Turns) != 1 || thread.Turns[0].Status != "completed" {
+		t.Fatalf("ReadThread = %+v", thread)
+	}
+
+	models, err := mgr.Models(ctx)
+	if err != nil {
+		t.Fatalf("Models returned error: %v", err)
+	}
+	if len(models) != 2 || !models[0].IsDefault || models[1].Model != "o4-mini" {
+		t.Fatalf("Models = %+v, want both pages", models)
+	}
+
+	account, err := mgr.Account(ctx)
+	if err != nil {
+		t.Fatalf("Account returned error: %v", err)
+	}
+	if account.Account == nil || account.Account.PlanType != "pro" || !account.RequiresOpenAIAuth {
+		t.Fatalf("Account = %+v", account)
+	}
+
+	limits, err := mgr.RateLimits(ctx)
+	if err != nil {
+		t.Fatalf("RateLimits returned error: %v", err)
+	}
+	if limits.Primary == nil || limits.Primary.UsedPercent != 42.5 || limits.Secondary != nil {
+		t.Fatalf("RateLimits = %+v", limits)
+	}
+
+	if err := mgr.Archive(ctx, "t1"); err != nil {
+		t.Fatalf("Archive returned error: %v", err)
+	}
+	if mgr.Count() != 0 {
+		t.Fatalf("Count = %d, queries must not create sessions", mgr.Count())
+	}
+}
+
+func TestSessionForkAndInterrupt(t *testing.T) {
+	mgr, logPath := newThreadsManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := mgr.Fork(ctx, "t1", WithModel("o4-mini"))
+	if err != nil {
+		t.Fatalf("Fork returned error: %v", err)
+	}
+	if s.ThreadID() != "t2" {
+		t.Fatalf("forked thread = %q, want t2", s.ThreadID())
+	}
+
+	if err := s.Interrupt(ctx); err == nil {
+		t.Fatal("Interrupt without an active turn should fail")
+	}
+	if err := s.Send(ctx, NewUserMessage("go")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	for msg := range s.Output() {
+		if msg.IsTurnStarted() {
+			break
+		}
+	}
+	if err := mgr.Interrupt(ctx, "t2"); err != nil {
+		t.Fatalf("Interrupt returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("stub log = %q, want fork and interrupt", lines)
+	}
+	for _, want := range []string{`"method":"thread/fork"`, `"threadId":"t1"`, `"model":"o4-mini"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("fork request %s lacks %s", lines[0], want)
+		}
+	}
+	for _, want := range []string{`"method":"turn/interrupt"`, `"threadId":"t2"`, `"turnId":"turn1"`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("interrupt request %s lacks %s", lines[1], want)
+		}
+	}
+}
+
+func TestSessionCallErrors(t *testing.T) {
+	mgr, _ := newThreadsManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := mgr.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	err = s.Call(ctx, "thread/rollback", ThreadReadParams{ThreadID: "t1"}, nil)
+	if !errors.Is(err, ErrUnknownMethod) || !strings.Contains(err.Error(), "thread/rollback") {
+		t.Fatalf("Call(thread/rollback) = %v, want ErrUnknownMethod naming the method", err)
+	}
+
+	// An error without an ID answers no known request: it is published,
+	// and the call keeps waiting for its own response.
+	sub := s.Subscribe()
+	defer sub.Close()
+	callCtx, callCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer callCancel()
+	if err := s.Call(callCtx, "bogus/nullId", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call(bogus/nullId) = %v, want it left waiting", err)
+	}
+	for {
+		select {
+		case msg := <-sub.C():
+			if msg.Type != EventRPCError {
+				continue
+			}
+			if !strings.Contains(msg.Error, "parse error") {
+				t.Fatalf("rpc error message = %+v", msg)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("uncorrelated error response was not published")
+		}
+	}
+}
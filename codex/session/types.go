@@ -28,6 +28,15 @@ const (
 	MethodTurnStart    = "turn/start"
 	MethodTurnSteer    = "turn/steer"
 	MethodShutdown     = "shutdown"
+
+	MethodThreadList        = "thread/list"
+	MethodThreadRead        = "thread/read"
+	MethodThreadFork        = "thread/fork"
+	MethodThreadArchive     = "thread/archive"
+	MethodTurnInterrupt     = "turn/interrupt"
+	MethodModelList         = "model/list"
+	MethodAccountRead       = "account/read"
+	MethodAccountRateLimits = "account/rateLimits/read"
 )
 
 // JSONRPCVersion is the JSON-RPC protocol version.
@@ -96,15 +105,13 @@ type ThreadResumeParams struct {
 	ThreadID string `json:"threadId"`
 }
 
-// ThreadStartResult is the result of a thread/start or thread/resume call.
-// The actual response nests the thread ID inside a "thread" object:
-// {"thread":{"id":"..."},"model":"...","cwd":"...",...}
+// ThreadStartResult is the result of a thread/start, thread/resume or
+// thread/fork call. The actual response nests the thread inside a "thread"
+// object: {"thread":{"id":"..."},"model":"...","cwd":"...",...}
 type ThreadStartResult struct {
-	Thread struct {
-		ID string `json:"id"`
-	} `json:"thread"`
-	Model string `json:"model,omitempty"`
-	CWD   string `json:"cwd,omitempty"`
+	Thread Thread `json:"thread"`
+	Model  string `json:"model,omitempty"`
+	CWD    string `json:"cwd,omitempty"`
 }
 
 // InputItem represents a content item in a turn/start or turn/steer request.
@@ -167,6 +174,10 @@ type OutputMessage struct {
 	// Error holds error information when a turn fails.
 	Error string `json:"error,omitempty"`
 
+	// Approval is set on approval.decision messages, which this package
+	// emits after answering an approval request.
+	Approval *ApprovalEvent `json:"-"`
+
 	// Raw holds the original JSON for advanced parsing.
 	Raw []byte `json:"-"`
 }
@@ -211,6 +222,12 @@ func (m *OutputMessage) IsError() bool {
 	return m.Type == codexcontract.EventError || m.Type == codexcontract.EventTurnFailed
 }
 
+// IsApprovalDecision returns true if this message reports an approval
+// decision made by the session's ApprovalHandler.
+func (m *OutputMessage) IsApprovalDecision() bool {
+	return m.Type == EventApprovalDecision && m.Approval != nil
+}
+
 // IsAgentMessage returns true if this is an agent_message item.
 // Accepts both snake_case ("agent_message" from codex exec) and
 // camelCase ("agentMessage" from app-server).
@@ -379,9 +396,30 @@ func extractNestedFields(params json.RawMessage, msg *OutputMessage) {
 	}
 }
 
+// serverRequest is a JSON-RPC request the app-server sends to the client,
+// such as an approval request. Its ID is echoed verbatim in the answer.
+type serverRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// parseServerRequest reports whether a line is a request from the server:
+// it has both an "id" and a "method".
+func parseServerRequest(data []byte) (*serverRequest, bool) {
+	var req serverRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, false
+	}
+	if req.Method == "" || len(req.ID) == 0 || string(req.ID) == "null" {
+		return nil, false
+	}
+	return &req, true
+}
+
 // parseJSONRPCLine classifies a line from stdout as either a JSON-RPC response
-// (has an "id" field) or a notification (has a "method" field). Returns the
-// response if it's a response, or nil if it's a notification.
+// (has an "id" field and no "method") or a notification (has a "method" field).
+// Returns the response if it's a response, or nil otherwise.
 func parseJSONRPCLine(data []byte) (*JSONRPCResponse, bool) {
 	// Quick check: does it look like a response? Responses have "id" and "result"/"error".
 	// Notifications have "method" but no "id".
@@ -396,8 +434,11 @@ func parseJSONRPCLine(data []byte) (*JSONRPCResponse, bool) {
 		return nil, false
 	}
 
-	// If it has an ID, it's a response.
-	if probe.ID != nil {
+	// If it has an ID and no method, it's a response. An ID with a method
+	// is a server request (see parseServerRequest). An error without an ID
+	// answers a request the server could not even decode; it is still a
+	// response, published as an EventRPCError message.
+	if (probe.ID != nil || probe.Error != nil) && probe.Method == "" {
 		resp := &JSONRPCResponse{
 			JSONRPC: JSONRPCVersion,
 			ID:      probe.ID,
@@ -485,3 +485,18 @@ func TestSessionStatusConstants(t *testing.T) {
 		t.Errorf("StatusError = %q, want 'error'", StatusError)
 	}
 }
+
+func TestParseJSONRPCLine_ServerRequest(t *testing.T) {
+	input := []byte(`{"jsonrpc":"2.0","id":7,"method":"item/commandExecution/requestApproval","params":{"command":"ls"}}`)
+
+	if _, isResp := parseJSONRPCLine(input); isResp {
+		t.Error("server request should not be classified as a response")
+	}
+	req, isReq := parseServerRequest(input)
+	if !isReq || string(req.ID) != "7" || req.Method != MethodCommandApproval {
+		t.Fatalf("parseServerRequest = %+v, %v", req, isReq)
+	}
+	if _, isReq := parseServerRequest([]byte(`{"jsonrpc":"2.0","method":"turn/started","params":{}}`)); isReq {
+		t.Error("notification should not be classified as a server request")
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/randalmurphal/llmkit/v2"
 	"github.com/randalmurphal/llmkit/v2/codex/session"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
 )
 
 // SessionClient wraps a session.Session to provide a simpler request/response interface.
@@ -172,7 +174,10 @@ func (c *SessionClient) Stream(ctx context.Context, req CompletionRequest) (<-ch
 
 			if msg.IsTurnFailed() {
 				chunks <- StreamChunk{
-					Error:     fmt.Errorf("turn failed: %s", msg.Error),
+					Error: fmt.Errorf("turn failed: %w", classifyError("session", llmkit.ErrorDiagnostic{
+						Message:   msg.Error,
+						EventType: codexcontract.EventTurnFailed,
+					})),
 					Done:      true,
 					SessionID: c.session.ID(),
 				}
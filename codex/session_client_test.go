@@ -10,6 +10,7 @@ import (
 	"github.com/randalmurphal/llmkit/v2/codex"
 	"github.com/randalmurphal/llmkit/v2/codex/session"
 	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/fanout"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -49,14 +50,20 @@ func newTestSession(id string) *testSession {
 	}
 }
 
-func (s *testSession) ID() string                           { return s.id }
-func (s *testSession) ThreadID() string                     { return s.threadID }
-func (s *testSession) Status() session.SessionStatus        { return s.status }
-func (s *testSession) Info() session.SessionInfo            { return s.info }
-func (s *testSession) Wait() error                          { return nil }
-func (s *testSession) WaitForInit(_ context.Context) error  { return nil }
-func (s *testSession) Output() <-chan session.OutputMessage { return s.outputCh }
-func (s *testSession) JSONLPath() string                    { return "" }
+func (s *testSession) ID() string                                       { return s.id }
+func (s *testSession) ThreadID() string                                 { return s.threadID }
+func (s *testSession) Status() session.SessionStatus                    { return s.status }
+func (s *testSession) Info() session.SessionInfo                        { return s.info }
+func (s *testSession) Wait() error                                      { return nil }
+func (s *testSession) WaitForInit(_ context.Context) error              { return nil }
+func (s *testSession) Output() <-chan session.OutputMessage             { return s.outputCh }
+func (s *testSession) JSONLPath() string                                { return "" }
+func (s *testSession) Interrupt(_ context.Context) error                { return nil }
+func (s *testSession) Call(_ context.Context, _ string, _, _ any) error { return nil }
+
+func (s *testSession) Subscribe(opts ...fanout.Option) *fanout.Subscription[session.OutputMessage] {
+	return fanout.New[session.OutputMessage](nil).Subscribe(opts...)
+}
 
 func (s *testSession) Send(_ context.Context, msg session.UserMessage) error {
 	s.closeMu.Lock()
@@ -192,6 +199,30 @@ func (m *testSessionManager) Info(sessionID string) (*session.SessionInfo, bool)
 	return &info, true
 }
 
+func (m *testSessionManager) ListThreads(context.Context, session.ThreadListParams) (*session.ThreadListResult, error) {
+	return &session.ThreadListResult{}, nil
+}
+
+func (m *testSessionManager) ReadThread(_ context.Context, threadID string, _ bool) (*session.Thread, error) {
+	return &session.Thread{ID: threadID}, nil
+}
+
+func (m *testSessionManager) Fork(ctx context.Context, _ string, opts ...session.SessionOption) (session.Session, error) {
+	return m.Create(ctx, opts...)
+}
+
+func (m *testSessionManager) Archive(context.Context, string) error   { return nil }
+func (m *testSessionManager) Interrupt(context.Context, string) error { return nil }
+func (m *testSessionManager) Models(context.Context) ([]session.Model, error) {
+	return nil, nil
+}
+func (m *testSessionManager) Account(context.Context) (*session.AccountReadResult, error) {
+	return &session.AccountReadResult{}, nil
+}
+func (m *testSessionManager) RateLimits(context.Context) (*session.RateLimitSnapshot, error) {
+	return &session.RateLimitSnapshot{}, nil
+}
+
 // Add a test session to the manager (for pre-populating).
 func (m *testSessionManager) addSession(s *testSession) {
 	m.mu.Lock()
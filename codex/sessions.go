@@ -30,6 +30,18 @@ type (
 
 	// CodexManagerOption configures session manager creation.
 	CodexManagerOption = session.ManagerOption
+
+	// CodexApprovalHandler answers command and patch approval requests.
+	CodexApprovalHandler = session.ApprovalHandler
+
+	// CodexApprovalRequest is a command or patch waiting for approval.
+	CodexApprovalRequest = session.ApprovalRequest
+
+	// CodexApprovalDecision is the answer to an approval request.
+	CodexApprovalDecision = session.ApprovalDecision
+
+	// CodexThread is a persisted Codex thread.
+	CodexThread = session.Thread
 )
 
 // Session status constants.
@@ -56,6 +68,10 @@ var (
 	CodexSessionWithFullAuto    = session.WithFullAuto
 	CodexSessionWithSandboxMode = session.WithSandboxMode
 	CodexSessionWithEnv         = session.WithEnv
+
+	CodexSessionWithApprovalHandler = session.WithApprovalHandler
+	CodexSessionWithApprovalTimeout = session.WithApprovalTimeout
+	CodexSessionWithFork            = session.WithFork
 )
 
 // Manager options re-exported for convenience.
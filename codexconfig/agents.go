@@ -1,6 +1,7 @@
 package codexconfig
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -49,6 +50,18 @@ func ParseCustomAgent(path string) (*CustomAgent, error) {
 	return &agent, nil
 }
 
+// MarshalCustomAgent renders agent as a custom agent TOML file.
+func MarshalCustomAgent(agent *CustomAgent) ([]byte, error) {
+	if err := agent.Validate(); err != nil {
+		return nil, fmt.Errorf("validate custom agent: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(agent); err != nil {
+		return nil, fmt.Errorf("encode custom agent: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 func DiscoverCustomAgents(projectRoot string) ([]*CustomAgent, error) {
 	var agents []*CustomAgent
 	userDir, err := UserAgentsDir()
@@ -38,3 +38,31 @@ func TestDiscoverCustomAgents(t *testing.T) {
 		t.Fatalf("len(agents) = %d, want 2", len(agents))
 	}
 }
+
+func TestMarshalCustomAgentRoundTrip(t *testing.T) {
+	agent := &CustomAgent{
+		Name:                  "reviewer",
+		Description:           "Reviews diffs",
+		DeveloperInstructions: "Review the staged diff.\nBe terse.",
+		Model:                 "gpt-5-codex",
+	}
+	data, err := MarshalCustomAgent(agent)
+	if err != nil {
+		t.Fatalf("MarshalCustomAgent: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "reviewer.toml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write agent: %v", err)
+	}
+	parsed, err := ParseCustomAgent(path)
+	if err != nil {
+		t.Fatalf("ParseCustomAgent: %v\n%s", err, data)
+	}
+	if parsed.Name != agent.Name || parsed.DeveloperInstructions != agent.DeveloperInstructions || parsed.Model != agent.Model {
+		t.Fatalf("round trip = %+v\n%s", parsed, data)
+	}
+
+	if _, err := MarshalCustomAgent(&CustomAgent{Name: "x"}); err == nil {
+		t.Fatal("MarshalCustomAgent accepted an agent without a description")
+	}
+}
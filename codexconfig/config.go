@@ -3,25 +3,62 @@ package codexconfig
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 type ConfigFile struct {
-	Profiles                    map[string]Profile   `toml:"profiles,omitempty"`
-	MCPServers                  map[string]MCPServer `toml:"mcp_servers,omitempty"`
-	Skills                      SkillsSettings       `toml:"skills,omitempty"`
-	Agents                      AgentsSettings       `toml:"agents,omitempty"`
-	ModelInstructionsFile       string               `toml:"model_instructions_file,omitempty"`
-	ProjectDocFallbackFilenames []string             `toml:"project_doc_fallback_filenames,omitempty"`
-	ProjectDocMaxBytes          int                  `toml:"project_doc_max_bytes,omitempty"`
-	ProjectRootMarkers          []string             `toml:"project_root_markers,omitempty"`
+	Profiles                    map[string]Profile     `toml:"profiles,omitempty"`
+	MCPServers                  map[string]MCPServer   `toml:"mcp_servers,omitempty"`
+	Skills                      SkillsSettings         `toml:"skills,omitempty"`
+	Agents                      AgentsSettings         `toml:"agents,omitempty"`
+	ModelInstructionsFile       string                 `toml:"model_instructions_file,omitempty"`
+	ProjectDocFallbackFilenames []string               `toml:"project_doc_fallback_filenames,omitempty"`
+	ProjectDocMaxBytes          int                    `toml:"project_doc_max_bytes,omitempty"`
+	ProjectRootMarkers          []string               `toml:"project_root_markers,omitempty"`
+	ShellEnvironmentPolicy      ShellEnvironmentPolicy `toml:"shell_environment_policy,omitempty"`
 
 	raw map[string]any `toml:"-"`
 }
 
+// ShellEnvironmentPolicy controls the environment of the commands Codex
+// runs. Codex starts from the variables Inherit selects ("all", "core" or
+// "none") out of its own process environment, drops the default KEY,
+// SECRET and TOKEN patterns unless IgnoreDefaultExcludes, then Exclude,
+// adds Set, and finally keeps only names matching IncludeOnly when it is
+// non-empty. Set therefore wins over the process environment, but not over
+// IncludeOnly. Patterns are case-insensitive globs.
+type ShellEnvironmentPolicy struct {
+	Inherit                string            `toml:"inherit,omitempty"`
+	IgnoreDefaultExcludes  bool              `toml:"ignore_default_excludes,omitempty"`
+	Exclude                []string          `toml:"exclude,omitempty"`
+	Set                    map[string]string `toml:"set,omitempty"`
+	IncludeOnly            []string          `toml:"include_only,omitempty"`
+	ExperimentalUseProfile bool              `toml:"experimental_use_profile,omitempty"`
+}
+
+// shellEnvironmentPolicyKeys are the shell_environment_policy keys
+// ShellEnvironmentPolicy models; others in the file are kept as they are.
+var shellEnvironmentPolicyKeys = []string{"inherit", "ignore_default_excludes", "exclude", "set", "include_only", "experimental_use_profile"}
+
+// Includes reports whether name survives IncludeOnly.
+func (p ShellEnvironmentPolicy) Includes(name string) bool {
+	if len(p.IncludeOnly) == 0 {
+		return true
+	}
+	for _, pattern := range p.IncludeOnly {
+		if ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
 type Profile struct {
 	Model                       string               `toml:"model,omitempty"`
 	ModelReasoningEffort        string               `toml:"model_reasoning_effort,omitempty"`
@@ -150,6 +187,7 @@ func MarshalConfig(cfg *ConfigFile) ([]byte, error) {
 		}
 		doc[key] = value
 	}
+	mergePolicy(doc, known)
 
 	var buf bytes.Buffer
 	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
@@ -160,14 +198,15 @@ func MarshalConfig(cfg *ConfigFile) ([]byte, error) {
 
 func knownConfigMap(cfg *ConfigFile) (map[string]any, error) {
 	typed := struct {
-		Profiles                    map[string]Profile   `toml:"profiles,omitempty"`
-		MCPServers                  map[string]MCPServer `toml:"mcp_servers,omitempty"`
-		Skills                      SkillsSettings       `toml:"skills,omitempty"`
-		Agents                      AgentsSettings       `toml:"agents,omitempty"`
-		ModelInstructionsFile       string               `toml:"model_instructions_file,omitempty"`
-		ProjectDocFallbackFilenames []string             `toml:"project_doc_fallback_filenames,omitempty"`
-		ProjectDocMaxBytes          int                  `toml:"project_doc_max_bytes,omitempty"`
-		ProjectRootMarkers          []string             `toml:"project_root_markers,omitempty"`
+		Profiles                    map[string]Profile     `toml:"profiles,omitempty"`
+		MCPServers                  map[string]MCPServer   `toml:"mcp_servers,omitempty"`
+		Skills                      SkillsSettings         `toml:"skills,omitempty"`
+		Agents                      AgentsSettings         `toml:"agents,omitempty"`
+		ModelInstructionsFile       string                 `toml:"model_instructions_file,omitempty"`
+		ProjectDocFallbackFilenames []string               `toml:"project_doc_fallback_filenames,omitempty"`
+		ProjectDocMaxBytes          int                    `toml:"project_doc_max_bytes,omitempty"`
+		ProjectRootMarkers          []string               `toml:"project_root_markers,omitempty"`
+		ShellEnvironmentPolicy      ShellEnvironmentPolicy `toml:"shell_environment_policy,omitempty"`
 	}{
 		Profiles:                    cfg.Profiles,
 		MCPServers:                  cfg.MCPServers,
@@ -177,6 +216,7 @@ func knownConfigMap(cfg *ConfigFile) (map[string]any, error) {
 		ProjectDocFallbackFilenames: cfg.ProjectDocFallbackFilenames,
 		ProjectDocMaxBytes:          cfg.ProjectDocMaxBytes,
 		ProjectRootMarkers:          cfg.ProjectRootMarkers,
+		ShellEnvironmentPolicy:      cfg.ShellEnvironmentPolicy,
 	}
 
 	var buf bytes.Buffer
@@ -190,6 +230,29 @@ func knownConfigMap(cfg *ConfigFile) (map[string]any, error) {
 	return out, nil
 }
 
+// mergePolicy writes the modelled shell_environment_policy keys from known
+// into doc. Unlike mergeAnyMap, a key such as set is replaced as a whole,
+// so variables removed from Set are removed from the file.
+func mergePolicy(doc, known map[string]any) {
+	const key = "shell_environment_policy"
+	policy, _ := doc[key].(map[string]any)
+	policy = cloneAnyMap(policy)
+	if policy == nil {
+		policy = map[string]any{}
+	}
+	for _, name := range shellEnvironmentPolicyKeys {
+		delete(policy, name)
+	}
+	if incoming, ok := known[key].(map[string]any); ok {
+		maps.Copy(policy, incoming)
+	}
+	if len(policy) == 0 {
+		delete(doc, key)
+		return
+	}
+	doc[key] = policy
+}
+
 func cloneAnyMap(in map[string]any) map[string]any {
 	if len(in) == 0 {
 		return nil
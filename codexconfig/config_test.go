@@ -3,6 +3,7 @@ package codexconfig
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -85,3 +86,50 @@ func TestConfigPreservesUnknownTopLevelFields(t *testing.T) {
 		t.Fatalf("expected known key written, got:\n%s", content)
 	}
 }
+
+func TestShellEnvironmentPolicyRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	path := ProjectConfigPath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	content := "[shell_environment_policy]\ninherit = \"core\"\nfuture_key = 1\ninclude_only = [\"PATH\", \"LLMKIT_*\"]\n\n[shell_environment_policy.set]\nKEEP = \"1\"\nDROP = \"1\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig: %v", err)
+	}
+	policy := cfg.ShellEnvironmentPolicy
+	if policy.Inherit != "core" || policy.Set["DROP"] != "1" {
+		t.Fatalf("ShellEnvironmentPolicy = %+v", policy)
+	}
+	if !policy.Includes("llmkit_mode") || policy.Includes("HOME") {
+		t.Fatalf("Includes: IncludeOnly = %v", policy.IncludeOnly)
+	}
+	delete(cfg.ShellEnvironmentPolicy.Set, "DROP")
+	if err := SaveProjectConfig(root, cfg); err != nil {
+		t.Fatalf("SaveProjectConfig: %v", err)
+	}
+
+	loaded, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if want := map[string]string{"KEEP": "1"}; !reflect.DeepEqual(loaded.ShellEnvironmentPolicy.Set, want) {
+		t.Fatalf("Set = %v, want %v", loaded.ShellEnvironmentPolicy.Set, want)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "future_key = 1") {
+		t.Fatalf("unknown policy key dropped:\n%s", data)
+	}
+
+	loaded.ShellEnvironmentPolicy = ShellEnvironmentPolicy{}
+	if err := SaveProjectConfig(root, loaded); err != nil {
+		t.Fatalf("SaveProjectConfig: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "inherit") || strings.Contains(string(data), "KEEP") {
+		t.Fatalf("cleared policy still written:\n%s", data)
+	}
+}
@@ -13,6 +13,8 @@ const (
 	DirPlugins          = "plugins"
 	DirAgents           = "agents"
 	DirRules            = "rules"
+	DirSessions         = "sessions"
+	DirLog              = "log"
 	FileConfigTOML      = "config.toml"
 	FileHooksJSON       = "hooks.json"
 	FileSkillMD         = "SKILL.md"
@@ -21,6 +23,7 @@ const (
 	FileMarketplaceJSON = "marketplace.json"
 	DirCodexPlugin      = ".codex-plugin"
 	FilePluginJSON      = "plugin.json"
+	FileHistoryJSONL    = "history.jsonl"
 )
 
 func codexHomeDir() (string, error) {
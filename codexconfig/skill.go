@@ -185,12 +185,25 @@ func parseSkillContent(data []byte) (*Skill, error) {
 }
 
 func WriteSkillMD(skill *Skill, dir string) error {
-	if err := skill.Validate(); err != nil {
-		return fmt.Errorf("validate skill: %w", err)
+	data, err := MarshalSkillMD(skill)
+	if err != nil {
+		return err
 	}
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("create directory: %w", err)
 	}
+	filePath := filepath.Join(dir, FileSkillMD)
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", FileSkillMD, err)
+	}
+	return nil
+}
+
+// MarshalSkillMD renders skill as SKILL.md content.
+func MarshalSkillMD(skill *Skill) ([]byte, error) {
+	if err := skill.Validate(); err != nil {
+		return nil, fmt.Errorf("validate skill: %w", err)
+	}
 
 	frontmatter := struct {
 		Name         string   `yaml:"name"`
@@ -206,7 +219,7 @@ func WriteSkillMD(skill *Skill, dir string) error {
 
 	fmBytes, err := yaml.Marshal(frontmatter)
 	if err != nil {
-		return fmt.Errorf("marshal frontmatter: %w", err)
+		return nil, fmt.Errorf("marshal frontmatter: %w", err)
 	}
 
 	var buf bytes.Buffer
@@ -217,12 +230,7 @@ func WriteSkillMD(skill *Skill, dir string) error {
 	if !strings.HasSuffix(skill.Content, "\n") {
 		buf.WriteString("\n")
 	}
-
-	filePath := filepath.Join(dir, FileSkillMD)
-	if err := os.WriteFile(filePath, buf.Bytes(), 0o644); err != nil {
-		return fmt.Errorf("write %s: %w", FileSkillMD, err)
-	}
-	return nil
+	return buf.Bytes(), nil
 }
 
 func DiscoverSkills(projectRoot, cwd string) ([]*Skill, error) {
@@ -32,6 +32,8 @@ type Config struct {
 	ReasoningEffort    string                     `json:"reasoning_effort" yaml:"reasoning_effort" mapstructure:"reasoning_effort"`
 	WebSearchMode      string                     `json:"web_search_mode" yaml:"web_search_mode" mapstructure:"web_search_mode"`
 	Runtime            RuntimeConfig              `json:"runtime,omitempty" yaml:"runtime,omitempty" mapstructure:"runtime"`
+	Launch             LaunchConfig               `json:"launch,omitempty" yaml:"launch,omitempty" mapstructure:"launch"`
+	RequestValidation  RequestValidationMode      `json:"request_validation,omitempty" yaml:"request_validation,omitempty" mapstructure:"request_validation"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -97,6 +99,11 @@ func (c *Config) Validate() error {
 	if c.Timeout < 0 {
 		return fmt.Errorf("timeout must be >= 0, got %v", c.Timeout)
 	}
+	switch c.RequestValidation {
+	case "", RequestValidationStrict, RequestValidationLenient, RequestValidationOff:
+	default:
+		return fmt.Errorf("request_validation must be strict, lenient, or off, got %q", c.RequestValidation)
+	}
 	if err := ValidateRuntimeConfig(c.Provider, c.Runtime); err != nil {
 		return err
 	}
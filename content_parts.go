@@ -0,0 +1,223 @@
+package llmkit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Size limits applied to image and document parts before any CLI runs.
+const (
+	MaxImageBytes    = 5 << 20
+	MaxDocumentBytes = 32 << 20
+)
+
+var (
+	// ImageMediaTypes lists the image formats accepted in image parts.
+	ImageMediaTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+	// DocumentMediaTypes lists the formats accepted in document parts.
+	DocumentMediaTypes = []string{"application/pdf", "text/plain"}
+)
+
+// ContentPartData is the resolved payload of an image or document part.
+type ContentPartData struct {
+	MediaType string
+	Data      []byte
+}
+
+// Base64 returns the payload encoded with standard base64.
+func (d ContentPartData) Base64() string {
+	return base64.StdEncoding.EncodeToString(d.Data)
+}
+
+// Extension returns a file extension matching the media type.
+func (d ContentPartData) Extension() string {
+	switch d.MediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "text/plain":
+		return ".txt"
+	}
+	if exts, _ := mime.ExtensionsByType(d.MediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// ReadContentPart loads an image or document part from its base64 data,
+// local file or URL and checks its size and media type. URLs are fetched with
+// ctx and read only up to the size limit.
+func ReadContentPart(ctx context.Context, part ContentPart) (ContentPartData, error) {
+	limit, allowed, ok := contentPartLimits(part.Type)
+	if !ok {
+		return ContentPartData{}, fmt.Errorf("%w: %s parts carry no binary content", ErrInvalidRequest, part.Type)
+	}
+
+	var data ContentPartData
+	var err error
+	switch {
+	case part.ImageBase64 != "":
+		data, err = decodeContentPartBase64(part.ImageBase64, part.MediaType)
+	case part.FilePath != "":
+		data, err = readContentPartFile(part.FilePath, part.MediaType, limit)
+	case part.ImageURL != "":
+		data, err = fetchContentPartURL(ctx, part.ImageURL, part.MediaType, limit)
+	default:
+		err = fmt.Errorf("%w: %s part has no content", ErrInvalidRequest, part.Type)
+	}
+	if err != nil {
+		return ContentPartData{}, err
+	}
+
+	if len(data.Data) > limit {
+		return ContentPartData{}, fmt.Errorf("%w: %s exceeds the %d byte limit", ErrInvalidRequest, part.Type, limit)
+	}
+	if data.MediaType == "" {
+		data.MediaType = http.DetectContentType(data.Data)
+	}
+	data.MediaType = normalizeMediaType(data.MediaType)
+	if !slices.Contains(allowed, data.MediaType) {
+		return ContentPartData{}, fmt.Errorf("%w: unsupported %s media type %q", ErrInvalidRequest, part.Type, data.MediaType)
+	}
+	return data, nil
+}
+
+// checkContentPartSource runs the cheap up-front checks for an image or
+// document part without reading its content. It returns the offending field
+// suffix and message, or "" when the part looks usable.
+func checkContentPartSource(part ContentPart) (string, string) {
+	limit, allowed, ok := contentPartLimits(part.Type)
+	if !ok {
+		return "", ""
+	}
+	if part.MediaType != "" && !slices.Contains(allowed, normalizeMediaType(part.MediaType)) {
+		return ".media_type", fmt.Sprintf("unsupported %s media type %q", part.Type, part.MediaType)
+	}
+
+	switch {
+	case part.ImageBase64 != "":
+		if part.MediaType == "" {
+			return ".media_type", "is required with image_base64"
+		}
+		if n := base64.StdEncoding.DecodedLen(len(part.ImageBase64)); n > limit+2 {
+			return ".image_base64", fmt.Sprintf("decodes to about %d bytes, limit is %d", n, limit)
+		}
+	case part.FilePath != "":
+		info, err := os.Stat(part.FilePath)
+		if err != nil {
+			return ".file_path", err.Error()
+		}
+		if !info.Mode().IsRegular() {
+			return ".file_path", "is not a regular file"
+		}
+		if info.Size() > int64(limit) {
+			return ".file_path", fmt.Sprintf("is %d bytes, limit is %d", info.Size(), limit)
+		}
+		if part.MediaType == "" {
+			if mediaType := mediaTypeFromPath(part.FilePath); mediaType != "" && !slices.Contains(allowed, mediaType) {
+				return ".file_path", fmt.Sprintf("unsupported %s media type %q", part.Type, mediaType)
+			}
+		}
+	case part.ImageURL != "":
+		if strings.HasPrefix(part.ImageURL, "data:") {
+			return "", ""
+		}
+		u, err := url.Parse(part.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ".image_url", "must be an http(s) or data: URL"
+		}
+	}
+	return "", ""
+}
+
+func contentPartLimits(partType string) (int, []string, bool) {
+	switch partType {
+	case ContentPartImage:
+		return MaxImageBytes, ImageMediaTypes, true
+	case ContentPartDocument:
+		return MaxDocumentBytes, DocumentMediaTypes, true
+	default:
+		return 0, nil, false
+	}
+}
+
+func decodeContentPartBase64(encoded, mediaType string) (ContentPartData, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("%w: decode base64 content: %v", ErrInvalidRequest, err)
+	}
+	return ContentPartData{MediaType: mediaType, Data: data}, nil
+}
+
+func readContentPartFile(path, mediaType string, limit int) (ContentPartData, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if info.Size() > int64(limit) {
+		return ContentPartData{}, fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrInvalidRequest, path, info.Size(), limit)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("read content part: %w", err)
+	}
+	if mediaType == "" {
+		mediaType = mediaTypeFromPath(path)
+	}
+	return ContentPartData{MediaType: mediaType, Data: data}, nil
+}
+
+func fetchContentPartURL(ctx context.Context, rawURL, mediaType string, limit int) (ContentPartData, error) {
+	if strings.HasPrefix(rawURL, "data:") {
+		meta, payload, ok := strings.Cut(strings.TrimPrefix(rawURL, "data:"), ",")
+		if !ok || !strings.HasSuffix(meta, ";base64") {
+			return ContentPartData{}, fmt.Errorf("%w: data URLs must be base64 encoded", ErrInvalidRequest)
+		}
+		if mediaType == "" {
+			mediaType = strings.TrimSuffix(meta, ";base64")
+		}
+		return decodeContentPartBase64(payload, mediaType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ContentPartData{}, fmt.Errorf("fetch %s: %s", rawURL, resp.Status)
+	}
+	// Read one byte past the limit so oversized bodies are detected without buffering them.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return ContentPartData{}, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	if mediaType == "" {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+	return ContentPartData{MediaType: mediaType, Data: data}, nil
+}
+
+func mediaTypeFromPath(path string) string {
+	return normalizeMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(path))))
+}
+
+// normalizeMediaType drops parameters such as "; charset=utf-8".
+func normalizeMediaType(mediaType string) string {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		return parsed
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
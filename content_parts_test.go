@@ -0,0 +1,90 @@
+package llmkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough for http.DetectContentType to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestReadContentPartSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cat.png")
+	if err := os.WriteFile(path, pngHeader, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(pngHeader)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big.png" {
+			_, _ = w.Write(bytes.Repeat([]byte{0}, MaxImageBytes+10))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngHeader)
+	}))
+	defer server.Close()
+
+	parts := map[string]ContentPart{
+		"file":     {Type: ContentPartImage, FilePath: path},
+		"base64":   {Type: ContentPartImage, ImageBase64: encoded, MediaType: "image/png"},
+		"data url": {Type: ContentPartImage, ImageURL: "data:image/png;base64," + encoded},
+		"http":     {Type: ContentPartImage, ImageURL: server.URL + "/cat.png"},
+	}
+	for name, part := range parts {
+		data, err := ReadContentPart(context.Background(), part)
+		if err != nil {
+			t.Fatalf("%s: ReadContentPart() error = %v", name, err)
+		}
+		if data.MediaType != "image/png" || !bytes.Equal(data.Data, pngHeader) || data.Extension() != ".png" {
+			t.Fatalf("%s: data = %q %q", name, data.MediaType, data.Data)
+		}
+	}
+
+	_, err := ReadContentPart(context.Background(), ContentPart{Type: ContentPartImage, ImageURL: server.URL + "/big.png"})
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("oversized URL error = %v", err)
+	}
+	_, err = ReadContentPart(context.Background(), ContentPart{Type: ContentPartDocument, ImageBase64: encoded, MediaType: "image/png"})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("image as document error = %v", err)
+	}
+}
+
+func TestValidateRequestChecksContentPartsUpFront(t *testing.T) {
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "spec.pdf")
+	if err := os.WriteFile(doc, []byte("%PDF-1.4"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateRequest("claude", Request{Messages: []Message{NewDocumentMessage(RoleUser, "summarize", doc)}}); err != nil {
+		t.Fatalf("document request error = %v", err)
+	}
+
+	tests := map[string]ContentPart{
+		"messages[0].content_parts[0].media_type":   {Type: ContentPartImage, ImageBase64: "AAAA", MediaType: "image/bmp"},
+		"messages[0].content_parts[0].image_base64": {Type: ContentPartImage, ImageBase64: strings.Repeat("A", MaxImageBytes*2), MediaType: "image/png"},
+		"messages[0].content_parts[0].file_path":    {Type: ContentPartImage, FilePath: filepath.Join(dir, "missing.png")},
+		"messages[0].content_parts[0].image_url":    {Type: ContentPartImage, ImageURL: "ftp://example.com/cat.png"},
+	}
+	for field, part := range tests {
+		err := ValidateRequest("codex", Request{Messages: []Message{{Role: RoleUser, ContentParts: []ContentPart{part}}}})
+		if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), field+":") {
+			t.Fatalf("%s: error = %v", field, err)
+		}
+	}
+
+	// Codex has no document input.
+	err := ValidateRequest("codex", Request{Messages: []Message{NewDocumentMessage(RoleUser, "summarize", doc)}})
+	if !IsCapabilityError(err) {
+		t.Fatalf("codex document error = %v", err)
+	}
+}
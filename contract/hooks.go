@@ -0,0 +1,50 @@
+package contract
+
+// HookEvent is a provider-neutral hook event. env translates it to each
+// provider's own event name.
+type HookEvent string
+
+const (
+	// HookBeforeTool fires before a tool runs and can block it.
+	HookBeforeTool HookEvent = "before_tool"
+	// HookAfterTool fires after a tool returns.
+	HookAfterTool HookEvent = "after_tool"
+	// HookPromptSubmit fires when a prompt is submitted, before the model sees it.
+	HookPromptSubmit HookEvent = "prompt_submit"
+	// HookSessionStart fires when a session starts or resumes.
+	HookSessionStart HookEvent = "session_start"
+	// HookStop fires when the agent is about to finish its turn.
+	HookStop HookEvent = "stop"
+)
+
+// ValidHookEvents returns all provider-neutral hook events.
+func ValidHookEvents() []HookEvent {
+	return []HookEvent{
+		HookBeforeTool,
+		HookAfterTool,
+		HookPromptSubmit,
+		HookSessionStart,
+		HookStop,
+	}
+}
+
+// Provider-neutral tool names for HookDefinition.Tools.
+const (
+	ToolShell     = "shell"
+	ToolEdit      = "edit"
+	ToolRead      = "read"
+	ToolWebSearch = "web_search"
+	ToolWebFetch  = "web_fetch"
+	ToolSubagent  = "subagent"
+)
+
+// HookDefinition is a command hook defined once for every provider.
+// Tools restricts before_tool and after_tool hooks to neutral tool names;
+// empty matches every tool.
+type HookDefinition struct {
+	Event         HookEvent `json:"event"`
+	Tools         []string  `json:"tools,omitempty"`
+	Command       string    `json:"command"`
+	Timeout       int       `json:"timeout,omitempty"`
+	StatusMessage string    `json:"status_message,omitempty"`
+}
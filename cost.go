@@ -131,6 +131,25 @@ func (t *CostTracker) TotalUsage() Usage {
 	return total
 }
 
+// PricingFor returns the pricing for a model family or full model name.
+func PricingFor(model string) (ModelPricing, bool) {
+	if prices, ok := ModelPrices[ModelName(model)]; ok {
+		return prices, true
+	}
+	prices, ok := ModelPrices[NormalizeModelName(model)]
+	return prices, ok
+}
+
+// EstimateCost returns the estimated cost of usage on model. It reports
+// false when the model has no pricing.
+func EstimateCost(model string, usage Usage) (float64, bool) {
+	prices, ok := PricingFor(model)
+	if !ok {
+		return 0, false
+	}
+	return usageCost(usage, prices), true
+}
+
 // usageCost calculates the cost of a usage record against a pricing model.
 func usageCost(usage Usage, prices ModelPricing) float64 {
 	inputCost := float64(usage.InputTokens) / 1_000_000 * prices.InputPerMillion
@@ -147,12 +166,9 @@ func (t *CostTracker) EstimatedCost() float64 {
 
 	var total float64
 	for model, usage := range t.totals {
-		prices, ok := ModelPrices[model]
+		prices, ok := PricingFor(string(model))
 		if !ok {
-			prices, ok = ModelPrices[NormalizeModelName(string(model))]
-			if !ok {
-				continue
-			}
+			continue
 		}
 		total += usageCost(usage, prices)
 	}
@@ -166,12 +182,9 @@ func (t *CostTracker) EstimatedCostByModel() map[ModelName]float64 {
 
 	result := make(map[ModelName]float64, len(t.totals))
 	for model, usage := range t.totals {
-		prices, ok := ModelPrices[model]
+		prices, ok := PricingFor(string(model))
 		if !ok {
-			prices, ok = ModelPrices[NormalizeModelName(string(model))]
-			if !ok {
-				continue
-			}
+			continue
 		}
 		result[model] = usageCost(usage, prices)
 	}
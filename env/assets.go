@@ -0,0 +1,293 @@
+package env
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+)
+
+// RulesFile is the Codex rules file holding scope-managed prefix rules,
+// relative to the project's rules dir.
+const RulesFile = "llmkit.rules"
+
+// assetRecord tracks the files, instruction sections and rules a scope
+// created, so Restore and orphan recovery can remove exactly those.
+type assetRecord struct {
+	// Files maps workDir-relative paths to the SHA-256 of what was written.
+	Files map[string]string `json:"files,omitempty"`
+	// Dirs are workDir-relative directories the scope created, parents first.
+	Dirs []string `json:"dirs,omitempty"`
+	// Instructions is the workDir-relative file holding the managed section.
+	Instructions        string `json:"instructions,omitempty"`
+	InstructionsCreated bool   `json:"instructions_created,omitempty"`
+	// PrefixRules are the managed rule IDs in RulesFile.
+	PrefixRules []string `json:"prefix_rules,omitempty"`
+}
+
+func (r assetRecord) empty() bool {
+	return len(r.Files) == 0 && len(r.Dirs) == 0 && r.Instructions == "" && len(r.PrefixRules) == 0
+}
+
+// applyAssets writes cfg's files, instructions and prefix rules, recording
+// each in rec as it goes so a partial failure can still be restored.
+func applyAssets(provider, workDir, tag string, cfg ScopeConfig, rec *assetRecord) error {
+	paths := make([]string, 0, len(cfg.Files))
+	for path := range cfg.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, rel := range paths {
+		if err := writeScopeFile(workDir, rel, cfg.Files[rel], rec); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Instructions != "" {
+		if err := addInstructions(provider, workDir, tag, cfg.Instructions, rec); err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.PrefixRules) > 0 {
+		path := rulesPath(workDir)
+		file, err := codexconfig.LoadRuleFile(path)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(cfg.PrefixRules))
+		for id := range cfg.PrefixRules {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			codexconfig.UpsertManagedPrefixRule(file, managedRuleID(tag, id), cfg.PrefixRules[id])
+		}
+		if err := mkdirTracked(workDir, filepath.Join(codexconfig.DirCodex, codexconfig.DirRules), rec); err != nil {
+			return err
+		}
+		if err := codexconfig.SaveRuleFile(path, file); err != nil {
+			return err
+		}
+		rec.PrefixRules = ids
+	}
+	return nil
+}
+
+// restoreAssets undoes rec. Files edited since the scope wrote them are
+// left in place.
+func restoreAssets(workDir, tag string, rec assetRecord) error {
+	var errs []error
+	if len(rec.PrefixRules) > 0 {
+		path := rulesPath(workDir)
+		file, err := codexconfig.LoadRuleFile(path)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			for _, id := range rec.PrefixRules {
+				codexconfig.RemoveManagedRule(file, managedRuleID(tag, id))
+			}
+			if strings.TrimSpace(file.Content) == "" {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					errs = append(errs, err)
+				}
+			} else if err := codexconfig.SaveRuleFile(path, file); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if rec.Instructions != "" {
+		if err := removeInstructions(workDir, tag, rec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for rel, sum := range rec.Files {
+		path := filepath.Join(workDir, rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if contentHash(data) != sum {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Deepest first; directories that still hold anything stay.
+	for _, rel := range slices.Backward(rec.Dirs) {
+		_ = os.Remove(filepath.Join(workDir, rel))
+	}
+	return errors.Join(errs...)
+}
+
+func writeScopeFile(workDir, rel, content string, rec *assetRecord) error {
+	if err := validateScopePath(rel); err != nil {
+		return fmt.Errorf("scope file %q: %w", rel, err)
+	}
+	path := filepath.Join(workDir, rel)
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("scope file %s already exists", rel)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := mkdirTracked(workDir, filepath.Dir(rel), rec); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if rec.Files == nil {
+		rec.Files = map[string]string{}
+	}
+	rec.Files[rel] = contentHash([]byte(content))
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// mkdirTracked creates rel under workDir, recording each directory it had
+// to create.
+func mkdirTracked(workDir, rel string, rec *assetRecord) error {
+	if rel == "." || rel == "" {
+		return nil
+	}
+	if err := mkdirTracked(workDir, filepath.Dir(rel), rec); err != nil {
+		return err
+	}
+	path := filepath.Join(workDir, rel)
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", rel)
+		}
+		return nil
+	}
+	if err := os.Mkdir(path, 0o755); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	rec.Dirs = append(rec.Dirs, rel)
+	return nil
+}
+
+func validateScopePath(rel string) error {
+	if rel == "" {
+		return fmt.Errorf("path is required")
+	}
+	if filepath.IsAbs(rel) || !filepath.IsLocal(rel) {
+		return fmt.Errorf("path must stay inside the project")
+	}
+	return nil
+}
+
+// instructionsFile returns the workDir-relative instructions file the
+// provider reads. Codex prefers a non-empty AGENTS.override.md.
+func instructionsFile(provider, workDir string) (string, error) {
+	switch provider {
+	case "claude":
+		return "CLAUDE.md", nil
+	case "codex":
+		data, err := os.ReadFile(filepath.Join(workDir, codexconfig.FileAgentsOverride))
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			return codexconfig.FileAgentsOverride, nil
+		}
+		return codexconfig.FileAgentsMD, nil
+	default:
+		return "", fmt.Errorf("unknown provider: %s", provider)
+	}
+}
+
+func addInstructions(provider, workDir, tag, text string, rec *assetRecord) error {
+	rel, err := instructionsFile(provider, workDir)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(workDir, rel)
+	data, err := os.ReadFile(path)
+	created := os.IsNotExist(err)
+	if err != nil && !created {
+		return err
+	}
+	if err := writeFileAtomic(path, []byte(appendInstructions(string(data), tag, text))); err != nil {
+		return err
+	}
+	rec.Instructions = rel
+	rec.InstructionsCreated = created
+	return nil
+}
+
+// appendInstructions adds text to content as a section marked with tag.
+func appendInstructions(content, tag, text string) string {
+	if content != "" {
+		content = strings.TrimRight(content, "\n") + "\n\n"
+	}
+	return content + instructionsBegin(tag) + "\n" + strings.TrimSpace(text) + "\n" + instructionsEnd(tag) + "\n"
+}
+
+func removeInstructions(workDir, tag string, rec assetRecord) error {
+	path := filepath.Join(workDir, rec.Instructions)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	content := string(data)
+	begin, end := instructionsBegin(tag), instructionsEnd(tag)
+	start := strings.Index(content, begin)
+	if start < 0 {
+		return nil
+	}
+	finish := strings.Index(content[start:], end)
+	if finish < 0 {
+		return nil
+	}
+	finish += start + len(end)
+	before := strings.TrimRight(content[:start], "\n")
+	after := strings.TrimLeft(content[finish:], "\n")
+	content = before
+	if before != "" && after != "" {
+		content += "\n\n"
+	}
+	content += after
+	if before != "" && after == "" {
+		content += "\n"
+	}
+	if strings.TrimSpace(content) == "" && rec.InstructionsCreated {
+		return os.Remove(path)
+	}
+	return writeFileAtomic(path, []byte(content))
+}
+
+func instructionsBegin(tag string) string { return "<!-- BEGIN llmkit:" + tag + " -->" }
+func instructionsEnd(tag string) string   { return "<!-- END llmkit:" + tag + " -->" }
+
+func rulesPath(workDir string) string {
+	return filepath.Join(codexconfig.ProjectRulesDir(workDir), RulesFile)
+}
+
+func managedRuleID(tag, id string) string { return tag + "/" + id }
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+)
+
+func codexAssetsConfig() ScopeConfig {
+	return ScopeConfig{
+		Files: map[string]string{
+			".agents/skills/review/SKILL.md": "---\nname: review\ndescription: Review\n---\n\nReview.\n",
+			".codex/agents/explorer.toml":    "name = \"explorer\"\n",
+		},
+		Instructions: "Run make test before finishing.",
+		PrefixRules: map[string]codexconfig.PrefixRule{
+			"no-push": {Pattern: codexconfig.LiteralPattern("git", "push"), Decision: codexconfig.RuleForbidden},
+		},
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestScopeAssetsRestore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	original := "# Project\n\nUse Go.\n"
+	if err := os.WriteFile(filepath.Join(root, "AGENTS.md"), []byte(original), 0o644); err != nil {
+		t.Fatalf("write AGENTS.md: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".agents", "skills"), 0o755); err != nil {
+		t.Fatalf("mkdir skills: %v", err)
+	}
+
+	scope, err := NewScope("codex", root, codexAssetsConfig())
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	agents := readFile(t, filepath.Join(root, "AGENTS.md"))
+	if !strings.HasPrefix(agents, original) || !strings.Contains(agents, "Run make test before finishing.") {
+		t.Fatalf("AGENTS.md = %q", agents)
+	}
+	rules := readFile(t, filepath.Join(root, ".codex", "rules", RulesFile))
+	if !strings.Contains(rules, `pattern = ["git", "push"]`) || !strings.Contains(rules, "llmkit:"+scope.record.Tag+"/no-push") {
+		t.Fatalf("rules = %q", rules)
+	}
+	skills, err := codexconfig.DiscoverSkills(root, root)
+	if err != nil || len(skills) == 0 || skills[0].Name != "review" {
+		t.Fatalf("DiscoverSkills = %v, %v", skills, err)
+	}
+
+	// A file edited after the scope wrote it is the user's now.
+	edited := filepath.Join(root, ".codex", "agents", "explorer.toml")
+	if err := os.WriteFile(edited, []byte("name = \"mine\"\n"), 0o644); err != nil {
+		t.Fatalf("edit agent: %v", err)
+	}
+
+	if err := scope.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := readFile(t, filepath.Join(root, "AGENTS.md")); got != original {
+		t.Fatalf("AGENTS.md after restore = %q, want %q", got, original)
+	}
+	for _, path := range []string{
+		filepath.Join(root, ".agents", "skills", "review"),
+		filepath.Join(root, ".codex", "rules"),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after restore: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, ".agents", "skills")); err != nil {
+		t.Errorf("pre-existing skills dir removed: %v", err)
+	}
+	if got := readFile(t, edited); got != "name = \"mine\"\n" {
+		t.Errorf("edited agent = %q", got)
+	}
+}
+
+func TestScopeAssetsRefuseOverwriteAndRollBack(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, ".codex", "agents", "explorer.toml")
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("name = \"explorer\"\n"), 0o644); err != nil {
+		t.Fatalf("write agent: %v", err)
+	}
+
+	if _, err := NewScope("codex", root, codexAssetsConfig()); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("NewScope err = %v, want already exists", err)
+	}
+	// The skill was written first (sorted order) and must be rolled back.
+	if _, err := os.Stat(filepath.Join(root, ".agents")); !os.IsNotExist(err) {
+		t.Fatalf(".agents left behind after failed scope: %v", err)
+	}
+
+	if _, err := NewScope("codex", root, ScopeConfig{Files: map[string]string{"../escape": "x"}}); err == nil {
+		t.Fatal("NewScope accepted a path outside the project")
+	}
+	if _, err := NewScope("claude", root, ScopeConfig{PrefixRules: codexAssetsConfig().PrefixRules}); err == nil {
+		t.Fatal("NewScope accepted prefix rules for claude")
+	}
+}
+
+func TestRecoverOrphanedScopeAssets(t *testing.T) {
+	root := t.TempDir()
+	scope, err := NewScope("codex", root, codexAssetsConfig())
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+
+	// Simulate the owning process dying without restoring.
+	reg, err := loadRegistry(root)
+	if err != nil {
+		t.Fatalf("load registry: %v", err)
+	}
+	record := reg.Scopes[scope.record.Tag]
+	record.PID = 999999
+	reg.Scopes[scope.record.Tag] = record
+	if err := saveRegistry(root, reg); err != nil {
+		t.Fatalf("save registry: %v", err)
+	}
+
+	next, err := NewScope("codex", root, ScopeConfig{RecoverOrphans: true})
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	defer next.Restore()
+
+	for _, name := range []string{"AGENTS.md", ".agents", ".codex/agents", ".codex/rules"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("%s survived orphan recovery: %v", name, err)
+		}
+	}
+}
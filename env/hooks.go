@@ -0,0 +1,118 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/contract"
+)
+
+// ErrNoHookEquivalent reports a neutral hook event or tool that a provider
+// has no equivalent for.
+var ErrNoHookEquivalent = errors.New("no equivalent hook")
+
+// hookEvents maps neutral events to each provider's event name.
+var hookEvents = map[string]map[contract.HookEvent]string{
+	"claude": {
+		contract.HookBeforeTool:   string(claudecontract.HookPreToolUse),
+		contract.HookAfterTool:    string(claudecontract.HookPostToolUse),
+		contract.HookPromptSubmit: string(claudecontract.HookUserPromptSubmit),
+		contract.HookSessionStart: string(claudecontract.HookSessionStart),
+		contract.HookStop:         string(claudecontract.HookStop),
+	},
+	"codex": {
+		contract.HookBeforeTool:   string(codexcontract.HookPreToolUse),
+		contract.HookAfterTool:    string(codexcontract.HookPostToolUse),
+		contract.HookPromptSubmit: string(codexcontract.HookUserPromptSubmit),
+		contract.HookSessionStart: string(codexcontract.HookSessionStart),
+		contract.HookStop:         string(codexcontract.HookStop),
+	},
+}
+
+// hookTools maps neutral tool names to each provider's tool names. A
+// missing entry has no equivalent.
+var hookTools = map[string]map[string][]string{
+	"claude": {
+		contract.ToolShell:     {claudecontract.ToolBash},
+		contract.ToolEdit:      {claudecontract.ToolEdit, claudecontract.ToolWrite, claudecontract.ToolNotebookEdit},
+		contract.ToolRead:      {claudecontract.ToolRead},
+		contract.ToolWebSearch: {claudecontract.ToolWebSearch},
+		contract.ToolWebFetch:  {claudecontract.ToolWebFetch},
+		contract.ToolSubagent:  {claudecontract.ToolTask},
+	},
+	"codex": {
+		contract.ToolShell:     {"shell"},
+		contract.ToolEdit:      {"apply_patch"},
+		contract.ToolRead:      {"read_file"},
+		contract.ToolWebSearch: {"web_search"},
+	},
+}
+
+func isNeutralTool(name string) bool {
+	_, ok := hookTools["claude"][name]
+	return ok
+}
+
+// TranslateHooks converts neutral hook definitions to the provider's event
+// names, matchers and tool names. It fails with ErrNoHookEquivalent when an
+// event or tool has no equivalent, so a definition never silently protects
+// one agent but not the other.
+func TranslateHooks(provider string, defs []contract.HookDefinition) (map[string][]Hook, error) {
+	if len(defs) == 0 {
+		return nil, nil
+	}
+	events, ok := hookEvents[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+	out := make(map[string][]Hook, len(defs))
+	for i, def := range defs {
+		if def.Command == "" {
+			return nil, fmt.Errorf("hook %d (%s): command is required", i, def.Event)
+		}
+		if !slices.Contains(contract.ValidHookEvents(), def.Event) {
+			return nil, fmt.Errorf("hook %d: unknown event %q", i, def.Event)
+		}
+		event, ok := events[def.Event]
+		if !ok {
+			return nil, fmt.Errorf("hook %d: %w: %s has no %s event", i, ErrNoHookEquivalent, provider, def.Event)
+		}
+		matcher, err := hookMatcher(provider, def)
+		if err != nil {
+			return nil, fmt.Errorf("hook %d (%s): %w", i, def.Event, err)
+		}
+		out[event] = append(out[event], Hook{
+			Matcher:       matcher,
+			Type:          "command",
+			Command:       def.Command,
+			Timeout:       def.Timeout,
+			StatusMessage: def.StatusMessage,
+		})
+	}
+	return out, nil
+}
+
+func hookMatcher(provider string, def contract.HookDefinition) (string, error) {
+	if len(def.Tools) == 0 {
+		return "", nil
+	}
+	if def.Event != contract.HookBeforeTool && def.Event != contract.HookAfterTool {
+		return "", fmt.Errorf("tools only apply to %s and %s hooks", contract.HookBeforeTool, contract.HookAfterTool)
+	}
+	var names []string
+	for _, tool := range def.Tools {
+		if !isNeutralTool(tool) {
+			return "", fmt.Errorf("unknown tool %q", tool)
+		}
+		mapped, ok := hookTools[provider][tool]
+		if !ok {
+			return "", fmt.Errorf("%w: %s has no %s tool", ErrNoHookEquivalent, provider, tool)
+		}
+		names = append(names, mapped...)
+	}
+	return strings.Join(names, "|"), nil
+}
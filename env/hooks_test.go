@@ -0,0 +1,110 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+	"github.com/randalmurphal/llmkit/v2/contract"
+)
+
+func TestTranslateHooks(t *testing.T) {
+	defs := []contract.HookDefinition{
+		{Event: contract.HookBeforeTool, Tools: []string{contract.ToolShell, contract.ToolEdit}, Command: "guard", Timeout: 10},
+		{Event: contract.HookAfterTool, Command: "audit"},
+		{Event: contract.HookPromptSubmit, Command: "prompt"},
+		{Event: contract.HookSessionStart, Command: "start", StatusMessage: "loading"},
+		{Event: contract.HookStop, Command: "stop"},
+	}
+	tests := map[string]map[string][]Hook{
+		"claude": {
+			"PreToolUse":       {{Matcher: "Bash|Edit|Write|NotebookEdit", Type: "command", Command: "guard", Timeout: 10}},
+			"PostToolUse":      {{Type: "command", Command: "audit"}},
+			"UserPromptSubmit": {{Type: "command", Command: "prompt"}},
+			"SessionStart":     {{Type: "command", Command: "start", StatusMessage: "loading"}},
+			"Stop":             {{Type: "command", Command: "stop"}},
+		},
+		"codex": {
+			"PreToolUse":       {{Matcher: "shell|apply_patch", Type: "command", Command: "guard", Timeout: 10}},
+			"PostToolUse":      {{Type: "command", Command: "audit"}},
+			"UserPromptSubmit": {{Type: "command", Command: "prompt"}},
+			"SessionStart":     {{Type: "command", Command: "start", StatusMessage: "loading"}},
+			"Stop":             {{Type: "command", Command: "stop"}},
+		},
+	}
+	for provider, want := range tests {
+		got, err := TranslateHooks(provider, defs)
+		if err != nil {
+			t.Fatalf("TranslateHooks(%s): %v", provider, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("TranslateHooks(%s) = %#v, want %#v", provider, got, want)
+		}
+	}
+}
+
+func TestTranslateHooksErrors(t *testing.T) {
+	tests := []struct {
+		provider   string
+		def        contract.HookDefinition
+		want       string
+		equivalent bool
+	}{
+		{"codex", contract.HookDefinition{Event: contract.HookBeforeTool, Tools: []string{contract.ToolWebFetch}, Command: "x"}, "codex has no web_fetch tool", true},
+		{"codex", contract.HookDefinition{Event: contract.HookAfterTool, Tools: []string{contract.ToolSubagent}, Command: "x"}, "codex has no subagent tool", true},
+		{"claude", contract.HookDefinition{Event: contract.HookBeforeTool, Tools: []string{"Bash"}, Command: "x"}, `unknown tool "Bash"`, false},
+		{"claude", contract.HookDefinition{Event: contract.HookStop, Tools: []string{contract.ToolShell}, Command: "x"}, "tools only apply", false},
+		{"claude", contract.HookDefinition{Event: "PreToolUse", Command: "x"}, `unknown event "PreToolUse"`, false},
+		{"claude", contract.HookDefinition{Event: contract.HookStop}, "command is required", false},
+		{"gemini", contract.HookDefinition{Event: contract.HookStop, Command: "x"}, "unknown provider", false},
+	}
+	for _, tt := range tests {
+		_, err := TranslateHooks(tt.provider, []contract.HookDefinition{tt.def})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %+v: err = %v, want %q", tt.provider, tt.def, err, tt.want)
+		}
+		if errors.Is(err, ErrNoHookEquivalent) != tt.equivalent {
+			t.Errorf("%s %+v: errors.Is(ErrNoHookEquivalent) = %v", tt.provider, tt.def, !tt.equivalent)
+		}
+	}
+}
+
+func TestScopeAppliesNeutralHooks(t *testing.T) {
+	root := t.TempDir()
+	scope, err := NewScope("codex", root, ScopeConfig{
+		NeutralHooks: []contract.HookDefinition{
+			{Event: contract.HookBeforeTool, Tools: []string{contract.ToolShell}, Command: "guard"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	hooks, err := codexconfig.LoadHooks(root)
+	if err != nil {
+		t.Fatalf("load hooks: %v", err)
+	}
+	pre := hooks.Hooks["PreToolUse"]
+	if len(pre) != 1 || pre[0].Matcher != "shell" || pre[0].Hooks[0].Command != "guard" {
+		t.Fatalf("PreToolUse hooks = %#v", pre)
+	}
+
+	if err := scope.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	hooks, err = codexconfig.LoadHooks(root)
+	if err != nil {
+		t.Fatalf("reload hooks: %v", err)
+	}
+	if len(hooks.Hooks["PreToolUse"]) != 0 {
+		t.Fatalf("PreToolUse hooks after restore = %#v", hooks.Hooks["PreToolUse"])
+	}
+
+	_, err = NewScope("codex", root, ScopeConfig{
+		Hooks: map[string][]Hook{"Stop": {{Type: "prompt", Prompt: "done?"}}},
+	})
+	if !errors.Is(err, ErrNoHookEquivalent) {
+		t.Fatalf("prompt hook on codex: err = %v, want ErrNoHookEquivalent", err)
+	}
+}
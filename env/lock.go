@@ -0,0 +1,35 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockProject takes an exclusive advisory lock on workDir's scope lock
+// file. It serializes registry and settings updates across goroutines,
+// which each open their own descriptor, and across processes. The
+// returned func releases the lock.
+func lockProject(workDir string) (func(), error) {
+	path := lockPath(workDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+func lockPath(workDir string) string {
+	return filepath.Join(workDir, ".llmkit", "env-scopes.lock")
+}
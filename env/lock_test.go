@@ -0,0 +1,219 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/contract"
+)
+
+func TestScopesShareIdenticalEntries(t *testing.T) {
+	root := t.TempDir()
+	settings := claudeconfig.NewSettings()
+	settings.Env["MODE"] = "user"
+	settings.AddHook(claudeconfig.HookStop, claudeconfig.Hook{
+		Matcher: "mine",
+		Hooks:   []claudeconfig.HookEntry{{Type: "command", Command: "echo mine"}},
+	})
+	if err := claudeconfig.SaveProjectSettings(root, settings); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	mcp := claudeconfig.NewMCPConfig()
+	mcp.MCPServers["docs"] = &claudeconfig.MCPServer{Command: "user-docs"}
+	if err := claudeconfig.SaveProjectMCPConfig(root, mcp); err != nil {
+		t.Fatalf("save mcp: %v", err)
+	}
+
+	cfg := ScopeConfig{
+		Hooks: map[string][]Hook{"Stop": {
+			{Matcher: "*", Type: "command", Command: "echo shared"},
+			{Matcher: "mine", Type: "command", Command: "echo mine"},
+		}},
+		MCPServers: map[string]contract.MCPServerConfig{"docs": {Command: "docs-mcp", Args: []string{"--stdio"}}},
+		Env:        map[string]string{"MODE": "scoped"},
+	}
+	first, err := NewScope("claude", root, cfg)
+	if err != nil {
+		t.Fatalf("NewScope first: %v", err)
+	}
+	second, err := NewScope("claude", root, cfg)
+	if err != nil {
+		t.Fatalf("NewScope second: %v", err)
+	}
+	if _, err := NewScope("claude", root, ScopeConfig{Env: map[string]string{"MODE": "other"}}); err == nil {
+		t.Fatal("NewScope replaced an env value another scope holds")
+	}
+
+	current, err := LoadSettings("claude", root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if got := len(current.GetHooks("Stop")); got != 2 {
+		t.Fatalf("Stop hooks = %#v, want the shared hook once plus the user's", current.GetHooks("Stop"))
+	}
+
+	if err := first.Restore(); err != nil {
+		t.Fatalf("Restore first: %v", err)
+	}
+	current, err = LoadSettings("claude", root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if current.Env["MODE"] != "scoped" || current.MCPServers["docs"].Command != "docs-mcp" || len(current.GetHooks("Stop")) != 2 {
+		t.Fatalf("settings after first restore = %+v", current)
+	}
+
+	if err := second.Restore(); err != nil {
+		t.Fatalf("Restore second: %v", err)
+	}
+	current, err = LoadSettings("claude", root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if current.Env["MODE"] != "user" {
+		t.Errorf("MODE = %q, want the user's value back", current.Env["MODE"])
+	}
+	if got := current.MCPServers["docs"]; got.Command != "user-docs" {
+		t.Errorf("docs = %+v, want the user's server back", got)
+	}
+	if hooks := current.GetHooks("Stop"); len(hooks) != 1 || hooks[0].Matcher != "mine" {
+		t.Errorf("Stop hooks = %#v, want only the user's", hooks)
+	}
+}
+
+func TestScopeExternalEdits(t *testing.T) {
+	root := t.TempDir()
+	scope, err := NewScope("claude", root, ScopeConfig{
+		Hooks:      map[string][]Hook{"Stop": {{Matcher: "*", Type: "command", Command: "echo stop"}}},
+		MCPServers: map[string]contract.MCPServerConfig{"docs": {Command: "docs-mcp"}},
+		Env:        map[string]string{"LLMKIT": "1", "KEEP": "1"},
+	})
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	if edits, err := scope.ExternalEdits(); err != nil || len(edits) != 0 {
+		t.Fatalf("ExternalEdits before editing = %v, %v", edits, err)
+	}
+
+	project, err := claudeconfig.LoadProjectSettings(root)
+	if err != nil {
+		t.Fatalf("load settings: %v", err)
+	}
+	project.Env["LLMKIT"] = "manual"
+	if err := claudeconfig.SaveProjectSettings(root, project); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	edits, err := scope.ExternalEdits()
+	if err != nil {
+		t.Fatalf("ExternalEdits: %v", err)
+	}
+	if want := []string{".claude/settings.json", "env.LLMKIT"}; !reflect.DeepEqual(edits, want) {
+		t.Fatalf("ExternalEdits = %v, want %v", edits, want)
+	}
+
+	if err := scope.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	project, err = claudeconfig.LoadProjectSettings(root)
+	if err != nil {
+		t.Fatalf("reload settings: %v", err)
+	}
+	if project.Env["LLMKIT"] != "manual" {
+		t.Errorf("LLMKIT = %q, want the manual edit kept", project.Env["LLMKIT"])
+	}
+	if _, ok := project.Env["KEEP"]; ok {
+		t.Error("KEEP survived restore")
+	}
+}
+
+// stressScopes opens and restores scopes in root, each with one private
+// env value plus a hook and MCP server every scope shares.
+func stressScopes(root, prefix string, rounds int) error {
+	for i := range rounds {
+		scope, err := NewScope("claude", root, ScopeConfig{
+			Hooks:      map[string][]Hook{"Stop": {{Matcher: "*", Type: "command", Command: "echo shared"}}},
+			MCPServers: map[string]contract.MCPServerConfig{"shared": {Command: "shared-mcp"}},
+			Env:        map[string]string{fmt.Sprintf("%s_%d", prefix, i): "1"},
+		})
+		if err != nil {
+			return err
+		}
+		if err := scope.Restore(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestScopeHelperProcess is run as a subprocess by TestScopeStress.
+func TestScopeHelperProcess(t *testing.T) {
+	root := os.Getenv("LLMKIT_SCOPE_STRESS_DIR")
+	if root == "" {
+		t.Skip("helper process")
+	}
+	if err := stressScopes(root, "PROC_"+strconv.Itoa(os.Getpid()), 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScopeStress(t *testing.T) {
+	root := t.TempDir()
+	settings := claudeconfig.NewSettings()
+	settings.Env["USER_VALUE"] = "1"
+	if err := claudeconfig.SaveProjectSettings(root, settings); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 24)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestScopeHelperProcess$")
+			cmd.Env = append(os.Environ(), "LLMKIT_SCOPE_STRESS_DIR="+root)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("process %d: %v\n%s", i, err, out)
+			}
+		}()
+	}
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stressScopes(root, fmt.Sprintf("G%d", i), 10); err != nil {
+				errs <- fmt.Errorf("goroutine %d: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	current, err := LoadSettings("claude", root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if want := map[string]string{"USER_VALUE": "1"}; !reflect.DeepEqual(current.Env, want) {
+		t.Errorf("env = %v, want %v", current.Env, want)
+	}
+	if len(current.Hooks) != 0 || len(current.MCPServers) != 0 {
+		t.Errorf("hooks = %v, mcp = %v, want none left", current.Hooks, current.MCPServers)
+	}
+	reg, err := loadRegistry(root)
+	if err != nil {
+		t.Fatalf("load registry: %v", err)
+	}
+	if len(reg.Scopes) != 0 || len(reg.Files) != 0 {
+		t.Errorf("registry = %+v, want empty", reg)
+	}
+}
@@ -0,0 +1,401 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+	"github.com/randalmurphal/llmkit/v2/contract"
+)
+
+// ErrNotEphemeral reports ScopeConfig features that only take effect by
+// writing to the project.
+var ErrNotEphemeral = errors.New("not supported ephemerally")
+
+// Overlay is a ScopeConfig rendered outside the project, for passing to
+// the CLI per invocation instead of editing the project's config files.
+type Overlay struct {
+	// ClaudeSettings is a settings file with the hooks and env, for
+	// claude --settings.
+	ClaudeSettings string
+	// ClaudeMCPConfig is an MCP config file for claude --mcp-config.
+	ClaudeMCPConfig string
+	// CodexHome links every entry of the user's Codex home and adds the
+	// hooks, agents, rules and instructions. The sessions, log and history
+	// entries are created in the user's home first, so threads started
+	// through the overlay can be resumed after it is gone.
+	CodexHome string
+	// CodexOverrides are codex -c key=value overrides for the MCP servers
+	// and for env as shell_environment_policy.set entries. When the user's
+	// config has a non-empty include_only, it is overridden with the env
+	// keys added so Codex does not filter them out again.
+	CodexOverrides map[string]any
+	// Env is added to the CLI process environment. It sets CODEX_HOME when
+	// CodexHome is used.
+	Env map[string]string
+}
+
+// EphemeralUnsupported lists the parts of cfg that RenderOverlay cannot
+// express for provider. Claude only reads instructions from CLAUDE.md and
+// has no home directory to layer files into; Codex files map into
+// CODEX_HOME only when they live under .codex/, so .agents/skills does not.
+func EphemeralUnsupported(provider string, cfg ScopeConfig) []string {
+	var out []string
+	if provider != "codex" && cfg.Instructions != "" {
+		out = append(out, "instructions")
+	}
+	if provider != "codex" && len(cfg.PrefixRules) > 0 {
+		out = append(out, "prefix rules")
+	}
+	for rel := range cfg.Files {
+		if _, ok := codexHomePath(provider, rel); !ok {
+			out = append(out, "file "+rel)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RenderOverlay writes cfg's hooks, MCP servers, env, files, instructions
+// and prefix rules into dir, which must be outside the project, and
+// returns how to pass them to the CLI. It never touches workDir; features
+// listed by EphemeralUnsupported fail with ErrNotEphemeral.
+func RenderOverlay(provider, dir string, cfg ScopeConfig) (*Overlay, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dir is required")
+	}
+	if _, ok := hookEvents[provider]; !ok {
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+	if unsupported := EphemeralUnsupported(provider, cfg); len(unsupported) > 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotEphemeral, provider, strings.Join(unsupported, ", "))
+	}
+	hooks, err := collectHooks(provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	tag := cfg.Tag
+	if tag == "" {
+		tag = fmt.Sprintf("llmkit-%d-%d", os.Getpid(), time.Now().UnixNano())
+	}
+
+	overlay := &Overlay{}
+	switch provider {
+	case "claude":
+		err = renderClaudeOverlay(dir, hooks, cfg, overlay)
+	case "codex":
+		err = renderCodexOverlay(dir, tag, hooks, cfg, overlay)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+func renderClaudeOverlay(dir string, hooks map[string][]Hook, cfg ScopeConfig, overlay *Overlay) error {
+	store := &claudeStore{settings: &claudeconfig.Settings{}, mcp: &claudeconfig.MCPConfig{}}
+	for event, entries := range hooks {
+		for _, hook := range entries {
+			if err := store.addHook(event, hook); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range cfg.Env {
+		if err := store.setEnv(key, value); err != nil {
+			return err
+		}
+	}
+	for name, server := range cfg.MCPServers {
+		if err := store.setMCP(name, server); err != nil {
+			return err
+		}
+	}
+
+	if len(store.settings.Hooks) > 0 || len(store.settings.Env) > 0 {
+		overlay.ClaudeSettings = filepath.Join(dir, "settings.json")
+		if err := writeJSONAtomic(overlay.ClaudeSettings, store.settings); err != nil {
+			return err
+		}
+	}
+	if len(store.mcp.MCPServers) > 0 {
+		overlay.ClaudeMCPConfig = filepath.Join(dir, "mcp.json")
+		if err := writeJSONAtomic(overlay.ClaudeMCPConfig, store.mcp); err != nil {
+			return err
+		}
+	}
+	overlay.Env = cloneStringMap(cfg.Env)
+	return nil
+}
+
+func renderCodexOverlay(dir, tag string, hooks map[string][]Hook, cfg ScopeConfig, overlay *Overlay) error {
+	overrides, err := codexMCPOverrides(cfg.MCPServers)
+	if err != nil {
+		return err
+	}
+	for key, value := range cfg.Env {
+		if key == "" || strings.ContainsAny(key, ". \"'") {
+			return fmt.Errorf("env %q: name cannot be used as a codex config key", key)
+		}
+		if overrides == nil {
+			overrides = map[string]any{}
+		}
+		overrides["shell_environment_policy.set."+key] = value
+	}
+	if len(cfg.Env) > 0 {
+		include, err := codexIncludeOnly(cfg.Env)
+		if err != nil {
+			return err
+		}
+		if include != nil {
+			overrides["shell_environment_policy.include_only"] = include
+		}
+	}
+	overlay.CodexOverrides = overrides
+	overlay.Env = cloneStringMap(cfg.Env)
+
+	userConfig, err := codexconfig.UserConfigPath()
+	if err != nil {
+		return err
+	}
+	userHome := filepath.Dir(userConfig)
+
+	// owned maps CODEX_HOME-relative slash paths to what the overlay
+	// writes there in place of the user's entry.
+	owned := map[string]string{}
+	for rel, content := range cfg.Files {
+		home, _ := codexHomePath("codex", rel)
+		if _, err := os.Lstat(filepath.Join(userHome, home)); err == nil {
+			return fmt.Errorf("scope file %s already exists in %s", rel, userHome)
+		}
+		owned[home] = content
+	}
+
+	if len(hooks) > 0 {
+		userHooks := &codexconfig.HookConfig{Hooks: map[string][]codexconfig.HookMatcher{}}
+		data, err := os.ReadFile(filepath.Join(userHome, codexconfig.FileHooksJSON))
+		if err == nil {
+			if err := json.Unmarshal(data, userHooks); err != nil {
+				return fmt.Errorf("parse user hooks: %w", err)
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		store := &codexStore{config: &codexconfig.ConfigFile{}, hooks: userHooks}
+		for event, entries := range hooks {
+			for _, hook := range entries {
+				if err := store.addHook(event, hook); err != nil {
+					return err
+				}
+			}
+		}
+		data, err = json.MarshalIndent(store.hooks, "", "  ")
+		if err != nil {
+			return err
+		}
+		owned[codexconfig.FileHooksJSON] = string(data) + "\n"
+	}
+
+	if cfg.Instructions != "" {
+		name := codexconfig.FileAgentsMD
+		override, err := os.ReadFile(filepath.Join(userHome, codexconfig.FileAgentsOverride))
+		if err == nil && strings.TrimSpace(string(override)) != "" {
+			name = codexconfig.FileAgentsOverride
+		}
+		data, err := os.ReadFile(filepath.Join(userHome, name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		owned[name] = appendInstructions(string(data), tag, cfg.Instructions)
+	}
+
+	if len(cfg.PrefixRules) > 0 {
+		rel := codexconfig.DirRules + "/" + RulesFile
+		file, err := codexconfig.LoadRuleFile(filepath.Join(userHome, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(cfg.PrefixRules))
+		for id := range cfg.PrefixRules {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			codexconfig.UpsertManagedPrefixRule(file, managedRuleID(tag, id), cfg.PrefixRules[id])
+		}
+		owned[rel] = file.Content
+	}
+
+	if len(owned) == 0 {
+		return nil
+	}
+	home := filepath.Join(dir, "codex-home")
+	paths := make([]string, 0, len(owned))
+	for rel := range owned {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	if err := ensureCodexState(userHome); err != nil {
+		return fmt.Errorf("prepare codex home: %w", err)
+	}
+	if err := layerDir(userHome, home, paths); err != nil {
+		return fmt.Errorf("link codex home: %w", err)
+	}
+	for _, rel := range paths {
+		if err := writeFileAtomic(filepath.Join(home, filepath.FromSlash(rel)), []byte(owned[rel])); err != nil {
+			return err
+		}
+	}
+	overlay.CodexHome = home
+	if overlay.Env == nil {
+		overlay.Env = map[string]string{}
+	}
+	overlay.Env["CODEX_HOME"] = home
+	return nil
+}
+
+// codexIncludeOnly returns the user's shell_environment_policy.include_only
+// extended with the env keys it would drop, or nil when it is empty or
+// already admits them all. It mirrors what a scope does to the project's
+// include_only.
+func codexIncludeOnly(env map[string]string) ([]string, error) {
+	user, err := codexconfig.LoadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	policy := user.ShellEnvironmentPolicy
+	if len(policy.IncludeOnly) == 0 {
+		return nil, nil
+	}
+	include := slices.Clone(policy.IncludeOnly)
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		if !policy.Includes(key) {
+			include = append(include, key)
+		}
+	}
+	if len(include) == len(policy.IncludeOnly) {
+		return nil, nil
+	}
+	return include, nil
+}
+
+// ensureCodexState creates the entries codex records session state in when
+// the user's home lacks them, so the overlay links them instead of
+// creating them in a directory that is deleted with it.
+func ensureCodexState(userHome string) error {
+	for _, name := range []string{codexconfig.DirSessions, codexconfig.DirLog} {
+		if err := os.MkdirAll(filepath.Join(userHome, name), 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(filepath.Join(userHome, codexconfig.FileHistoryJSONL), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// codexHomePath maps a project-relative scope file under .codex/ to its
+// CODEX_HOME-relative slash path. The files the overlay itself manages
+// cannot be replaced.
+func codexHomePath(provider, rel string) (string, bool) {
+	if provider != "codex" || validateScopePath(rel) != nil {
+		return "", false
+	}
+	home, ok := strings.CutPrefix(filepath.ToSlash(filepath.Clean(rel)), codexconfig.DirCodex+"/")
+	if !ok {
+		return "", false
+	}
+	switch home {
+	case codexconfig.FileConfigTOML, codexconfig.FileHooksJSON, codexconfig.DirRules + "/" + RulesFile:
+		return "", false
+	}
+	return home, true
+}
+
+// layerDir makes dst mirror src with symlinks, except along the slash
+// paths in owned, which become real directories so the owned files can
+// be written without touching src.
+func layerDir(src, dst string, owned []string) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	children := map[string][]string{}
+	for _, rel := range owned {
+		head, rest, _ := strings.Cut(rel, "/")
+		children[head] = append(children[head], rest)
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		sub, ok := children[name]
+		if !ok {
+			if err := os.Symlink(filepath.Join(src, name), filepath.Join(dst, name)); err != nil {
+				return err
+			}
+			continue
+		}
+		if slices.Contains(sub, "") {
+			continue
+		}
+		if err := layerDir(filepath.Join(src, name), filepath.Join(dst, name), sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// codexMCPOverrides flattens servers into leaf -c overrides, so each value
+// is a plain TOML string, array or bool.
+func codexMCPOverrides(servers map[string]contract.MCPServerConfig) (map[string]any, error) {
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	out := map[string]any{}
+	for name, server := range servers {
+		if name == "" || strings.ContainsAny(name, ". \"'") {
+			return nil, fmt.Errorf("mcp server %q: name cannot be used as a codex config key", name)
+		}
+		prefix := "mcp_servers." + name + "."
+		if server.Type != "" {
+			out[prefix+"type"] = server.Type
+		}
+		if server.Command != "" {
+			out[prefix+"command"] = server.Command
+		}
+		if len(server.Args) > 0 {
+			out[prefix+"args"] = append([]string(nil), server.Args...)
+		}
+		if server.URL != "" {
+			out[prefix+"url"] = server.URL
+		}
+		if server.Disabled {
+			out[prefix+"disabled"] = true
+		}
+		for key, value := range server.Env {
+			out[prefix+"env."+key] = value
+		}
+		for key, value := range server.Headers {
+			out[prefix+"headers."+key] = value
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,200 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+	"github.com/randalmurphal/llmkit/v2/contract"
+)
+
+// snapshotTree maps every path under root to its content, or its link
+// target for symlinks.
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			out[rel] = "-> " + target
+			return err
+		case d.IsDir():
+			out[rel] = "dir"
+		default:
+			data, err := os.ReadFile(path)
+			out[rel] = string(data)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+	return out
+}
+
+func TestRenderOverlayClaude(t *testing.T) {
+	dir := t.TempDir()
+	overlay, err := RenderOverlay("claude", dir, ScopeConfig{
+		NeutralHooks: []contract.HookDefinition{{Event: contract.HookBeforeTool, Tools: []string{contract.ToolShell}, Command: "guard"}},
+		MCPServers:   map[string]contract.MCPServerConfig{"docs": {Command: "docs-mcp", Args: []string{"--stdio"}}},
+		Env:          map[string]string{"FOO": "bar"},
+	})
+	if err != nil {
+		t.Fatalf("RenderOverlay: %v", err)
+	}
+
+	var settings claudeconfig.Settings
+	if err := json.Unmarshal([]byte(readFile(t, overlay.ClaudeSettings)), &settings); err != nil {
+		t.Fatalf("parse settings: %v", err)
+	}
+	pre := settings.Hooks["PreToolUse"]
+	if len(pre) != 1 || pre[0].Matcher != "Bash" || pre[0].Hooks[0].Command != "guard" {
+		t.Fatalf("PreToolUse = %#v", pre)
+	}
+	if settings.Env["FOO"] != "bar" || overlay.Env["FOO"] != "bar" {
+		t.Fatalf("env = %v, overlay env = %v", settings.Env, overlay.Env)
+	}
+	if mcp := readFile(t, overlay.ClaudeMCPConfig); !strings.Contains(mcp, `"docs-mcp"`) || !strings.Contains(mcp, `"mcpServers"`) {
+		t.Fatalf("mcp config = %s", mcp)
+	}
+	if overlay.CodexHome != "" || overlay.CodexOverrides != nil {
+		t.Fatalf("claude overlay has codex settings: %+v", overlay)
+	}
+}
+
+func TestRenderOverlayCodex(t *testing.T) {
+	userHome := t.TempDir()
+	t.Setenv("CODEX_HOME", userHome)
+	for rel, content := range map[string]string{
+		"config.toml":         "model = \"gpt-5\"\n",
+		"auth.json":           "{}\n",
+		"hooks.json":          `{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"mine"}]}]}}`,
+		"AGENTS.md":           "# Global\n",
+		"agents/mine.toml":    "name = \"mine\"\n",
+		"rules/default.rules": "prefix_rule(pattern = [\"ls\"], decision = \"allow\")\n",
+	} {
+		path := filepath.Join(userHome, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	before := snapshotTree(t, userHome)
+
+	overlay, err := RenderOverlay("codex", t.TempDir(), ScopeConfig{
+		Tag:          "t1",
+		NeutralHooks: []contract.HookDefinition{{Event: contract.HookBeforeTool, Tools: []string{contract.ToolShell}, Command: "guard"}},
+		MCPServers:   map[string]contract.MCPServerConfig{"docs": {Command: "docs-mcp", Args: []string{"--stdio"}, Env: map[string]string{"TOKEN": "x"}}},
+		Env:          map[string]string{"FOO": "bar"},
+		Files:        map[string]string{".codex/agents/explorer.toml": "name = \"explorer\"\n"},
+		Instructions: "Run make test.",
+		PrefixRules: map[string]codexconfig.PrefixRule{
+			"no-push": {Pattern: codexconfig.LiteralPattern("git", "push"), Decision: codexconfig.RuleForbidden},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RenderOverlay: %v", err)
+	}
+
+	home := overlay.CodexHome
+	if home == "" || overlay.Env["CODEX_HOME"] != home || overlay.Env["FOO"] != "bar" {
+		t.Fatalf("overlay = %+v", overlay)
+	}
+	wantOverrides := map[string]any{
+		"mcp_servers.docs.command":         "docs-mcp",
+		"mcp_servers.docs.args":            []string{"--stdio"},
+		"mcp_servers.docs.env.TOKEN":       "x",
+		"shell_environment_policy.set.FOO": "bar",
+	}
+	if !reflect.DeepEqual(overlay.CodexOverrides, wantOverrides) {
+		t.Fatalf("CodexOverrides = %#v, want %#v", overlay.CodexOverrides, wantOverrides)
+	}
+	for _, rel := range []string{"config.toml", "auth.json", "agents/mine.toml", "rules/default.rules", "sessions", "log", "history.jsonl"} {
+		target, err := os.Readlink(filepath.Join(home, rel))
+		if err != nil || target != filepath.Join(userHome, rel) {
+			t.Errorf("%s links to %q, %v", rel, target, err)
+		}
+	}
+	hooks := readFile(t, filepath.Join(home, "hooks.json"))
+	if !strings.Contains(hooks, `"mine"`) || !strings.Contains(hooks, `"guard"`) || !strings.Contains(hooks, `"shell"`) {
+		t.Errorf("hooks.json = %s", hooks)
+	}
+	if got := readFile(t, filepath.Join(home, "agents", "explorer.toml")); got != "name = \"explorer\"\n" {
+		t.Errorf("explorer agent = %q", got)
+	}
+	if rules := readFile(t, filepath.Join(home, "rules", RulesFile)); !strings.Contains(rules, "llmkit:t1/no-push") {
+		t.Errorf("rules = %s", rules)
+	}
+	if agents := readFile(t, filepath.Join(home, "AGENTS.md")); !strings.HasPrefix(agents, "# Global\n") || !strings.Contains(agents, "Run make test.") {
+		t.Errorf("AGENTS.md = %q", agents)
+	}
+	// Session state goes to the user's home so it outlives the overlay.
+	before["sessions"], before["log"], before["history.jsonl"] = "dir", "dir", ""
+	if after := snapshotTree(t, userHome); !reflect.DeepEqual(after, before) {
+		t.Errorf("user codex home changed:\nbefore %v\nafter  %v", before, after)
+	}
+
+	if _, err := RenderOverlay("codex", t.TempDir(), ScopeConfig{Files: map[string]string{".codex/agents/mine.toml": "x"}}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("overwriting a user agent: err = %v", err)
+	}
+}
+
+func TestRenderOverlayCodexExtendsIncludeOnly(t *testing.T) {
+	userHome := t.TempDir()
+	t.Setenv("CODEX_HOME", userHome)
+	config := "[shell_environment_policy]\ninclude_only = [\"PATH\", \"HOME\"]\n"
+	if err := os.WriteFile(filepath.Join(userHome, "config.toml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := RenderOverlay("codex", t.TempDir(), ScopeConfig{Env: map[string]string{"FOO": "bar", "PATH": "/bin"}})
+	if err != nil {
+		t.Fatalf("RenderOverlay: %v", err)
+	}
+	want := []string{"PATH", "HOME", "FOO"}
+	if got := overlay.CodexOverrides["shell_environment_policy.include_only"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("include_only override = %#v, want %#v", got, want)
+	}
+
+	overlay, err = RenderOverlay("codex", t.TempDir(), ScopeConfig{Env: map[string]string{"HOME": "/tmp"}})
+	if err != nil {
+		t.Fatalf("RenderOverlay: %v", err)
+	}
+	if _, ok := overlay.CodexOverrides["shell_environment_policy.include_only"]; ok {
+		t.Fatalf("CodexOverrides = %#v, want include_only left alone", overlay.CodexOverrides)
+	}
+}
+
+func TestRenderOverlayUnsupported(t *testing.T) {
+	cfg := ScopeConfig{
+		Files:        map[string]string{".agents/skills/review/SKILL.md": "x", ".codex/config.toml": "x"},
+		Instructions: "Be brief.",
+	}
+	if got, want := EphemeralUnsupported("codex", cfg), []string{"file .agents/skills/review/SKILL.md", "file .codex/config.toml"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("EphemeralUnsupported(codex) = %v, want %v", got, want)
+	}
+	if got := EphemeralUnsupported("claude", ScopeConfig{Instructions: "Be brief."}); !reflect.DeepEqual(got, []string{"instructions"}) {
+		t.Fatalf("EphemeralUnsupported(claude) = %v", got)
+	}
+	if _, err := RenderOverlay("codex", t.TempDir(), cfg); !errors.Is(err, ErrNotEphemeral) {
+		t.Fatalf("RenderOverlay err = %v, want ErrNotEphemeral", err)
+	}
+	if _, err := RenderOverlay("codex", t.TempDir(), ScopeConfig{MCPServers: map[string]contract.MCPServerConfig{"a.b": {Command: "x"}}}); err == nil {
+		t.Fatal("RenderOverlay accepted an MCP server name with a dot")
+	}
+}
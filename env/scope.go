@@ -3,26 +3,58 @@ package env
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
 	"github.com/randalmurphal/llmkit/v2/contract"
 )
 
 // ScopeConfig controls temporary provider-local mutations for a project.
+// Hooks use the provider's own event names; NeutralHooks are translated
+// with TranslateHooks and applied alongside them.
+//
+// Files maps project-relative paths to content. They must not exist yet,
+// and are removed on restore unless edited since. Instructions is added as
+// a marked section of the provider's instructions file (CLAUDE.md, or
+// AGENTS.md for Codex), and PrefixRules are managed Codex prefix rules in
+// .codex/rules/llmkit.rules keyed by ID.
+//
+// Env goes into Claude's settings env block, or Codex's
+// shell_environment_policy.set in .codex/config.toml, where it reaches the
+// commands Codex runs but not the codex process; pass the same values with
+// the client's WithEnv for that. For those commands the config value wins
+// over the process environment. A key a non-empty include_only would drop
+// is added to it for the life of the scope.
 type ScopeConfig struct {
 	Hooks          map[string][]Hook                  `json:"hooks,omitempty"`
+	NeutralHooks   []contract.HookDefinition          `json:"neutral_hooks,omitempty"`
 	MCPServers     map[string]contract.MCPServerConfig `json:"mcp_servers,omitempty"`
 	Env            map[string]string                  `json:"env,omitempty"`
 	Tag            string                             `json:"tag,omitempty"`
+	Files          map[string]string                  `json:"files,omitempty"`
+	Instructions   string                             `json:"instructions,omitempty"`
+	PrefixRules    map[string]codexconfig.PrefixRule  `json:"prefix_rules,omitempty"`
 	RecoverOrphans bool                               `json:"recover_orphans,omitempty"`
 	BackupSettings bool                               `json:"backup_settings,omitempty"`
 }
 
 // Scope tracks llmkit-owned project-local environment changes.
+//
+// Scopes in one workDir coordinate through an advisory lock on
+// .llmkit/env-scopes.lock, whether they live in one process or several.
+// A hook, MCP server or env value that another active scope already added
+// identically is shared rather than added twice, and stays until the last
+// scope holding it is restored. Restore merges with the files as they are
+// then: entries edited outside llmkit keep the edited value, and values a
+// scope replaced are put back only if the scope's own value is still there.
 type Scope struct {
 	provider string
 	workDir  string
@@ -34,17 +66,28 @@ type Scope struct {
 
 type scopeRegistry struct {
 	Scopes map[string]scopeRecord `json:"scopes"`
+	// Files maps workDir-relative settings files to the SHA-256 of what
+	// llmkit last wrote there, so later edits by anything else show up.
+	Files map[string]string `json:"files,omitempty"`
 }
 
 type scopeRecord struct {
-	Tag        string                            `json:"tag"`
-	Provider   string                            `json:"provider"`
-	WorkDir    string                            `json:"work_dir"`
-	PID        int                               `json:"pid"`
-	CreatedAt  time.Time                         `json:"created_at"`
-	Hooks      map[string][]Hook                 `json:"hooks,omitempty"`
+	Tag        string                              `json:"tag"`
+	Provider   string                              `json:"provider"`
+	WorkDir    string                              `json:"work_dir"`
+	PID        int                                 `json:"pid"`
+	CreatedAt  time.Time                           `json:"created_at"`
+	Hooks      map[string][]Hook                   `json:"hooks,omitempty"`
 	MCPServers map[string]contract.MCPServerConfig `json:"mcp_servers,omitempty"`
-	Env        map[string]string                 `json:"env,omitempty"`
+	Env        map[string]string                   `json:"env,omitempty"`
+	// PriorMCP and PriorEnv hold the values MCPServers and Env replaced,
+	// to put back on restore.
+	PriorMCP map[string]contract.MCPServerConfig `json:"prior_mcp_servers,omitempty"`
+	PriorEnv map[string]string                   `json:"prior_env,omitempty"`
+	// EnvIncludes are the Env keys the scope added to Codex's
+	// shell_environment_policy.include_only.
+	EnvIncludes []string     `json:"env_includes,omitempty"`
+	Assets      *assetRecord `json:"assets,omitempty"`
 }
 
 // NewScope applies the requested project-local mutations and records them for later cleanup.
@@ -56,8 +99,26 @@ func NewScope(provider, workDir string, cfg ScopeConfig) (*Scope, error) {
 		return nil, fmt.Errorf("workDir is required")
 	}
 
+	hooks, err := collectHooks(provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.PrefixRules) > 0 && provider != "codex" {
+		return nil, fmt.Errorf("capability not supported: %s prefix rules", provider)
+	}
+
+	unlock, err := lockProject(workDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reg, err := loadRegistry(workDir)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.RecoverOrphans {
-		if err := recoverOrphanedScopes(provider, workDir); err != nil {
+		if err := recoverOrphanedScopes(provider, workDir, reg); err != nil {
 			return nil, err
 		}
 	}
@@ -73,44 +134,40 @@ func NewScope(provider, workDir string, cfg ScopeConfig) (*Scope, error) {
 	}
 
 	record := scopeRecord{
-		Tag:        cfg.Tag,
-		Provider:   provider,
-		WorkDir:    workDir,
-		PID:        os.Getpid(),
-		CreatedAt:  time.Now().UTC(),
-		Hooks:      cloneHookMap(cfg.Hooks),
-		MCPServers: cloneMCPServerMap(cfg.MCPServers),
-		Env:        cloneStringMap(cfg.Env),
+		Tag:       cfg.Tag,
+		Provider:  provider,
+		WorkDir:   workDir,
+		PID:       os.Getpid(),
+		CreatedAt: time.Now().UTC(),
 	}
 	if record.Tag == "" {
-		record.Tag = fmt.Sprintf("llmkit-%d-%d", record.PID, time.Now().UnixNano())
-	}
-
-	for event, hooks := range record.Hooks {
-		for _, hook := range hooks {
-			if err := store.addHook(event, hook); err != nil {
-				return nil, err
-			}
-		}
-	}
-	for name, server := range record.MCPServers {
-		if err := store.setMCP(name, server); err != nil {
-			return nil, err
+		for record.Tag == "" || reg.Scopes[record.Tag].Tag != "" {
+			record.Tag = fmt.Sprintf("llmkit-%d-%d", record.PID, time.Now().UnixNano())
 		}
+	} else if _, ok := reg.Scopes[record.Tag]; ok {
+		return nil, fmt.Errorf("scope %s is already active in %s", record.Tag, workDir)
 	}
-	for key, value := range record.Env {
-		if err := store.setEnv(key, value); err != nil {
-			return nil, err
-		}
+
+	if err := applyEntries(store, reg, &record, hooks, cfg); err != nil {
+		return nil, err
 	}
 	if err := store.save(); err != nil {
 		return nil, err
 	}
+	noteWrites(workDir, reg, store.paths())
 
-	reg, err := loadRegistry(workDir)
-	if err != nil {
+	assets := &assetRecord{}
+	if err := applyAssets(provider, workDir, record.Tag, cfg, assets); err != nil {
+		record.Assets = assets
+		if restoreErr := restoreRecord(workDir, reg, record); restoreErr == nil {
+			_ = saveRegistry(workDir, reg)
+		}
 		return nil, err
 	}
+	if !assets.empty() {
+		record.Assets = assets
+	}
+
 	reg.Scopes[record.Tag] = record
 	if err := saveRegistry(workDir, reg); err != nil {
 		return nil, err
@@ -123,6 +180,89 @@ func NewScope(provider, workDir string, cfg ScopeConfig) (*Scope, error) {
 	}, nil
 }
 
+// applyEntries adds the hooks, MCP servers and env values to store and
+// records in record the ones the scope now holds. Entries already present
+// with the same value are shared when another scope in reg holds them and
+// left to the user otherwise.
+func applyEntries(store projectStore, reg *scopeRegistry, record *scopeRecord, hooks map[string][]Hook, cfg ScopeConfig) error {
+	for event, entries := range hooks {
+		for _, hook := range entries {
+			if store.hasHook(event, hook) {
+				if !reg.holdsHook(record.Provider, event, hook) {
+					continue
+				}
+			} else if err := store.addHook(event, hook); err != nil {
+				return err
+			}
+			if record.Hooks == nil {
+				record.Hooks = map[string][]Hook{}
+			}
+			record.Hooks[event] = append(record.Hooks[event], hook)
+		}
+	}
+
+	for name, server := range cfg.MCPServers {
+		current, exists := store.getMCP(name)
+		owner, held := reg.mcpHolder(record.Provider, name)
+		switch {
+		case exists && mcpEqual(current, server):
+			if !held {
+				continue
+			}
+			if prior, ok := owner.PriorMCP[name]; ok {
+				setPriorMCP(record, name, prior)
+			}
+		case held:
+			return fmt.Errorf("mcp server %s is held by scope %s with a different config", name, owner.Tag)
+		default:
+			if exists {
+				setPriorMCP(record, name, current)
+			}
+			if err := store.setMCP(name, server); err != nil {
+				return err
+			}
+		}
+		if record.MCPServers == nil {
+			record.MCPServers = map[string]contract.MCPServerConfig{}
+		}
+		record.MCPServers[name] = cloneMCPServer(server)
+	}
+
+	for key, value := range cfg.Env {
+		current, exists := store.getEnv(key)
+		owner, held := reg.envHolder(record.Provider, key)
+		switch {
+		case exists && current == value:
+			if !held {
+				continue
+			}
+			if prior, ok := owner.PriorEnv[key]; ok {
+				setPriorEnv(record, key, prior)
+			}
+			if slices.Contains(owner.EnvIncludes, key) {
+				record.EnvIncludes = append(record.EnvIncludes, key)
+			}
+		case held:
+			return fmt.Errorf("env %s is held by scope %s with a different value", key, owner.Tag)
+		default:
+			if err := store.setEnv(key, value); err != nil {
+				return err
+			}
+			if exists {
+				setPriorEnv(record, key, current)
+			}
+			if store.includeEnv(key) {
+				record.EnvIncludes = append(record.EnvIncludes, key)
+			}
+		}
+		if record.Env == nil {
+			record.Env = map[string]string{}
+		}
+		record.Env[key] = value
+	}
+	return nil
+}
+
 // Restore removes the exact llmkit-owned mutations tracked by the scope.
 func (s *Scope) Restore() error {
 	if s == nil {
@@ -137,16 +277,21 @@ func (s *Scope) Restore() error {
 	s.restored = true
 	s.mu.Unlock()
 
-	if err := restoreRecord(s.workDir, s.record); err != nil {
+	unlock, err := lockProject(s.workDir)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	reg, err := loadRegistry(s.workDir)
-	if err == nil {
-		delete(reg.Scopes, s.record.Tag)
-		_ = saveRegistry(s.workDir, reg)
+	if err != nil {
+		return err
 	}
-	return nil
+	delete(reg.Scopes, s.record.Tag)
+	if err := restoreRecord(s.workDir, reg, s.record); err != nil {
+		return err
+	}
+	return saveRegistry(s.workDir, reg)
 }
 
 // Close is an io.Closer alias for Restore.
@@ -154,7 +299,77 @@ func (s *Scope) Close() error {
 	return s.Restore()
 }
 
-func restoreRecord(workDir string, record scopeRecord) error {
+// ExternalEdits reports what changed outside llmkit since the scope was
+// created: the scope's hooks ("hook <event> <matcher>"), MCP servers
+// ("mcp_servers.<name>") and env values ("env.<KEY>") that no longer hold
+// its value, and the workDir-relative settings and scope files whose
+// content differs from what llmkit last wrote. Restore keeps those edits.
+func (s *Scope) ExternalEdits() ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	unlock, err := lockProject(s.workDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reg, err := loadRegistry(s.workDir)
+	if err != nil {
+		return nil, err
+	}
+	store, err := openProjectStore(s.provider, s.workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for event, hooks := range s.record.Hooks {
+		for _, hook := range hooks {
+			if !store.hasHook(event, hook) {
+				out = append(out, "hook "+event+" "+hook.Matcher)
+			}
+		}
+	}
+	for name, server := range s.record.MCPServers {
+		if current, ok := store.getMCP(name); !ok || !mcpEqual(current, server) {
+			out = append(out, "mcp_servers."+name)
+		}
+	}
+	for key, value := range s.record.Env {
+		if current, ok := store.getEnv(key); !ok || current != value {
+			out = append(out, "env."+key)
+		}
+	}
+
+	files := map[string]string{}
+	for _, path := range store.paths() {
+		if rel, err := filepath.Rel(s.workDir, path); err == nil {
+			if sum, ok := reg.Files[rel]; ok {
+				files[rel] = sum
+			}
+		}
+	}
+	if s.record.Assets != nil {
+		maps.Copy(files, s.record.Assets.Files)
+	}
+	for rel, sum := range files {
+		data, err := os.ReadFile(filepath.Join(s.workDir, rel))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err != nil || contentHash(data) != sum {
+			out = append(out, rel)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// restoreRecord undoes record against the current files. reg holds the
+// other active scopes; entries they still hold stay. The caller holds the
+// project lock.
+func restoreRecord(workDir string, reg *scopeRegistry, record scopeRecord) error {
 	store, err := openProjectStore(record.Provider, workDir)
 	if err != nil {
 		return err
@@ -162,23 +377,58 @@ func restoreRecord(workDir string, record scopeRecord) error {
 
 	for event, hooks := range record.Hooks {
 		for _, hook := range hooks {
-			store.removeHookIfMatches(event, hook)
+			if !reg.holdsHook(record.Provider, event, hook) {
+				store.removeHookIfMatches(event, hook)
+			}
 		}
 	}
 	for name, server := range record.MCPServers {
-		store.removeMCPIfMatches(name, server)
+		if owner, held := reg.mcpHolder(record.Provider, name); held && mcpEqual(owner.MCPServers[name], server) {
+			continue
+		}
+		current, ok := store.getMCP(name)
+		if !ok || !mcpEqual(current, server) {
+			continue
+		}
+		if prior, ok := record.PriorMCP[name]; ok {
+			if err := store.setMCP(name, prior); err != nil {
+				return err
+			}
+		} else {
+			store.removeMCP(name)
+		}
 	}
 	for key, value := range record.Env {
-		store.removeEnvIfMatches(key, value)
+		if owner, held := reg.envHolder(record.Provider, key); held && owner.Env[key] == value {
+			continue
+		}
+		if current, ok := store.getEnv(key); !ok || current != value {
+			continue
+		}
+		if prior, ok := record.PriorEnv[key]; ok {
+			if err := store.setEnv(key, prior); err != nil {
+				return err
+			}
+		} else {
+			store.removeEnvIfMatches(key, value)
+		}
+		if slices.Contains(record.EnvIncludes, key) {
+			store.excludeEnv(key)
+		}
 	}
-	return store.save()
-}
-
-func recoverOrphanedScopes(provider, workDir string) error {
-	reg, err := loadRegistry(workDir)
-	if err != nil {
+	if err := store.save(); err != nil {
 		return err
 	}
+	noteWrites(workDir, reg, store.paths())
+	if record.Assets != nil {
+		return restoreAssets(workDir, record.Tag, *record.Assets)
+	}
+	return nil
+}
+
+// recoverOrphanedScopes restores provider's scopes in reg whose process is
+// gone, removing them from reg. The caller holds the project lock.
+func recoverOrphanedScopes(provider, workDir string, reg *scopeRegistry) error {
 	changed := false
 	for tag, record := range reg.Scopes {
 		if record.Provider != provider {
@@ -187,10 +437,11 @@ func recoverOrphanedScopes(provider, workDir string) error {
 		if processExists(record.PID) {
 			continue
 		}
-		if err := restoreRecord(workDir, record); err != nil {
+		delete(reg.Scopes, tag)
+		if err := restoreRecord(workDir, reg, record); err != nil {
+			reg.Scopes[tag] = record
 			return err
 		}
-		delete(reg.Scopes, tag)
 		changed = true
 	}
 	if changed {
@@ -199,6 +450,78 @@ func recoverOrphanedScopes(provider, workDir string) error {
 	return nil
 }
 
+func (r *scopeRegistry) holdsHook(provider, event string, hook Hook) bool {
+	for _, record := range r.Scopes {
+		if record.Provider == provider && slices.ContainsFunc(record.Hooks[event], func(h Hook) bool { return hookEqual(h, hook) }) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *scopeRegistry) mcpHolder(provider, name string) (scopeRecord, bool) {
+	for _, record := range r.Scopes {
+		if _, ok := record.MCPServers[name]; ok && record.Provider == provider {
+			return record, true
+		}
+	}
+	return scopeRecord{}, false
+}
+
+func (r *scopeRegistry) envHolder(provider, key string) (scopeRecord, bool) {
+	for _, record := range r.Scopes {
+		if _, ok := record.Env[key]; ok && record.Provider == provider {
+			return record, true
+		}
+	}
+	return scopeRecord{}, false
+}
+
+// noteWrites records the current content hash of the settings files llmkit
+// just wrote.
+func noteWrites(workDir string, reg *scopeRegistry, paths []string) {
+	if reg.Files == nil {
+		reg.Files = map[string]string{}
+	}
+	for _, path := range paths {
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			delete(reg.Files, rel)
+			continue
+		}
+		reg.Files[rel] = contentHash(data)
+	}
+}
+
+func setPriorMCP(record *scopeRecord, name string, server contract.MCPServerConfig) {
+	if record.PriorMCP == nil {
+		record.PriorMCP = map[string]contract.MCPServerConfig{}
+	}
+	record.PriorMCP[name] = cloneMCPServer(server)
+}
+
+func setPriorEnv(record *scopeRecord, key, value string) {
+	if record.PriorEnv == nil {
+		record.PriorEnv = map[string]string{}
+	}
+	record.PriorEnv[key] = value
+}
+
+func hookEqual(a, b Hook) bool {
+	a.Headers, b.Headers = cloneStringMap(a.Headers), cloneStringMap(b.Headers)
+	return reflect.DeepEqual(a, b)
+}
+
+// mcpEqual compares through cloneMCPServer, which drops the empty versus
+// nil distinction the config files do not preserve.
+func mcpEqual(a, b contract.MCPServerConfig) bool {
+	return reflect.DeepEqual(cloneMCPServer(a), cloneMCPServer(b))
+}
+
 func processExists(pid int) bool {
 	if pid <= 0 {
 		return false
@@ -232,6 +555,10 @@ func saveRegistry(workDir string, reg *scopeRegistry) error {
 	if reg.Scopes == nil {
 		reg.Scopes = map[string]scopeRecord{}
 	}
+	// Without active scopes there is nothing to compare file hashes against.
+	if len(reg.Scopes) == 0 {
+		reg.Files = nil
+	}
 	return writeJSONAtomic(registryPath(workDir), reg)
 }
 
@@ -258,6 +585,22 @@ func backupPaths(paths []string) error {
 	return nil
 }
 
+// collectHooks returns cfg's provider hooks plus its translated neutral hooks.
+func collectHooks(provider string, cfg ScopeConfig) (map[string][]Hook, error) {
+	neutral, err := TranslateHooks(provider, cfg.NeutralHooks)
+	if err != nil {
+		return nil, err
+	}
+	hooks := cloneHookMap(cfg.Hooks)
+	for event, entries := range neutral {
+		if hooks == nil {
+			hooks = map[string][]Hook{}
+		}
+		hooks[event] = append(hooks[event], entries...)
+	}
+	return hooks, nil
+}
+
 func cloneHookMap(in map[string][]Hook) map[string][]Hook {
 	if len(in) == 0 {
 		return nil
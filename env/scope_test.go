@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -284,3 +285,53 @@ func TestBackupSettings(t *testing.T) {
 		}
 	}
 }
+
+func TestCodexScopeEnv(t *testing.T) {
+	root := t.TempDir()
+	configPath := codexconfig.ProjectConfigPath(root)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	original := "[shell_environment_policy]\ninclude_only = [\"PATH\", \"CI_*\"]\n\n[shell_environment_policy.set]\nMODE = \"user\"\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	scope, err := NewScope("codex", root, ScopeConfig{
+		Env: map[string]string{"MODE": "scoped", "CI_JOB": "1", "LLMKIT": "1"},
+	})
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	cfg, err := codexconfig.LoadProjectConfig(root)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	policy := cfg.ShellEnvironmentPolicy
+	if want := map[string]string{"MODE": "scoped", "CI_JOB": "1", "LLMKIT": "1"}; !reflect.DeepEqual(policy.Set, want) {
+		t.Fatalf("set = %v, want %v", policy.Set, want)
+	}
+	for _, key := range []string{"MODE", "CI_JOB", "LLMKIT"} {
+		if !policy.Includes(key) {
+			t.Errorf("%s filtered out by include_only %v", key, policy.IncludeOnly)
+		}
+	}
+	if settings, err := LoadSettings("codex", root); err != nil || settings.Env["LLMKIT"] != "1" {
+		t.Fatalf("LoadSettings env = %v, %v", settings, err)
+	}
+
+	if err := scope.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	cfg, err = codexconfig.LoadProjectConfig(root)
+	if err != nil {
+		t.Fatalf("reload config: %v", err)
+	}
+	policy = cfg.ShellEnvironmentPolicy
+	if want := map[string]string{"MODE": "user"}; !reflect.DeepEqual(policy.Set, want) {
+		t.Errorf("set after restore = %v, want %v", policy.Set, want)
+	}
+	if want := []string{"PATH", "CI_*"}; !reflect.DeepEqual(policy.IncludeOnly, want) {
+		t.Errorf("include_only after restore = %v, want %v", policy.IncludeOnly, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 
 	"github.com/BurntSushi/toml"
 	"github.com/randalmurphal/llmkit/v2/claudeconfig"
@@ -130,6 +131,11 @@ func LoadSettings(provider, workDir string) (*Settings, error) {
 
 // SaveSettings replaces the provider-local project settings represented by the shared view.
 func SaveSettings(provider, workDir string, settings *Settings) error {
+	unlock, err := lockProject(workDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	store, err := openProjectStore(provider, workDir)
 	if err != nil {
 		return err
@@ -137,17 +143,33 @@ func SaveSettings(provider, workDir string, settings *Settings) error {
 	if err := store.replace(settings); err != nil {
 		return err
 	}
-	return store.save()
+	if err := store.save(); err != nil {
+		return err
+	}
+	reg, err := loadRegistry(workDir)
+	if err != nil || len(reg.Scopes) == 0 {
+		return err
+	}
+	noteWrites(workDir, reg, store.paths())
+	return saveRegistry(workDir, reg)
 }
 
 type projectStore interface {
 	snapshot() *Settings
 	replace(*Settings) error
+	hasHook(event string, hook Hook) bool
 	addHook(event string, hook Hook) error
 	removeHookIfMatches(event string, hook Hook) bool
+	getEnv(key string) (string, bool)
 	setEnv(key, value string) error
 	removeEnvIfMatches(key, value string) bool
+	// includeEnv makes key visible where the provider filters env
+	// variables, reporting whether that took a change; excludeEnv undoes it.
+	includeEnv(key string) bool
+	excludeEnv(key string)
+	getMCP(name string) (contract.MCPServerConfig, bool)
 	setMCP(name string, cfg contract.MCPServerConfig) error
+	removeMCP(name string)
 	removeMCPIfMatches(name string, cfg contract.MCPServerConfig) bool
 	save() error
 	paths() []string
@@ -225,6 +247,21 @@ func (s *claudeStore) replace(settings *Settings) error {
 	return nil
 }
 
+func (s *claudeStore) hasHook(event string, hook Hook) bool {
+	entry := claudeHookEntry(hook)
+	for _, group := range s.settings.Hooks[event] {
+		if group.Matcher != hook.Matcher {
+			continue
+		}
+		for _, existing := range group.Hooks {
+			if reflect.DeepEqual(existing, entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *claudeStore) addHook(event string, hook Hook) error {
 	if s.settings.Hooks == nil {
 		s.settings.Hooks = map[string][]claudeconfig.Hook{}
@@ -263,6 +300,11 @@ func (s *claudeStore) removeHookIfMatches(event string, hook Hook) bool {
 	return false
 }
 
+func (s *claudeStore) getEnv(key string) (string, bool) {
+	value, ok := s.settings.Env[key]
+	return value, ok
+}
+
 func (s *claudeStore) setEnv(key, value string) error {
 	if s.settings.Env == nil {
 		s.settings.Env = map[string]string{}
@@ -279,6 +321,28 @@ func (s *claudeStore) removeEnvIfMatches(key, value string) bool {
 	return false
 }
 
+func (s *claudeStore) includeEnv(string) bool { return false }
+
+func (s *claudeStore) excludeEnv(string) {}
+
+func (s *claudeStore) getMCP(name string) (contract.MCPServerConfig, bool) {
+	server, ok := s.mcp.MCPServers[name]
+	if !ok || server == nil {
+		return contract.MCPServerConfig{}, false
+	}
+	return contract.MCPServerConfig{
+		Type:     server.Type,
+		Command:  server.Command,
+		Args:     append([]string(nil), server.Args...),
+		Env:      cloneStringMap(server.Env),
+		URL:      server.URL,
+		Headers:  sliceHeadersToMap(server.Headers),
+		Disabled: server.Disabled,
+	}, true
+}
+
+func (s *claudeStore) removeMCP(name string) { delete(s.mcp.MCPServers, name) }
+
 func (s *claudeStore) setMCP(name string, cfg contract.MCPServerConfig) error {
 	if s.mcp.MCPServers == nil {
 		s.mcp.MCPServers = map[string]*claudeconfig.MCPServer{}
@@ -339,6 +403,7 @@ type codexStore struct {
 func (s *codexStore) snapshot() *Settings {
 	out := NewSettings("codex")
 	out.Hooks = flattenCodexHooks(s.hooks.Hooks)
+	maps.Copy(out.Env, s.config.ShellEnvironmentPolicy.Set)
 	for name, server := range s.config.MCPServers {
 		out.MCPServers[name] = contract.MCPServerConfig{
 			Type:     server.Type,
@@ -357,9 +422,7 @@ func (s *codexStore) replace(settings *Settings) error {
 	if settings == nil {
 		settings = NewSettings("codex")
 	}
-	if len(settings.Env) > 0 {
-		return fmt.Errorf("capability not supported: codex project env overrides")
-	}
+	s.config.ShellEnvironmentPolicy.Set = cloneStringMap(settings.Env)
 	s.config.MCPServers = map[string]codexconfig.MCPServer{}
 	for name, server := range settings.MCPServers {
 		s.config.MCPServers[name] = codexconfig.MCPServer{
@@ -376,7 +439,25 @@ func (s *codexStore) replace(settings *Settings) error {
 	return nil
 }
 
+func (s *codexStore) hasHook(event string, hook Hook) bool {
+	entry := codexHookEntry(hook)
+	for _, matcher := range s.hooks.Hooks[event] {
+		if matcher.Matcher != hook.Matcher {
+			continue
+		}
+		for _, existing := range matcher.Hooks {
+			if reflect.DeepEqual(existing, entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *codexStore) addHook(event string, hook Hook) error {
+	if hook.Type != "" && hook.Type != "command" {
+		return fmt.Errorf("%w: codex has no %s hooks", ErrNoHookEquivalent, hook.Type)
+	}
 	if s.hooks.Hooks == nil {
 		s.hooks.Hooks = map[string][]codexconfig.HookMatcher{}
 	}
@@ -414,11 +495,66 @@ func (s *codexStore) removeHookIfMatches(event string, hook Hook) bool {
 	return false
 }
 
-func (s *codexStore) setEnv(_, _ string) error {
-	return fmt.Errorf("capability not supported: codex project env overrides")
+// Codex env lives in shell_environment_policy.set, which applies to the
+// commands Codex runs rather than to the codex process itself.
+func (s *codexStore) getEnv(key string) (string, bool) {
+	value, ok := s.config.ShellEnvironmentPolicy.Set[key]
+	return value, ok
+}
+
+func (s *codexStore) setEnv(key, value string) error {
+	policy := &s.config.ShellEnvironmentPolicy
+	if policy.Set == nil {
+		policy.Set = map[string]string{}
+	}
+	policy.Set[key] = value
+	return nil
+}
+
+func (s *codexStore) removeEnvIfMatches(key, value string) bool {
+	policy := &s.config.ShellEnvironmentPolicy
+	if current, ok := policy.Set[key]; ok && current == value {
+		delete(policy.Set, key)
+		return true
+	}
+	return false
+}
+
+// includeEnv adds key to a non-empty include_only that would otherwise
+// drop it, since Codex applies include_only after set.
+func (s *codexStore) includeEnv(key string) bool {
+	policy := &s.config.ShellEnvironmentPolicy
+	if policy.Includes(key) {
+		return false
+	}
+	policy.IncludeOnly = append(policy.IncludeOnly, key)
+	return true
+}
+
+func (s *codexStore) excludeEnv(key string) {
+	policy := &s.config.ShellEnvironmentPolicy
+	if i := slices.Index(policy.IncludeOnly, key); i >= 0 {
+		policy.IncludeOnly = slices.Delete(policy.IncludeOnly, i, i+1)
+	}
+}
+
+func (s *codexStore) getMCP(name string) (contract.MCPServerConfig, bool) {
+	server, ok := s.config.MCPServers[name]
+	if !ok {
+		return contract.MCPServerConfig{}, false
+	}
+	return contract.MCPServerConfig{
+		Type:     server.Type,
+		Command:  server.Command,
+		Args:     append([]string(nil), server.Args...),
+		Env:      cloneStringMap(server.Env),
+		URL:      server.URL,
+		Headers:  cloneStringMap(server.Headers),
+		Disabled: server.Disabled,
+	}, true
 }
 
-func (s *codexStore) removeEnvIfMatches(_, _ string) bool { return false }
+func (s *codexStore) removeMCP(name string) { delete(s.config.MCPServers, name) }
 
 func (s *codexStore) setMCP(name string, cfg contract.MCPServerConfig) error {
 	if s.config.MCPServers == nil {
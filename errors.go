@@ -3,6 +3,7 @@ package llmkit
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Sentinel errors for provider operations.
@@ -39,22 +40,41 @@ var (
 
 	// ErrUnsupportedFeature indicates the provider cannot satisfy a requested feature or mode.
 	ErrUnsupportedFeature = errors.New("unsupported feature")
+
+	// ErrOverloaded indicates the provider API is temporarily overloaded.
+	ErrOverloaded = errors.New("provider overloaded")
+
+	// ErrMaxTurns indicates the run stopped after reaching its turn limit.
+	ErrMaxTurns = errors.New("max turns reached")
+
+	// ErrBudgetExceeded indicates the run stopped after reaching its spend limit.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+
+	// ErrStructuredOutputFailed indicates the provider could not produce schema-valid output.
+	ErrStructuredOutputFailed = errors.New("structured output failed")
 )
 
 // Error wraps provider errors with context.
 type Error struct {
-	Provider  string // Provider name ("claude", "gemini", etc.)
-	Op        string // Operation that failed ("complete", "stream")
-	Err       error  // Underlying error
-	Retryable bool   // Whether the error is likely transient
+	Provider   string        // Provider name ("claude", "gemini", etc.)
+	Op         string        // Operation that failed ("complete", "stream")
+	Err        error         // Underlying error
+	Retryable  bool          // Whether the error is likely transient
+	RetryAfter time.Duration // Provider-suggested wait before retrying, if known
+	Diagnostic string        // Raw provider diagnostic (stderr or error event text)
 }
 
 // Error implements the error interface.
 func (e *Error) Error() string {
-	if e.Provider != "" {
+	switch {
+	case e.Provider != "":
 		return fmt.Sprintf("%s %s: %v", e.Provider, e.Op, e.Err)
+	case e.Op != "":
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	default:
+		// Classified errors nested inside provider errors carry no prefix of their own.
+		return e.Err.Error()
 	}
-	return fmt.Sprintf("%s: %v", e.Op, e.Err)
 }
 
 // Unwrap returns the underlying error for errors.Is/As support.
@@ -81,10 +101,28 @@ func IsRetryable(err error) bool {
 
 	// Check for known retryable sentinel errors
 	return errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrOverloaded) ||
 		errors.Is(err, ErrUnavailable) ||
 		errors.Is(err, ErrTimeout)
 }
 
+// RetryAfter returns the provider-suggested retry delay carried by err.
+func RetryAfter(err error) (time.Duration, bool) {
+	var provErr *Error
+	if errors.As(err, &provErr) && provErr.RetryAfter > 0 {
+		return provErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// IsLimitError checks if a run stopped on a caller-configured limit
+// (turns, budget, or structured-output retries) rather than a provider fault.
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrMaxTurns) ||
+		errors.Is(err, ErrBudgetExceeded) ||
+		errors.Is(err, ErrStructuredOutputFailed)
+}
+
 // IsCapabilityError checks if an error is due to missing provider capability.
 func IsCapabilityError(err error) bool {
 	return errors.Is(err, ErrCapabilityNotSupported)
@@ -0,0 +1,319 @@
+package llmkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClientFactory creates a client configured for one model.
+type ClientFactory func(model ModelName) (Client, error)
+
+// RegistryFactory returns a ClientFactory that creates clients through the
+// provider registry using cfg with its Model replaced per attempt.
+func RegistryFactory(cfg Config) ClientFactory {
+	return func(model ModelName) (Client, error) {
+		attemptCfg := cfg
+		if model != "" {
+			attemptCfg.Model = string(model)
+		}
+		return New(attemptCfg.Provider, attemptCfg)
+	}
+}
+
+// SessionFactory creates a session for one model. A non-nil resume carries the
+// previous attempt's session so the stronger model continues with its context.
+type SessionFactory func(ctx context.Context, model ModelName, resume *SessionMetadata) (Session, error)
+
+// RegistrySessionFactory returns a SessionFactory backed by NewSession.
+func RegistrySessionFactory(cfg Config) SessionFactory {
+	return func(ctx context.Context, model ModelName, resume *SessionMetadata) (Session, error) {
+		attemptCfg := cfg
+		if model != "" {
+			attemptCfg.Model = string(model)
+		}
+		if resume != nil {
+			attemptCfg.Session = resume
+			attemptCfg.ResumeSession = true
+		}
+		return NewSession(ctx, attemptCfg.Provider, attemptCfg)
+	}
+}
+
+// Judge decides whether a response is acceptable. A non-nil error marks the
+// attempt as failed and triggers escalation.
+type Judge func(ctx context.Context, resp *Response) error
+
+// JudgeTyped returns a Judge that accepts responses which strictly decode into T,
+// using the same rules as CompleteTyped.
+func JudgeTyped[T any]() Judge {
+	return func(_ context.Context, resp *Response) error {
+		if resp == nil || strings.TrimSpace(resp.Content) == "" {
+			return fmt.Errorf("empty structured response")
+		}
+		data, err := extractStructuredJSON(resp.Content)
+		if err != nil {
+			return err
+		}
+		var value T
+		if err := decodeStructuredJSON(data, &value); err != nil {
+			return fmt.Errorf("parse structured response: %w", err)
+		}
+		return nil
+	}
+}
+
+// EscalationAttempt records one model attempt made by an escalation run.
+type EscalationAttempt struct {
+	Attempt  int           `json:"attempt"`
+	Model    ModelName     `json:"model"`
+	Response *Response     `json:"response,omitempty"`
+	Err      error         `json:"-"`
+	Error    string        `json:"error,omitempty"`
+	CostUSD  float64       `json:"cost_usd"`
+	Duration time.Duration `json:"duration"`
+}
+
+// EscalationResult is the outcome of RunWithEscalation or RunSessionWithEscalation.
+type EscalationResult struct {
+	// Response is the accepted response, or nil when every attempt failed.
+	Response *Response `json:"response,omitempty"`
+	// Model is the model that produced Response or made the last attempt.
+	Model        ModelName           `json:"model"`
+	Attempts     []EscalationAttempt `json:"attempts"`
+	TotalCostUSD float64             `json:"total_cost_usd"`
+	// Session identifies the last session used by a session run.
+	Session *SessionMetadata `json:"session,omitempty"`
+}
+
+// ErrEscalationExhausted indicates every attempt in an escalation chain failed.
+var ErrEscalationExhausted = errors.New("escalation attempts exhausted")
+
+// RunWithEscalation runs req against models from chain until judge accepts a
+// response. Each attempt gets a fresh client from factory. Judge rejections
+// and retryable or capability errors (see escalatable) count as failures and
+// move on; any other error, such as an auth failure, an invalid request or
+// context cancellation, stops the run and is returned as is.
+// The result is returned even on failure so callers can inspect the attempts.
+// A nil chain uses DefaultEscalation and a nil judge accepts any response.
+func RunWithEscalation(ctx context.Context, factory ClientFactory, req Request, chain *EscalationChain, judge Judge) (*EscalationResult, error) {
+	if factory == nil {
+		return nil, fmt.Errorf("client factory is required")
+	}
+	return runEscalation(ctx, req, chain, func(ctx context.Context, model ModelName, _ *EscalationResult) (*Response, error) {
+		client, err := factory(model)
+		if err != nil {
+			return nil, fmt.Errorf("create client for %s: %w", model, err)
+		}
+		defer func() { _ = client.Close() }()
+
+		attemptReq := req
+		attemptReq.Model = ""
+		if model != "" {
+			attemptReq.Model = string(model)
+		}
+		resp, err := client.Complete(ctx, attemptReq)
+		if err == nil && resp == nil {
+			err = fmt.Errorf("nil response")
+		}
+		return resp, err
+	}, judge)
+}
+
+// RunSessionWithEscalation is RunWithEscalation for session-based runs.
+// After a failed attempt the next model resumes the previous session and is
+// told why the attempt was rejected instead of replaying req.
+func RunSessionWithEscalation(ctx context.Context, factory SessionFactory, req Request, chain *EscalationChain, judge Judge) (*EscalationResult, error) {
+	if factory == nil {
+		return nil, fmt.Errorf("session factory is required")
+	}
+	return runEscalation(ctx, req, chain, func(ctx context.Context, model ModelName, result *EscalationResult) (*Response, error) {
+		send := req
+		var lastErr error
+		if n := len(result.Attempts); n > 0 {
+			lastErr = result.Attempts[n-1].Err
+		}
+		if result.Session != nil && lastErr != nil {
+			send = escalationFollowUp(lastErr)
+		}
+
+		sess, err := factory(ctx, model, result.Session)
+		if err != nil {
+			return nil, fmt.Errorf("create session for %s: %w", model, err)
+		}
+		defer func() { _ = sess.Close() }()
+
+		resp, err := runSessionTurn(ctx, sess, send)
+		if resp != nil && resp.Session != nil {
+			result.Session = resp.Session
+		} else if id := sess.ID(); id != "" {
+			result.Session = SessionMetadataForID(sess.Provider(), id)
+		}
+		return resp, err
+	}, judge)
+}
+
+type escalationAttemptFunc func(ctx context.Context, model ModelName, result *EscalationResult) (*Response, error)
+
+func runEscalation(ctx context.Context, req Request, chain *EscalationChain, run escalationAttemptFunc, judge Judge) (*EscalationResult, error) {
+	if chain == nil {
+		chain = &DefaultEscalation
+	}
+	if chain.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("escalation chain max attempts must be > 0")
+	}
+
+	start := ModelName(req.Model)
+	if start == "" && len(chain.Models) > 0 {
+		start = chain.Models[0]
+	}
+	state := NewEscalationState(chain, start)
+	result := &EscalationResult{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		model := state.CurrentModel
+		began := time.Now()
+		resp, err := run(ctx, model, result)
+		rejected := false
+		if err == nil && judge != nil {
+			err = judge(ctx, resp)
+			rejected = err != nil
+		}
+
+		attempt := EscalationAttempt{
+			Attempt:  state.Attempt + 1,
+			Model:    model,
+			Response: resp,
+			Err:      err,
+			CostUSD:  responseCost(model, resp),
+			Duration: time.Since(began),
+		}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		result.Attempts = append(result.Attempts, attempt)
+		result.TotalCostUSD += attempt.CostUSD
+		result.Model = model
+
+		if err == nil {
+			result.Response = resp
+			return result, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
+		}
+		if !rejected && !escalatable(err) {
+			return result, err
+		}
+		if !state.RecordFailure(err) {
+			return result, fmt.Errorf("%w after %d attempts: %w", ErrEscalationExhausted, len(result.Attempts), err)
+		}
+	}
+}
+
+// escalatable reports whether a failed attempt is worth retrying on the next
+// model: transient provider errors, and failures a stronger or different model
+// may not hit (unsupported capabilities, context length, turn limits,
+// malformed structured output).
+func escalatable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return IsRetryable(err) ||
+		IsCapabilityError(err) ||
+		errors.Is(err, ErrUnsupportedFeature) ||
+		errors.Is(err, ErrContextTooLong) ||
+		errors.Is(err, ErrMaxTurns) ||
+		errors.Is(err, ErrStructuredOutputFailed)
+}
+
+// responseCost returns the provider-reported cost, or an estimate from the
+// pricing table when the provider did not report one.
+func responseCost(model ModelName, resp *Response) float64 {
+	if resp == nil {
+		return 0
+	}
+	if resp.CostUSD > 0 {
+		return resp.CostUSD
+	}
+	name := model
+	if resp.Model != "" {
+		name = ModelName(resp.Model)
+	}
+	cost, _ := EstimateCost(string(name), Usage{
+		InputTokens:              resp.Usage.InputTokens,
+		OutputTokens:             resp.Usage.OutputTokens,
+		CacheCreationInputTokens: resp.Usage.CacheCreationInputTokens,
+		CacheReadInputTokens:     resp.Usage.CacheReadInputTokens,
+	})
+	return cost
+}
+
+func escalationFollowUp(failure error) Request {
+	return Request{
+		Messages: []Message{NewTextMessage(RoleUser, fmt.Sprintf(
+			"The previous attempt was rejected: %v\n\nAddress the problem and complete the original task again.", failure,
+		))},
+	}
+}
+
+// runSessionTurn sends req and collects session events until the turn completes.
+func runSessionTurn(ctx context.Context, sess Session, req Request) (*Response, error) {
+	began := time.Now()
+	if err := sess.Send(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	var content strings.Builder
+	var turnErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case chunk, ok := <-sess.Events():
+			if !ok {
+				if turnErr != nil {
+					return resp, turnErr
+				}
+				return nil, fmt.Errorf("%s session closed before the turn completed", sess.Provider())
+			}
+			if chunk.Session != nil {
+				resp.Session = chunk.Session
+				resp.SessionID = chunk.SessionID
+			}
+			if chunk.Model != "" {
+				resp.Model = chunk.Model
+			}
+			switch chunk.Type {
+			case "assistant":
+				content.WriteString(chunk.Content)
+			case "error":
+				turnErr = chunk.Error
+			}
+			if !chunk.Done {
+				continue
+			}
+			resp.Content = chunk.FinalContent
+			if resp.Content == "" {
+				resp.Content = content.String()
+			}
+			if chunk.Usage != nil {
+				resp.Usage = *chunk.Usage
+			}
+			resp.CostUSD = chunk.CostUSD
+			resp.NumTurns = chunk.NumTurns
+			resp.Duration = time.Since(began)
+			if turnErr != nil {
+				return resp, turnErr
+			}
+			return resp, nil
+		}
+	}
+}
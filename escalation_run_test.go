@@ -0,0 +1,216 @@
+package llmkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/fanout"
+)
+
+type scriptedClient struct {
+	model   ModelName
+	respond func(model ModelName, req Request) (*Response, error)
+}
+
+func (c *scriptedClient) Complete(_ context.Context, req Request) (*Response, error) {
+	return c.respond(c.model, req)
+}
+
+func (c *scriptedClient) Stream(context.Context, Request) (<-chan StreamChunk, error) {
+	return nil, ErrUnsupportedFeature
+}
+
+func (c *scriptedClient) Provider() string           { return "test" }
+func (c *scriptedClient) Capabilities() Capabilities { return Capabilities{} }
+func (c *scriptedClient) Close() error               { return nil }
+
+func TestRunWithEscalationEscalatesOnJudgeFailure(t *testing.T) {
+	type verdict struct {
+		OK bool `json:"ok"`
+	}
+	factory := func(model ModelName) (Client, error) {
+		return &scriptedClient{model: model, respond: func(model ModelName, req Request) (*Response, error) {
+			if req.Model != string(model) {
+				return nil, fmt.Errorf("request model = %q, want %q", req.Model, model)
+			}
+			switch model {
+			case ModelHaiku:
+				return nil, ErrRateLimited
+			case ModelSonnet:
+				return &Response{Content: "not json", Model: "claude-sonnet-4", CostUSD: 0.02}, nil
+			default:
+				return &Response{Content: `{"ok":true}`, Model: "claude-opus-4", Usage: TokenUsage{InputTokens: 1_000_000}}, nil
+			}
+		}}, nil
+	}
+
+	result, err := RunWithEscalation(context.Background(), factory, Request{
+		Messages: []Message{NewTextMessage(RoleUser, "check")},
+	}, &FullEscalation, JudgeTyped[verdict]())
+	if err != nil {
+		t.Fatalf("RunWithEscalation() error = %v", err)
+	}
+	if result.Model != ModelOpus || result.Response == nil || result.Response.Content != `{"ok":true}` {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(result.Attempts))
+	}
+	if !errors.Is(result.Attempts[0].Err, ErrRateLimited) {
+		t.Fatalf("attempt 1 error = %v", result.Attempts[0].Err)
+	}
+	if !strings.Contains(result.Attempts[1].Error, "valid JSON") {
+		t.Fatalf("attempt 2 error = %q", result.Attempts[1].Error)
+	}
+	// 0.02 reported by sonnet plus 1M opus input tokens priced at $5.
+	if result.TotalCostUSD < 5.019 || result.TotalCostUSD > 5.021 {
+		t.Fatalf("TotalCostUSD = %f", result.TotalCostUSD)
+	}
+}
+
+func TestRunWithEscalationExhausted(t *testing.T) {
+	calls := 0
+	factory := func(model ModelName) (Client, error) {
+		return &scriptedClient{model: model, respond: func(ModelName, Request) (*Response, error) {
+			calls++
+			return nil, ErrUnavailable
+		}}, nil
+	}
+
+	chain := &EscalationChain{Models: []ModelName{ModelSonnet, ModelOpus}, MaxAttempts: 3}
+	result, err := RunWithEscalation(context.Background(), factory, Request{}, chain, nil)
+	if !errors.Is(err, ErrEscalationExhausted) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("error = %v, want exhausted wrapping unavailable", err)
+	}
+	if calls != 3 || len(result.Attempts) != 3 {
+		t.Fatalf("calls = %d, attempts = %d", calls, len(result.Attempts))
+	}
+	if result.Attempts[2].Model != ModelOpus {
+		t.Fatalf("last attempt model = %q", result.Attempts[2].Model)
+	}
+}
+
+func TestRunWithEscalationStopsOnNonEscalatableErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"auth", &Error{Provider: "test", Op: "complete", Err: ErrCredentialsExpired}},
+		{"invalid request", fmt.Errorf("bad schema: %w", ErrInvalidRequest)},
+		{"canceled", context.Canceled},
+		{"unclassified", errors.New("boom")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			factory := func(model ModelName) (Client, error) {
+				return &scriptedClient{model: model, respond: func(ModelName, Request) (*Response, error) {
+					calls++
+					return nil, tc.err
+				}}, nil
+			}
+			result, err := RunWithEscalation(context.Background(), factory, Request{}, &FullEscalation, nil)
+			if !errors.Is(err, tc.err) || errors.Is(err, ErrEscalationExhausted) {
+				t.Fatalf("error = %v, want %v without exhaustion", err, tc.err)
+			}
+			if calls != 1 || len(result.Attempts) != 1 {
+				t.Fatalf("calls = %d, attempts = %d, want 1", calls, len(result.Attempts))
+			}
+		})
+	}
+}
+
+func TestRunWithEscalationEscalatesOnCapabilityErrors(t *testing.T) {
+	factory := func(model ModelName) (Client, error) {
+		return &scriptedClient{model: model, respond: func(model ModelName, _ Request) (*Response, error) {
+			if model == ModelHaiku {
+				return nil, fmt.Errorf("prompt: %w", ErrContextTooLong)
+			}
+			return &Response{Content: "ok"}, nil
+		}}, nil
+	}
+	result, err := RunWithEscalation(context.Background(), factory, Request{}, &FullEscalation, nil)
+	if err != nil || result.Model != ModelSonnet || len(result.Attempts) != 2 {
+		t.Fatalf("result = %+v, err = %v", result, err)
+	}
+}
+
+type scriptedSession struct {
+	id     string
+	events chan StreamChunk
+	reply  func(req Request) []StreamChunk
+	mu     sync.Mutex
+	sent   []Request
+}
+
+func (s *scriptedSession) Provider() string      { return "claude" }
+func (s *scriptedSession) ID() string            { return s.id }
+func (s *scriptedSession) Status() SessionStatus { return SessionStatusActive }
+func (s *scriptedSession) Info() SessionInfo     { return SessionInfo{ID: s.id} }
+func (s *scriptedSession) Close() error          { return nil }
+
+func (s *scriptedSession) Events() <-chan StreamChunk { return s.events }
+
+func (s *scriptedSession) Subscribe(opts ...fanout.Option) *fanout.Subscription[StreamChunk] {
+	return fanout.New[StreamChunk](nil).Subscribe(opts...)
+}
+
+func (s *scriptedSession) Send(_ context.Context, req Request) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, req)
+	s.mu.Unlock()
+	for _, chunk := range s.reply(req) {
+		s.events <- chunk
+	}
+	return nil
+}
+
+func TestRunSessionWithEscalationResumesOnStrongerModel(t *testing.T) {
+	var resumes []*SessionMetadata
+	var sessions []*scriptedSession
+	factory := func(_ context.Context, model ModelName, resume *SessionMetadata) (Session, error) {
+		resumes = append(resumes, resume)
+		sess := &scriptedSession{id: "sess-1", events: make(chan StreamChunk, 8)}
+		sess.reply = func(Request) []StreamChunk {
+			meta := SessionMetadataForID("claude", "sess-1")
+			if model == ModelSonnet {
+				return []StreamChunk{
+					{Type: "assistant", Content: "partial", Session: meta, SessionID: "sess-1"},
+					{Type: "final", FinalContent: "tests fail", Done: true, Session: meta, CostUSD: 0.1},
+				}
+			}
+			return []StreamChunk{{Type: "final", FinalContent: "tests pass", Done: true, Session: meta, CostUSD: 0.3}}
+		}
+		sessions = append(sessions, sess)
+		return sess, nil
+	}
+	judge := func(_ context.Context, resp *Response) error {
+		if resp.Content != "tests pass" {
+			return fmt.Errorf("%s", resp.Content)
+		}
+		return nil
+	}
+
+	result, err := RunSessionWithEscalation(context.Background(), factory, Request{
+		Messages: []Message{NewTextMessage(RoleUser, "fix the bug")},
+	}, &DefaultEscalation, judge)
+	if err != nil {
+		t.Fatalf("RunSessionWithEscalation() error = %v", err)
+	}
+	if result.Model != ModelOpus || result.TotalCostUSD < 0.39 || result.TotalCostUSD > 0.41 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(resumes) != 2 || resumes[0] != nil || SessionID(resumes[1]) != "sess-1" {
+		t.Fatalf("resumes = %+v", resumes)
+	}
+	followUp := sessions[1].sent[0].Messages[0].Content
+	if !strings.Contains(followUp, "tests fail") {
+		t.Fatalf("follow-up prompt = %q", followUp)
+	}
+	if SessionID(result.Session) != "sess-1" {
+		t.Fatalf("result session = %+v", result.Session)
+	}
+}
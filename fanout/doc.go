@@ -0,0 +1,20 @@
+// Package fanout broadcasts a session's event stream to any number of
+// subscribers.
+//
+// Each subscriber gets its own buffered channel and a slow-consumer policy:
+// DropOldest (the default) discards the oldest buffered value, Block applies
+// backpressure to the publisher, and Disconnect closes the subscription and
+// reports ErrSlowConsumer. A Block subscriber that stops reading stalls the
+// whole session, so reserve it for consumers that always drain. A late
+// subscriber first receives the values already published in the current
+// turn, so a UI attached mid-turn can render the whole turn.
+//
+//	sub := sess.Subscribe(fanout.WithBuffer(256))
+//	defer sub.Close()
+//	for chunk := range sub.C() {
+//	    render(chunk)
+//	}
+//
+// The unified llmkit.Session and the claude/session and codex/session
+// sessions all expose Subscribe.
+package fanout
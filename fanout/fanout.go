@@ -0,0 +1,293 @@
+package fanout
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSlowConsumer is reported by Subscription.Err when a Disconnect
+// subscriber fell behind and was dropped.
+var ErrSlowConsumer = errors.New("subscriber disconnected: buffer full")
+
+// Policy decides what Publish does when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// DropOldest discards the oldest buffered value to make room. It is
+	// the default.
+	DropOldest Policy = iota
+	// Block waits for the subscriber to make room. A blocked subscriber
+	// stalls the publisher and therefore every other subscriber; in a
+	// session that includes reading the CLI's output.
+	Block
+	// Disconnect closes the subscription and reports ErrSlowConsumer.
+	Disconnect
+)
+
+// String returns the policy name.
+func (p Policy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case Block:
+		return "block"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// Option configures a subscription.
+type Option func(*subscribeConfig)
+
+// subscribeConfig holds subscription configuration.
+type subscribeConfig struct {
+	buffer int
+	policy Policy
+	replay bool
+}
+
+// defaultSubscribeConfig returns the default subscription configuration.
+func defaultSubscribeConfig() subscribeConfig {
+	return subscribeConfig{
+		buffer: 128,
+		policy: DropOldest,
+		replay: true,
+	}
+}
+
+// WithBuffer sets the subscription's channel capacity, not counting replayed
+// values.
+func WithBuffer(n int) Option {
+	return func(c *subscribeConfig) {
+		if n >= 0 {
+			c.buffer = n
+		}
+	}
+}
+
+// WithPolicy sets the slow-consumer policy. The default is DropOldest.
+func WithPolicy(p Policy) Option {
+	return func(c *subscribeConfig) { c.policy = p }
+}
+
+// WithReplay controls whether a new subscriber first receives the values
+// already published in the current turn. It defaults to true.
+func WithReplay(replay bool) Option {
+	return func(c *subscribeConfig) { c.replay = replay }
+}
+
+// Broadcaster delivers each published value to every subscriber.
+type Broadcaster[T any] struct {
+	turnEnd func(T) bool
+
+	mu       sync.Mutex
+	subs     map[*Subscription[T]]struct{}
+	turn     []T
+	turnDone bool
+	closed   bool
+}
+
+// New creates a broadcaster. turnEnd reports whether a value ends a turn;
+// the replay buffer restarts with the first value after it. A nil turnEnd
+// replays everything published so far.
+func New[T any](turnEnd func(T) bool) *Broadcaster[T] {
+	return &Broadcaster[T]{
+		turnEnd: turnEnd,
+		subs:    make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber. Subscribing to a closed broadcaster
+// returns a subscription that delivers the replay and then closes.
+func (b *Broadcaster[T]) Subscribe(opts ...Option) *Subscription[T] {
+	cfg := defaultSubscribeConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []T
+	if cfg.replay {
+		replay = b.turn
+	}
+	sub := &Subscription[T]{
+		ch:     make(chan T, cfg.buffer+len(replay)),
+		done:   make(chan struct{}),
+		policy: cfg.policy,
+	}
+	sub.unsubscribe = func() { b.remove(sub) }
+	for _, v := range replay {
+		sub.ch <- v
+	}
+	if b.closed {
+		sub.close(nil)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers v to every subscriber according to its policy. Publish
+// is meant to be called from a single goroutine.
+func (b *Broadcaster[T]) Publish(v T) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	if b.turnDone {
+		b.turn = nil
+		b.turnDone = false
+	}
+	b.turn = append(b.turn, v)
+	b.turnDone = b.turnEnd != nil && b.turnEnd(v)
+	subs := make([]*Subscription[T], 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		if !sub.deliver(v) {
+			b.remove(sub)
+		}
+	}
+}
+
+// Close closes every subscription. Buffered values stay readable.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[*Subscription[T]]struct{})
+	b.mu.Unlock()
+
+	for sub := range subs {
+		sub.close(nil)
+	}
+}
+
+// Len returns the number of live subscriptions.
+func (b *Broadcaster[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Broadcaster[T]) remove(sub *Subscription[T]) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// Subscription is one subscriber's view of a Broadcaster.
+type Subscription[T any] struct {
+	ch          chan T
+	done        chan struct{}
+	policy      Policy
+	unsubscribe func()
+
+	mu        sync.Mutex
+	closed    bool
+	closeOnce sync.Once
+	err       error
+	dropped   atomic.Uint64
+}
+
+// C returns the channel of values. It is closed when the subscription or
+// the broadcaster closes.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription[T]) Close() {
+	s.unsubscribe()
+	s.close(nil)
+}
+
+// Err returns ErrSlowConsumer if the subscription was disconnected for
+// falling behind, and nil otherwise.
+func (s *Subscription[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns how many values a DropOldest subscription discarded.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// deliver sends v and reports whether the subscription is still live.
+func (s *Subscription[T]) deliver(v T) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+
+	select {
+	case s.ch <- v:
+		s.mu.Unlock()
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case DropOldest:
+		if cap(s.ch) == 0 {
+			s.dropped.Add(1)
+			s.mu.Unlock()
+			return true
+		}
+		for {
+			select {
+			case s.ch <- v:
+				s.mu.Unlock()
+				return true
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	case Disconnect:
+		s.mu.Unlock()
+		s.close(ErrSlowConsumer)
+		return false
+	default:
+		// Block until there is room or the subscriber closes. Close signals
+		// done before taking mu, so this cannot deadlock.
+		select {
+		case s.ch <- v:
+			s.mu.Unlock()
+			return true
+		case <-s.done:
+			s.mu.Unlock()
+			return false
+		}
+	}
+}
+
+func (s *Subscription[T]) close(err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		s.closed = true
+		s.err = err
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
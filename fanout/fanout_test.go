@@ -0,0 +1,108 @@
+package fanout
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func drain(sub *Subscription[int]) []int {
+	var got []int
+	for v := range sub.C() {
+		got = append(got, v)
+	}
+	return got
+}
+
+func TestBroadcasterReplaysCurrentTurn(t *testing.T) {
+	// Negative values end a turn.
+	b := New(func(v int) bool { return v < 0 })
+	early := b.Subscribe()
+
+	b.Publish(1)
+	b.Publish(-1)
+	midTurnEnd := b.Subscribe()
+	b.Publish(2)
+	b.Publish(3)
+	late := b.Subscribe()
+	noReplay := b.Subscribe(WithReplay(false))
+	b.Publish(-2)
+	b.Close()
+
+	for name, tc := range map[string]struct {
+		sub  *Subscription[int]
+		want []int
+	}{
+		"early":       {early, []int{1, -1, 2, 3, -2}},
+		"after turn":  {midTurnEnd, []int{1, -1, 2, 3, -2}},
+		"mid turn":    {late, []int{2, 3, -2}},
+		"replay off":  {noReplay, []int{-2}},
+		"after close": {b.Subscribe(), []int{2, 3, -2}},
+	} {
+		if got := drain(tc.sub); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+func TestBroadcasterSlowConsumerPolicies(t *testing.T) {
+	b := New[int](nil)
+	dropper := b.Subscribe(WithBuffer(2), WithReplay(false)) // DropOldest is the default
+	disconnected := b.Subscribe(WithBuffer(2), WithPolicy(Disconnect), WithReplay(false))
+	blocked := b.Subscribe(WithBuffer(1), WithPolicy(Block), WithReplay(false))
+
+	published := make(chan struct{})
+	go func() {
+		for i := 1; i <= 4; i++ {
+			b.Publish(i)
+		}
+		close(published)
+	}()
+
+	// The Block subscriber holds the publisher back until it reads.
+	select {
+	case <-published:
+		t.Fatal("Publish did not block on a full Block subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+	var blockedGot []int
+	for len(blockedGot) < 4 {
+		blockedGot = append(blockedGot, <-blocked.C())
+	}
+	<-published
+	b.Close()
+
+	if got := drain(dropper); !slices.Equal(got, []int{3, 4}) || dropper.Dropped() != 2 {
+		t.Fatalf("drop oldest got %v, dropped %d", got, dropper.Dropped())
+	}
+	if got := drain(disconnected); !slices.Equal(got, []int{1, 2}) || !errors.Is(disconnected.Err(), ErrSlowConsumer) {
+		t.Fatalf("disconnect got %v, err %v", got, disconnected.Err())
+	}
+	if !slices.Equal(blockedGot, []int{1, 2, 3, 4}) {
+		t.Fatalf("block got %v", blockedGot)
+	}
+}
+
+func TestSubscriptionCloseUnblocksPublisher(t *testing.T) {
+	b := New[int](nil)
+	sub := b.Subscribe(WithBuffer(0), WithPolicy(Block))
+
+	done := make(chan struct{})
+	go func() {
+		b.Publish(1)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	sub.Close()
+	sub.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish stayed blocked after the subscriber closed")
+	}
+	if b.Len() != 0 {
+		t.Fatalf("Len = %d after Close", b.Len())
+	}
+}
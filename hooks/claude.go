@@ -0,0 +1,430 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+)
+
+// ClaudeInput holds the fields Claude Code sends with every hook event.
+type ClaudeInput struct {
+	HookEventName  claudecontract.HookEvent `json:"hook_event_name"`
+	SessionID      string                   `json:"session_id"`
+	TranscriptPath string                   `json:"transcript_path,omitempty"`
+	CWD            string                   `json:"cwd,omitempty"`
+	PermissionMode string                   `json:"permission_mode,omitempty"`
+
+	// Set when the hook fires inside a subagent.
+	AgentID   string `json:"agent_id,omitempty"`
+	AgentType string `json:"agent_type,omitempty"`
+}
+
+// ClaudeToolInput is the tool call shared by the tool events.
+type ClaudeToolInput struct {
+	ClaudeInput
+	ToolName  string          `json:"tool_name"`
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+}
+
+// ClaudePreToolUseInput is the input of PreToolUse.
+type ClaudePreToolUseInput struct {
+	ClaudeToolInput
+}
+
+// ClaudePermissionRequestInput is the input of PermissionRequest.
+type ClaudePermissionRequestInput struct {
+	ClaudeToolInput
+	PermissionSuggestions json.RawMessage `json:"permission_suggestions,omitempty"`
+}
+
+// ClaudePostToolUseInput is the input of PostToolUse.
+type ClaudePostToolUseInput struct {
+	ClaudeToolInput
+	ToolResponse json.RawMessage `json:"tool_response,omitempty"`
+}
+
+// ClaudePostToolUseFailureInput is the input of PostToolUseFailure.
+type ClaudePostToolUseFailureInput struct {
+	ClaudeToolInput
+	Error       string `json:"error,omitempty"`
+	IsInterrupt bool   `json:"is_interrupt,omitempty"`
+}
+
+// ClaudeUserPromptSubmitInput is the input of UserPromptSubmit.
+type ClaudeUserPromptSubmitInput struct {
+	ClaudeInput
+	Prompt string `json:"prompt"`
+}
+
+// ClaudeStopInput is the input of Stop.
+type ClaudeStopInput struct {
+	ClaudeInput
+	StopHookActive       bool   `json:"stop_hook_active"` // A Stop hook already continued this turn
+	LastAssistantMessage string `json:"last_assistant_message,omitempty"`
+}
+
+// ClaudeSubagentStopInput is the input of SubagentStop.
+type ClaudeSubagentStopInput struct {
+	ClaudeStopInput
+	AgentTranscriptPath string `json:"agent_transcript_path,omitempty"`
+}
+
+// ClaudeSubagentStartInput is the input of SubagentStart.
+type ClaudeSubagentStartInput struct {
+	ClaudeInput
+}
+
+// ClaudeStopFailureInput is the input of StopFailure.
+type ClaudeStopFailureInput struct {
+	ClaudeInput
+	Error                string          `json:"error,omitempty"`
+	ErrorDetails         json.RawMessage `json:"error_details,omitempty"`
+	LastAssistantMessage string          `json:"last_assistant_message,omitempty"`
+}
+
+// ClaudeSessionStartInput is the input of SessionStart.
+type ClaudeSessionStartInput struct {
+	ClaudeInput
+	Source claudecontract.SessionStartSource `json:"source"`
+	Model  string                            `json:"model,omitempty"`
+}
+
+// ClaudeSessionEndInput is the input of SessionEnd.
+type ClaudeSessionEndInput struct {
+	ClaudeInput
+	Reason string `json:"reason"` // clear, logout, prompt_input_exit, other, ...
+}
+
+// ClaudeCompactInput is the input of PreCompact and PostCompact.
+type ClaudeCompactInput struct {
+	ClaudeInput
+	Trigger            string `json:"trigger"` // manual or auto
+	CustomInstructions string `json:"custom_instructions,omitempty"`
+	CompactSummary     string `json:"compact_summary,omitempty"` // PostCompact only
+}
+
+// ClaudeNotificationInput is the input of Notification.
+type ClaudeNotificationInput struct {
+	ClaudeInput
+	Message          string `json:"message"`
+	Title            string `json:"title,omitempty"`
+	NotificationType string `json:"notification_type,omitempty"`
+}
+
+// ClaudeTeammateIdleInput is the input of TeammateIdle.
+type ClaudeTeammateIdleInput struct {
+	ClaudeInput
+	TeammateName string `json:"teammate_name,omitempty"`
+	TeamName     string `json:"team_name,omitempty"`
+}
+
+// ClaudeTaskCompletedInput is the input of TaskCompleted.
+type ClaudeTaskCompletedInput struct {
+	ClaudeInput
+	TaskID          string `json:"task_id,omitempty"`
+	TaskSubject     string `json:"task_subject,omitempty"`
+	TaskDescription string `json:"task_description,omitempty"`
+	TeammateName    string `json:"teammate_name,omitempty"`
+	TeamName        string `json:"team_name,omitempty"`
+}
+
+// ClaudeInstructionsLoadedInput is the input of InstructionsLoaded.
+type ClaudeInstructionsLoadedInput struct {
+	ClaudeInput
+	FilePath        string   `json:"file_path"`
+	MemoryType      string   `json:"memory_type,omitempty"` // User, Project, Local or Managed
+	LoadReason      string   `json:"load_reason,omitempty"`
+	Globs           []string `json:"globs,omitempty"`
+	TriggerFilePath string   `json:"trigger_file_path,omitempty"`
+	ParentFilePath  string   `json:"parent_file_path,omitempty"`
+}
+
+// ClaudeConfigChangeInput is the input of ConfigChange.
+type ClaudeConfigChangeInput struct {
+	ClaudeInput
+	Source   string `json:"source"` // user_settings, project_settings, local_settings, policy_settings or skills
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// ClaudeWorktreeCreateInput is the input of WorktreeCreate.
+type ClaudeWorktreeCreateInput struct {
+	ClaudeInput
+	Name string `json:"name"`
+}
+
+// ClaudeWorktreeRemoveInput is the input of WorktreeRemove.
+type ClaudeWorktreeRemoveInput struct {
+	ClaudeInput
+	WorktreePath string `json:"worktree_path"`
+}
+
+// ClaudeElicitationInput is the input of Elicitation and ElicitationResult.
+type ClaudeElicitationInput struct {
+	ClaudeInput
+	MCPServerName   string          `json:"mcp_server_name"`
+	ElicitationID   string          `json:"elicitation_id,omitempty"`
+	Mode            string          `json:"mode,omitempty"` // form or url
+	Message         string          `json:"message,omitempty"`
+	URL             string          `json:"url,omitempty"`
+	RequestedSchema json.RawMessage `json:"requested_schema,omitempty"`
+	Action          string          `json:"action,omitempty"`  // ElicitationResult only
+	Content         json.RawMessage `json:"content,omitempty"` // ElicitationResult only
+}
+
+// ClaudePreToolUseOutput answers PreToolUse.
+type ClaudePreToolUseOutput struct {
+	Common
+
+	// Decision is allow (skip the permission prompt), deny or ask; empty
+	// leaves the normal permission flow. Reason is shown to the model on
+	// deny and to the user otherwise.
+	Decision claudecontract.PermissionBehavior
+	Reason   string
+
+	// UpdatedInput replaces the tool input.
+	UpdatedInput json.RawMessage
+
+	// AdditionalContext is added to the conversation.
+	AdditionalContext string
+}
+
+func (o *ClaudePreToolUseOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.set("permissionDecision", string(o.Decision))
+	out.set("permissionDecisionReason", o.Reason)
+	out.set("updatedInput", o.UpdatedInput)
+	out.set("additionalContext", o.AdditionalContext)
+	return out.encode(event)
+}
+
+// ClaudePermissionRequestOutput answers PermissionRequest on the user's
+// behalf. An empty Behavior shows the dialog as usual.
+type ClaudePermissionRequestOutput struct {
+	Common
+	Behavior           claudecontract.PermissionBehavior // allow or deny
+	UpdatedInput       json.RawMessage                   // allow: replaces the tool input
+	UpdatedPermissions json.RawMessage                   // allow: permission rule updates to apply
+	Message            string                            // deny: shown to the model
+	Interrupt          bool                              // deny: also stop the turn
+}
+
+func (o *ClaudePermissionRequestOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	if o.Behavior != "" {
+		decision := map[string]any{"behavior": string(o.Behavior)}
+		if len(o.UpdatedInput) > 0 {
+			decision["updatedInput"] = o.UpdatedInput
+		}
+		if len(o.UpdatedPermissions) > 0 {
+			decision["updatedPermissions"] = o.UpdatedPermissions
+		}
+		if o.Message != "" {
+			decision["message"] = o.Message
+		}
+		if o.Interrupt {
+			decision["interrupt"] = true
+		}
+		out.set("decision", decision)
+	}
+	return out.encode(event)
+}
+
+// ClaudePostToolUseOutput answers PostToolUse.
+type ClaudePostToolUseOutput struct {
+	Common
+
+	// Block feeds Reason back to the model as a problem with the result.
+	Block  bool
+	Reason string
+
+	AdditionalContext string
+
+	// UpdatedMCPToolOutput replaces an MCP tool's output.
+	UpdatedMCPToolOutput json.RawMessage
+}
+
+func (o *ClaudePostToolUseOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.block(o.Block, o.Reason)
+	out.set("additionalContext", o.AdditionalContext)
+	out.set("updatedMCPToolOutput", o.UpdatedMCPToolOutput)
+	return out.encode(event)
+}
+
+// ClaudeContextOutput answers events that can only add context:
+// PostToolUseFailure, SessionStart and SubagentStart.
+type ClaudeContextOutput struct {
+	Common
+	AdditionalContext string
+}
+
+func (o *ClaudeContextOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.set("additionalContext", o.AdditionalContext)
+	return out.encode(event)
+}
+
+// ClaudeUserPromptSubmitOutput answers UserPromptSubmit.
+type ClaudeUserPromptSubmitOutput struct {
+	Common
+
+	// Block discards the prompt; Reason is shown to the user.
+	Block  bool
+	Reason string
+
+	// AdditionalContext is added to the conversation with the prompt.
+	AdditionalContext string
+}
+
+func (o *ClaudeUserPromptSubmitOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.block(o.Block, o.Reason)
+	out.set("additionalContext", o.AdditionalContext)
+	return out.encode(event)
+}
+
+// ClaudeBlockOutput answers events whose only decision is to block: Stop
+// and SubagentStop (Block keeps the agent working, with Reason as its
+// instructions) and ConfigChange (Block rejects the change).
+type ClaudeBlockOutput struct {
+	Common
+	Block  bool
+	Reason string
+}
+
+func (o *ClaudeBlockOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.block(o.Block, o.Reason)
+	return out.encode(event)
+}
+
+// ClaudeWorktreeCreateOutput answers WorktreeCreate with the path of the
+// worktree the hook created. The CLI reads it as plain text.
+type ClaudeWorktreeCreateOutput struct {
+	Path string
+}
+
+func (o *ClaudeWorktreeCreateOutput) encode(string) ([]byte, error) {
+	if o == nil || o.Path == "" {
+		return nil, nil
+	}
+	return []byte(o.Path + "\n"), nil
+}
+
+// ClaudeElicitationOutput answers Elicitation (responding instead of the
+// user) and ElicitationResult (overriding the user's response).
+type ClaudeElicitationOutput struct {
+	Common
+	Action  string          // accept, decline or cancel; empty leaves it to the user
+	Content json.RawMessage // Form values when accepting
+}
+
+func (o *ClaudeElicitationOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.set("action", o.Action)
+	out.set("content", o.Content)
+	return out.encode(event)
+}
+
+// ClaudeHandlers maps Claude Code hook events to handlers. Set the fields
+// for the events the program handles; events without a handler exit
+// cleanly with no output. A handler returning a nil output also lets the
+// CLI carry on unchanged, and returning Block(reason) blocks with exit
+// code 2 for any event that can block.
+type ClaudeHandlers struct {
+	PreToolUse         func(context.Context, *ClaudePreToolUseInput) (*ClaudePreToolUseOutput, error)
+	PermissionRequest  func(context.Context, *ClaudePermissionRequestInput) (*ClaudePermissionRequestOutput, error)
+	PostToolUse        func(context.Context, *ClaudePostToolUseInput) (*ClaudePostToolUseOutput, error)
+	PostToolUseFailure func(context.Context, *ClaudePostToolUseFailureInput) (*ClaudeContextOutput, error)
+	UserPromptSubmit   func(context.Context, *ClaudeUserPromptSubmitInput) (*ClaudeUserPromptSubmitOutput, error)
+	Stop               func(context.Context, *ClaudeStopInput) (*ClaudeBlockOutput, error)
+	SubagentStart      func(context.Context, *ClaudeSubagentStartInput) (*ClaudeContextOutput, error)
+	SubagentStop       func(context.Context, *ClaudeSubagentStopInput) (*ClaudeBlockOutput, error)
+	StopFailure        func(context.Context, *ClaudeStopFailureInput) (*Common, error)
+	SessionStart       func(context.Context, *ClaudeSessionStartInput) (*ClaudeContextOutput, error)
+	SessionEnd         func(context.Context, *ClaudeSessionEndInput) (*Common, error)
+	PreCompact         func(context.Context, *ClaudeCompactInput) (*Common, error)
+	PostCompact        func(context.Context, *ClaudeCompactInput) (*Common, error)
+	Notification       func(context.Context, *ClaudeNotificationInput) (*Common, error)
+	TeammateIdle       func(context.Context, *ClaudeTeammateIdleInput) (*Common, error)
+	TaskCompleted      func(context.Context, *ClaudeTaskCompletedInput) (*Common, error)
+	InstructionsLoaded func(context.Context, *ClaudeInstructionsLoadedInput) (*Common, error)
+	ConfigChange       func(context.Context, *ClaudeConfigChangeInput) (*ClaudeBlockOutput, error)
+	WorktreeCreate     func(context.Context, *ClaudeWorktreeCreateInput) (*ClaudeWorktreeCreateOutput, error)
+	WorktreeRemove     func(context.Context, *ClaudeWorktreeRemoveInput) (*Common, error)
+	Elicitation        func(context.Context, *ClaudeElicitationInput) (*ClaudeElicitationOutput, error)
+	ElicitationResult  func(context.Context, *ClaudeElicitationInput) (*ClaudeElicitationOutput, error)
+}
+
+func (h ClaudeHandlers) dispatch(ctx context.Context, event string, payload []byte) (encoder, bool, error) {
+	switch claudecontract.HookEvent(event) {
+	case claudecontract.HookPreToolUse:
+		return decode(ctx, h.PreToolUse, payload)
+	case claudecontract.HookPermissionRequest:
+		return decode(ctx, h.PermissionRequest, payload)
+	case claudecontract.HookPostToolUse:
+		return decode(ctx, h.PostToolUse, payload)
+	case claudecontract.HookPostToolUseFailure:
+		return decode(ctx, h.PostToolUseFailure, payload)
+	case claudecontract.HookUserPromptSubmit:
+		return decode(ctx, h.UserPromptSubmit, payload)
+	case claudecontract.HookStop:
+		return decode(ctx, h.Stop, payload)
+	case claudecontract.HookSubagentStart:
+		return decode(ctx, h.SubagentStart, payload)
+	case claudecontract.HookSubagentStop:
+		return decode(ctx, h.SubagentStop, payload)
+	case claudecontract.HookStopFailure:
+		return decode(ctx, h.StopFailure, payload)
+	case claudecontract.HookSessionStart:
+		return decode(ctx, h.SessionStart, payload)
+	case claudecontract.HookSessionEnd:
+		return decode(ctx, h.SessionEnd, payload)
+	case claudecontract.HookPreCompact:
+		return decode(ctx, h.PreCompact, payload)
+	case claudecontract.HookPostCompact:
+		return decode(ctx, h.PostCompact, payload)
+	case claudecontract.HookNotification:
+		return decode(ctx, h.Notification, payload)
+	case claudecontract.HookTeammateIdle:
+		return decode(ctx, h.TeammateIdle, payload)
+	case claudecontract.HookTaskCompleted:
+		return decode(ctx, h.TaskCompleted, payload)
+	case claudecontract.HookInstructionsLoaded:
+		return decode(ctx, h.InstructionsLoaded, payload)
+	case claudecontract.HookConfigChange:
+		return decode(ctx, h.ConfigChange, payload)
+	case claudecontract.HookWorktreeCreate:
+		return decode(ctx, h.WorktreeCreate, payload)
+	case claudecontract.HookWorktreeRemove:
+		return decode(ctx, h.WorktreeRemove, payload)
+	case claudecontract.HookElicitation:
+		return decode(ctx, h.Elicitation, payload)
+	case claudecontract.HookElicitationResult:
+		return decode(ctx, h.ElicitationResult, payload)
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"context"
+
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+)
+
+// Codex hook inputs are the codexcontract stdin types.
+type (
+	CodexSessionStartInput     = codexcontract.SessionStartInput
+	CodexUserPromptSubmitInput = codexcontract.UserPromptSubmitInput
+	CodexToolInput             = codexcontract.ToolHookInput // PreToolUse and PostToolUse
+	CodexStopInput             = codexcontract.StopInput
+)
+
+// CodexPreToolUseOutput answers PreToolUse.
+type CodexPreToolUseOutput struct {
+	Common
+
+	// Deny stops the tool call; Reason is shown to the model.
+	Deny   bool
+	Reason string
+}
+
+func (o *CodexPreToolUseOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	if o.Deny {
+		out.set("permissionDecision", "deny")
+		out.set("permissionDecisionReason", o.Reason)
+	}
+	return out.encode(event)
+}
+
+// CodexBlockOutput answers PostToolUse and UserPromptSubmit. Block feeds
+// Reason back to the model (PostToolUse) or discards the prompt
+// (UserPromptSubmit).
+type CodexBlockOutput struct {
+	Common
+	Block             bool
+	Reason            string
+	AdditionalContext string
+}
+
+func (o *CodexBlockOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.block(o.Block, o.Reason)
+	out.set("additionalContext", o.AdditionalContext)
+	return out.encode(event)
+}
+
+// CodexStopOutput answers Stop. Block keeps the agent working with Reason
+// as its next instruction.
+type CodexStopOutput struct {
+	Common
+	Block  bool
+	Reason string
+}
+
+func (o *CodexStopOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.block(o.Block, o.Reason)
+	return out.encode(event)
+}
+
+// CodexSessionStartOutput answers SessionStart.
+type CodexSessionStartOutput struct {
+	Common
+	AdditionalContext string
+}
+
+func (o *CodexSessionStartOutput) encode(event string) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := newOutput(o.Common)
+	out.set("additionalContext", o.AdditionalContext)
+	return out.encode(event)
+}
+
+// CodexHandlers maps Codex hook events to handlers, with the same
+// conventions as ClaudeHandlers. Codex runs hooks only with the codex_hooks
+// feature enabled.
+type CodexHandlers struct {
+	SessionStart     func(context.Context, *CodexSessionStartInput) (*CodexSessionStartOutput, error)
+	PreToolUse       func(context.Context, *CodexToolInput) (*CodexPreToolUseOutput, error)
+	PostToolUse      func(context.Context, *CodexToolInput) (*CodexBlockOutput, error)
+	UserPromptSubmit func(context.Context, *CodexUserPromptSubmitInput) (*CodexBlockOutput, error)
+	Stop             func(context.Context, *CodexStopInput) (*CodexStopOutput, error)
+}
+
+func (h CodexHandlers) dispatch(ctx context.Context, event string, payload []byte) (encoder, bool, error) {
+	switch codexcontract.HookEvent(event) {
+	case codexcontract.HookSessionStart:
+		return decode(ctx, h.SessionStart, payload)
+	case codexcontract.HookPreToolUse:
+		return decode(ctx, h.PreToolUse, payload)
+	case codexcontract.HookPostToolUse:
+		return decode(ctx, h.PostToolUse, payload)
+	case codexcontract.HookUserPromptSubmit:
+		return decode(ctx, h.UserPromptSubmit, payload)
+	case codexcontract.HookStop:
+		return decode(ctx, h.Stop, payload)
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,31 @@
+// Package hooks writes Claude Code and Codex hook programs in Go.
+//
+// A hook program is a command the CLI runs for a lifecycle event with a
+// JSON payload on stdin. It answers with JSON on stdout and an exit code:
+// 0 to proceed, 2 to block with a reason on stderr, anything else for a
+// non-blocking failure. Run does all of that around typed handlers:
+//
+//	func main() {
+//	    hooks.Run(hooks.ClaudeHandlers{
+//	        PreToolUse: func(ctx context.Context, in *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+//	            if in.ToolName == "Bash" && strings.Contains(string(in.ToolInput), "rm -rf") {
+//	                return nil, hooks.Block("destructive command")
+//	            }
+//	            return nil, nil
+//	        },
+//	        SessionStart: func(ctx context.Context, in *hooks.ClaudeSessionStartInput) (*hooks.ClaudeContextOutput, error) {
+//	            return &hooks.ClaudeContextOutput{AdditionalContext: "Run make test before finishing."}, nil
+//	        },
+//	    }, hooks.WithTimeout(10*time.Second))
+//	}
+//
+// ClaudeHandlers covers every Claude Code hook event and CodexHandlers every
+// Codex one. Events without a handler, and handlers returning a nil output,
+// let the CLI carry on. Returning Block(reason) blocks with exit code 2;
+// other errors, panics and timeouts fail open with exit code 1 unless
+// WithFailClosed is set. Each output type encodes only the decisions its
+// event supports, with AdditionalContext and the Common fields mapped to
+// the CLI's JSON.
+//
+// Package hookstest invokes handlers with fixture payloads for tests.
+package hooks
@@ -0,0 +1,286 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Exit codes understood by both CLIs.
+const (
+	ExitOK       = 0 // Stdout is parsed as the hook's output
+	ExitError    = 1 // Non-blocking failure; stderr is shown to the user
+	ExitBlocking = 2 // Blocks the action; stderr is fed back to the model
+)
+
+// DefaultTimeout bounds a handler when WithTimeout is not given. It is
+// under Claude Code's 60 second hook timeout so that a slow handler is
+// reported as a failure instead of being killed.
+const DefaultTimeout = 55 * time.Second
+
+// ErrTimeout is reported when a handler does not return within the timeout.
+var ErrTimeout = errors.New("hook handler timed out")
+
+// Handlers is implemented by ClaudeHandlers and CodexHandlers.
+type Handlers interface {
+	// dispatch decodes payload for event and runs its handler. handled is
+	// false when no handler is set for the event.
+	dispatch(ctx context.Context, event string, payload []byte) (out encoder, handled bool, err error)
+}
+
+// encoder is implemented by every output type. A nil receiver writes
+// nothing, which lets the CLI carry on unchanged.
+type encoder interface {
+	encode(event string) ([]byte, error)
+}
+
+// BlockError blocks the action a hook fired for. Run exits with
+// ExitBlocking and writes Reason to stderr, which the CLI shows the model.
+type BlockError struct {
+	Reason string
+}
+
+// Error implements error.
+func (e *BlockError) Error() string {
+	return e.Reason
+}
+
+// Block returns an error that blocks the action with reason. It works for
+// every event that can block, including those without a JSON decision.
+func Block(reason string) error {
+	return &BlockError{Reason: reason}
+}
+
+// Option configures Run and Execute.
+type Option func(*config)
+
+type config struct {
+	timeout    time.Duration
+	failClosed bool
+}
+
+// WithTimeout bounds how long a handler may run; zero disables the bound.
+// Default: DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// WithFailClosed makes handler errors, timeouts and undecodable input block
+// the action (ExitBlocking) instead of failing open (ExitError). Use it for
+// guard hooks where letting an action through on a bug is worse than
+// stopping the agent.
+func WithFailClosed() Option {
+	return func(c *config) { c.failClosed = true }
+}
+
+// Run reads a hook payload from stdin, dispatches it to handlers and exits
+// with the resulting code. It is meant to be the whole of a hook program's
+// main. SIGINT and SIGTERM cancel the handler's context.
+func Run(handlers Handlers, opts ...Option) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := Execute(ctx, handlers, os.Stdin, os.Stdout, os.Stderr, opts...)
+	stop()
+	os.Exit(code)
+}
+
+// Execute is Run without the process: it reads the payload from stdin,
+// writes the hook output to stdout or a message to stderr, and returns the
+// exit code. Events without a handler exit ExitOK with no output.
+func Execute(ctx context.Context, handlers Handlers, stdin io.Reader, stdout, stderr io.Writer, opts ...Option) int {
+	cfg := config{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	payload, err := io.ReadAll(stdin)
+	if err != nil {
+		return cfg.fail(stderr, fmt.Errorf("read hook input: %w", err))
+	}
+	var envelope struct {
+		HookEventName string `json:"hook_event_name"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return cfg.fail(stderr, fmt.Errorf("decode hook input: %w", err))
+	}
+	if envelope.HookEventName == "" {
+		return cfg.fail(stderr, errors.New("decode hook input: missing hook_event_name"))
+	}
+
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		out     encoder
+		handled bool
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{handled: true, err: fmt.Errorf("hook handler panicked: %v", r)}
+			}
+		}()
+		out, handled, err := handlers.dispatch(ctx, envelope.HookEventName, payload)
+		done <- result{out, handled, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return cfg.fail(stderr, fmt.Errorf("%s: %w after %s", envelope.HookEventName, ErrTimeout, cfg.timeout))
+		}
+		return cfg.fail(stderr, fmt.Errorf("%s: %w", envelope.HookEventName, ctx.Err()))
+	}
+
+	var block *BlockError
+	switch {
+	case !r.handled:
+		return ExitOK
+	case errors.As(r.err, &block):
+		fmt.Fprintln(stderr, block.Reason)
+		return ExitBlocking
+	case r.err != nil:
+		return cfg.fail(stderr, fmt.Errorf("%s: %w", envelope.HookEventName, r.err))
+	}
+
+	data, err := r.out.encode(envelope.HookEventName)
+	if err != nil {
+		return cfg.fail(stderr, fmt.Errorf("encode %s output: %w", envelope.HookEventName, err))
+	}
+	if len(data) > 0 {
+		if _, err := stdout.Write(data); err != nil {
+			return cfg.fail(stderr, fmt.Errorf("write hook output: %w", err))
+		}
+	}
+	return ExitOK
+}
+
+// fail reports err on stderr and returns the failure exit code.
+func (c config) fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, err)
+	if c.failClosed {
+		return ExitBlocking
+	}
+	return ExitError
+}
+
+// decode runs fn on payload decoded as In. It adapts a typed handler to
+// dispatch; a nil fn is reported as unhandled.
+func decode[In any, Out encoder](ctx context.Context, fn func(context.Context, *In) (Out, error), payload []byte) (encoder, bool, error) {
+	if fn == nil {
+		return nil, false, nil
+	}
+	in := new(In)
+	if err := json.Unmarshal(payload, in); err != nil {
+		return nil, true, fmt.Errorf("decode input: %w", err)
+	}
+	out, err := fn(ctx, in)
+	return out, true, err
+}
+
+// Common holds the output fields every event accepts.
+type Common struct {
+	// Stop ends the agent's run after the hook, with StopReason shown to
+	// the user. It takes precedence over every event-specific decision.
+	Stop       bool
+	StopReason string
+
+	// SystemMessage is shown to the user as a warning.
+	SystemMessage string
+
+	// SuppressOutput hides the hook's stdout from the transcript.
+	SuppressOutput bool
+}
+
+// encode lets *Common answer events that have only the common fields.
+func (c *Common) encode(event string) ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return newOutput(*c).encode(event)
+}
+
+// fields returns the common output JSON fields.
+func (c Common) fields() map[string]any {
+	out := map[string]any{}
+	if c.Stop {
+		out["continue"] = false
+		if c.StopReason != "" {
+			out["stopReason"] = c.StopReason
+		}
+	}
+	if c.SystemMessage != "" {
+		out["systemMessage"] = c.SystemMessage
+	}
+	if c.SuppressOutput {
+		out["suppressOutput"] = true
+	}
+	return out
+}
+
+// output assembles hook output JSON from the common fields, a top-level
+// block decision and hookSpecificOutput. Empty output writes nothing.
+type output struct {
+	fields   map[string]any
+	specific map[string]any
+}
+
+func newOutput(c Common) *output {
+	return &output{fields: c.fields(), specific: map[string]any{}}
+}
+
+// block sets the top-level "decision": "block" used by events that block
+// with a reason the model sees.
+func (o *output) block(blocked bool, reason string) {
+	if blocked {
+		o.fields["decision"] = "block"
+		o.fields["reason"] = reason
+	}
+}
+
+// set adds a hookSpecificOutput field when value is non-empty.
+func (o *output) set(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case json.RawMessage:
+		if len(v) == 0 {
+			return
+		}
+	case nil:
+		return
+	}
+	o.specific[key] = value
+}
+
+func (o *output) encode(event string) ([]byte, error) {
+	if len(o.specific) > 0 {
+		o.specific["hookEventName"] = event
+		o.fields["hookSpecificOutput"] = o.specific
+	}
+	if len(o.fields) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(o.fields); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
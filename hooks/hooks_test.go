@@ -0,0 +1,223 @@
+package hooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/hooks"
+	"github.com/randalmurphal/llmkit/v2/hooks/hookstest"
+)
+
+func TestClaudeOutputs(t *testing.T) {
+	handlers := hooks.ClaudeHandlers{
+		PreToolUse: func(_ context.Context, in *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+			return &hooks.ClaudePreToolUseOutput{
+				Decision:     claudecontract.PermissionBehaviorAllow,
+				Reason:       "tests are safe",
+				UpdatedInput: json.RawMessage(`{"command":"go test -race ./..."}`),
+			}, nil
+		},
+		PermissionRequest: func(context.Context, *hooks.ClaudePermissionRequestInput) (*hooks.ClaudePermissionRequestOutput, error) {
+			return &hooks.ClaudePermissionRequestOutput{Behavior: claudecontract.PermissionBehaviorDeny, Message: "ask a human", Interrupt: true}, nil
+		},
+		PostToolUse: func(_ context.Context, in *hooks.ClaudePostToolUseInput) (*hooks.ClaudePostToolUseOutput, error) {
+			return &hooks.ClaudePostToolUseOutput{Block: true, Reason: "lint failed", AdditionalContext: "see golangci-lint"}, nil
+		},
+		UserPromptSubmit: func(_ context.Context, in *hooks.ClaudeUserPromptSubmitInput) (*hooks.ClaudeUserPromptSubmitOutput, error) {
+			return &hooks.ClaudeUserPromptSubmitOutput{AdditionalContext: "branch: main"}, nil
+		},
+		Stop: func(_ context.Context, in *hooks.ClaudeStopInput) (*hooks.ClaudeBlockOutput, error) {
+			return &hooks.ClaudeBlockOutput{Common: hooks.Common{Stop: true, StopReason: "budget spent", SystemMessage: "stopping"}}, nil
+		},
+		SessionEnd: func(context.Context, *hooks.ClaudeSessionEndInput) (*hooks.Common, error) {
+			return nil, nil
+		},
+		WorktreeCreate: func(_ context.Context, in *hooks.ClaudeWorktreeCreateInput) (*hooks.ClaudeWorktreeCreateOutput, error) {
+			return &hooks.ClaudeWorktreeCreateOutput{Path: "/tmp/worktrees/" + in.Name}, nil
+		},
+		Elicitation: func(context.Context, *hooks.ClaudeElicitationInput) (*hooks.ClaudeElicitationOutput, error) {
+			return &hooks.ClaudeElicitationOutput{Action: "accept", Content: json.RawMessage(`{"repo":"acme/api"}`)}, nil
+		},
+	}
+
+	tests := []struct {
+		event claudecontract.HookEvent
+		want  string
+	}{
+		{claudecontract.HookPreToolUse, `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow","permissionDecisionReason":"tests are safe","updatedInput":{"command":"go test -race ./..."}}}`},
+		{claudecontract.HookPermissionRequest, `{"hookSpecificOutput":{"decision":{"behavior":"deny","interrupt":true,"message":"ask a human"},"hookEventName":"PermissionRequest"}}`},
+		{claudecontract.HookPostToolUse, `{"decision":"block","hookSpecificOutput":{"additionalContext":"see golangci-lint","hookEventName":"PostToolUse"},"reason":"lint failed"}`},
+		{claudecontract.HookUserPromptSubmit, `{"hookSpecificOutput":{"additionalContext":"branch: main","hookEventName":"UserPromptSubmit"}}`},
+		{claudecontract.HookStop, `{"continue":false,"stopReason":"budget spent","systemMessage":"stopping"}`},
+		{claudecontract.HookSessionEnd, ``},
+		{claudecontract.HookNotification, ``}, // No handler
+		{claudecontract.HookWorktreeCreate, "/tmp/worktrees/feature-auth"},
+		{claudecontract.HookElicitation, `{"hookSpecificOutput":{"action":"accept","content":{"repo":"acme/api"},"hookEventName":"Elicitation"}}`},
+	}
+	for _, tt := range tests {
+		res := hookstest.Invoke(context.Background(), handlers, hookstest.ClaudePayload(tt.event, nil))
+		if res.ExitCode != hooks.ExitOK || res.Stderr != "" {
+			t.Errorf("%s: exit %d, stderr %q", tt.event, res.ExitCode, res.Stderr)
+		}
+		if got := strings.TrimSpace(res.Stdout); got != tt.want {
+			t.Errorf("%s: stdout = %s, want %s", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestClaudeInputDecoding(t *testing.T) {
+	var post *hooks.ClaudePostToolUseInput
+	var stop *hooks.ClaudeSubagentStopInput
+	handlers := hooks.ClaudeHandlers{
+		PostToolUse: func(_ context.Context, in *hooks.ClaudePostToolUseInput) (*hooks.ClaudePostToolUseOutput, error) {
+			post = in
+			return nil, nil
+		},
+		SubagentStop: func(_ context.Context, in *hooks.ClaudeSubagentStopInput) (*hooks.ClaudeBlockOutput, error) {
+			stop = in
+			return nil, nil
+		},
+	}
+	ctx := context.Background()
+	hookstest.Invoke(ctx, handlers, hookstest.ClaudePayload(claudecontract.HookPostToolUse, map[string]any{"tool_name": "Edit"}))
+	hookstest.Invoke(ctx, handlers, hookstest.ClaudePayload(claudecontract.HookSubagentStop, nil))
+
+	if post == nil || post.ToolName != "Edit" || post.HookEventName != claudecontract.HookPostToolUse || post.ToolUseID != "toolu_01ABC" || !strings.Contains(string(post.ToolResponse), "stdout") {
+		t.Fatalf("PostToolUse input = %+v", post)
+	}
+	if stop == nil || stop.AgentType != "Explore" || stop.AgentTranscriptPath == "" || stop.LastAssistantMessage != "Found 3 call sites." {
+		t.Fatalf("SubagentStop input = %+v", stop)
+	}
+}
+
+func TestCodexHandlers(t *testing.T) {
+	handlers := hooks.CodexHandlers{
+		PreToolUse: func(_ context.Context, in *hooks.CodexToolInput) (*hooks.CodexPreToolUseOutput, error) {
+			if in.TurnID != "1" || in.Model != "gpt-5-codex" {
+				t.Errorf("PreToolUse input = %+v", in)
+			}
+			if strings.Contains(string(in.ToolInput), "rm") {
+				return &hooks.CodexPreToolUseOutput{Deny: true, Reason: "no rm"}, nil
+			}
+			return nil, nil
+		},
+		SessionStart: func(_ context.Context, in *hooks.CodexSessionStartInput) (*hooks.CodexSessionStartOutput, error) {
+			if in.Source != codexcontract.SessionStartSourceStartup {
+				t.Errorf("SessionStart source = %q", in.Source)
+			}
+			return &hooks.CodexSessionStartOutput{AdditionalContext: "use make"}, nil
+		},
+		Stop: func(_ context.Context, in *hooks.CodexStopInput) (*hooks.CodexStopOutput, error) {
+			if in.StopHookActive {
+				return nil, nil
+			}
+			return &hooks.CodexStopOutput{Block: true, Reason: "run the tests first"}, nil
+		},
+	}
+	ctx := context.Background()
+
+	res := hookstest.Invoke(ctx, handlers, hookstest.CodexPayload(codexcontract.HookPreToolUse, map[string]any{"tool_input": map[string]any{"command": []string{"rm", "-rf", "/"}}}))
+	if !res.Blocked() || res.Specific()["permissionDecisionReason"] != "no rm" {
+		t.Fatalf("PreToolUse rm = %+v", res)
+	}
+	if res := hookstest.Invoke(ctx, handlers, hookstest.CodexPayload(codexcontract.HookPreToolUse, nil)); res.Blocked() || res.Stdout != "" {
+		t.Fatalf("PreToolUse go test = %+v", res)
+	}
+	if res := hookstest.Invoke(ctx, handlers, hookstest.CodexPayload(codexcontract.HookSessionStart, nil)); res.Specific()["additionalContext"] != "use make" {
+		t.Fatalf("SessionStart = %+v", res)
+	}
+	res = hookstest.Invoke(ctx, handlers, hookstest.CodexPayload(codexcontract.HookStop, nil))
+	if out, _ := res.Output(); out["decision"] != "block" || out["reason"] != "run the tests first" {
+		t.Fatalf("Stop = %+v", res)
+	}
+	if res := hookstest.Invoke(ctx, handlers, hookstest.CodexPayload(codexcontract.HookStop, map[string]any{"stop_hook_active": true})); res.Blocked() {
+		t.Fatalf("Stop with stop_hook_active = %+v", res)
+	}
+}
+
+func TestExecuteFailures(t *testing.T) {
+	slow := func(ctx context.Context, _ *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+		time.Sleep(time.Second) // Ignores ctx; the timeout must still answer
+		return nil, nil
+	}
+	tests := []struct {
+		name       string
+		handler    func(context.Context, *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error)
+		payload    []byte
+		opts       []hooks.Option
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name: "block error",
+			handler: func(context.Context, *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+				return nil, hooks.Block("destructive command")
+			},
+			wantCode:   hooks.ExitBlocking,
+			wantStderr: "destructive command",
+		},
+		{
+			name: "handler error fails open",
+			handler: func(context.Context, *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+				return nil, errors.New("policy server down")
+			},
+			wantCode:   hooks.ExitError,
+			wantStderr: "PreToolUse: policy server down",
+		},
+		{
+			name: "handler error fails closed",
+			handler: func(context.Context, *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+				return nil, errors.New("policy server down")
+			},
+			opts:       []hooks.Option{hooks.WithFailClosed()},
+			wantCode:   hooks.ExitBlocking,
+			wantStderr: "policy server down",
+		},
+		{
+			name: "panic",
+			handler: func(context.Context, *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+				panic("boom")
+			},
+			wantCode:   hooks.ExitError,
+			wantStderr: "panicked: boom",
+		},
+		{
+			name:       "timeout",
+			handler:    slow,
+			opts:       []hooks.Option{hooks.WithTimeout(20 * time.Millisecond)},
+			wantCode:   hooks.ExitError,
+			wantStderr: hooks.ErrTimeout.Error(),
+		},
+		{
+			name:       "bad payload",
+			handler:    slow,
+			payload:    []byte(`not json`),
+			wantCode:   hooks.ExitError,
+			wantStderr: "decode hook input",
+		},
+		{
+			name:       "mistyped field",
+			handler:    slow,
+			payload:    []byte(`{"hook_event_name":"PreToolUse","tool_name":42}`),
+			opts:       []hooks.Option{hooks.WithFailClosed()},
+			wantCode:   hooks.ExitBlocking,
+			wantStderr: "decode input",
+		},
+	}
+	for _, tt := range tests {
+		payload := tt.payload
+		if payload == nil {
+			payload = hookstest.ClaudePayload(claudecontract.HookPreToolUse, nil)
+		}
+		res := hookstest.Invoke(context.Background(), hooks.ClaudeHandlers{PreToolUse: tt.handler}, payload, tt.opts...)
+		if res.ExitCode != tt.wantCode || !strings.Contains(res.Stderr, tt.wantStderr) || res.Stdout != "" {
+			t.Errorf("%s: exit %d, stderr %q, stdout %q; want exit %d with %q", tt.name, res.ExitCode, res.Stderr, res.Stdout, tt.wantCode, tt.wantStderr)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package hookstest runs hooks handlers against fixture payloads, the way
+// net/http/httptest runs HTTP handlers.
+//
+//	payload := hookstest.ClaudePayload(claudecontract.HookPreToolUse, map[string]any{
+//	    "tool_input": map[string]any{"command": "rm -rf /"},
+//	})
+//	res := hookstest.Invoke(ctx, handlers, payload)
+//	if !res.Blocked() { t.Fatal("rm -rf / was allowed") }
+//
+// The fixtures are realistic payloads for every Claude Code and Codex hook
+// event; overrides replace top-level fields.
+package hookstest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/hooks"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// ClaudePayload returns the fixture payload for a Claude Code hook event
+// with overrides applied. It panics if there is no fixture for event.
+func ClaudePayload(event claudecontract.HookEvent, overrides map[string]any) []byte {
+	return payload("claude", string(event), overrides)
+}
+
+// CodexPayload returns the fixture payload for a Codex hook event with
+// overrides applied. It panics if there is no fixture for event.
+func CodexPayload(event codexcontract.HookEvent, overrides map[string]any) []byte {
+	return payload("codex", string(event), overrides)
+}
+
+func payload(provider, event string, overrides map[string]any) []byte {
+	data, err := fixtures.ReadFile("fixtures/" + provider + "/" + event + ".json")
+	if err != nil {
+		panic(fmt.Sprintf("hookstest: no %s fixture for %s", provider, event))
+	}
+	if len(overrides) == 0 {
+		return data
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(fmt.Sprintf("hookstest: %s fixture for %s: %v", provider, event, err))
+	}
+	for k, v := range overrides {
+		fields[k] = v
+	}
+	data, err = json.Marshal(fields)
+	if err != nil {
+		panic(fmt.Sprintf("hookstest: overrides for %s: %v", event, err))
+	}
+	return data
+}
+
+// Result is what a hook program would have produced.
+type Result struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// Invoke runs handlers on payload as hooks.Run would, without exiting.
+func Invoke(ctx context.Context, handlers hooks.Handlers, payload []byte, opts ...hooks.Option) Result {
+	var stdout, stderr bytes.Buffer
+	code := hooks.Execute(ctx, handlers, bytes.NewReader(payload), &stdout, &stderr, opts...)
+	return Result{ExitCode: code, Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Blocked reports whether the hook blocked the action, by exit code or by
+// a JSON deny or block decision.
+func (r Result) Blocked() bool {
+	if r.ExitCode == hooks.ExitBlocking {
+		return true
+	}
+	out, err := r.Output()
+	if err != nil {
+		return false
+	}
+	if out["decision"] == "block" {
+		return true
+	}
+	specific, _ := out["hookSpecificOutput"].(map[string]any)
+	return specific["permissionDecision"] == "deny"
+}
+
+// Output decodes stdout as hook output JSON. Empty stdout decodes to an
+// empty map.
+func (r Result) Output() (map[string]any, error) {
+	out := map[string]any{}
+	if r.Stdout == "" {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(r.Stdout), &out); err != nil {
+		return nil, fmt.Errorf("hook stdout is not JSON: %w", err)
+	}
+	return out, nil
+}
+
+// Specific returns the hookSpecificOutput object, or nil.
+func (r Result) Specific() map[string]any {
+	out, err := r.Output()
+	if err != nil {
+		return nil
+	}
+	specific, _ := out["hookSpecificOutput"].(map[string]any)
+	return specific
+}
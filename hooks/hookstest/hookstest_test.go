@@ -0,0 +1,139 @@
+package hookstest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/claudecontract"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/hooks"
+)
+
+func TestFixturesCoverEveryEvent(t *testing.T) {
+	for _, event := range claudecontract.ValidHookEvents() {
+		assertFixture(t, "claude", string(event), ClaudePayload(event, nil))
+	}
+	for _, event := range codexcontract.ValidHookEvents() {
+		assertFixture(t, "codex", string(event), CodexPayload(event, nil))
+	}
+}
+
+func assertFixture(t *testing.T, provider, event string, payload []byte) {
+	t.Helper()
+	var fields struct {
+		HookEventName string `json:"hook_event_name"`
+		SessionID     string `json:"session_id"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatalf("%s %s fixture: %v", provider, event, err)
+	}
+	if fields.HookEventName != event || fields.SessionID == "" {
+		t.Errorf("%s %s fixture has hook_event_name %q, session_id %q", provider, event, fields.HookEventName, fields.SessionID)
+	}
+}
+
+func TestInvokeDecodesEveryClaudeFixture(t *testing.T) {
+	seen := map[claudecontract.HookEvent]bool{}
+	note := func(e claudecontract.HookEvent) { seen[e] = true }
+	handlers := hooks.ClaudeHandlers{
+		PreToolUse: func(_ context.Context, in *hooks.ClaudePreToolUseInput) (*hooks.ClaudePreToolUseOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		PermissionRequest: func(_ context.Context, in *hooks.ClaudePermissionRequestInput) (*hooks.ClaudePermissionRequestOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		PostToolUse: func(_ context.Context, in *hooks.ClaudePostToolUseInput) (*hooks.ClaudePostToolUseOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		PostToolUseFailure: func(_ context.Context, in *hooks.ClaudePostToolUseFailureInput) (*hooks.ClaudeContextOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		UserPromptSubmit: func(_ context.Context, in *hooks.ClaudeUserPromptSubmitInput) (*hooks.ClaudeUserPromptSubmitOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		Stop: func(_ context.Context, in *hooks.ClaudeStopInput) (*hooks.ClaudeBlockOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		SubagentStart: func(_ context.Context, in *hooks.ClaudeSubagentStartInput) (*hooks.ClaudeContextOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		SubagentStop: func(_ context.Context, in *hooks.ClaudeSubagentStopInput) (*hooks.ClaudeBlockOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		StopFailure: func(_ context.Context, in *hooks.ClaudeStopFailureInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		SessionStart: func(_ context.Context, in *hooks.ClaudeSessionStartInput) (*hooks.ClaudeContextOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		SessionEnd: func(_ context.Context, in *hooks.ClaudeSessionEndInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		PreCompact: func(_ context.Context, in *hooks.ClaudeCompactInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		PostCompact: func(_ context.Context, in *hooks.ClaudeCompactInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		Notification: func(_ context.Context, in *hooks.ClaudeNotificationInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		TeammateIdle: func(_ context.Context, in *hooks.ClaudeTeammateIdleInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		TaskCompleted: func(_ context.Context, in *hooks.ClaudeTaskCompletedInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		InstructionsLoaded: func(_ context.Context, in *hooks.ClaudeInstructionsLoadedInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		ConfigChange: func(_ context.Context, in *hooks.ClaudeConfigChangeInput) (*hooks.ClaudeBlockOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		WorktreeCreate: func(_ context.Context, in *hooks.ClaudeWorktreeCreateInput) (*hooks.ClaudeWorktreeCreateOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		WorktreeRemove: func(_ context.Context, in *hooks.ClaudeWorktreeRemoveInput) (*hooks.Common, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		Elicitation: func(_ context.Context, in *hooks.ClaudeElicitationInput) (*hooks.ClaudeElicitationOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+		ElicitationResult: func(_ context.Context, in *hooks.ClaudeElicitationInput) (*hooks.ClaudeElicitationOutput, error) {
+			note(in.HookEventName)
+			return nil, nil
+		},
+	}
+
+	for _, event := range claudecontract.ValidHookEvents() {
+		res := Invoke(context.Background(), handlers, ClaudePayload(event, nil))
+		if res.ExitCode != hooks.ExitOK || res.Stdout != "" {
+			t.Errorf("%s: %+v", event, res)
+		}
+		if !seen[event] {
+			t.Errorf("%s fixture did not reach its handler", event)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Package monitor follows every local Claude Code and Codex session at once.
+//
+// A Monitor watches ~/.claude/projects and CODEX_HOME/sessions with
+// fsnotify, rescanning periodically for anything notifications miss, and
+// reports typed events as sessions write to their files: session started,
+// assistant message, tool call, todo update, usage update and session idle.
+//
+//	m, err := monitor.Start(ctx, monitor.WithStateFile(statePath))
+//	if err != nil {
+//	    return err
+//	}
+//	defer m.Close()
+//	for ev := range m.Events() {
+//	    fmt.Println(ev.Provider, ev.SessionID, ev.Type)
+//	}
+//
+// Files are opened only while being read, by a fixed pool of workers, so
+// goroutines and file descriptors stay bounded with hundreds of session
+// files. Truncated or replaced files are read again from the start. With
+// WithStateFile, read offsets survive restarts; delivery is at least once,
+// so a monitor stopped mid-line may repeat that line's events.
+package monitor
@@ -0,0 +1,279 @@
+package monitor
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claude/session"
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// EventType identifies what happened in a session.
+type EventType string
+
+const (
+	// EventSessionStarted is sent when a session file is read from its
+	// beginning: a new session, or one whose file was truncated or replaced.
+	EventSessionStarted EventType = "session_started"
+	// EventAssistantMessage carries assistant text in Text.
+	EventAssistantMessage EventType = "assistant_message"
+	// EventToolCall carries a tool invocation in Tool.
+	EventToolCall EventType = "tool_call"
+	// EventTodoUpdate carries the full todo list (Claude TodoWrite) or plan
+	// (Codex update_plan) in Todos.
+	EventTodoUpdate EventType = "todo_update"
+	// EventUsageUpdate carries one request's usage in Usage and the running
+	// total in TotalUsage.
+	EventUsageUpdate EventType = "usage_update"
+	// EventSessionIdle is sent once when an active session has written
+	// nothing for the idle timeout.
+	EventSessionIdle EventType = "session_idle"
+)
+
+// Event is activity in one session file.
+type Event struct {
+	Type      EventType `json:"type"`
+	Provider  string    `json:"provider"`
+	SessionID string    `json:"session_id"`
+	Project   string    `json:"project,omitempty"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	Model     string    `json:"model,omitempty"`
+
+	Text       string    `json:"text,omitempty"`
+	Tool       *ToolCall `json:"tool,omitempty"`
+	Todos      []Todo    `json:"todos,omitempty"`
+	Usage      *Usage    `json:"usage,omitempty"`
+	TotalUsage *Usage    `json:"total_usage,omitempty"`
+}
+
+// ToolCall is a tool invocation.
+type ToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// Todo is one todo or plan item.
+type Todo struct {
+	Content string `json:"content"`
+	Status  string `json:"status"` // "pending", "in_progress", "completed"
+}
+
+// Usage is token usage. InputTokens excludes cached input for both
+// providers.
+type Usage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheCreationTokens += o.CacheCreationTokens
+	u.CacheReadTokens += o.CacheReadTokens
+}
+
+// cursor is the read position and session state of one file. It is what
+// the state file persists.
+type cursor struct {
+	Provider  string `json:"provider"`
+	Offset    int64  `json:"offset"`
+	SessionID string `json:"session_id,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Model     string `json:"model,omitempty"`
+
+	// Claude repeats a request's usage on each content block
+	LastMessageID string `json:"last_message_id,omitempty"`
+	// Codex reports cumulative totals
+	CodexTotal *rollout.TokenUsage `json:"codex_total,omitempty"`
+	// Usage seen since the file was first read
+	Total Usage `json:"total"`
+}
+
+// newCursor returns the cursor for the start of a file. Session IDs come
+// from the file name until the content names them.
+func newCursor(path, provider string) cursor {
+	c := cursor{Provider: provider}
+	if provider == ProviderCodex {
+		if info, ok := rollout.ParseFileName(path); ok {
+			c.SessionID = info.ThreadID
+		}
+	} else {
+		c.SessionID = strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	}
+	return c
+}
+
+func (c *cursor) event(typ EventType, path string, ts time.Time) Event {
+	return Event{
+		Type:      typ,
+		Provider:  c.Provider,
+		SessionID: c.SessionID,
+		Project:   c.Project,
+		Path:      path,
+		Timestamp: ts,
+		Model:     c.Model,
+	}
+}
+
+// parseLine advances c past one line and returns the line's events.
+func parseLine(path string, c *cursor, line []byte) []Event {
+	if c.Provider == ProviderCodex {
+		return parseCodex(path, c, line)
+	}
+	return parseClaude(path, c, line)
+}
+
+func parseClaude(path string, c *cursor, line []byte) []Event {
+	msg, err := session.ParseJSONLMessage(line)
+	if err != nil {
+		return nil
+	}
+	var extra struct {
+		CWD     string `json:"cwd"`
+		Message struct {
+			ID string `json:"id"`
+		} `json:"message"`
+	}
+	_ = json.Unmarshal(line, &extra) // Same line already parsed above
+
+	if msg.SessionID != "" {
+		c.SessionID = msg.SessionID
+	}
+	if extra.CWD != "" {
+		c.Project = extra.CWD
+	}
+	if !msg.IsAssistant() {
+		return nil
+	}
+	if model := msg.GetModel(); model != "" {
+		c.Model = model
+	}
+
+	ts := parseTime(msg.Timestamp)
+	var events []Event
+	if text := msg.GetText(); strings.TrimSpace(text) != "" {
+		ev := c.event(EventAssistantMessage, path, ts)
+		ev.Text = text
+		events = append(events, ev)
+	}
+	for _, call := range msg.GetToolCalls() {
+		ev := c.event(EventToolCall, path, ts)
+		ev.Tool = &ToolCall{ID: call.ID, Name: call.Name, Input: call.Input}
+		events = append(events, ev)
+
+		if call.Name != "TodoWrite" {
+			continue
+		}
+		var input struct {
+			Todos []session.TodoItem `json:"todos"`
+		}
+		if json.Unmarshal(call.Input, &input) == nil {
+			ev := c.event(EventTodoUpdate, path, ts)
+			ev.Todos = make([]Todo, len(input.Todos))
+			for i, todo := range input.Todos {
+				ev.Todos[i] = Todo{Content: todo.Content, Status: todo.Status}
+			}
+			events = append(events, ev)
+		}
+	}
+	if u := msg.GetUsage(); u != nil && (extra.Message.ID == "" || extra.Message.ID != c.LastMessageID) {
+		c.LastMessageID = extra.Message.ID
+		request := Usage{
+			InputTokens:         u.InputTokens,
+			OutputTokens:        u.OutputTokens,
+			CacheCreationTokens: u.CacheCreationInputTokens,
+			CacheReadTokens:     u.CacheReadInputTokens,
+		}
+		events = append(events, c.usageEvent(path, ts, request))
+	}
+	return events
+}
+
+func parseCodex(path string, c *cursor, line []byte) []Event {
+	msg, err := rollout.ParseMessage(line)
+	if err != nil {
+		return nil
+	}
+	ts := parseTime(msg.Timestamp)
+
+	switch {
+	case msg.SessionMeta != nil:
+		if msg.SessionMeta.ID != "" {
+			c.SessionID = msg.SessionMeta.ID
+		}
+		if msg.SessionMeta.CWD != "" {
+			c.Project = msg.SessionMeta.CWD
+		}
+	case msg.TurnContext != nil:
+		if msg.TurnContext.CWD != "" {
+			c.Project = msg.TurnContext.CWD
+		}
+		if model := msg.GetModel(); model != "" {
+			c.Model = model
+		}
+	case msg.IsAssistant():
+		if text := msg.GetText(); strings.TrimSpace(text) != "" {
+			ev := c.event(EventAssistantMessage, path, ts)
+			ev.Text = text
+			return []Event{ev}
+		}
+	case msg.IsToolCall():
+		call := msg.GetToolCall()
+		ev := c.event(EventToolCall, path, ts)
+		ev.Tool = &ToolCall{ID: call.CallID, Name: call.Name, Input: call.Arguments}
+		events := []Event{ev}
+		if plan := msg.GetPlan(); plan != nil {
+			ev := c.event(EventTodoUpdate, path, ts)
+			ev.Todos = make([]Todo, len(plan))
+			for i, step := range plan {
+				ev.Todos[i] = Todo{Content: step.Step, Status: step.Status}
+			}
+			events = append(events, ev)
+		}
+		return events
+	case msg.Event != nil && msg.Event.Info != nil:
+		total := msg.Event.Info.TotalTokenUsage
+		request := msg.Event.Info.LastTokenUsage
+		if prev := c.CodexTotal; prev != nil && total.InputTokens >= prev.InputTokens && total.OutputTokens >= prev.OutputTokens {
+			// Deltas of the running total ignore repeated token_count events.
+			request = rollout.TokenUsage{
+				InputTokens:       total.InputTokens - prev.InputTokens,
+				CachedInputTokens: total.CachedInputTokens - prev.CachedInputTokens,
+				OutputTokens:      total.OutputTokens - prev.OutputTokens,
+			}
+		}
+		c.CodexTotal = &total
+		if request.InputTokens == 0 && request.OutputTokens == 0 {
+			return nil
+		}
+		return []Event{c.usageEvent(path, ts, Usage{
+			InputTokens:     request.InputTokens - request.CachedInputTokens,
+			OutputTokens:    request.OutputTokens,
+			CacheReadTokens: request.CachedInputTokens,
+		})}
+	}
+	return nil
+}
+
+func (c *cursor) usageEvent(path string, ts time.Time, request Usage) Event {
+	c.Total.add(request)
+	total := c.Total
+	ev := c.event(EventUsageUpdate, path, ts)
+	ev.Usage = &request
+	ev.TotalUsage = &total
+	return ev
+}
+
+func parseTime(s string) time.Time {
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Now()
+	}
+	return ts
+}
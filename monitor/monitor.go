@@ -0,0 +1,616 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/randalmurphal/llmkit/v2/claude/jsonl"
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// Providers whose sessions are monitored.
+const (
+	ProviderClaude = "claude"
+	ProviderCodex  = "codex"
+)
+
+// maxReadPerJob bounds how much of one file a worker reads before yielding,
+// so one large backlog cannot starve other sessions.
+const maxReadPerJob = 4 << 20
+
+// Option configures a Monitor.
+type Option func(*config)
+
+// config holds monitor configuration.
+type config struct {
+	// Claude projects directory ("" = not monitored)
+	claudeDir string
+
+	// Codex sessions directory ("" = not monitored)
+	codexDir string
+
+	// File that persists read offsets across restarts ("" = none)
+	stateFile string
+
+	// Read files that exist at start from the beginning
+	fromStart bool
+
+	// Number of file reader goroutines
+	workers int
+
+	// Quiet period after which a session is reported idle
+	idleTimeout time.Duration
+
+	// Interval between directory rescans and idle checks
+	pollInterval time.Duration
+
+	// Events channel capacity
+	buffer int
+}
+
+// defaultConfig returns the default monitor configuration.
+func defaultConfig() config {
+	cfg := config{
+		workers:      4,
+		idleTimeout:  5 * time.Minute,
+		pollInterval: 2 * time.Second,
+		buffer:       256,
+	}
+	if dir, err := jsonl.DefaultProjectsDir(); err == nil {
+		cfg.claudeDir = dir
+	}
+	if dir, err := rollout.DefaultSessionsDir(); err == nil {
+		cfg.codexDir = dir
+	}
+	return cfg
+}
+
+// WithClaudeProjectsDir sets the Claude projects directory to watch. The
+// default is jsonl.DefaultProjectsDir; "" disables Claude monitoring.
+func WithClaudeProjectsDir(dir string) Option {
+	return func(c *config) { c.claudeDir = dir }
+}
+
+// WithCodexSessionsDir sets the Codex sessions directory to watch. The
+// default is rollout.DefaultSessionsDir; "" disables Codex monitoring.
+func WithCodexSessionsDir(dir string) Option {
+	return func(c *config) { c.codexDir = dir }
+}
+
+// WithStateFile persists read offsets to path so a restarted monitor
+// resumes where the previous one stopped instead of at the end of each file.
+func WithStateFile(path string) Option {
+	return func(c *config) { c.stateFile = path }
+}
+
+// WithFromStart reads files that already exist at start, and have no saved
+// offset, from the beginning. By default only content written after Start
+// is reported.
+func WithFromStart() Option {
+	return func(c *config) { c.fromStart = true }
+}
+
+// WithWorkers sets how many files are read concurrently. The default is 4.
+// The monitor runs this many goroutines plus one, however many files exist.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithIdleTimeout sets how long a session must be quiet before
+// EventSessionIdle. The default is 5 minutes.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.idleTimeout = d
+		}
+	}
+}
+
+// WithPollInterval sets how often directories are rescanned and idle
+// sessions are checked. Rescans pick up changes fsnotify missed, so this is
+// also the worst-case latency without fsnotify. The default is 2 seconds.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.pollInterval = d
+		}
+	}
+}
+
+// WithBuffer sets the Events channel capacity. The default is 256. A full
+// channel pauses reading until the consumer catches up.
+func WithBuffer(n int) Option {
+	return func(c *config) {
+		if n >= 0 {
+			c.buffer = n
+		}
+	}
+}
+
+// file is a tracked session file. It is owned by the event loop.
+type file struct {
+	path   string
+	cursor cursor
+	info   os.FileInfo // Identity at the last read, for rotation checks
+
+	lastActivity time.Time
+	idle         bool
+
+	queued  bool // Waiting in the job queue
+	reading bool // Handed to a worker
+	dirty   bool // Changed while reading
+}
+
+// job asks a worker to read a file from its cursor.
+type job struct {
+	path   string
+	cursor cursor
+	info   os.FileInfo
+}
+
+// result is a worker's report on a job.
+type result struct {
+	path   string
+	cursor cursor
+	info   os.FileInfo
+	read   bool // New lines were read
+	more   bool // Stopped at maxReadPerJob
+	gone   bool // The file no longer exists
+}
+
+// Monitor watches Claude and Codex session directories and reports activity
+// in every session as typed events.
+type Monitor struct {
+	config config
+	events chan Event
+
+	watcher *fsnotify.Watcher // nil when only polling
+	watched map[string]bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	jobs    chan job
+	results chan result
+	workers sync.WaitGroup
+
+	// Owned by the event loop
+	files      map[string]*file
+	queue      []*file
+	recovered  map[string]cursor
+	stateDirty bool
+
+	errMu sync.Mutex
+	err   error
+}
+
+// Start scans the session directories and begins monitoring them until ctx
+// is cancelled or Close is called. Directories that do not exist yet are
+// picked up when they appear.
+func Start(ctx context.Context, opts ...Option) (*Monitor, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	m := &Monitor{
+		config:  cfg,
+		events:  make(chan Event, cfg.buffer),
+		watched: make(map[string]bool),
+		done:    make(chan struct{}),
+		jobs:    make(chan job),
+		results: make(chan result, cfg.workers),
+		files:   make(map[string]*file),
+	}
+	if cfg.stateFile != "" {
+		recovered, err := loadState(cfg.stateFile)
+		if err != nil {
+			return nil, err
+		}
+		m.recovered = recovered
+	}
+	if watcher, err := fsnotify.NewWatcher(); err == nil {
+		m.watcher = watcher // Without it, rescans find every change
+	}
+	m.scan(true)
+	m.recovered = nil
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	for range cfg.workers {
+		m.workers.Add(1)
+		go m.work(ctx)
+	}
+	go m.run(ctx)
+	return m, nil
+}
+
+// Events returns the event channel. It is closed after the monitor stops.
+func (m *Monitor) Events() <-chan Event {
+	return m.events
+}
+
+// Err returns the first error that stopped state from being saved, if any.
+func (m *Monitor) Err() error {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	return m.err
+}
+
+// Close stops the monitor, saves its state and waits for Events to close.
+func (m *Monitor) Close() error {
+	m.cancel()
+	<-m.done
+	return m.Err()
+}
+
+func (m *Monitor) setErr(err error) {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// run is the event loop. It owns the file table and feeds the workers.
+func (m *Monitor) run(ctx context.Context) {
+	defer close(m.done)
+	defer close(m.events)
+
+	ticker := time.NewTicker(m.config.pollInterval)
+	defer ticker.Stop()
+
+	var watchEvents <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if m.watcher != nil {
+		defer m.watcher.Close()
+		watchEvents, watchErrors = m.watcher.Events, m.watcher.Errors
+	}
+
+	for {
+		var jobs chan<- job
+		var next job
+		if len(m.queue) > 0 {
+			jobs = m.jobs
+			f := m.queue[0]
+			next = job{path: f.path, cursor: f.cursor, info: f.info}
+		}
+
+		select {
+		case <-ctx.Done():
+			m.shutdown()
+			return
+
+		case jobs <- next:
+			f := m.queue[0]
+			m.queue = m.queue[1:]
+			f.queued = false
+			f.reading = true
+
+		case r := <-m.results:
+			m.finish(r, time.Now())
+
+		case ev, ok := <-watchEvents:
+			if !ok {
+				watchEvents = nil
+				continue
+			}
+			m.handle(ev)
+
+		case _, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+			}
+			// Overflows and watch errors are covered by the next rescan
+
+		case now := <-ticker.C:
+			m.scan(false)
+			m.checkIdle(ctx, now)
+			m.saveIfDirty()
+		}
+	}
+}
+
+// shutdown stops the workers, keeps the offsets they reached and saves.
+func (m *Monitor) shutdown() {
+	close(m.jobs)
+	m.workers.Wait()
+	for {
+		select {
+		case r := <-m.results:
+			m.finish(r, time.Now())
+		default:
+			m.saveIfDirty()
+			return
+		}
+	}
+}
+
+// work reads files handed out by the event loop.
+func (m *Monitor) work(ctx context.Context) {
+	defer m.workers.Done()
+	for j := range m.jobs {
+		r := m.read(ctx, j)
+		select {
+		case m.results <- r:
+		case <-ctx.Done():
+			// results has room for one report per worker, so this only
+			// loses a report when the loop fell behind; the lines are then
+			// read again after a restart.
+			select {
+			case m.results <- r:
+			default:
+			}
+			return
+		}
+	}
+}
+
+// finish applies a worker's result.
+func (m *Monitor) finish(r result, now time.Time) {
+	f := m.files[r.path]
+	if f == nil {
+		return
+	}
+	f.reading = false
+	if r.gone {
+		delete(m.files, r.path)
+		m.stateDirty = true
+		return
+	}
+	if f.cursor != r.cursor {
+		m.stateDirty = true
+	}
+	f.cursor = r.cursor
+	f.info = r.info
+	if r.read {
+		f.lastActivity = now
+		f.idle = false
+	}
+	if r.more || f.dirty {
+		f.dirty = false
+		m.markDirty(f)
+	}
+}
+
+// markDirty queues f for reading, or re-reads it after its current read.
+func (m *Monitor) markDirty(f *file) {
+	switch {
+	case f.reading:
+		f.dirty = true
+	case !f.queued:
+		f.queued = true
+		m.queue = append(m.queue, f)
+	}
+}
+
+// handle reacts to a filesystem notification.
+func (m *Monitor) handle(ev fsnotify.Event) {
+	provider := m.providerFor(ev.Name)
+	if provider == "" {
+		return
+	}
+	switch {
+	case ev.Has(fsnotify.Create):
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			m.walk(ev.Name, provider, false) // Files may predate the new watch
+			return
+		}
+		if isSessionFile(provider, ev.Name) {
+			m.touch(ev.Name, provider, false)
+		}
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		delete(m.watched, ev.Name)
+		if f := m.files[ev.Name]; f != nil && !f.queued && !f.reading {
+			delete(m.files, ev.Name)
+			m.stateDirty = true
+		}
+	case ev.Has(fsnotify.Write):
+		if isSessionFile(provider, ev.Name) {
+			m.touch(ev.Name, provider, false)
+		}
+	}
+}
+
+// scan walks both session directories, adding watches for new directories,
+// queueing changed files and forgetting deleted ones.
+func (m *Monitor) scan(initial bool) {
+	seen := make(map[string]bool, len(m.files))
+	for _, root := range m.roots() {
+		for _, path := range m.walk(root.dir, root.provider, initial) {
+			seen[path] = true
+		}
+	}
+	for path, f := range m.files {
+		if !seen[path] && !f.queued && !f.reading {
+			delete(m.files, path)
+			m.stateDirty = true
+		}
+	}
+}
+
+// walk watches dir and its subdirectories and touches the session files in
+// them, which it returns.
+func (m *Monitor) walk(dir, provider string, initial bool) []string {
+	var paths []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Vanished or unreadable; the next rescan retries
+		}
+		if d.IsDir() {
+			if m.watcher != nil && !m.watched[path] && m.watcher.Add(path) == nil {
+				m.watched[path] = true
+			}
+			return nil
+		}
+		if isSessionFile(provider, path) {
+			paths = append(paths, path)
+			m.touch(path, provider, initial)
+		}
+		return nil
+	})
+	return paths
+}
+
+// touch starts tracking path and queues it if it changed.
+func (m *Monitor) touch(path, provider string, initial bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	f := m.files[path]
+	if f == nil {
+		f = &file{path: path}
+		if c, ok := m.recovered[path]; ok && c.Provider == provider {
+			f.cursor = c
+		} else {
+			f.cursor = newCursor(path, provider)
+			if initial && !m.config.fromStart {
+				f.cursor.Offset = info.Size()
+				f.info = info
+			}
+		}
+		m.files[path] = f
+		m.stateDirty = true
+	}
+	if info.Size() != f.cursor.Offset || (f.info != nil && !os.SameFile(f.info, info)) {
+		m.markDirty(f)
+	}
+}
+
+// checkIdle reports sessions that have been quiet for the idle timeout.
+func (m *Monitor) checkIdle(ctx context.Context, now time.Time) {
+	for _, f := range m.files {
+		if f.idle || f.lastActivity.IsZero() || now.Sub(f.lastActivity) < m.config.idleTimeout {
+			continue
+		}
+		f.idle = true
+		ev := f.cursor.event(EventSessionIdle, f.path, now)
+		select {
+		case m.events <- ev:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Monitor) saveIfDirty() {
+	if !m.stateDirty || m.config.stateFile == "" {
+		return
+	}
+	cursors := make(map[string]cursor, len(m.files))
+	for path, f := range m.files {
+		cursors[path] = f.cursor
+	}
+	if err := saveState(m.config.stateFile, cursors); err != nil {
+		m.setErr(err)
+		return
+	}
+	m.stateDirty = false
+}
+
+// read reads complete lines from the job's cursor and emits their events.
+// A trailing line without its newline is left for the next read.
+func (m *Monitor) read(ctx context.Context, j job) result {
+	r := result{path: j.path, cursor: j.cursor, info: j.info}
+	f, err := os.Open(j.path)
+	if err != nil {
+		r.gone = errors.Is(err, os.ErrNotExist)
+		return r
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return r
+	}
+	if (j.info != nil && !os.SameFile(j.info, info)) || info.Size() < r.cursor.Offset {
+		// Rotated or truncated: the file holds new content from the start.
+		r.cursor = newCursor(j.path, r.cursor.Provider)
+	}
+	r.info = info
+	if r.cursor.Offset >= info.Size() {
+		return r
+	}
+	if _, err := f.Seek(r.cursor.Offset, io.SeekStart); err != nil {
+		return r
+	}
+
+	announce := r.cursor.Offset == 0
+	start := r.cursor.Offset
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for r.cursor.Offset-start < maxReadPerJob {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return r // EOF or a partial line
+		}
+		next := r.cursor
+		events := parseLine(j.path, &next, line[:len(line)-1])
+		if announce && (len(events) > 0 || next.SessionID != r.cursor.SessionID || next.Project != r.cursor.Project) {
+			announce = false
+			ts := time.Now()
+			if len(events) > 0 {
+				ts = events[0].Timestamp
+			}
+			events = append([]Event{next.event(EventSessionStarted, j.path, ts)}, events...)
+		}
+		for _, ev := range events {
+			select {
+			case m.events <- ev:
+			case <-ctx.Done():
+				return r // Resume at this line
+			}
+		}
+		next.Offset += int64(len(line))
+		r.cursor = next
+		r.read = true
+	}
+	r.more = true
+	return r
+}
+
+type root struct {
+	dir      string
+	provider string
+}
+
+func (m *Monitor) roots() []root {
+	var roots []root
+	if m.config.claudeDir != "" {
+		roots = append(roots, root{m.config.claudeDir, ProviderClaude})
+	}
+	if m.config.codexDir != "" {
+		roots = append(roots, root{m.config.codexDir, ProviderCodex})
+	}
+	return roots
+}
+
+// providerFor returns the provider whose directory contains path.
+func (m *Monitor) providerFor(path string) string {
+	for _, r := range m.roots() {
+		if rel, err := filepath.Rel(r.dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return r.provider
+		}
+	}
+	return ""
+}
+
+func isSessionFile(provider, path string) bool {
+	if provider == ProviderCodex {
+		_, ok := rollout.ParseFileName(path)
+		return ok
+	}
+	return strings.HasSuffix(path, ".jsonl")
+}
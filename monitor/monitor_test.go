@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const claudeLine = `{"type":"assistant","timestamp":"2025-06-01T10:00:00Z","sessionId":"c1","cwd":"/work/api","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Looking now."},{"type":"tool_use","id":"toolu_1","name":"TodoWrite","input":{"todos":[{"content":"Fix tests","status":"in_progress","activeForm":"Fixing tests"}]}}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+`
+
+// The repeated usage line belongs to the same request and adds nothing.
+const claudeRepeat = `{"type":"assistant","timestamp":"2025-06-01T10:00:01Z","sessionId":"c1","cwd":"/work/api","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Done."}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+`
+
+const codexLines = `{"timestamp":"2025-06-02T09:00:00Z","type":"session_meta","payload":{"id":"thread-1","cwd":"/work/web"}}
+{"timestamp":"2025-06-02T09:00:01Z","type":"turn_context","payload":{"cwd":"/work/web","model":"gpt-5-codex"}}
+{"timestamp":"2025-06-02T09:00:02Z","type":"response_item","payload":{"type":"function_call","name":"update_plan","arguments":"{\"plan\":[{\"step\":\"Write code\",\"status\":\"completed\"}]}","call_id":"call_1"}}
+{"timestamp":"2025-06-02T09:00:03Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100}}}}
+{"timestamp":"2025-06-02T09:00:04Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100}}}}
+`
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next returns the next event, failing the test after a timeout.
+func next(t *testing.T, m *Monitor) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-m.Events():
+		if !ok {
+			t.Fatal("events closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func expect(t *testing.T, m *Monitor, want ...EventType) []Event {
+	t.Helper()
+	events := make([]Event, len(want))
+	for i, typ := range want {
+		events[i] = next(t, m)
+		if events[i].Type != typ {
+			t.Fatalf("event %d = %s (%+v), want %s", i, events[i].Type, events[i], typ)
+		}
+	}
+	return events
+}
+
+func TestMonitorReportsSessionActivity(t *testing.T) {
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, "projects")
+	codexDir := filepath.Join(root, "sessions")
+	existing := filepath.Join(claudeDir, "-work-old", "old.jsonl")
+	appendFile(t, existing, claudeLine) // Written before Start, so not reported
+
+	m, err := Start(context.Background(),
+		WithClaudeProjectsDir(claudeDir),
+		WithCodexSessionsDir(codexDir),
+		WithPollInterval(20*time.Millisecond),
+		WithIdleTimeout(300*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	appendFile(t, existing, claudeRepeat)
+	events := expect(t, m, EventAssistantMessage, EventUsageUpdate)
+	if events[0].Text != "Done." || events[0].SessionID != "c1" {
+		t.Fatalf("assistant event = %+v", events[0])
+	}
+
+	path := filepath.Join(claudeDir, "-work-api", "c1.jsonl")
+	appendFile(t, path, claudeLine+claudeRepeat)
+	events = expect(t, m,
+		EventSessionStarted, EventAssistantMessage, EventToolCall, EventTodoUpdate, EventUsageUpdate,
+		EventAssistantMessage, // The repeat's usage is skipped
+	)
+	if events[0].Project != "/work/api" || events[0].Path != path {
+		t.Fatalf("started event = %+v", events[0])
+	}
+	if todos := events[3].Todos; len(todos) != 1 || todos[0].Status != "in_progress" {
+		t.Fatalf("todos = %+v", todos)
+	}
+	if u := events[4].Usage; u.InputTokens != 10 || u.CacheReadTokens != 100 || events[4].Model != "claude-sonnet-4-5" {
+		t.Fatalf("usage event = %+v", events[4])
+	}
+
+	rolloutPath := filepath.Join(codexDir, "2025", "06", "02", "rollout-2025-06-02T09-00-00-thread-1.jsonl")
+	appendFile(t, rolloutPath, codexLines)
+	events = expect(t, m, EventSessionStarted, EventToolCall, EventTodoUpdate, EventUsageUpdate)
+	if events[0].SessionID != "thread-1" || events[0].Project != "/work/web" {
+		t.Fatalf("codex started event = %+v", events[0])
+	}
+	if u := events[3].Usage; u.InputTokens != 600 || u.CacheReadTokens != 400 || u.OutputTokens != 100 {
+		t.Fatalf("codex usage = %+v", u)
+	}
+
+	idle := map[string]bool{}
+	for len(idle) < 3 {
+		ev := next(t, m)
+		if ev.Type != EventSessionIdle {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+		idle[ev.Path] = true
+	}
+}
+
+func TestMonitorRecoversOffsetsAndHandlesTruncation(t *testing.T) {
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, "projects")
+	statePath := filepath.Join(root, "state.json")
+	path := filepath.Join(claudeDir, "-work-api", "c1.jsonl")
+	opts := []Option{
+		WithClaudeProjectsDir(claudeDir),
+		WithCodexSessionsDir(""),
+		WithStateFile(statePath),
+		WithPollInterval(20 * time.Millisecond),
+	}
+
+	m, err := Start(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, claudeLine)
+	expect(t, m, EventSessionStarted, EventAssistantMessage, EventToolCall, EventTodoUpdate, EventUsageUpdate)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Written while stopped; a restart picks up from the saved offset.
+	appendFile(t, path, claudeRepeat)
+	m, err = Start(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	events := expect(t, m, EventAssistantMessage)
+	if events[0].Text != "Done." {
+		t.Fatalf("resumed event = %+v", events[0])
+	}
+
+	if err := os.WriteFile(path, []byte(claudeRepeat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events = expect(t, m, EventSessionStarted, EventAssistantMessage, EventUsageUpdate)
+	if events[2].TotalUsage.OutputTokens != 5 {
+		t.Fatalf("usage after truncation = %+v, want a fresh total", events[2].TotalUsage)
+	}
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stateVersion is bumped when the state format changes; older state is
+// ignored and files are read from their ends again.
+const stateVersion = 1
+
+// state is the persisted form of a monitor's file table.
+type state struct {
+	Version int               `json:"version"`
+	Files   map[string]cursor `json:"files"`
+}
+
+// loadState reads saved cursors. A missing, corrupt or outdated state file
+// yields none.
+func loadState(path string) (map[string]cursor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read monitor state: %w", err)
+	}
+	var s state
+	if json.Unmarshal(data, &s) != nil || s.Version != stateVersion {
+		return nil, nil
+	}
+	return s.Files, nil
+}
+
+// saveState atomically replaces the state file.
+func saveState(path string, files map[string]cursor) error {
+	data, err := json.Marshal(state{Version: stateVersion, Files: files})
+	if err != nil {
+		return fmt.Errorf("marshal monitor state: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create monitor state dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".monitor-state-*")
+	if err != nil {
+		return fmt.Errorf("create temp state file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write temp state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close temp state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("rename state file: %w", err)
+	}
+	return nil
+}
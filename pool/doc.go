@@ -0,0 +1,14 @@
+// Package pool keeps pre-started sessions ready so callers do not pay
+// startup latency on the hot path.
+//
+// A Pool is generic over the session type. It creates sessions through a
+// caller-supplied function, fills itself in the background, and replaces
+// idle sessions that go stale or stop being active:
+//
+//	p := pool.New(create, func(s Session) bool { return s.Status() == StatusActive },
+//	    pool.WithSize(2), pool.WithMaxIdleAge(time.Minute))
+//	defer p.Close()
+//	s, err := p.Acquire(ctx)
+//
+// The claude/session and codex/session packages wrap it as their NewPool.
+package pool
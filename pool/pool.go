@@ -0,0 +1,294 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned by Acquire after Close.
+var ErrClosed = errors.New("pool is closed")
+
+// Session is the constraint on pooled sessions: the pool closes the ones it
+// evicts or still holds at Close.
+type Session interface {
+	Close() error
+}
+
+// Option configures a Pool.
+type Option func(*config)
+
+// config holds pool configuration.
+type config struct {
+	// Idle sessions kept ready
+	size int
+
+	// Idle sessions older than this are replaced (0 = never)
+	maxIdleAge time.Duration
+
+	// How often idle sessions are checked and refilled
+	checkInterval time.Duration
+}
+
+// defaultConfig returns the default pool configuration.
+func defaultConfig() config {
+	return config{
+		size:          1,
+		maxIdleAge:    5 * time.Minute,
+		checkInterval: 10 * time.Second,
+	}
+}
+
+// WithSize sets how many idle sessions the pool keeps ready.
+func WithSize(n int) Option {
+	return func(c *config) { c.size = n }
+}
+
+// WithMaxIdleAge sets how long a session may sit in the pool before it is
+// closed and replaced. Keep it below the sessions' idle timeout.
+func WithMaxIdleAge(d time.Duration) Option {
+	return func(c *config) { c.maxIdleAge = d }
+}
+
+// WithCheckInterval sets how often the pool evicts stale or inactive
+// sessions and retries failed refills.
+func WithCheckInterval(d time.Duration) Option {
+	return func(c *config) { c.checkInterval = d }
+}
+
+// Stats reports pool activity.
+type Stats struct {
+	// Hits counts Acquire calls served from an idle session.
+	Hits uint64 `json:"hits"`
+	// Misses counts Acquire calls that had to create a session.
+	Misses uint64 `json:"misses"`
+	// Evicted counts idle sessions closed because they were stale or no
+	// longer active.
+	Evicted uint64 `json:"evicted"`
+	// Idle is the number of sessions ready right now.
+	Idle int `json:"idle"`
+	// LastError is the most recent background refill failure, if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+type pooledSession[S Session] struct {
+	session S
+	addedAt time.Time
+}
+
+// Pool keeps pre-started sessions so Acquire does not pay startup latency.
+// It creates sessions with the function given to New and keeps only those
+// the active function accepts.
+type Pool[S Session] struct {
+	create func(context.Context) (S, error)
+	active func(S) bool
+	config config
+
+	mu     sync.Mutex
+	idle   []pooledSession[S]
+	closed bool
+	err    error
+
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	evicted atomic.Uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+// New creates a pool that fills itself in the background. create makes a
+// session; it should fail rather than block when no more sessions may be
+// started. active reports whether an idle session can still be handed out.
+func New[S Session](create func(context.Context) (S, error), active func(S) bool, opts ...Option) *Pool[S] {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[S]{
+		create: create,
+		active: active,
+		config: cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Acquire returns an idle session, or creates one when none is ready. The
+// caller owns the returned session and closes it as usual.
+func (p *Pool[S]) Acquire(ctx context.Context) (S, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		var zero S
+		return zero, ErrClosed
+	}
+	var stale []S
+	var got S
+	found := false
+	for len(p.idle) > 0 {
+		ps := p.idle[0]
+		p.idle = p.idle[1:]
+		if p.usable(ps) {
+			got, found = ps.session, true
+			break
+		}
+		stale = append(stale, ps.session)
+	}
+	p.mu.Unlock()
+
+	p.evict(stale)
+	p.signal()
+
+	if found {
+		p.hits.Add(1)
+		return got, nil
+	}
+	p.misses.Add(1)
+	return p.create(ctx)
+}
+
+// Stats returns the pool's counters.
+func (p *Pool[S]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := Stats{
+		Hits:    p.hits.Load(),
+		Misses:  p.misses.Load(),
+		Evicted: p.evicted.Load(),
+		Idle:    len(p.idle),
+	}
+	if p.err != nil {
+		stats.LastError = p.err.Error()
+	}
+	return stats
+}
+
+// Close stops refilling and closes the idle sessions. Sessions already
+// handed out are not affected.
+func (p *Pool[S]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	p.cancel()
+	<-p.done
+
+	var lastErr error
+	for _, ps := range idle {
+		if err := ps.session.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// run refills the pool until Close.
+func (p *Pool[S]) run() {
+	defer close(p.done)
+
+	var tick <-chan time.Time
+	if p.config.checkInterval > 0 {
+		ticker := time.NewTicker(p.config.checkInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		p.evictUnusable()
+		p.fill()
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.wake:
+		case <-tick:
+		}
+	}
+}
+
+// fill creates sessions until the pool is full, the manager refuses, or
+// the pool closes.
+func (p *Pool[S]) fill() {
+	for {
+		p.mu.Lock()
+		need := !p.closed && len(p.idle) < p.config.size
+		p.mu.Unlock()
+		if !need {
+			return
+		}
+
+		s, err := p.create(p.ctx)
+
+		p.mu.Lock()
+		p.err = err
+		if err != nil {
+			p.mu.Unlock()
+			return
+		}
+		if p.closed {
+			p.mu.Unlock()
+			_ = s.Close()
+			return
+		}
+		p.idle = append(p.idle, pooledSession[S]{session: s, addedAt: time.Now()})
+		p.mu.Unlock()
+	}
+}
+
+// evictUnusable closes idle sessions that are stale or no longer active.
+func (p *Pool[S]) evictUnusable() {
+	p.mu.Lock()
+	var stale []S
+	kept := p.idle[:0]
+	for _, ps := range p.idle {
+		if p.usable(ps) {
+			kept = append(kept, ps)
+		} else {
+			stale = append(stale, ps.session)
+		}
+	}
+	p.idle = kept
+	p.mu.Unlock()
+
+	p.evict(stale)
+}
+
+func (p *Pool[S]) usable(ps pooledSession[S]) bool {
+	if !p.active(ps.session) {
+		return false
+	}
+	return p.config.maxIdleAge <= 0 || time.Since(ps.addedAt) < p.config.maxIdleAge
+}
+
+func (p *Pool[S]) evict(sessions []S) {
+	for _, s := range sessions {
+		p.evicted.Add(1)
+		_ = s.Close() // Best effort; the session is already unusable
+	}
+}
+
+// signal wakes the refill loop without blocking.
+func (p *Pool[S]) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
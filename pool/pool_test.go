@@ -0,0 +1,132 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSession is a pooled session whose liveness the test controls.
+type testSession struct {
+	id     int
+	active atomic.Bool
+}
+
+func (s *testSession) Close() error {
+	s.active.Store(false)
+	return nil
+}
+
+// testFactory creates testSessions, refusing once max are active.
+type testFactory struct {
+	mu      sync.Mutex
+	max     int
+	created []*testSession
+}
+
+func (f *testFactory) create(context.Context) (*testSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	live := 0
+	for _, s := range f.created {
+		if s.active.Load() {
+			live++
+		}
+	}
+	if live >= f.max {
+		return nil, fmt.Errorf("max sessions reached (%d)", f.max)
+	}
+	s := &testSession{id: len(f.created) + 1}
+	s.active.Store(true)
+	f.created = append(f.created, s)
+	return s, nil
+}
+
+func (f *testFactory) session(i int) *testSession {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created[i]
+}
+
+func isActive(s *testSession) bool { return s.active.Load() }
+
+func TestAcquireHitsMissesAndRefill(t *testing.T) {
+	f := &testFactory{max: 2}
+	p := New(f.create, isActive, WithSize(2), WithCheckInterval(10*time.Millisecond))
+	defer p.Close()
+	waitForIdle(t, p, 2)
+
+	first, err := p.Acquire(context.Background())
+	if err != nil || first.id != 1 {
+		t.Fatalf("Acquire = %v, %v; want pooled session 1", first, err)
+	}
+
+	// The factory is full (one acquired, one idle), so the pool cannot refill.
+	time.Sleep(40 * time.Millisecond)
+	if stats := p.Stats(); stats.Idle != 1 || stats.Hits != 1 || stats.LastError == "" {
+		t.Fatalf("stats after first acquire = %+v", stats)
+	}
+
+	if _, err := p.Acquire(context.Background()); err != nil {
+		t.Fatalf("second Acquire returned error: %v", err)
+	}
+	if _, err := p.Acquire(context.Background()); err == nil {
+		t.Fatal("third Acquire should fail while the factory is full")
+	}
+	if stats := p.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v, want 2 hits and 1 miss", stats)
+	}
+
+	// Releasing a session frees a slot for the background refill.
+	_ = first.Close()
+	waitForIdle(t, p, 1)
+}
+
+func TestEvictsStaleAndInactiveSessions(t *testing.T) {
+	f := &testFactory{max: 4}
+	p := New(f.create, isActive,
+		WithMaxIdleAge(50*time.Millisecond),
+		WithCheckInterval(10*time.Millisecond),
+	)
+	waitForIdle(t, p, 1)
+
+	_ = f.session(0).Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Stats().Evicted < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, want inactive and stale sessions evicted", p.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Acquire after Close = %v, want ErrClosed", err)
+	}
+	if stats := p.Stats(); stats.Idle != 0 {
+		t.Fatalf("idle after Close = %d", stats.Idle)
+	}
+	for i := range f.created {
+		if f.session(i).active.Load() {
+			t.Errorf("session %d still active after Close", i+1)
+		}
+	}
+}
+
+func waitForIdle(t *testing.T, p *Pool[*testSession], want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Stats().Idle != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool stats = %+v, want %d idle", p.Stats(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
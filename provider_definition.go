@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+
+	"github.com/randalmurphal/llmkit/v2/env"
 )
 
 type ProviderDefinition struct {
@@ -11,6 +13,7 @@ type ProviderDefinition struct {
 	Supported   bool               `json:"supported"`
 	Shared      SharedSupport      `json:"shared"`
 	Environment EnvironmentSupport `json:"environment"`
+	Request     RequestSupport     `json:"request"`
 	validate    func(RuntimeConfig) error
 }
 
@@ -26,6 +29,7 @@ type SharedSupport struct {
 	MaxTurns           bool `json:"max_turns"`
 	Env                bool `json:"env"`
 	AddDirs            bool `json:"add_dirs"`
+	Hooks              bool `json:"hooks"`
 }
 
 type EnvironmentSupport struct {
@@ -94,6 +98,9 @@ func ValidateRuntimeConfig(provider string, cfg RuntimeConfig) error {
 	if err := validateUnsupportedSharedConfig(provider, def.Shared, cfg.Shared); err != nil {
 		return err
 	}
+	if _, err := env.TranslateHooks(provider, cfg.Shared.Hooks); err != nil {
+		return fmt.Errorf("shared.hooks: %w", err)
+	}
 	switch provider {
 	case "claude":
 		return validateClaudeRuntimeConfig(cfg)
@@ -132,6 +139,8 @@ func validateUnsupportedSharedConfig(provider string, support SharedSupport, cfg
 		return fmt.Errorf("shared.env is not supported when provider=%s", provider)
 	case len(cfg.AddDirs) > 0 && !support.AddDirs:
 		return fmt.Errorf("shared.add_dirs is not supported when provider=%s", provider)
+	case len(cfg.Hooks) > 0 && !support.Hooks:
+		return fmt.Errorf("shared.hooks is not supported when provider=%s", provider)
 	default:
 		return nil
 	}
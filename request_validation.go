@@ -0,0 +1,283 @@
+package llmkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RequestValidationMode controls how adapters treat request fields the
+// provider cannot honor.
+type RequestValidationMode string
+
+const (
+	// RequestValidationStrict rejects requests that use unsupported fields.
+	// It is the default when Config.RequestValidation is empty.
+	RequestValidationStrict RequestValidationMode = "strict"
+	// RequestValidationLenient sends the request anyway and reports each
+	// unsupported field in Response.Metadata[MetadataWarnings].
+	RequestValidationLenient RequestValidationMode = "lenient"
+	// RequestValidationOff skips pre-flight validation entirely.
+	RequestValidationOff RequestValidationMode = "off"
+)
+
+// MetadataWarnings is the Response.Metadata (and final StreamChunk.Metadata)
+// key holding lenient-mode validation warnings as a []string.
+const MetadataWarnings = "warnings"
+
+// Content part types understood by the root request model.
+const (
+	ContentPartText  = "text"
+	ContentPartImage = "image"
+	// ContentPartFile references a local path the agent can open itself.
+	ContentPartFile = "file"
+	// ContentPartDocument is a PDF or plain-text document sent inline.
+	ContentPartDocument = "document"
+)
+
+// RequestSupport describes which Request fields a provider's CLI path forwards.
+type RequestSupport struct {
+	SystemPrompt bool                 `json:"system_prompt"`
+	MaxTokens    bool                 `json:"max_tokens"`
+	Temperature  bool                 `json:"temperature"`
+	Tools        bool                 `json:"tools"`
+	JSONSchema   bool                 `json:"json_schema"`
+	MessageNames bool                 `json:"message_names"`
+	Roles        []Role               `json:"roles,omitempty"`
+	ContentParts []ContentPartSupport `json:"content_parts,omitempty"`
+}
+
+// ContentPartSupport describes which sources a provider accepts for one
+// content part type.
+type ContentPartSupport struct {
+	Type        string `json:"type"`
+	Text        bool   `json:"text"`
+	ImageURL    bool   `json:"image_url"`
+	ImageBase64 bool   `json:"image_base64"`
+	FilePath    bool   `json:"file_path"`
+}
+
+// RequestIssue is one problem found by pre-flight validation.
+type RequestIssue struct {
+	// Field is the request path, for example "messages[1].content_parts[0].image_url".
+	Field string `json:"field"`
+	// Err is ErrInvalidRequest, ErrCapabilityNotSupported or ErrUnsupportedFeature.
+	Err     error  `json:"-"`
+	Message string `json:"message"`
+}
+
+func (i RequestIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// RequestValidationError reports every issue that blocked a request.
+// errors.Is matches each issue's sentinel.
+type RequestValidationError struct {
+	Provider string
+	Issues   []RequestIssue
+}
+
+func (e *RequestValidationError) Error() string {
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		parts[i] = issue.String()
+	}
+	return fmt.Sprintf("%s request validation failed: %s", e.Provider, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the distinct sentinels of the blocking issues.
+func (e *RequestValidationError) Unwrap() []error {
+	var errs []error
+	for _, issue := range e.Issues {
+		if issue.Err != nil && !slices.Contains(errs, issue.Err) {
+			errs = append(errs, issue.Err)
+		}
+	}
+	return errs
+}
+
+// ValidateRequest checks req against the provider's Capabilities and
+// registered ProviderDefinition in strict mode. Adapters run it before every
+// Complete and Stream call unless Config.RequestValidation says otherwise.
+func ValidateRequest(provider string, req Request) error {
+	_, err := PreflightRequest(provider, req, RequestValidationStrict)
+	return err
+}
+
+// PreflightRequest validates req in the given mode. Malformed requests always
+// fail. Unsupported fields fail in strict mode and are returned as warnings in
+// lenient mode. RequestValidationOff returns nil, nil.
+func PreflightRequest(provider string, req Request, mode RequestValidationMode) ([]string, error) {
+	if mode == RequestValidationOff {
+		return nil, nil
+	}
+	def, ok := GetProviderDefinition(provider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+
+	issues := checkRequest(def, providerCapabilities(provider), req)
+	var blocking []RequestIssue
+	var warnings []string
+	for _, issue := range issues {
+		if mode == RequestValidationLenient && !errors.Is(issue.Err, ErrInvalidRequest) {
+			warnings = append(warnings, issue.String())
+			continue
+		}
+		blocking = append(blocking, issue)
+	}
+	if len(blocking) > 0 {
+		return nil, &RequestValidationError{Provider: provider, Issues: blocking}
+	}
+	return warnings, nil
+}
+
+// AttachRequestWarnings records lenient-mode warnings on metadata, creating
+// the map when needed, and returns it.
+func AttachRequestWarnings(metadata map[string]any, warnings []string) map[string]any {
+	if len(warnings) == 0 {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]any, 1)
+	}
+	metadata[MetadataWarnings] = append([]string(nil), warnings...)
+	return metadata
+}
+
+func providerCapabilities(provider string) Capabilities {
+	switch provider {
+	case "claude":
+		return ClaudeCapabilities
+	case "codex":
+		return CodexCapabilities
+	default:
+		return Capabilities{}
+	}
+}
+
+func checkRequest(def ProviderDefinition, caps Capabilities, req Request) []RequestIssue {
+	support := def.Request
+	var issues []RequestIssue
+	add := func(field string, err error, format string, args ...any) {
+		issues = append(issues, RequestIssue{Field: field, Err: err, Message: fmt.Sprintf(format, args...)})
+	}
+	unsupported := func(field, what string) {
+		add(field, ErrUnsupportedFeature, "%s is not forwarded by the %s CLI", what, def.Name)
+	}
+
+	if req.SystemPrompt != "" && !support.SystemPrompt {
+		unsupported("system_prompt", "system prompt")
+	}
+	switch {
+	case req.MaxTokens < 0:
+		add("max_tokens", ErrInvalidRequest, "must be >= 0, got %d", req.MaxTokens)
+	case req.MaxTokens > 0 && !support.MaxTokens:
+		unsupported("max_tokens", "max tokens")
+	}
+	switch {
+	case req.Temperature < 0 || req.Temperature > 2:
+		add("temperature", ErrInvalidRequest, "must be between 0 and 2, got %g", req.Temperature)
+	case req.Temperature != 0 && !support.Temperature:
+		unsupported("temperature", "temperature")
+	}
+	if len(req.JSONSchema) > 0 {
+		var schema map[string]any
+		switch {
+		case json.Unmarshal(req.JSONSchema, &schema) != nil:
+			add("json_schema", ErrInvalidRequest, "must be a JSON object")
+		case !support.JSONSchema:
+			unsupported("json_schema", "structured output")
+		}
+	}
+
+	for i, tool := range req.Tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		if tool.Name == "" {
+			add(field+".name", ErrInvalidRequest, "is required")
+		}
+		if len(tool.Parameters) > 0 && !json.Valid(tool.Parameters) {
+			add(field+".parameters", ErrInvalidRequest, "must be valid JSON")
+		}
+	}
+	if len(req.Tools) > 0 && !support.Tools {
+		unsupported("tools", "caller-defined tools")
+	}
+
+	if len(req.Messages) == 0 {
+		add("messages", ErrInvalidRequest, "at least one message is required")
+	}
+	for i, msg := range req.Messages {
+		issues = append(issues, checkMessage(def.Name, support, caps, fmt.Sprintf("messages[%d]", i), msg)...)
+	}
+	return issues
+}
+
+func checkMessage(provider string, support RequestSupport, caps Capabilities, field string, msg Message) []RequestIssue {
+	var issues []RequestIssue
+	add := func(path string, err error, format string, args ...any) {
+		issues = append(issues, RequestIssue{Field: field + path, Err: err, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch msg.Role {
+	case RoleUser, RoleAssistant, RoleTool, RoleSystem:
+		if !slices.Contains(support.Roles, msg.Role) {
+			add(".role", ErrUnsupportedFeature, "%s messages are not forwarded by the %s CLI", msg.Role, provider)
+		}
+	default:
+		add(".role", ErrInvalidRequest, "unknown role %q", msg.Role)
+	}
+	if msg.Name != "" && !support.MessageNames {
+		add(".name", ErrUnsupportedFeature, "message names are not forwarded by the %s CLI", provider)
+	}
+	if msg.IsMultimodal() && msg.Content != "" {
+		add(".content", ErrUnsupportedFeature, "content is ignored when content_parts is set")
+	}
+
+	for j, part := range msg.ContentParts {
+		path := fmt.Sprintf(".content_parts[%d]", j)
+		switch part.Type {
+		case ContentPartText, ContentPartImage, ContentPartFile, ContentPartDocument:
+		default:
+			add(path+".type", ErrInvalidRequest, "unknown content part type %q", part.Type)
+			continue
+		}
+		if part.Type == ContentPartImage && !caps.Runtime.Images {
+			add(path+".type", ErrCapabilityNotSupported, "%s does not accept images", provider)
+			continue
+		}
+		idx := slices.IndexFunc(support.ContentParts, func(s ContentPartSupport) bool { return s.Type == part.Type })
+		if idx < 0 {
+			add(path+".type", ErrCapabilityNotSupported, "%s content parts are not supported by %s", part.Type, provider)
+			continue
+		}
+		partSupport := support.ContentParts[idx]
+
+		sources, forwarded := 0, true
+		checkSource := func(set bool, name string, supported bool) {
+			if !set {
+				return
+			}
+			sources++
+			if !supported {
+				forwarded = false
+				add(path+"."+name, ErrUnsupportedFeature, "%s %s is not forwarded by the %s CLI", part.Type, name, provider)
+			}
+		}
+		checkSource(part.Text != "", "text", partSupport.Text)
+		checkSource(part.ImageURL != "", "image_url", partSupport.ImageURL)
+		checkSource(part.ImageBase64 != "", "image_base64", partSupport.ImageBase64)
+		checkSource(part.FilePath != "", "file_path", partSupport.FilePath)
+		switch {
+		case sources == 0:
+			add(path, ErrInvalidRequest, "%s content part has no content", part.Type)
+		case forwarded:
+			if suffix, msg := checkContentPartSource(part); msg != "" {
+				add(path+suffix, ErrInvalidRequest, "%s", msg)
+			}
+		}
+	}
+	return issues
+}
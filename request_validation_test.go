@@ -0,0 +1,93 @@
+package llmkit
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRequestReportsFieldPaths(t *testing.T) {
+	req := Request{
+		MaxTokens:   100,
+		Temperature: 0.2,
+		Tools:       []Tool{{Name: "lookup"}},
+		Messages: []Message{
+			NewTextMessage(RoleSystem, "be brief"),
+			{Role: RoleUser, Content: "dropped", ContentParts: []ContentPart{{Type: ContentPartText, Text: "kept"}}},
+		},
+	}
+
+	err := ValidateRequest("claude", req)
+	var validationErr *RequestValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidateRequest() error = %v, want *RequestValidationError", err)
+	}
+	if !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("error %v does not wrap ErrUnsupportedFeature", err)
+	}
+
+	var fields []string
+	for _, issue := range validationErr.Issues {
+		fields = append(fields, issue.Field)
+	}
+	want := []string{"max_tokens", "temperature", "tools", "messages[0].role", "messages[1].content"}
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Fatalf("issue fields = %v, want %v", fields, want)
+	}
+}
+
+func TestValidateRequestCapabilityAndInvalidIssues(t *testing.T) {
+	err := ValidateRequest("codex", Request{
+		Messages: []Message{{Role: RoleUser, ContentParts: []ContentPart{{Type: ContentPartFile, FilePath: "notes.md"}}}},
+	})
+	if !IsCapabilityError(err) || !strings.Contains(err.Error(), "messages[0].content_parts[0].type") {
+		t.Fatalf("codex file part error = %v", err)
+	}
+
+	err = ValidateRequest("claude", Request{
+		Temperature: 3,
+		JSONSchema:  json.RawMessage(`[1]`),
+		Messages:    []Message{{Role: "robot", ContentParts: []ContentPart{{Type: ContentPartImage}}}},
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("invalid request error = %v", err)
+	}
+	for _, field := range []string{"temperature", "json_schema", "messages[0].role", "messages[0].content_parts[0]:"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Fatalf("error %q missing field %q", err, field)
+		}
+	}
+
+	if err := ValidateRequest("claude", Request{Messages: []Message{NewTextMessage(RoleUser, "hi")}}); err != nil {
+		t.Fatalf("plain request error = %v", err)
+	}
+	if err := ValidateRequest("missing", Request{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("unknown provider error = %v", err)
+	}
+}
+
+func TestPreflightRequestLenientReturnsWarnings(t *testing.T) {
+	req := Request{MaxTokens: 50, Messages: []Message{NewTextMessage(RoleUser, "hi")}}
+
+	warnings, err := PreflightRequest("codex", req, RequestValidationLenient)
+	if err != nil {
+		t.Fatalf("PreflightRequest() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "max_tokens:") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	metadata := AttachRequestWarnings(nil, warnings)
+	if got, _ := metadata[MetadataWarnings].([]string); len(got) != 1 {
+		t.Fatalf("metadata = %+v", metadata)
+	}
+
+	// Malformed requests still fail in lenient mode.
+	req.MaxTokens = -1
+	if _, err := PreflightRequest("codex", req, RequestValidationLenient); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("lenient invalid error = %v", err)
+	}
+	if warnings, err := PreflightRequest("codex", req, RequestValidationOff); err != nil || warnings != nil {
+		t.Fatalf("off mode = %v, %v", warnings, err)
+	}
+}
@@ -0,0 +1,464 @@
+package llmkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome is the result of one model call reported back to a Router.
+// Success is decided by the caller (for example a typed decode or a test run).
+type Outcome struct {
+	Model   ModelName
+	Success bool
+	Latency time.Duration
+	CostUSD float64
+}
+
+// ModelStats aggregates observed outcomes for one model on one task type.
+type ModelStats struct {
+	Attempts     int           `json:"attempts"`
+	Successes    int           `json:"successes"`
+	TotalLatency time.Duration `json:"total_latency"`
+	TotalCostUSD float64       `json:"total_cost_usd"`
+	LastUsed     time.Time     `json:"last_used,omitempty"`
+}
+
+// SuccessRate returns the fraction of successful attempts, or 0 with no samples.
+func (s ModelStats) SuccessRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Attempts)
+}
+
+// AvgLatency returns the mean observed latency.
+func (s ModelStats) AvgLatency() time.Duration {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Attempts)
+}
+
+// AvgCostUSD returns the mean observed cost per attempt.
+func (s ModelStats) AvgCostUSD() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return s.TotalCostUSD / float64(s.Attempts)
+}
+
+func (s *ModelStats) record(o Outcome, at time.Time) {
+	s.Attempts++
+	if o.Success {
+		s.Successes++
+	}
+	s.TotalLatency += o.Latency
+	s.TotalCostUSD += o.CostUSD
+	s.LastUsed = at
+}
+
+// RoutingPolicy chooses one model from an ordered candidate list.
+// Candidates are ordered from least to most capable, like EscalationChain.Models.
+// Stats contains an entry for every candidate, zero-valued when unobserved.
+type RoutingPolicy interface {
+	Choose(candidates []ModelName, stats map[ModelName]ModelStats) ModelName
+}
+
+// RoutingPolicyFunc adapts a function to RoutingPolicy.
+type RoutingPolicyFunc func(candidates []ModelName, stats map[ModelName]ModelStats) ModelName
+
+// Choose calls f.
+func (f RoutingPolicyFunc) Choose(candidates []ModelName, stats map[ModelName]ModelStats) ModelName {
+	return f(candidates, stats)
+}
+
+// CheapestAboveThreshold picks the cheapest candidate whose success rate is at
+// least MinSuccessRate. Candidates with fewer than MinSamples attempts are
+// treated optimistically so new models get a chance to prove themselves.
+// When no candidate qualifies the most capable candidate is chosen.
+type CheapestAboveThreshold struct {
+	MinSuccessRate float64
+	MinSamples     int
+}
+
+// Choose implements RoutingPolicy.
+func (p CheapestAboveThreshold) Choose(candidates []ModelName, stats map[ModelName]ModelStats) ModelName {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	var (
+		best     ModelName
+		bestCost = math.Inf(1)
+		bestLat  time.Duration
+	)
+	for _, model := range candidates {
+		st := stats[model]
+		if st.Attempts >= p.MinSamples && st.Attempts > 0 && st.SuccessRate() < p.MinSuccessRate {
+			continue
+		}
+		cost := expectedCost(model, st)
+		lat := st.AvgLatency()
+		if cost < bestCost || (cost == bestCost && lat < bestLat) {
+			best, bestCost, bestLat = model, cost, lat
+		}
+	}
+	if best == "" {
+		return candidates[len(candidates)-1]
+	}
+	return best
+}
+
+// EpsilonGreedy explores a uniformly random candidate with probability Epsilon
+// and otherwise exploits Base. A nil Base uses CheapestAboveThreshold with
+// a 0.8 success-rate threshold and 3 minimum samples.
+type EpsilonGreedy struct {
+	Epsilon float64
+	Base    RoutingPolicy
+
+	// Float64 and IntN override the random source; nil uses math/rand/v2.
+	Float64 func() float64
+	IntN    func(n int) int
+}
+
+// Choose implements RoutingPolicy.
+func (p EpsilonGreedy) Choose(candidates []ModelName, stats map[ModelName]ModelStats) ModelName {
+	if len(candidates) == 0 {
+		return ""
+	}
+	float64Fn, intNFn := p.Float64, p.IntN
+	if float64Fn == nil {
+		float64Fn = rand.Float64
+	}
+	if intNFn == nil {
+		intNFn = rand.IntN
+	}
+	if p.Epsilon > 0 && float64Fn() < p.Epsilon {
+		return candidates[intNFn(len(candidates))]
+	}
+	base := p.Base
+	if base == nil {
+		base = defaultRoutingPolicy()
+	}
+	return base.Choose(candidates, stats)
+}
+
+func defaultRoutingPolicy() RoutingPolicy {
+	return CheapestAboveThreshold{MinSuccessRate: 0.8, MinSamples: 3}
+}
+
+// typicalCallUsage is the token count priced for models with no observed
+// cost, so their estimate is per call like ModelStats.AvgCostUSD.
+var typicalCallUsage = Usage{InputTokens: 20_000, OutputTokens: 2_000}
+
+// expectedCost returns the expected cost of one call in USD: the observed
+// per-attempt cost, or the list price of typicalCallUsage.
+func expectedCost(model ModelName, st ModelStats) float64 {
+	if st.Attempts > 0 && st.TotalCostUSD > 0 {
+		return st.AvgCostUSD()
+	}
+	cost, ok := EstimateCost(string(model), typicalCallUsage)
+	if !ok {
+		return math.MaxFloat64
+	}
+	return cost
+}
+
+// RouterStats is the persisted routing state keyed by task key and model.
+type RouterStats map[string]map[ModelName]ModelStats
+
+// RouterStore persists routing statistics between runs.
+type RouterStore interface {
+	Load() (RouterStats, error)
+	Save(RouterStats) error
+}
+
+// FileRouterStore stores routing statistics as a JSON file. Loads and saves
+// through one store are serialized, but the file is owned by a single
+// process: a Router loads it once and each Save replaces it with that
+// Router's view, so routers in other processes sharing the path overwrite
+// each other's outcomes. Give each process its own path.
+type FileRouterStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+type routerStatsFile struct {
+	Version int         `json:"version"`
+	Tasks   RouterStats `json:"tasks"`
+}
+
+// NewFileRouterStore returns a store backed by the JSON file at path.
+func NewFileRouterStore(path string) *FileRouterStore {
+	return &FileRouterStore{Path: path}
+}
+
+// DefaultRouterStatsPath returns the default stats location under the user cache dir.
+func DefaultRouterStatsPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "llmkit", "routing.json"), nil
+}
+
+// Load reads the stats file. A missing file yields empty stats.
+func (s *FileRouterStore) Load() (RouterStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RouterStats{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read routing stats: %w", err)
+	}
+	var file routerStatsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse routing stats %s: %w", s.Path, err)
+	}
+	if file.Tasks == nil {
+		file.Tasks = RouterStats{}
+	}
+	return file.Tasks, nil
+}
+
+// Save atomically replaces the stats file.
+func (s *FileRouterStore) Save(stats RouterStats) error {
+	data, err := json.MarshalIndent(routerStatsFile{Version: 1, Tasks: stats}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal routing stats: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("create routing stats dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".routing-*.json")
+	if err != nil {
+		return fmt.Errorf("create routing stats temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write routing stats: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("close routing stats: %w", err)
+	}
+	if err := os.Rename(tmpName, s.Path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("replace routing stats: %w", err)
+	}
+	return nil
+}
+
+// TaskKeyFunc converts a task value into a stable key for statistics.
+type TaskKeyFunc func(task any) string
+
+// Router chooses models from observed quality, latency, and cost per task type.
+// Attach it to a Selector with WithRouter to route through Select.
+// Router is safe for concurrent use.
+type Router struct {
+	mu        sync.Mutex
+	policy    RoutingPolicy
+	store     RouterStore
+	keyFunc   TaskKeyFunc
+	tierCands map[Tier][]ModelName
+	taskCands map[string][]ModelName
+	pending   map[any][]ModelName
+	stats     RouterStats
+	now       func() time.Time
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithRoutingPolicy sets the policy used to choose among candidates.
+// A nil policy keeps the default CheapestAboveThreshold.
+func WithRoutingPolicy(policy RoutingPolicy) RouterOption {
+	return func(r *Router) {
+		if policy == nil {
+			policy = defaultRoutingPolicy()
+		}
+		r.policy = policy
+	}
+}
+
+// WithRouterStore sets where statistics are loaded from and saved to.
+func WithRouterStore(store RouterStore) RouterOption {
+	return func(r *Router) {
+		r.store = store
+	}
+}
+
+// WithRouterStatsFile persists statistics to a JSON file at path. The file
+// must not be shared with routers in other processes; see FileRouterStore.
+func WithRouterStatsFile(path string) RouterOption {
+	return WithRouterStore(NewFileRouterStore(path))
+}
+
+// WithTaskKeyFunc sets how tasks are converted to statistic keys.
+// The default uses fmt.Sprint(task).
+func WithTaskKeyFunc(fn TaskKeyFunc) RouterOption {
+	return func(r *Router) {
+		r.keyFunc = fn
+	}
+}
+
+// WithTierCandidates sets the models the router may choose for a tier,
+// ordered from least to most capable.
+func WithTierCandidates(tier Tier, models ...ModelName) RouterOption {
+	return func(r *Router) {
+		r.tierCands[tier] = append([]ModelName(nil), models...)
+	}
+}
+
+// WithTaskCandidates sets the models the router may choose for a task,
+// taking precedence over tier candidates.
+func WithTaskCandidates(task any, models ...ModelName) RouterOption {
+	return func(r *Router) {
+		r.pending[task] = append([]ModelName(nil), models...)
+	}
+}
+
+// NewRouter creates a router and loads persisted statistics from its store.
+// Without candidates for a task or tier, the router defers to the Selector.
+func NewRouter(opts ...RouterOption) (*Router, error) {
+	r := &Router{
+		policy:    defaultRoutingPolicy(),
+		keyFunc:   func(task any) string { return fmt.Sprint(task) },
+		tierCands: make(map[Tier][]ModelName),
+		taskCands: make(map[string][]ModelName),
+		pending:   make(map[any][]ModelName),
+		stats:     RouterStats{},
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	// Task keys are resolved after all options so WithTaskKeyFunc can appear anywhere.
+	for task, models := range r.pending {
+		r.taskCands[r.keyFunc(task)] = models
+	}
+	r.pending = nil
+	if r.store != nil {
+		stats, err := r.store.Load()
+		if err != nil {
+			return nil, err
+		}
+		r.stats = stats
+	}
+	return r, nil
+}
+
+// Choose returns the routed model for a task in the given tier.
+// The boolean is false when the router has no candidates for the task.
+func (r *Router) Choose(task any, tier Tier) (ModelName, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.keyFunc(task)
+	candidates, ok := r.taskCands[key]
+	if !ok {
+		candidates = r.tierCands[tier]
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	stats := make(map[ModelName]ModelStats, len(candidates))
+	for _, model := range candidates {
+		stats[model] = r.stats[key][model]
+	}
+	model := r.policy.Choose(candidates, stats)
+	if model == "" {
+		return "", false
+	}
+	return model, true
+}
+
+// Record adds an outcome for a task and persists the updated statistics.
+func (r *Router) Record(task any, outcome Outcome) error {
+	if outcome.Model == "" {
+		return fmt.Errorf("outcome model is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.keyFunc(task)
+	byModel := r.stats[key]
+	if byModel == nil {
+		byModel = make(map[ModelName]ModelStats)
+		r.stats[key] = byModel
+	}
+	st := byModel[outcome.Model]
+	st.record(outcome, r.now())
+	byModel[outcome.Model] = st
+
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Save(r.stats)
+}
+
+// RecordResponse records the outcome of routing task to model using the
+// response's duration and cost. A nil response is recorded as a failure.
+func (r *Router) RecordResponse(task any, model ModelName, resp *Response, success bool) error {
+	outcome := Outcome{Model: model, Success: success && resp != nil}
+	if resp != nil {
+		outcome.Latency = resp.Duration
+		outcome.CostUSD = resp.CostUSD
+	}
+	return r.Record(task, outcome)
+}
+
+// Stats returns a copy of the statistics for a task, keyed by model.
+func (r *Router) Stats(task any) map[ModelName]ModelStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	src := r.stats[r.keyFunc(task)]
+	out := make(map[ModelName]ModelStats, len(src))
+	for model, st := range src {
+		out[model] = st
+	}
+	return out
+}
+
+// Tasks returns the task keys with recorded statistics, sorted.
+func (r *Router) Tasks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.stats))
+	for key := range r.stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reset clears all statistics and persists the empty state.
+func (r *Router) Reset() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats = RouterStats{}
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Save(r.stats)
+}
@@ -0,0 +1,168 @@
+package llmkit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheapestAboveThresholdPrefersCheapQualifiedModel(t *testing.T) {
+	policy := CheapestAboveThreshold{MinSuccessRate: 0.8, MinSamples: 2}
+	candidates := []ModelName{ModelHaiku, ModelSonnet, ModelOpus}
+
+	stats := map[ModelName]ModelStats{
+		ModelHaiku:  {Attempts: 4, Successes: 1},
+		ModelSonnet: {Attempts: 4, Successes: 4},
+		ModelOpus:   {Attempts: 4, Successes: 4},
+	}
+	if got := policy.Choose(candidates, stats); got != ModelSonnet {
+		t.Fatalf("Choose() = %q, want %q", got, ModelSonnet)
+	}
+
+	// Unsampled models are tried optimistically before falling back.
+	if got := policy.Choose(candidates, map[ModelName]ModelStats{}); got != ModelHaiku {
+		t.Fatalf("Choose() with no stats = %q, want %q", got, ModelHaiku)
+	}
+
+	failing := map[ModelName]ModelStats{
+		ModelHaiku:  {Attempts: 3},
+		ModelSonnet: {Attempts: 3},
+		ModelOpus:   {Attempts: 3},
+	}
+	if got := policy.Choose(candidates, failing); got != ModelOpus {
+		t.Fatalf("Choose() with no qualified model = %q, want most capable %q", got, ModelOpus)
+	}
+}
+
+func TestCheapestAboveThresholdUsesObservedCost(t *testing.T) {
+	policy := CheapestAboveThreshold{MinSuccessRate: 0.5, MinSamples: 1}
+	stats := map[ModelName]ModelStats{
+		ModelSonnet: {Attempts: 2, Successes: 2, TotalCostUSD: 0.02},
+		ModelOpus:   {Attempts: 2, Successes: 2, TotalCostUSD: 0.01},
+	}
+	if got := policy.Choose([]ModelName{ModelSonnet, ModelOpus}, stats); got != ModelOpus {
+		t.Fatalf("Choose() = %q, want %q", got, ModelOpus)
+	}
+}
+
+func TestCheapestAboveThresholdComparesObservedAndListCostPerCall(t *testing.T) {
+	policy := CheapestAboveThreshold{MinSuccessRate: 0.8, MinSamples: 3}
+	stats := map[ModelName]ModelStats{
+		ModelHaiku: {Attempts: 10, Successes: 10, TotalCostUSD: 0.05},
+	}
+	if got := policy.Choose([]ModelName{ModelHaiku, ModelSonnet}, stats); got != ModelHaiku {
+		t.Fatalf("Choose() = %q, want observed cheap %q over unobserved %q", got, ModelHaiku, ModelSonnet)
+	}
+}
+
+func TestEpsilonGreedyExploresAndExploits(t *testing.T) {
+	candidates := []ModelName{ModelHaiku, ModelSonnet, ModelOpus}
+	stats := map[ModelName]ModelStats{ModelHaiku: {Attempts: 5, Successes: 5}}
+
+	explore := EpsilonGreedy{
+		Epsilon: 0.1,
+		Float64: func() float64 { return 0.05 },
+		IntN:    func(int) int { return 2 },
+	}
+	if got := explore.Choose(candidates, stats); got != ModelOpus {
+		t.Fatalf("explore Choose() = %q, want %q", got, ModelOpus)
+	}
+
+	exploit := EpsilonGreedy{
+		Epsilon: 0.1,
+		Float64: func() float64 { return 0.5 },
+	}
+	if got := exploit.Choose(candidates, stats); got != ModelHaiku {
+		t.Fatalf("exploit Choose() = %q, want %q", got, ModelHaiku)
+	}
+}
+
+func TestRouterPersistsStatsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.json")
+
+	router, err := NewRouter(
+		WithRouterStatsFile(path),
+		WithTierCandidates(TierDefault, ModelHaiku, ModelSonnet),
+		WithRoutingPolicy(CheapestAboveThreshold{MinSuccessRate: 0.9, MinSamples: 2}),
+	)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := router.Record("review", Outcome{Model: ModelHaiku, Latency: time.Second}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := router.RecordResponse("review", ModelSonnet, &Response{Duration: 2 * time.Second, CostUSD: 0.03}, true); err != nil {
+		t.Fatalf("RecordResponse() error = %v", err)
+	}
+
+	reloaded, err := NewRouter(
+		WithRouterStatsFile(path),
+		WithTierCandidates(TierDefault, ModelHaiku, ModelSonnet),
+		WithRoutingPolicy(CheapestAboveThreshold{MinSuccessRate: 0.9, MinSamples: 2}),
+	)
+	if err != nil {
+		t.Fatalf("NewRouter() reload error = %v", err)
+	}
+	stats := reloaded.Stats("review")
+	if stats[ModelHaiku].Attempts != 2 || stats[ModelHaiku].Successes != 0 {
+		t.Fatalf("haiku stats = %+v", stats[ModelHaiku])
+	}
+	if stats[ModelSonnet].AvgLatency() != 2*time.Second || stats[ModelSonnet].AvgCostUSD() != 0.03 {
+		t.Fatalf("sonnet stats = %+v", stats[ModelSonnet])
+	}
+	if model, ok := reloaded.Choose("review", TierDefault); !ok || model != ModelSonnet {
+		t.Fatalf("Choose() = %q, %v; want %q", model, ok, ModelSonnet)
+	}
+}
+
+func TestRouterNilPolicyUsesDefault(t *testing.T) {
+	router, err := NewRouter(
+		WithTierCandidates(TierDefault, ModelHaiku, ModelSonnet),
+		WithRoutingPolicy(nil),
+	)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	if model, ok := router.Choose("review", TierDefault); !ok || model == "" {
+		t.Fatalf("Choose() = %q, %v", model, ok)
+	}
+}
+
+func TestSelectorWithRouter(t *testing.T) {
+	type task string
+	router, err := NewRouter(
+		WithTaskCandidates(task("lint"), ModelHaiku),
+		WithTierCandidates(TierThinking, ModelSonnet, ModelOpus),
+	)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	selector := NewSelector(
+		WithRouter(router),
+		WithTaskOverride(task("pinned"), ModelOpus),
+		WithTierFunc(func(tk any) Tier {
+			if tk == task("plan") {
+				return TierThinking
+			}
+			return TierDefault
+		}),
+	)
+
+	if got := selector.Select(task("pinned")); got != ModelOpus {
+		t.Fatalf("override Select() = %q", got)
+	}
+	if got := selector.Select(task("lint")); got != ModelHaiku {
+		t.Fatalf("task candidate Select() = %q", got)
+	}
+	if got := selector.Select(task("plan")); got != ModelSonnet {
+		t.Fatalf("tier candidate Select() = %q", got)
+	}
+	if got := selector.Select(task("other")); got != ModelSonnet {
+		t.Fatalf("fallback Select() = %q", got)
+	}
+	if got := selector.Clone().Select(task("lint")); got != ModelHaiku {
+		t.Fatalf("cloned Select() = %q", got)
+	}
+}
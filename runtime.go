@@ -2,8 +2,10 @@ package llmkit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -11,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
 	"github.com/randalmurphal/llmkit/v2/env"
 )
 
@@ -31,6 +34,7 @@ type SharedRuntimeConfig struct {
 	MaxTurns           int                        `json:"max_turns,omitempty"`
 	Env                map[string]string          `json:"env,omitempty"`
 	AddDirs            []string                   `json:"add_dirs,omitempty"`
+	Hooks              []HookDefinition           `json:"hooks,omitempty"`
 }
 
 type RuntimeProviderConfig struct {
@@ -50,12 +54,20 @@ type ClaudeRuntimeConfig struct {
 	SettingSources             []string                  `json:"setting_sources,omitempty"`
 }
 
+// CodexRuntimeConfig holds Codex-only runtime settings. PrepareRuntime
+// writes SkillRefs into the repo skill root, InlineAgents as custom agent
+// TOML files, Instructions as a temporary AGENTS.md section and PrefixRules
+// as managed rules, and removes them all on Close.
 type CodexRuntimeConfig struct {
-	ReasoningEffort           string `json:"reasoning_effort,omitempty"`
-	WebSearchMode             string `json:"web_search_mode,omitempty"`
-	SandboxMode               string `json:"sandbox_mode,omitempty"`
-	ApprovalMode              string `json:"approval_mode,omitempty"`
-	BypassApprovalsAndSandbox bool   `json:"bypass_approvals_and_sandbox,omitempty"`
+	ReasoningEffort           string                            `json:"reasoning_effort,omitempty"`
+	WebSearchMode             string                            `json:"web_search_mode,omitempty"`
+	SandboxMode               string                            `json:"sandbox_mode,omitempty"`
+	ApprovalMode              string                            `json:"approval_mode,omitempty"`
+	BypassApprovalsAndSandbox bool                              `json:"bypass_approvals_and_sandbox,omitempty"`
+	SkillRefs                 []string                          `json:"skill_refs,omitempty"`
+	InlineAgents              map[string]InlineAgentDef         `json:"inline_agents,omitempty"`
+	Instructions              string                            `json:"instructions,omitempty"`
+	PrefixRules               map[string]codexconfig.PrefixRule `json:"prefix_rules,omitempty"`
 }
 
 type InlineAgentDef struct {
@@ -90,6 +102,22 @@ type RuntimeAssets struct {
 	HookScripts map[string]string     `json:"hook_scripts,omitempty"`
 }
 
+// PrepareMode selects how PrepareRuntime applies a RuntimeConfig.
+type PrepareMode string
+
+const (
+	// PrepareModeScoped edits the project's provider config files under an
+	// env.Scope and restores them on Close. It is the default.
+	PrepareModeScoped PrepareMode = "scoped"
+	// PrepareModeEphemeral renders everything into a temporary directory
+	// outside the project and returns it as PreparedRuntime.Launch, so
+	// nothing in WorkDir is written. Claude gets --settings, --mcp-config,
+	// --agents and a --plugin-dir whose skills are namespaced "llmkit:";
+	// Codex gets -c overrides and a CODEX_HOME overlay. Features listed by
+	// EphemeralUnsupported fail with ErrUnsupportedFeature.
+	PrepareModeEphemeral PrepareMode = "ephemeral"
+)
+
 type PrepareRequest struct {
 	Provider       string         `json:"provider"`
 	WorkDir        string         `json:"work_dir"`
@@ -97,16 +125,33 @@ type PrepareRequest struct {
 	Assets         *RuntimeAssets `json:"assets,omitempty"`
 	Tag            string         `json:"tag,omitempty"`
 	RecoverOrphans bool           `json:"recover_orphans,omitempty"`
+	Mode           PrepareMode    `json:"mode,omitempty"`
 }
 
 type PreparedRuntime struct {
 	Provider string         `json:"provider"`
 	Scope    io.Closer      `json:"-"`
 	Metadata map[string]any `json:"metadata,omitempty"`
+	// Launch holds the per-invocation settings of an ephemeral runtime. A
+	// scoped Codex runtime sets Launch.Env too: the scope puts Shared.Env in
+	// shell_environment_policy.set, which only reaches the commands Codex
+	// runs, so the codex process gets it through WithEnv.
+	Launch LaunchConfig `json:"launch,omitempty"`
 
 	cleanup []func() error
 }
 
+// LaunchConfig carries CLI settings passed per invocation instead of
+// written to the project. The claude and codex clients and sessions apply
+// it from Config.Launch; PreparedRuntime.Apply fills that in.
+type LaunchConfig struct {
+	ClaudeSettings   string            `json:"claude_settings,omitempty" yaml:"claude_settings,omitempty" mapstructure:"claude_settings"`
+	ClaudeMCPConfig  string            `json:"claude_mcp_config,omitempty" yaml:"claude_mcp_config,omitempty" mapstructure:"claude_mcp_config"`
+	ClaudePluginDirs []string          `json:"claude_plugin_dirs,omitempty" yaml:"claude_plugin_dirs,omitempty" mapstructure:"claude_plugin_dirs"`
+	CodexOverrides   map[string]any    `json:"codex_overrides,omitempty" yaml:"codex_overrides,omitempty" mapstructure:"codex_overrides"`
+	Env              map[string]string `json:"env,omitempty" yaml:"env,omitempty" mapstructure:"env"`
+}
+
 var hookRefPattern = regexp.MustCompile(`\{\{hook:([^}]+)\}\}`)
 
 func PrepareRuntime(_ context.Context, req PrepareRequest) (*PreparedRuntime, error) {
@@ -119,13 +164,37 @@ func PrepareRuntime(_ context.Context, req PrepareRequest) (*PreparedRuntime, er
 	if err := ValidateRuntimeConfig(req.Provider, req.RuntimeConfig); err != nil {
 		return nil, err
 	}
+	ephemeral := false
+	switch req.Mode {
+	case "", PrepareModeScoped:
+	case PrepareModeEphemeral:
+		if unsupported := EphemeralUnsupported(req.Provider, req.RuntimeConfig); len(unsupported) > 0 {
+			return nil, fmt.Errorf("%w: %s has no ephemeral form for %s", ErrUnsupportedFeature, req.Provider, strings.Join(unsupported, ", "))
+		}
+		ephemeral = true
+	default:
+		return nil, fmt.Errorf("unknown prepare mode %q", req.Mode)
+	}
 
 	prepared := &PreparedRuntime{
 		Provider: req.Provider,
 		Metadata: map[string]any{},
 	}
 
+	hooksDir := hookScriptsDir(req.Provider, req.WorkDir)
+	var tmpDir string
+	if ephemeral {
+		dir, err := os.MkdirTemp("", "llmkit-runtime-")
+		if err != nil {
+			return nil, fmt.Errorf("create runtime dir: %w", err)
+		}
+		tmpDir = dir
+		prepared.cleanup = append(prepared.cleanup, func() error { return os.RemoveAll(dir) })
+		hooksDir = filepath.Join(tmpDir, "hooks")
+	}
+
 	scopeCfg := env.ScopeConfig{
+		NeutralHooks:   resolveNeutralHooks(hooksDir, req.RuntimeConfig.Shared.Hooks),
 		MCPServers:     req.RuntimeConfig.Shared.MCPServers,
 		Env:            req.RuntimeConfig.Shared.Env,
 		Tag:            req.Tag,
@@ -133,7 +202,21 @@ func PrepareRuntime(_ context.Context, req PrepareRequest) (*PreparedRuntime, er
 	}
 
 	if req.Provider == "claude" && req.RuntimeConfig.Providers.Claude != nil {
-		scopeCfg.Hooks = convertClaudeHooks(req.WorkDir, req.RuntimeConfig.Providers.Claude.Hooks)
+		scopeCfg.Hooks = convertClaudeHooks(hooksDir, req.RuntimeConfig.Providers.Claude.Hooks)
+	}
+	if req.Provider == "codex" && req.RuntimeConfig.Providers.Codex != nil {
+		if err := addCodexAssets(req, &scopeCfg, prepared); err != nil {
+			_ = prepared.Close()
+			return nil, err
+		}
+	}
+
+	if ephemeral {
+		if err := prepareEphemeral(req, tmpDir, hooksDir, scopeCfg, prepared); err != nil {
+			_ = prepared.Close()
+			return nil, err
+		}
+		return prepared, nil
 	}
 
 	scope, err := env.NewScope(req.Provider, req.WorkDir, scopeCfg)
@@ -141,8 +224,11 @@ func PrepareRuntime(_ context.Context, req PrepareRequest) (*PreparedRuntime, er
 		return nil, fmt.Errorf("create environment scope: %w", err)
 	}
 	prepared.Scope = scope
+	if req.Provider == "codex" && len(req.RuntimeConfig.Shared.Env) > 0 {
+		prepared.Launch.Env = cloneStringMap(req.RuntimeConfig.Shared.Env)
+	}
 
-	if err := writeRuntimeAssets(req, prepared); err != nil {
+	if err := writeRuntimeAssets(req, hooksDir, prepared); err != nil {
 		_ = prepared.Close()
 		return nil, err
 	}
@@ -150,6 +236,91 @@ func PrepareRuntime(_ context.Context, req PrepareRequest) (*PreparedRuntime, er
 	return prepared, nil
 }
 
+// EphemeralUnsupported lists the RuntimeConfig settings that
+// PrepareModeEphemeral cannot apply without writing to the project. Codex
+// only discovers skills under the repo or $HOME, so skill_refs is one.
+func EphemeralUnsupported(provider string, cfg RuntimeConfig) []string {
+	var out []string
+	if provider == "codex" && cfg.Providers.Codex != nil && len(cfg.Providers.Codex.SkillRefs) > 0 {
+		out = append(out, "providers.codex.skill_refs")
+	}
+	return out
+}
+
+// prepareEphemeral renders scopeCfg, hook scripts and Claude skills under
+// dir and records how to pass them in prepared.Launch.
+func prepareEphemeral(req PrepareRequest, dir, hooksDir string, scopeCfg env.ScopeConfig, prepared *PreparedRuntime) error {
+	overlay, err := env.RenderOverlay(req.Provider, filepath.Join(dir, "env"), scopeCfg)
+	if err != nil {
+		if errors.Is(err, env.ErrNotEphemeral) {
+			return fmt.Errorf("%w: %w", ErrUnsupportedFeature, err)
+		}
+		return fmt.Errorf("render ephemeral environment: %w", err)
+	}
+	prepared.Launch = LaunchConfig{
+		ClaudeSettings:  overlay.ClaudeSettings,
+		ClaudeMCPConfig: overlay.ClaudeMCPConfig,
+		CodexOverrides:  overlay.CodexOverrides,
+		Env:             overlay.Env,
+	}
+
+	if req.Assets != nil {
+		if err := writeHookScripts(hooksDir, req.Assets.HookScripts); err != nil {
+			return err
+		}
+	}
+
+	if req.Provider != "claude" || req.RuntimeConfig.Providers.Claude == nil {
+		return nil
+	}
+	cfg := req.RuntimeConfig.Providers.Claude
+	if len(cfg.SkillRefs) > 0 {
+		var skills map[string]SkillAsset
+		if req.Assets != nil {
+			skills = req.Assets.Skills
+		}
+		plugin := filepath.Join(dir, "plugin")
+		created, err := writeClaudeSkills(filepath.Join(plugin, "skills"), cfg.SkillRefs, skills)
+		if err != nil {
+			return err
+		}
+		manifest := filepath.Join(plugin, ".claude-plugin", "plugin.json")
+		if err := os.MkdirAll(filepath.Dir(manifest), 0o755); err != nil {
+			return fmt.Errorf("create plugin dir: %w", err)
+		}
+		if err := os.WriteFile(manifest, []byte(`{"name": "llmkit"}`+"\n"), 0o644); err != nil {
+			return fmt.Errorf("write plugin manifest: %w", err)
+		}
+		prepared.Launch.ClaudePluginDirs = []string{plugin}
+		prepared.Metadata["skills"] = created
+	}
+	if len(cfg.InlineAgents) > 0 {
+		// Clients and sessions pass inline agents with --agents.
+		names := make([]string, 0, len(cfg.InlineAgents))
+		for name := range cfg.InlineAgents {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		prepared.Metadata["inline_agents"] = names
+	}
+	return nil
+}
+
+// Apply copies the runtime's Launch settings onto cfg, for the Config
+// passed to New or NewSession.
+func (p *PreparedRuntime) Apply(cfg *Config) {
+	if p == nil || cfg == nil {
+		return
+	}
+	cfg.Launch = LaunchConfig{
+		ClaudeSettings:   p.Launch.ClaudeSettings,
+		ClaudeMCPConfig:  p.Launch.ClaudeMCPConfig,
+		ClaudePluginDirs: append([]string(nil), p.Launch.ClaudePluginDirs...),
+		CodexOverrides:   maps.Clone(p.Launch.CodexOverrides),
+		Env:              cloneStringMap(p.Launch.Env),
+	}
+}
+
 func (p *PreparedRuntime) Close() error {
 	if p == nil {
 		return nil
@@ -172,21 +343,21 @@ func (p *PreparedRuntime) Close() error {
 	return errorsJoin(errs...)
 }
 
-func writeRuntimeAssets(req PrepareRequest, prepared *PreparedRuntime) error {
-	if req.Provider != "claude" || req.RuntimeConfig.Providers.Claude == nil {
-		return nil
-	}
-	cfg := req.RuntimeConfig.Providers.Claude
+func writeRuntimeAssets(req PrepareRequest, hooksDir string, prepared *PreparedRuntime) error {
 	if req.Assets == nil {
 		req.Assets = &RuntimeAssets{}
 	}
-
-	if err := writeHookScripts(req.WorkDir, req.Assets.HookScripts); err != nil {
+	if err := writeHookScripts(hooksDir, req.Assets.HookScripts); err != nil {
 		return err
 	}
 
+	if req.Provider != "claude" || req.RuntimeConfig.Providers.Claude == nil {
+		return nil
+	}
+	cfg := req.RuntimeConfig.Providers.Claude
+
 	if len(cfg.SkillRefs) > 0 {
-		created, err := writeClaudeSkills(req.WorkDir, cfg.SkillRefs, req.Assets.Skills)
+		created, err := writeClaudeSkills(filepath.Join(req.WorkDir, ".claude", "skills"), cfg.SkillRefs, req.Assets.Skills)
 		if err != nil {
 			return err
 		}
@@ -208,7 +379,88 @@ func writeRuntimeAssets(req PrepareRequest, prepared *PreparedRuntime) error {
 	return nil
 }
 
-func convertClaudeHooks(workDir string, hooks map[string][]HookMatcher) map[string][]env.Hook {
+// addCodexAssets renders Codex skills, custom agents, instructions and
+// prefix rules into scopeCfg, so the scope records them for Close and
+// orphan recovery.
+func addCodexAssets(req PrepareRequest, scopeCfg *env.ScopeConfig, prepared *PreparedRuntime) error {
+	cfg := req.RuntimeConfig.Providers.Codex
+	var skills map[string]SkillAsset
+	if req.Assets != nil {
+		skills = req.Assets.Skills
+	}
+	files := map[string]string{}
+
+	if len(cfg.SkillRefs) > 0 {
+		dirs := make([]string, 0, len(cfg.SkillRefs))
+		for _, ref := range cfg.SkillRefs {
+			asset, ok := skills[ref]
+			if !ok {
+				return fmt.Errorf("skill asset %q not provided", ref)
+			}
+			if err := validateAssetPathComponent(ref); err != nil {
+				return fmt.Errorf("invalid skill ref %q: %w", ref, err)
+			}
+			data, err := codexconfig.MarshalSkillMD(&codexconfig.Skill{
+				Name:        asset.Name,
+				Description: asset.Description,
+				Content:     asset.Content,
+			})
+			if err != nil {
+				return fmt.Errorf("render skill %s: %w", ref, err)
+			}
+			dir := filepath.Join(codexconfig.DirAgentsDot, codexconfig.DirSkills, ref)
+			files[filepath.Join(dir, codexconfig.FileSkillMD)] = string(data)
+			for name, content := range asset.SupportingFiles {
+				if err := validateRelativeAssetPath(name); err != nil {
+					return fmt.Errorf("invalid skill supporting file %q: %w", name, err)
+				}
+				files[filepath.Join(dir, name)] = content
+			}
+			dirs = append(dirs, filepath.Join(req.WorkDir, dir))
+		}
+		prepared.Metadata["skills"] = dirs
+	}
+
+	if len(cfg.InlineAgents) > 0 {
+		names := make([]string, 0, len(cfg.InlineAgents))
+		for name, def := range cfg.InlineAgents {
+			if err := validateAssetPathComponent(name); err != nil {
+				return fmt.Errorf("invalid inline agent %q: %w", name, err)
+			}
+			data, err := codexconfig.MarshalCustomAgent(&codexconfig.CustomAgent{
+				Name:                  name,
+				Description:           def.Description,
+				DeveloperInstructions: def.Prompt,
+				Model:                 def.Model,
+			})
+			if err != nil {
+				return fmt.Errorf("render inline agent %s: %w", name, err)
+			}
+			files[filepath.Join(codexconfig.DirCodex, codexconfig.DirAgents, name+".toml")] = string(data)
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		prepared.Metadata["inline_agents"] = names
+	}
+
+	if len(cfg.PrefixRules) > 0 {
+		ids := make([]string, 0, len(cfg.PrefixRules))
+		for id := range cfg.PrefixRules {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		prepared.Metadata["prefix_rules"] = ids
+	}
+
+	if len(files) > 0 {
+		scopeCfg.Files = files
+	}
+	scopeCfg.Instructions = cfg.Instructions
+	scopeCfg.PrefixRules = cfg.PrefixRules
+	return nil
+}
+
+func convertClaudeHooks(hooksDir string, hooks map[string][]HookMatcher) map[string][]env.Hook {
 	if len(hooks) == 0 {
 		return nil
 	}
@@ -216,18 +468,10 @@ func convertClaudeHooks(workDir string, hooks map[string][]HookMatcher) map[stri
 	for event, matchers := range hooks {
 		for _, matcher := range matchers {
 			for _, hook := range matcher.Hooks {
-				command := hook.Command
-				command = hookRefPattern.ReplaceAllStringFunc(command, func(match string) string {
-					parts := hookRefPattern.FindStringSubmatch(match)
-					if len(parts) != 2 {
-						return match
-					}
-					return filepath.Join(workDir, ".claude", "hooks", parts[1])
-				})
 				out[event] = append(out[event], env.Hook{
 					Matcher: matcher.Matcher,
 					Type:    hook.Type,
-					Command: command,
+					Command: resolveHookRefs(hooksDir, hook.Command),
 					Prompt:  hook.Prompt,
 					Timeout: hook.Timeout,
 					Once:    hook.Once,
@@ -238,11 +482,39 @@ func convertClaudeHooks(workDir string, hooks map[string][]HookMatcher) map[stri
 	return out
 }
 
-func writeHookScripts(workDir string, scripts map[string]string) error {
+// resolveNeutralHooks points {{hook:name}} references in shared hooks at
+// hooksDir.
+func resolveNeutralHooks(hooksDir string, hooks []HookDefinition) []HookDefinition {
+	if len(hooks) == 0 {
+		return nil
+	}
+	out := make([]HookDefinition, len(hooks))
+	for i, hook := range hooks {
+		hook.Tools = append([]string(nil), hook.Tools...)
+		hook.Command = resolveHookRefs(hooksDir, hook.Command)
+		out[i] = hook
+	}
+	return out
+}
+
+func resolveHookRefs(hooksDir, command string) string {
+	return hookRefPattern.ReplaceAllStringFunc(command, func(match string) string {
+		parts := hookRefPattern.FindStringSubmatch(match)
+		if len(parts) != 2 {
+			return match
+		}
+		return filepath.Join(hooksDir, parts[1])
+	})
+}
+
+func hookScriptsDir(provider, workDir string) string {
+	return filepath.Join(workDir, "."+provider, "hooks")
+}
+
+func writeHookScripts(hooksDir string, scripts map[string]string) error {
 	if len(scripts) == 0 {
 		return nil
 	}
-	hooksDir := filepath.Join(workDir, ".claude", "hooks")
 	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
 		return fmt.Errorf("create hooks dir: %w", err)
 	}
@@ -258,7 +530,7 @@ func writeHookScripts(workDir string, scripts map[string]string) error {
 	return nil
 }
 
-func writeClaudeSkills(workDir string, refs []string, skills map[string]SkillAsset) ([]string, error) {
+func writeClaudeSkills(skillsDir string, refs []string, skills map[string]SkillAsset) ([]string, error) {
 	created := make([]string, 0, len(refs))
 	for _, ref := range refs {
 		asset, ok := skills[ref]
@@ -268,7 +540,7 @@ func writeClaudeSkills(workDir string, refs []string, skills map[string]SkillAss
 		if err := validateAssetPathComponent(ref); err != nil {
 			return nil, fmt.Errorf("invalid skill ref %q: %w", ref, err)
 		}
-		dir := filepath.Join(workDir, ".claude", "skills", ref)
+		dir := filepath.Join(skillsDir, ref)
 		skill := &claudeconfig.Skill{
 			Name:        asset.Name,
 			Description: asset.Description,
@@ -434,6 +706,11 @@ func validateCodexRuntimeConfig(cfg RuntimeConfig) error {
 	if err := validateCodexWebSearchMode(cfg.Providers.Codex.WebSearchMode); err != nil {
 		return err
 	}
+	for name, def := range cfg.Providers.Codex.InlineAgents {
+		if len(def.Tools) > 0 {
+			return fmt.Errorf("providers.codex.inline_agents.%s.tools is not supported: codex custom agents have no tool allowlist", name)
+		}
+	}
 	return nil
 }
 
@@ -2,12 +2,18 @@ package llmkit
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/randalmurphal/llmkit/v2/claudeconfig"
+	"github.com/randalmurphal/llmkit/v2/codexconfig"
+	"github.com/randalmurphal/llmkit/v2/contract"
+	"github.com/randalmurphal/llmkit/v2/env"
 )
 
 func TestProviderDefinitionsExposeClaudeAndCodex(t *testing.T) {
@@ -185,3 +191,343 @@ func TestPrepareRuntimeWritesClaudeAssetsAndRestoresScope(t *testing.T) {
 		t.Fatal("expected scoped env to be restored")
 	}
 }
+
+func TestPrepareRuntimeTranslatesSharedHooks(t *testing.T) {
+	hooks := []HookDefinition{{
+		Event:   HookBeforeTool,
+		Tools:   []string{contract.ToolShell},
+		Command: "{{hook:guard.sh}}",
+	}}
+	assets := &RuntimeAssets{HookScripts: map[string]string{"guard.sh": "#!/bin/sh\nexit 0\n"}}
+
+	claudeRoot := t.TempDir()
+	prepared, err := PrepareRuntime(context.Background(), PrepareRequest{
+		Provider:      "claude",
+		WorkDir:       claudeRoot,
+		RuntimeConfig: RuntimeConfig{Shared: SharedRuntimeConfig{Hooks: hooks}},
+		Assets:        assets,
+	})
+	if err != nil {
+		t.Fatalf("PrepareRuntime(claude): %v", err)
+	}
+	settings, err := claudeconfig.LoadProjectSettings(claudeRoot)
+	if err != nil {
+		t.Fatalf("load settings: %v", err)
+	}
+	pre := settings.GetHooks(claudeconfig.HookPreToolUse)
+	wantCommand := filepath.Join(claudeRoot, ".claude", "hooks", "guard.sh")
+	if len(pre) != 1 || pre[0].Matcher != "Bash" || pre[0].Hooks[0].Command != wantCommand {
+		t.Fatalf("claude PreToolUse hooks = %#v", pre)
+	}
+	if err := prepared.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	codexRoot := t.TempDir()
+	prepared, err = PrepareRuntime(context.Background(), PrepareRequest{
+		Provider:      "codex",
+		WorkDir:       codexRoot,
+		RuntimeConfig: RuntimeConfig{Shared: SharedRuntimeConfig{Hooks: hooks}},
+		Assets:        assets,
+	})
+	if err != nil {
+		t.Fatalf("PrepareRuntime(codex): %v", err)
+	}
+	defer prepared.Close()
+	codexHooks, err := codexconfig.LoadHooks(codexRoot)
+	if err != nil {
+		t.Fatalf("load codex hooks: %v", err)
+	}
+	codexPre := codexHooks.Hooks["PreToolUse"]
+	wantCommand = filepath.Join(codexRoot, ".codex", "hooks", "guard.sh")
+	if len(codexPre) != 1 || codexPre[0].Matcher != "shell" || codexPre[0].Hooks[0].Command != wantCommand {
+		t.Fatalf("codex PreToolUse hooks = %#v", codexPre)
+	}
+	if _, err := os.Stat(wantCommand); err != nil {
+		t.Fatalf("expected codex hook script written: %v", err)
+	}
+}
+
+func TestValidateRuntimeConfigRejectsUntranslatableHooks(t *testing.T) {
+	cfg := RuntimeConfig{Shared: SharedRuntimeConfig{Hooks: []HookDefinition{{
+		Event:   HookBeforeTool,
+		Tools:   []string{contract.ToolWebFetch},
+		Command: "guard",
+	}}}}
+	if err := ValidateRuntimeConfig("claude", cfg); err != nil {
+		t.Fatalf("ValidateRuntimeConfig(claude): %v", err)
+	}
+	err := ValidateRuntimeConfig("codex", cfg)
+	if !errors.Is(err, env.ErrNoHookEquivalent) || !strings.Contains(err.Error(), "shared.hooks") {
+		t.Fatalf("ValidateRuntimeConfig(codex) = %v, want ErrNoHookEquivalent", err)
+	}
+}
+
+func TestPrepareRuntimeWritesCodexAssetsAndCleansUp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CODEX_HOME", t.TempDir())
+	root := t.TempDir()
+	prepared, err := PrepareRuntime(context.Background(), PrepareRequest{
+		Provider: "codex",
+		WorkDir:  root,
+		RuntimeConfig: RuntimeConfig{
+			Shared: SharedRuntimeConfig{Env: map[string]string{"LLMKIT": "1"}},
+			Providers: RuntimeProviderConfig{
+				Codex: &CodexRuntimeConfig{
+					SkillRefs: []string{"review"},
+					InlineAgents: map[string]InlineAgentDef{
+						"explorer": {Description: "Maps the codebase", Prompt: "Map the codebase.", Model: "gpt-5-codex"},
+					},
+					Instructions: "Run make test before finishing.",
+					PrefixRules: map[string]codexconfig.PrefixRule{
+						"no-push": {Pattern: codexconfig.LiteralPattern("git", "push"), Decision: codexconfig.RuleForbidden},
+					},
+				},
+			},
+		},
+		Assets: &RuntimeAssets{
+			Skills: map[string]SkillAsset{
+				"review": {
+					Name:            "review",
+					Description:     "review skill",
+					Content:         "Use this skill.",
+					SupportingFiles: map[string]string{"checklist.md": "- tests\n"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareRuntime: %v", err)
+	}
+
+	skills, err := codexconfig.DiscoverSkills(root, root)
+	if err != nil || len(skills) != 1 || skills[0].Name != "review" {
+		t.Fatalf("DiscoverSkills = %v, %v", skills, err)
+	}
+	agents, err := codexconfig.DiscoverCustomAgents(root)
+	if err != nil {
+		t.Fatalf("DiscoverCustomAgents: %v", err)
+	}
+	var explorer *codexconfig.CustomAgent
+	for _, agent := range agents {
+		if agent.Name == "explorer" {
+			explorer = agent
+		}
+	}
+	if explorer == nil || explorer.DeveloperInstructions != "Map the codebase." || explorer.Model != "gpt-5-codex" {
+		t.Fatalf("custom agents = %+v", agents)
+	}
+	instructions, err := codexconfig.ResolveInstructions(root, root, nil)
+	if err != nil || len(instructions.Project) != 1 || !strings.Contains(instructions.Project[0].Content, "Run make test") {
+		t.Fatalf("ResolveInstructions = %+v, %v", instructions, err)
+	}
+	rules, err := os.ReadFile(filepath.Join(codexconfig.ProjectRulesDir(root), env.RulesFile))
+	if err != nil || !strings.Contains(string(rules), `decision = "forbidden"`) {
+		t.Fatalf("rules = %q, %v", rules, err)
+	}
+	if names, _ := prepared.Metadata["inline_agents"].([]string); len(names) != 1 || names[0] != "explorer" {
+		t.Fatalf("metadata = %+v", prepared.Metadata)
+	}
+	config, err := codexconfig.LoadProjectConfig(root)
+	if err != nil || config.ShellEnvironmentPolicy.Set["LLMKIT"] != "1" {
+		t.Fatalf("shell_environment_policy = %+v, %v", config, err)
+	}
+	if prepared.Launch.Env["LLMKIT"] != "1" {
+		t.Fatalf("launch env = %v", prepared.Launch.Env)
+	}
+
+	if err := prepared.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if config, err := codexconfig.LoadProjectConfig(root); err != nil || len(config.ShellEnvironmentPolicy.Set) != 0 {
+		t.Errorf("shell_environment_policy after Close = %+v, %v", config, err)
+	}
+	for _, path := range []string{
+		filepath.Join(root, ".agents"),
+		filepath.Join(root, "AGENTS.md"),
+		codexconfig.ProjectAgentsDir(root),
+		codexconfig.ProjectRulesDir(root),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after Close: %v", path, err)
+		}
+	}
+}
+
+func TestValidateRuntimeConfigRejectsCodexAgentTools(t *testing.T) {
+	err := ValidateRuntimeConfig("codex", RuntimeConfig{Providers: RuntimeProviderConfig{Codex: &CodexRuntimeConfig{
+		InlineAgents: map[string]InlineAgentDef{"explorer": {Description: "d", Prompt: "p", Tools: []string{"Read"}}},
+	}}})
+	if err == nil || !strings.Contains(err.Error(), "inline_agents.explorer.tools") {
+		t.Fatalf("ValidateRuntimeConfig = %v, want inline agent tools error", err)
+	}
+}
+
+// snapshotDir maps every path under root to its content.
+func snapshotDir(t *testing.T, root string) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		rel, _ := filepath.Rel(root, path)
+		out[rel] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+	return out
+}
+
+func TestPrepareRuntimeEphemeralClaudeLeavesProjectUntouched(t *testing.T) {
+	root := t.TempDir()
+	if err := claudeconfig.SaveProjectSettings(root, claudeconfig.NewSettings()); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	before := snapshotDir(t, root)
+
+	prepared, err := PrepareRuntime(context.Background(), PrepareRequest{
+		Provider: "claude",
+		WorkDir:  root,
+		Mode:     PrepareModeEphemeral,
+		RuntimeConfig: RuntimeConfig{
+			Shared: SharedRuntimeConfig{
+				MCPServers: map[string]MCPServerConfig{"docs": {Command: "npx", Args: []string{"demo"}}},
+				Env:        map[string]string{"LLMKIT": "1"},
+				Hooks:      []HookDefinition{{Event: HookBeforeTool, Tools: []string{contract.ToolShell}, Command: "{{hook:guard.sh}}"}},
+			},
+			Providers: RuntimeProviderConfig{
+				Claude: &ClaudeRuntimeConfig{
+					SkillRefs:    []string{"review"},
+					InlineAgents: map[string]InlineAgentDef{"reviewer": {Description: "Reviews", Prompt: "Review."}},
+				},
+			},
+		},
+		Assets: &RuntimeAssets{
+			Skills:      map[string]SkillAsset{"review": {Name: "review", Description: "review skill", Content: "Use this skill."}},
+			HookScripts: map[string]string{"guard.sh": "#!/bin/sh\nexit 0\n"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareRuntime: %v", err)
+	}
+	if after := snapshotDir(t, root); !reflect.DeepEqual(after, before) {
+		t.Fatalf("project changed:\nbefore %v\nafter  %v", before, after)
+	}
+
+	launch := prepared.Launch
+	settings, err := os.ReadFile(launch.ClaudeSettings)
+	if err != nil {
+		t.Fatalf("read launch settings: %v", err)
+	}
+	guard := filepath.Join(filepath.Dir(filepath.Dir(launch.ClaudeSettings)), "hooks", "guard.sh")
+	if !strings.Contains(string(settings), guard) || !strings.Contains(string(settings), `"LLMKIT": "1"`) {
+		t.Fatalf("launch settings = %s, want hook %s", settings, guard)
+	}
+	if info, err := os.Stat(guard); err != nil || info.Mode()&0o100 == 0 {
+		t.Fatalf("hook script = %v, %v", info, err)
+	}
+	if mcp, err := os.ReadFile(launch.ClaudeMCPConfig); err != nil || !strings.Contains(string(mcp), `"npx"`) {
+		t.Fatalf("launch mcp config = %s, %v", mcp, err)
+	}
+	if len(launch.ClaudePluginDirs) != 1 {
+		t.Fatalf("plugin dirs = %v", launch.ClaudePluginDirs)
+	}
+	plugin := launch.ClaudePluginDirs[0]
+	for _, rel := range []string{".claude-plugin/plugin.json", "skills/review/SKILL.md"} {
+		if _, err := os.Stat(filepath.Join(plugin, rel)); err != nil {
+			t.Errorf("plugin %s: %v", rel, err)
+		}
+	}
+
+	var cfg Config
+	prepared.Apply(&cfg)
+	if !reflect.DeepEqual(cfg.Launch, launch) {
+		t.Fatalf("Apply launch = %+v, want %+v", cfg.Launch, launch)
+	}
+
+	if err := prepared.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(plugin); !os.IsNotExist(err) {
+		t.Fatalf("runtime dir survived Close: %v", err)
+	}
+	if after := snapshotDir(t, root); !reflect.DeepEqual(after, before) {
+		t.Fatalf("project changed after Close:\nbefore %v\nafter  %v", before, after)
+	}
+}
+
+func TestPrepareRuntimeEphemeralCodex(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	userHome := t.TempDir()
+	t.Setenv("CODEX_HOME", userHome)
+	root := t.TempDir()
+
+	prepared, err := PrepareRuntime(context.Background(), PrepareRequest{
+		Provider: "codex",
+		WorkDir:  root,
+		Mode:     PrepareModeEphemeral,
+		RuntimeConfig: RuntimeConfig{
+			Shared: SharedRuntimeConfig{
+				MCPServers: map[string]MCPServerConfig{"docs": {Command: "npx"}},
+				Env:        map[string]string{"LLMKIT": "1"},
+			},
+			Providers: RuntimeProviderConfig{
+				Codex: &CodexRuntimeConfig{
+					InlineAgents: map[string]InlineAgentDef{"explorer": {Description: "Maps", Prompt: "Map."}},
+					Instructions: "Run make test.",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareRuntime: %v", err)
+	}
+	defer prepared.Close()
+
+	if entries, err := os.ReadDir(root); err != nil || len(entries) != 0 {
+		t.Fatalf("project entries = %v, %v", entries, err)
+	}
+	// Only the session state entries are added to the user's home, so
+	// threads outlive the overlay.
+	var names []string
+	entries, err := os.ReadDir(userHome)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if err != nil || !slices.Equal(names, []string{"history.jsonl", "log", "sessions"}) {
+		t.Fatalf("user codex home entries = %v, %v", names, err)
+	}
+	home := prepared.Launch.Env["CODEX_HOME"]
+	if _, err := os.Stat(filepath.Join(home, "agents", "explorer.toml")); err != nil {
+		t.Fatalf("overlay agent: %v", err)
+	}
+	if agents, err := os.ReadFile(filepath.Join(home, "AGENTS.md")); err != nil || !strings.Contains(string(agents), "Run make test.") {
+		t.Fatalf("overlay AGENTS.md = %q, %v", agents, err)
+	}
+	if got := prepared.Launch.CodexOverrides["mcp_servers.docs.command"]; got != "npx" {
+		t.Fatalf("codex overrides = %v", prepared.Launch.CodexOverrides)
+	}
+	if prepared.Launch.CodexOverrides["shell_environment_policy.set.LLMKIT"] != "1" || prepared.Launch.Env["LLMKIT"] != "1" {
+		t.Fatalf("launch = %+v", prepared.Launch)
+	}
+}
+
+func TestPrepareRuntimeEphemeralRejectsCodexSkills(t *testing.T) {
+	cfg := RuntimeConfig{Providers: RuntimeProviderConfig{Codex: &CodexRuntimeConfig{SkillRefs: []string{"review"}}}}
+	if got := EphemeralUnsupported("codex", cfg); !reflect.DeepEqual(got, []string{"providers.codex.skill_refs"}) {
+		t.Fatalf("EphemeralUnsupported = %v", got)
+	}
+	_, err := PrepareRuntime(context.Background(), PrepareRequest{
+		Provider:      "codex",
+		WorkDir:       t.TempDir(),
+		Mode:          PrepareModeEphemeral,
+		RuntimeConfig: cfg,
+	})
+	if !errors.Is(err, ErrUnsupportedFeature) || !strings.Contains(err.Error(), "skill_refs") {
+		t.Fatalf("PrepareRuntime err = %v, want ErrUnsupportedFeature naming skill_refs", err)
+	}
+}
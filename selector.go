@@ -18,6 +18,7 @@ type Selector struct {
 	overrides  map[any]ModelName
 	globalOver ModelName
 	tierFunc   TierFunc
+	router     *Router
 
 	// Model names configured by user
 	defaultModel  ModelName
@@ -102,8 +103,17 @@ func WithTierFunc(fn TierFunc) SelectorOption {
 	}
 }
 
+// WithRouter routes tasks without an override or default through an adaptive Router.
+// The task's tier from the TierFunc selects router candidates; tasks the router
+// has no candidates for fall back to the tier model.
+func WithRouter(router *Router) SelectorOption {
+	return func(s *Selector) {
+		s.router = router
+	}
+}
+
 // Select returns the appropriate model for the given task.
-// Priority order: global override > task override > task default > tier model > sonnet fallback
+// Priority order: global override > task override > task default > router > tier model > sonnet fallback
 func (s *Selector) Select(task any) ModelName {
 	// Global override wins
 	if s.globalOver != "" {
@@ -122,6 +132,11 @@ func (s *Selector) Select(task any) ModelName {
 
 	// Look up the tier for this task
 	tier := s.tierFunc(task)
+	if s.router != nil {
+		if model, ok := s.router.Choose(task, tier); ok {
+			return model
+		}
+	}
 	return s.SelectForTier(tier)
 }
 
@@ -162,6 +177,7 @@ func (s *Selector) Clone() *Selector {
 		thinkingModel: s.thinkingModel,
 		fastModel:     s.fastModel,
 		tierFunc:      s.tierFunc,
+		router:        s.router,
 	}
 }
 
@@ -2,11 +2,15 @@ package llmkit
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync/atomic"
 
 	claudesession "github.com/randalmurphal/llmkit/v2/claude/session"
 	codexsession "github.com/randalmurphal/llmkit/v2/codex/session"
+	"github.com/randalmurphal/llmkit/v2/codexcontract"
+	"github.com/randalmurphal/llmkit/v2/fanout"
 )
 
 type SessionStatus string
@@ -36,6 +40,10 @@ type Session interface {
 	Info() SessionInfo
 	Send(ctx context.Context, req Request) error
 	Events() <-chan StreamChunk
+	// Subscribe returns an independent event stream with its own buffer and
+	// slow-consumer policy. Late subscribers first receive the current
+	// turn's chunks.
+	Subscribe(opts ...fanout.Option) *fanout.Subscription[StreamChunk]
 	Close() error
 }
 
@@ -69,7 +77,9 @@ func NewSession(ctx context.Context, provider string, cfg Config) (Session, erro
 type claudeRootSession struct {
 	session claudesession.Session
 	manager claudesession.SessionManager
-	events  chan StreamChunk
+	// pending counts sent turns that have not produced a result yet
+	pending atomic.Int32
+	*sessionEvents
 }
 
 func newClaudeRootSession(ctx context.Context, cfg Config) (Session, error) {
@@ -130,6 +140,25 @@ func newClaudeRootSession(ctx context.Context, cfg Config) (Session, error) {
 		if len(claudeCfg.Hooks) > 0 {
 			opts = append(opts, claudesession.WithIncludeHookOutput(true))
 		}
+		if len(claudeCfg.InlineAgents) > 0 {
+			data, err := json.Marshal(claudeCfg.InlineAgents)
+			if err != nil {
+				return nil, fmt.Errorf("marshal inline agents: %w", err)
+			}
+			opts = append(opts, claudesession.WithAgentsJSON(string(data)))
+		}
+	}
+	if cfg.Launch.ClaudeSettings != "" {
+		opts = append(opts, claudesession.WithSettings(cfg.Launch.ClaudeSettings))
+	}
+	if cfg.Launch.ClaudeMCPConfig != "" {
+		opts = append(opts, claudesession.WithMCPConfig(cfg.Launch.ClaudeMCPConfig))
+	}
+	for _, dir := range cfg.Launch.ClaudePluginDirs {
+		opts = append(opts, claudesession.WithPluginDir(dir))
+	}
+	if len(cfg.Launch.Env) > 0 {
+		opts = append(opts, claudesession.WithEnv(cfg.Launch.Env))
 	}
 	if sessionID := SessionID(cfg.Session); sessionID != "" {
 		if cfg.ResumeSession {
@@ -147,9 +176,9 @@ func newClaudeRootSession(ctx context.Context, cfg Config) (Session, error) {
 	}
 
 	root := &claudeRootSession{
-		session: sess,
-		manager: manager,
-		events:  make(chan StreamChunk, 128),
+		session:       sess,
+		manager:       manager,
+		sessionEvents: newSessionEvents(),
 	}
 	go root.forward()
 	return root, nil
@@ -180,21 +209,46 @@ func (s *claudeRootSession) Send(ctx context.Context, req Request) error {
 	if err != nil {
 		return err
 	}
-	return s.session.Send(ctx, claudesession.NewUserMessage(prompt))
+	if err := s.session.Send(ctx, claudesession.NewUserMessage(prompt)); err != nil {
+		return err
+	}
+	s.pending.Add(1)
+	return nil
 }
 
-func (s *claudeRootSession) Events() <-chan StreamChunk { return s.events }
-
 func (s *claudeRootSession) Close() error {
 	return s.manager.CloseAll()
 }
 
+// Steer interrupts the running turn and sends req as the next message.
+// Claude cannot take input mid-turn, so the interrupted turn ends with its
+// own final chunk before the steered turn starts. When no turn is running,
+// Steer just sends req.
 func (s *claudeRootSession) Steer(ctx context.Context, req Request) error {
+	if s.pending.Load() > 0 {
+		if _, err := sessionPrompt(req); err != nil {
+			return err
+		}
+		if err := s.session.Interrupt(ctx); err != nil {
+			return fmt.Errorf("interrupt claude turn: %w", err)
+		}
+	}
 	return s.Send(ctx, req)
 }
 
+// finishTurn records that a sent turn produced its result. Results the
+// session did not send, such as a resumed turn's, leave the count at zero.
+func (s *claudeRootSession) finishTurn() {
+	for {
+		n := s.pending.Load()
+		if n <= 0 || s.pending.CompareAndSwap(n, n-1) {
+			return
+		}
+	}
+}
+
 func (s *claudeRootSession) forward() {
-	defer close(s.events)
+	defer s.broadcast.Close()
 
 	for msg := range s.session.Output() {
 		session := SessionMetadataForID("claude", msg.SessionID)
@@ -208,13 +262,13 @@ func (s *claudeRootSession) forward() {
 				metadata["permission_mode"] = msg.Init.PermissionMode
 				metadata["claude_code_version"] = msg.Init.ClaudeCodeVersion
 			}
-			s.events <- StreamChunk{
+			s.emit(StreamChunk{
 				Type:      "session",
 				SessionID: msg.SessionID,
 				Session:   session,
 				Model:     model,
 				Metadata:  metadata,
-			}
+			})
 		case msg.IsAssistant():
 			text := msg.GetText()
 			if text != "" {
@@ -236,7 +290,7 @@ func (s *claudeRootSession) forward() {
 						CacheReadInputTokens:     msg.Assistant.Message.Usage.CacheReadInputTokens,
 					}
 				}
-				s.events <- chunk
+				s.emit(chunk)
 			}
 			if msg.Assistant != nil {
 				var toolCalls []ToolCall
@@ -250,7 +304,7 @@ func (s *claudeRootSession) forward() {
 					}
 				}
 				if len(toolCalls) > 0 {
-					s.events <- StreamChunk{
+					s.emit(StreamChunk{
 						Type:      "tool_call",
 						Role:      "assistant",
 						Model:     msg.Assistant.Message.Model,
@@ -258,14 +312,14 @@ func (s *claudeRootSession) forward() {
 						SessionID: msg.SessionID,
 						Session:   session,
 						ToolCalls: toolCalls,
-					}
+					})
 				}
 			}
 		case msg.IsHook():
 			if msg.Hook == nil {
 				continue
 			}
-			s.events <- StreamChunk{
+			s.emit(StreamChunk{
 				Type:      "hook",
 				Role:      "system",
 				SessionID: msg.SessionID,
@@ -277,8 +331,9 @@ func (s *claudeRootSession) forward() {
 					"stderr":     msg.Hook.Stderr,
 					"exit_code":  msg.Hook.ExitCode,
 				},
-			}
+			})
 		case msg.IsResult():
+			s.finishTurn()
 			final := ""
 			usage := &TokenUsage{}
 			if msg.Result != nil {
@@ -291,7 +346,21 @@ func (s *claudeRootSession) forward() {
 					CacheReadInputTokens:     msg.Result.Usage.CacheReadInputTokens,
 				}
 			}
-			s.events <- StreamChunk{
+			// Errors precede the final chunk so consumers that stop at Done see them.
+			if msg.IsError() {
+				s.emit(StreamChunk{
+					Type:      "error",
+					SessionID: msg.SessionID,
+					Session:   session,
+					Error: ClassifyError(ErrorDiagnostic{
+						Provider:      "claude",
+						Op:            "session",
+						Message:       final,
+						ResultSubtype: msg.Subtype,
+					}),
+				})
+			}
+			chunk := StreamChunk{
 				Type:         "final",
 				FinalContent: final,
 				SessionID:    msg.SessionID,
@@ -299,18 +368,11 @@ func (s *claudeRootSession) forward() {
 				Usage:        usage,
 				Done:         true,
 			}
-			if msg.IsError() {
-				errText := final
-				if errText == "" {
-					errText = "claude session turn failed"
-				}
-				s.events <- StreamChunk{
-					Type:      "error",
-					SessionID: msg.SessionID,
-					Session:   session,
-					Error:     fmt.Errorf("%s", errText),
-				}
+			if msg.Result != nil {
+				chunk.CostUSD = msg.Result.TotalCostUSD
+				chunk.NumTurns = msg.Result.NumTurns
 			}
+			s.emit(chunk)
 		}
 	}
 }
@@ -318,7 +380,7 @@ func (s *claudeRootSession) forward() {
 type codexRootSession struct {
 	session codexsession.Session
 	manager codexsession.SessionManager
-	events  chan StreamChunk
+	*sessionEvents
 }
 
 func newCodexRootSession(ctx context.Context, cfg Config) (Session, error) {
@@ -363,6 +425,12 @@ func newCodexRootSession(ctx context.Context, cfg Config) (Session, error) {
 			}
 		}
 	}
+	if len(cfg.Launch.CodexOverrides) > 0 {
+		opts = append(opts, codexsession.WithConfigOverrides(cfg.Launch.CodexOverrides))
+	}
+	if len(cfg.Launch.Env) > 0 {
+		opts = append(opts, codexsession.WithEnv(cfg.Launch.Env))
+	}
 
 	manager := codexsession.NewManager()
 	sess, err := manager.Create(ctx, opts...)
@@ -372,9 +440,9 @@ func newCodexRootSession(ctx context.Context, cfg Config) (Session, error) {
 	}
 
 	root := &codexRootSession{
-		session: sess,
-		manager: manager,
-		events:  make(chan StreamChunk, 128),
+		session:       sess,
+		manager:       manager,
+		sessionEvents: newSessionEvents(),
 	}
 	go root.forward()
 	return root, nil
@@ -415,60 +483,67 @@ func (s *codexRootSession) Steer(ctx context.Context, req Request) error {
 	return s.session.Steer(ctx, codexsession.NewUserMessage(prompt))
 }
 
-func (s *codexRootSession) Events() <-chan StreamChunk { return s.events }
-
 func (s *codexRootSession) Close() error {
 	return s.manager.CloseAll()
 }
 
 func (s *codexRootSession) forward() {
-	defer close(s.events)
+	defer s.broadcast.Close()
 
+	// lastMessage is the most recent completed agent message of the turn.
+	var lastMessage string
 	for msg := range s.session.Output() {
 		session := SessionMetadataForID("codex", msg.ThreadID)
 		switch {
 		case msg.IsThreadStarted():
-			s.events <- StreamChunk{
+			s.emit(StreamChunk{
 				Type:      "session",
 				SessionID: msg.ThreadID,
 				Session:   session,
-			}
+			})
 		case msg.IsAgentMessage() || msg.IsAgentMessageDelta():
 			text := msg.GetText()
 			if text == "" {
 				continue
 			}
-			s.events <- StreamChunk{
+			if msg.IsItemComplete() {
+				lastMessage = text
+			}
+			s.emit(StreamChunk{
 				Type:      "assistant",
 				Content:   text,
 				Role:      "assistant",
 				SessionID: msg.ThreadID,
 				Session:   session,
-			}
+			})
 		case msg.IsTurnComplete():
-			s.events <- StreamChunk{
-				Type:      "final",
-				SessionID: msg.ThreadID,
-				Session:   session,
-				Done:      true,
-			}
+			s.emit(StreamChunk{
+				Type:         "final",
+				FinalContent: lastMessage,
+				SessionID:    msg.ThreadID,
+				Session:      session,
+				Done:         true,
+			})
+			lastMessage = ""
 		case msg.IsTurnFailed():
-			errText := msg.GetText()
-			if errText == "" {
-				errText = "codex session turn failed"
-			}
-			s.events <- StreamChunk{
+			s.emit(StreamChunk{
 				Type:      "error",
 				SessionID: msg.ThreadID,
 				Session:   session,
-				Error:     fmt.Errorf("%s", errText),
-			}
-			s.events <- StreamChunk{
+				Error: ClassifyError(ErrorDiagnostic{
+					Provider:  "codex",
+					Op:        "session",
+					Message:   msg.GetText(),
+					EventType: codexcontract.EventTurnFailed,
+				}),
+			})
+			s.emit(StreamChunk{
 				Type:      "final",
 				SessionID: msg.ThreadID,
 				Session:   session,
 				Done:      true,
-			}
+			})
+			lastMessage = ""
 		}
 	}
 }
@@ -536,3 +611,32 @@ func mapCodexStatus(status codexsession.SessionStatus) SessionStatus {
 		return SessionStatusError
 	}
 }
+
+// sessionEvents fans a root session's chunks out to Events and Subscribe.
+type sessionEvents struct {
+	broadcast *fanout.Broadcaster[StreamChunk]
+	events    <-chan StreamChunk
+}
+
+func newSessionEvents() *sessionEvents {
+	broadcast := fanout.New(func(chunk StreamChunk) bool { return chunk.Done })
+	return &sessionEvents{
+		broadcast: broadcast,
+		events:    broadcast.Subscribe(fanout.WithBuffer(128), fanout.WithPolicy(fanout.Block)).C(),
+	}
+}
+
+func (e *sessionEvents) emit(chunk StreamChunk) {
+	e.broadcast.Publish(chunk)
+}
+
+// Events returns the session's default subscription. It is open from the
+// start of the session, so it holds every chunk not yet read, and blocks
+// the session when its 128-chunk buffer is full.
+func (e *sessionEvents) Events() <-chan StreamChunk {
+	return e.events
+}
+
+func (e *sessionEvents) Subscribe(opts ...fanout.Option) *fanout.Subscription[StreamChunk] {
+	return e.broadcast.Subscribe(opts...)
+}
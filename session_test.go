@@ -1,6 +1,14 @@
 package llmkit
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2/fanout"
+)
 
 func TestSessionMetadataRoundTrip(t *testing.T) {
 	session := SessionMetadataForID("codex", "thread-123")
@@ -28,3 +36,103 @@ func TestSessionMetadataRoundTrip(t *testing.T) {
 		t.Fatalf("round-trip SessionID() = %q, want %q", got, "thread-123")
 	}
 }
+
+func TestSessionEventsFanOutWithTurnReplay(t *testing.T) {
+	events := newSessionEvents()
+	events.emit(StreamChunk{Type: "assistant", Content: "one"})
+	events.emit(StreamChunk{Type: "final", Done: true})
+	events.emit(StreamChunk{Type: "assistant", Content: "two"})
+
+	// Events buffers from the start, so a first call made during the second
+	// turn still sees the first.
+	defaultCh := events.Events()
+	if events.Events() != defaultCh {
+		t.Fatal("Events should return the same channel on every call")
+	}
+
+	late := events.Subscribe(fanout.WithPolicy(fanout.DropOldest))
+	events.emit(StreamChunk{Type: "final", Done: true})
+	events.broadcast.Close()
+
+	var got []string
+	for chunk := range defaultCh {
+		got = append(got, chunk.Type+":"+chunk.Content)
+	}
+	if len(got) != 4 || got[0] != "assistant:one" || got[2] != "assistant:two" {
+		t.Fatalf("Events got %v", got)
+	}
+
+	var lateGot []string
+	for chunk := range late.C() {
+		lateGot = append(lateGot, chunk.Type+":"+chunk.Content)
+	}
+	if len(lateGot) != 2 || lateGot[0] != "assistant:two" || lateGot[1] != "final:" {
+		t.Fatalf("late subscriber got %v, want the second turn only", lateGot)
+	}
+}
+
+// steerStub writes a fake claude CLI that logs stdin lines and answers
+// control requests but never finishes a turn.
+func steerStub(t *testing.T) (stub, logPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath = filepath.Join(dir, "stdin.log")
+	stub = filepath.Join(dir, "claude")
+	script := "#!/bin/sh\n" +
+		"while IFS= read -r line; do\n" +
+		"  printf '%s\\n' \"$line\" >> " + logPath + "\n" +
+		"  id=$(printf '%s' \"$line\" | sed -n 's/.*\"request_id\":\"\\([^\"]*\\)\".*/\\1/p')\n" +
+		"  [ -n \"$id\" ] && printf '{\"type\":\"control_response\",\"response\":{\"subtype\":\"success\",\"request_id\":\"%s\"}}\\n' \"$id\"\n" +
+		"done\n"
+	if err := os.WriteFile(stub, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return stub, logPath
+}
+
+// steer sends each prompt with Send, except the last, which it steers, and
+// returns the lines the CLI received.
+func steer(t *testing.T, prompts ...string) []string {
+	t.Helper()
+	stub, logPath := steerStub(t)
+	sess, err := NewSession(context.Background(), "claude", Config{BinaryPath: stub})
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	defer sess.Close()
+	steerable, ok := sess.(SteerableSession)
+	if !ok {
+		t.Fatal("claude session should be steerable")
+	}
+	for i, prompt := range prompts {
+		req := Request{Messages: []Message{NewTextMessage(RoleUser, prompt)}}
+		send := sess.Send
+		if i == len(prompts)-1 {
+			send = steerable.Steer
+		}
+		if err := send(context.Background(), req); err != nil {
+			t.Fatalf("sending %q returned error: %v", prompt, err)
+		}
+	}
+	_ = sess.Close()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestClaudeSessionSteerInterruptsBeforeSending(t *testing.T) {
+	lines := steer(t, "fix the bug", "use the other API")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"subtype":"interrupt"`) || !strings.Contains(lines[2], "use the other API") {
+		t.Fatalf("stdin lines = %q, want the first turn, an interrupt, then the message", lines)
+	}
+}
+
+func TestClaudeSessionSteerIdleSessionJustSends(t *testing.T) {
+	lines := steer(t, "use the other API")
+	if len(lines) != 1 || !strings.Contains(lines[0], "use the other API") {
+		t.Fatalf("stdin lines = %q, want only the message", lines)
+	}
+}
@@ -0,0 +1,32 @@
+// Package sessionindex maintains a local full-text index over Claude Code
+// session files (~/.claude/projects) and Codex rollouts
+// (CODEX_HOME/sessions).
+//
+// The index records each session's project path, first and last activity,
+// models, tools and the files its tools touched, and indexes the text of
+// prompts, responses and tool inputs:
+//
+//	dir, _ := sessionindex.DefaultDir()
+//	ix, err := sessionindex.Open(dir)
+//	if err != nil {
+//	    return err
+//	}
+//	if _, err := ix.Update(ctx); err != nil {
+//	    return err
+//	}
+//	hits, err := ix.Search("users migration",
+//	    sessionindex.WithProject("/work/api"),
+//	    sessionindex.WithTimeRange(time.Now().AddDate(0, 0, -7), time.Time{}),
+//	)
+//
+// Each hit carries the session ID, the Claude message UUID (or Codex call
+// ID), the source path and line offset, and a snippet.
+//
+// # Storage
+//
+// The index is a directory of JSON segment files, one per source file. A
+// segment stores the source's size, modification time and the offset read
+// so far, so Update only reads lines appended since the last run and only
+// rewrites segments whose sources changed. Message text is not copied into
+// the index; snippets are cut from the source line at search time.
+package sessionindex
@@ -0,0 +1,189 @@
+package sessionindex
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claude/session"
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// lineRecord is what one source line contributes to the index. Empty
+// SessionID and Project mean "same as the previous line".
+type lineRecord struct {
+	SessionID string
+	Project   string
+	Timestamp time.Time
+	Model     string
+	Tools     []string
+	Files     []string
+	Docs      []docText
+}
+
+// docText is one searchable piece of a line.
+type docText struct {
+	Kind Kind
+	UUID string
+	Text string
+}
+
+// extractLine parses a source line. It reports false for lines that are not
+// valid records.
+func extractLine(provider string, line []byte) (lineRecord, bool) {
+	if provider == ProviderCodex {
+		return extractCodex(line)
+	}
+	return extractClaude(line)
+}
+
+func extractClaude(line []byte) (lineRecord, bool) {
+	msg, err := session.ParseJSONLMessage(line)
+	if err != nil {
+		return lineRecord{}, false
+	}
+	var extra struct {
+		CWD string `json:"cwd"`
+	}
+	_ = json.Unmarshal(line, &extra) // Same line already parsed above
+
+	rec := lineRecord{SessionID: msg.SessionID, Project: extra.CWD, Model: msg.GetModel()}
+	rec.Timestamp, _ = time.Parse(time.RFC3339Nano, msg.Timestamp)
+
+	switch {
+	case msg.IsUser():
+		if msg.Message == nil {
+			break
+		}
+		var prompt string
+		if json.Unmarshal(msg.Message.Content, &prompt) == nil {
+			rec.addDoc(KindPrompt, msg.UUID, prompt)
+			break
+		}
+		for _, block := range msg.GetContentBlocks() {
+			if block.Type == "text" {
+				rec.addDoc(KindPrompt, msg.UUID, block.Text)
+			}
+		}
+	case msg.IsAssistant():
+		for _, block := range msg.GetContentBlocks() {
+			switch block.Type {
+			case "text":
+				rec.addDoc(KindResponse, msg.UUID, block.Text)
+			case "tool_use":
+				rec.Tools = append(rec.Tools, block.Name)
+				rec.Files = append(rec.Files, inputPaths(block.Input)...)
+				rec.addDoc(KindToolInput, msg.UUID, flattenJSON(block.Input))
+			}
+		}
+	}
+	return rec, true
+}
+
+func extractCodex(line []byte) (lineRecord, bool) {
+	msg, err := rollout.ParseMessage(line)
+	if err != nil {
+		return lineRecord{}, false
+	}
+	var rec lineRecord
+	rec.Timestamp, _ = time.Parse(time.RFC3339Nano, msg.Timestamp)
+
+	switch {
+	case msg.SessionMeta != nil:
+		rec.SessionID = msg.SessionMeta.ID
+		rec.Project = msg.SessionMeta.CWD
+	case msg.TurnContext != nil:
+		rec.Project = msg.TurnContext.CWD
+		rec.Model = msg.GetModel()
+	case msg.IsUser():
+		if !msg.IsInjectedContext() {
+			rec.addDoc(KindPrompt, "", msg.GetText())
+		}
+	case msg.IsAssistant():
+		rec.addDoc(KindResponse, "", msg.GetText())
+	case msg.IsToolCall():
+		call := msg.GetToolCall()
+		rec.Tools = append(rec.Tools, call.Name)
+		text := flattenJSON(call.Arguments)
+		if call.Name == "apply_patch" {
+			rec.Files = append(rec.Files, patchPaths(text)...)
+		} else {
+			rec.Files = append(rec.Files, inputPaths(call.Arguments)...)
+		}
+		rec.addDoc(KindToolInput, call.CallID, text)
+	}
+	return rec, true
+}
+
+func (r *lineRecord) addDoc(kind Kind, uuid, text string) {
+	if strings.TrimSpace(text) != "" {
+		r.Docs = append(r.Docs, docText{Kind: kind, UUID: uuid, Text: text})
+	}
+}
+
+// flattenJSON joins every string value in raw, so tool inputs are searchable
+// by their contents rather than their JSON syntax.
+func flattenJSON(raw json.RawMessage) string {
+	var v any
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return string(raw)
+	}
+	var parts []string
+	var walk func(any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			parts = append(parts, v)
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k])
+			}
+		}
+	}
+	walk(v)
+	return strings.Join(parts, "\n")
+}
+
+// pathKeys are tool input fields that name a file.
+var pathKeys = []string{"file_path", "notebook_path", "path"}
+
+// inputPaths returns the file paths named in a tool input object.
+func inputPaths(raw json.RawMessage) []string {
+	var input map[string]any
+	if json.Unmarshal(raw, &input) != nil {
+		return nil
+	}
+	var paths []string
+	for _, key := range pathKeys {
+		if p, ok := input[key].(string); ok && p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// patchPrefixes mark file headers in a Codex apply_patch body.
+var patchPrefixes = []string{"*** Add File: ", "*** Update File: ", "*** Delete File: ", "*** Move to: "}
+
+// patchPaths returns the files an apply_patch body touches.
+func patchPaths(patch string) []string {
+	var paths []string
+	for _, line := range strings.Split(patch, "\n") {
+		for _, prefix := range patchPrefixes {
+			if p, ok := strings.CutPrefix(line, prefix); ok {
+				paths = append(paths, strings.TrimSpace(p))
+			}
+		}
+	}
+	return paths
+}
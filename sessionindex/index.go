@@ -0,0 +1,517 @@
+package sessionindex
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claude/jsonl"
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// Providers whose histories are indexed.
+const (
+	ProviderClaude = "claude"
+	ProviderCodex  = "codex"
+)
+
+// Kind identifies what part of a session a hit matched.
+type Kind string
+
+const (
+	KindPrompt    Kind = "prompt"
+	KindResponse  Kind = "response"
+	KindToolInput Kind = "tool_input"
+)
+
+// segmentVersion is bumped when the segment format changes; older segments
+// are rebuilt from their source files.
+const segmentVersion = 1
+
+// Option configures an Index.
+type Option func(*config)
+
+// config holds index configuration.
+type config struct {
+	// Claude projects directory ("" = not indexed)
+	claudeDir string
+
+	// Codex sessions directory ("" = not indexed)
+	codexDir string
+}
+
+// defaultConfig returns the default index configuration.
+func defaultConfig() config {
+	var cfg config
+	if dir, err := jsonl.DefaultProjectsDir(); err == nil {
+		cfg.claudeDir = dir
+	}
+	if dir, err := rollout.DefaultSessionsDir(); err == nil {
+		cfg.codexDir = dir
+	}
+	return cfg
+}
+
+// WithClaudeProjectsDir sets the Claude projects directory to index. The
+// default is jsonl.DefaultProjectsDir; "" disables Claude indexing.
+func WithClaudeProjectsDir(dir string) Option {
+	return func(c *config) { c.claudeDir = dir }
+}
+
+// WithCodexSessionsDir sets the Codex sessions directory to index. The
+// default is rollout.DefaultSessionsDir; "" disables Codex indexing.
+func WithCodexSessionsDir(dir string) Option {
+	return func(c *config) { c.codexDir = dir }
+}
+
+// DefaultDir returns the default index location under the user cache dir.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "llmkit", "sessionindex"), nil
+}
+
+// Session describes one indexed session.
+type Session struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Project   string    `json:"project,omitempty"`
+	Paths     []string  `json:"paths"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Models    []string  `json:"models,omitempty"`
+	Tools     []string  `json:"tools,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	// Messages counts indexed prompts and responses.
+	Messages int `json:"messages"`
+}
+
+// UpdateStats reports what an Update did.
+type UpdateStats struct {
+	Scanned   int `json:"scanned"`   // Source files found
+	Updated   int `json:"updated"`   // Source files read because they changed
+	Removed   int `json:"removed"`   // Segments dropped because their source is gone
+	Documents int `json:"documents"` // Documents added
+}
+
+// doc is one indexed piece of text, located by its source line.
+type doc struct {
+	SessionID string    `json:"s"`
+	UUID      string    `json:"u,omitempty"`
+	Kind      Kind      `json:"k"`
+	Timestamp time.Time `json:"t"`
+	Offset    int64     `json:"o"`
+	Part      int       `json:"p,omitempty"`
+}
+
+// segment indexes one source file. It is stored as its own file so an
+// appended source only rewrites its segment.
+type segment struct {
+	Version  int       `json:"version"`
+	Path     string    `json:"path"`
+	Provider string    `json:"provider"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Offset   int64     `json:"offset"`
+
+	// Session and project of the last line, for lines that omit them
+	SessionID string `json:"session_id,omitempty"`
+	Project   string `json:"project,omitempty"`
+
+	Sessions map[string]*Session `json:"sessions"`
+	Docs     []doc               `json:"docs"`
+	Terms    map[string][]int    `json:"terms"`
+}
+
+func newSegment(path, provider string) *segment {
+	seg := &segment{
+		Version:  segmentVersion,
+		Path:     path,
+		Provider: provider,
+		Sessions: make(map[string]*Session),
+		Terms:    make(map[string][]int),
+	}
+	if info, ok := rollout.ParseFileName(path); ok && provider == ProviderCodex {
+		seg.SessionID = info.ThreadID
+	}
+	return seg
+}
+
+// Index is a full-text index over local Claude and Codex session files,
+// stored under a cache directory. It is safe for concurrent use.
+type Index struct {
+	dir    string
+	config config
+
+	updateMu sync.Mutex // Serializes Update
+
+	mu       sync.RWMutex
+	segments map[string]*segment // By source path
+}
+
+// Open loads the index stored in dir, creating the directory if needed.
+// Call Update to ingest new or changed session files.
+func Open(dir string, opts ...Option) (*Index, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "segments"), 0o700); err != nil {
+		return nil, fmt.Errorf("create index dir: %w", err)
+	}
+
+	ix := &Index{dir: dir, config: cfg, segments: make(map[string]*segment)}
+	entries, err := os.ReadDir(filepath.Join(dir, "segments"))
+	if err != nil {
+		return nil, fmt.Errorf("read index dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "segments", entry.Name()))
+		if err != nil {
+			continue
+		}
+		var seg segment
+		// Corrupt or outdated segments are rebuilt by the next Update.
+		if json.Unmarshal(data, &seg) != nil || seg.Version != segmentVersion || seg.Path == "" {
+			continue
+		}
+		ix.segments[seg.Path] = &seg
+	}
+	return ix, nil
+}
+
+// Dir returns the index directory.
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// Update ingests new and changed session files and drops files that no
+// longer exist. Session files are append-only, so a grown file is read from
+// where the last Update stopped; a shrunken one is re-read from the start.
+func (ix *Index) Update(ctx context.Context) (UpdateStats, error) {
+	ix.updateMu.Lock()
+	defer ix.updateMu.Unlock()
+
+	var stats UpdateStats
+	sources, err := ix.sources()
+	if err != nil {
+		return stats, err
+	}
+	stats.Scanned = len(sources)
+
+	for path, provider := range sources {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		ix.mu.RLock()
+		seg := ix.segments[path]
+		ix.mu.RUnlock()
+		if seg != nil && seg.Size == info.Size() && seg.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		var next *segment
+		if seg == nil || info.Size() < seg.Offset {
+			next = newSegment(path, provider)
+		} else {
+			next = seg.clone()
+		}
+		added, err := next.ingest(path)
+		if err != nil {
+			continue // The file vanished or became unreadable; try again next time
+		}
+		next.Size = info.Size()
+		next.ModTime = info.ModTime()
+		if err := ix.writeSegment(next); err != nil {
+			return stats, err
+		}
+
+		ix.mu.Lock()
+		ix.segments[path] = next
+		ix.mu.Unlock()
+		stats.Updated++
+		stats.Documents += added
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path := range ix.segments {
+		if _, ok := sources[path]; ok {
+			continue
+		}
+		if err := os.Remove(ix.segmentPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return stats, fmt.Errorf("remove index segment: %w", err)
+		}
+		delete(ix.segments, path)
+		stats.Removed++
+	}
+	return stats, nil
+}
+
+// sources lists session files by path with their provider.
+func (ix *Index) sources() (map[string]string, error) {
+	sources := make(map[string]string)
+	if dir := ix.config.claudeDir; dir != "" && dirExists(dir) {
+		files, err := jsonl.FindSessionFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			sources[f] = ProviderClaude
+		}
+	}
+	if dir := ix.config.codexDir; dir != "" && dirExists(dir) {
+		files, err := rollout.FindRolloutFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			sources[f] = ProviderCodex
+		}
+	}
+	return sources, nil
+}
+
+// Sessions returns every indexed session, most recently active first. A
+// session written to several files is merged into one entry.
+func (ix *Index) Sessions() []Session {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	merged := make(map[string]*Session)
+	for _, seg := range ix.segments {
+		for id, s := range seg.Sessions {
+			key := s.Provider + "/" + id
+			m, ok := merged[key]
+			if !ok {
+				c := *s
+				c.Paths = append([]string(nil), s.Paths...)
+				c.Models = append([]string(nil), s.Models...)
+				c.Tools = append([]string(nil), s.Tools...)
+				c.Files = append([]string(nil), s.Files...)
+				merged[key] = &c
+				continue
+			}
+			m.merge(s)
+		}
+	}
+
+	sessions := make([]Session, 0, len(merged))
+	for _, s := range merged {
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeen.Equal(sessions[j].LastSeen) {
+			return sessions[i].LastSeen.After(sessions[j].LastSeen)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+func (s *Session) merge(other *Session) {
+	for _, p := range other.Paths {
+		s.Paths = addUnique(s.Paths, p)
+	}
+	for _, m := range other.Models {
+		s.Models = addUnique(s.Models, m)
+	}
+	for _, t := range other.Tools {
+		s.Tools = addUnique(s.Tools, t)
+	}
+	for _, f := range other.Files {
+		s.Files = addUnique(s.Files, f)
+	}
+	if s.Project == "" {
+		s.Project = other.Project
+	}
+	s.see(other.FirstSeen)
+	s.see(other.LastSeen)
+	s.Messages += other.Messages
+}
+
+func (s *Session) see(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if s.FirstSeen.IsZero() || at.Before(s.FirstSeen) {
+		s.FirstSeen = at
+	}
+	if at.After(s.LastSeen) {
+		s.LastSeen = at
+	}
+}
+
+// ingest reads complete lines from the segment's offset and returns how
+// many documents were added. A trailing line without its newline is left
+// for the next Update.
+func (seg *segment) ingest(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(seg.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return added, nil
+			}
+			return added, err
+		}
+		offset := seg.Offset
+		seg.Offset += int64(len(line))
+		if rec, ok := extractLine(seg.Provider, line[:len(line)-1]); ok {
+			added += seg.add(rec, offset)
+		}
+	}
+}
+
+// add records a parsed line found at offset.
+func (seg *segment) add(rec lineRecord, offset int64) int {
+	if rec.SessionID != "" {
+		seg.SessionID = rec.SessionID
+	}
+	if rec.Project != "" {
+		seg.Project = rec.Project
+	}
+	if seg.SessionID == "" {
+		return 0
+	}
+
+	s, ok := seg.Sessions[seg.SessionID]
+	if !ok {
+		s = &Session{ID: seg.SessionID, Provider: seg.Provider, Paths: []string{seg.Path}}
+		seg.Sessions[seg.SessionID] = s
+	}
+	if s.Project == "" {
+		s.Project = seg.Project
+	}
+	s.see(rec.Timestamp)
+	if rec.Model != "" {
+		s.Models = addUnique(s.Models, rec.Model)
+	}
+	for _, t := range rec.Tools {
+		s.Tools = addUnique(s.Tools, t)
+	}
+	for _, f := range rec.Files {
+		s.Files = addUnique(s.Files, f)
+	}
+
+	for part, d := range rec.Docs {
+		if d.Kind != KindToolInput {
+			s.Messages++
+		}
+		id := len(seg.Docs)
+		seg.Docs = append(seg.Docs, doc{
+			SessionID: seg.SessionID,
+			UUID:      d.UUID,
+			Kind:      d.Kind,
+			Timestamp: rec.Timestamp,
+			Offset:    offset,
+			Part:      part,
+		})
+		for _, term := range uniqueTerms(d.Text) {
+			seg.Terms[term] = append(seg.Terms[term], id)
+		}
+	}
+	return len(rec.Docs)
+}
+
+// clone deep-copies the parts of seg that ingest mutates.
+func (seg *segment) clone() *segment {
+	c := *seg
+	c.Sessions = make(map[string]*Session, len(seg.Sessions))
+	for id, s := range seg.Sessions {
+		sc := *s
+		sc.Paths = append([]string(nil), s.Paths...)
+		sc.Models = append([]string(nil), s.Models...)
+		sc.Tools = append([]string(nil), s.Tools...)
+		sc.Files = append([]string(nil), s.Files...)
+		c.Sessions[id] = &sc
+	}
+	c.Docs = append([]doc(nil), seg.Docs...)
+	c.Terms = make(map[string][]int, len(seg.Terms))
+	for term, ids := range seg.Terms {
+		c.Terms[term] = ids[:len(ids):len(ids)]
+	}
+	return &c
+}
+
+// segmentPath returns the segment file for a source path.
+func (ix *Index) segmentPath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(ix.dir, "segments", hex.EncodeToString(sum[:12])+".json")
+}
+
+// writeSegment atomically replaces seg's file.
+func (ix *Index) writeSegment(seg *segment) error {
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return fmt.Errorf("marshal index segment: %w", err)
+	}
+	path := ix.segmentPath(seg.Path)
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".segment-*.json")
+	if err != nil {
+		return fmt.Errorf("create index segment temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write index segment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("close index segment: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("replace index segment: %w", err)
+	}
+	return nil
+}
+
+// addUnique inserts v into the sorted slice s if it is missing.
+func addUnique(s []string, v string) []string {
+	i := sort.SearchStrings(s, v)
+	if i < len(s) && s[i] == v {
+		return s
+	}
+	s = append(s, "")
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func dirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
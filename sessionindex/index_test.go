@@ -0,0 +1,151 @@
+package sessionindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const claudeLines = `{"type":"user","timestamp":"2025-06-01T10:00:00Z","sessionId":"claude-1","uuid":"u1","cwd":"/work/api","message":{"role":"user","content":"Add the users migration"}}
+{"type":"assistant","timestamp":"2025-06-01T10:00:05Z","sessionId":"claude-1","uuid":"a1","cwd":"/work/api","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Creating the migration file now."},{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"/work/api/db/0042_add_users.sql","content":"CREATE TABLE users (id int);"}}]}}
+`
+
+const codexLines = `{"timestamp":"2025-06-03T09:00:00Z","type":"session_meta","payload":{"id":"thread-1","cwd":"/work/web"}}
+{"timestamp":"2025-06-03T09:00:01Z","type":"turn_context","payload":{"cwd":"/work/web","model":"gpt-5-codex"}}
+{"timestamp":"2025-06-03T09:00:02Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"Fix the login page styles"}]}}
+{"timestamp":"2025-06-03T09:00:03Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","call_id":"call_1","input":"*** Begin Patch\n*** Update File: src/login.css\n+body { color: red; }\n*** End Patch"}}
+`
+
+func setup(t *testing.T) (claudeFile, codexFile string, opts []Option) {
+	t.Helper()
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, "claude", "projects")
+	codexDir := filepath.Join(root, "codex", "sessions")
+	claudeFile = filepath.Join(claudeDir, "-work-api", "claude-1.jsonl")
+	codexFile = filepath.Join(codexDir, "2025", "06", "03", "rollout-2025-06-03T09-00-00-thread-1.jsonl")
+	for path, content := range map[string]string{claudeFile: claudeLines, codexFile: codexLines} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return claudeFile, codexFile, []Option{WithClaudeProjectsDir(claudeDir), WithCodexSessionsDir(codexDir)}
+}
+
+func TestIndexSearchAndSessions(t *testing.T) {
+	_, _, opts := setup(t)
+	ix, err := Open(t.TempDir(), opts...)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	stats, err := ix.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if stats.Scanned != 2 || stats.Updated != 2 || stats.Documents != 5 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	hits, err := ix.Search("migration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("hits = %+v, want the prompt and the response", hits)
+	}
+	if hits[0].SessionID != "claude-1" || hits[0].Project != "/work/api" || hits[0].UUID != "a1" {
+		t.Fatalf("first hit = %+v", hits[0])
+	}
+	if !strings.Contains(hits[len(hits)-1].Snippet, "users migration") {
+		t.Fatalf("prompt snippet = %q", hits[len(hits)-1].Snippet)
+	}
+
+	if hits, _ := ix.Search("0042_add_us*", WithKinds(KindToolInput)); len(hits) != 1 || hits[0].Kind != KindToolInput {
+		t.Fatalf("prefix tool-input hits = %+v", hits)
+	}
+	if hits, _ := ix.Search("login", WithProject("/work/api")); len(hits) != 0 {
+		t.Fatalf("project filter returned %+v", hits)
+	}
+	if hits, _ := ix.Search("login styles", WithProvider(ProviderCodex)); len(hits) != 1 || hits[0].SessionID != "thread-1" {
+		t.Fatalf("codex hits = %+v", hits)
+	}
+	june2 := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	if hits, _ := ix.Search("migration", WithTimeRange(june2, time.Time{})); len(hits) != 0 {
+		t.Fatalf("time filter returned %+v", hits)
+	}
+
+	sessions := ix.Sessions()
+	if len(sessions) != 2 || sessions[0].ID != "thread-1" {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+	codex, claude := sessions[0], sessions[1]
+	if len(codex.Files) != 1 || codex.Files[0] != "src/login.css" || codex.Models[0] != "gpt-5-codex" {
+		t.Fatalf("codex session = %+v", codex)
+	}
+	if len(claude.Files) != 1 || claude.Tools[0] != "Write" || claude.Messages != 2 {
+		t.Fatalf("claude session = %+v", claude)
+	}
+}
+
+func TestIndexUpdatesIncrementally(t *testing.T) {
+	claudeFile, codexFile, opts := setup(t)
+	dir := t.TempDir()
+	ix, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged files are skipped.
+	if stats, _ := ix.Update(context.Background()); stats.Updated != 0 {
+		t.Fatalf("second Update stats = %+v", stats)
+	}
+
+	// An appended line is ingested from the stored offset; a partial line
+	// waits for its newline.
+	f, err := os.OpenFile(claudeFile, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","timestamp":"2025-06-01T11:00:00Z","sessionId":"claude-1","uuid":"u2","message":{"role":"user","content":"rollback plan"}}`
+	_, _ = f.WriteString(line + "\n" + `{"type":"user"`)
+	f.Close()
+	stats, err := ix.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Updated != 1 || stats.Documents != 1 {
+		t.Fatalf("append Update stats = %+v", stats)
+	}
+
+	// A reopened index keeps the ingested documents.
+	reopened, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := reopened.Search("rollback"); len(hits) != 1 || hits[0].UUID != "u2" {
+		t.Fatalf("reopened hits = %+v", hits)
+	}
+
+	// Deleted sources drop their segment.
+	if err := os.Remove(codexFile); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := reopened.Update(context.Background()); stats.Removed != 1 {
+		t.Fatalf("removal stats = %+v", stats)
+	}
+	if hits, _ := reopened.Search("login"); len(hits) != 0 {
+		t.Fatalf("hits from removed file = %+v", hits)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "segments"))
+	if len(entries) != 1 {
+		t.Fatalf("segment files = %d, want 1", len(entries))
+	}
+}
@@ -0,0 +1,334 @@
+package sessionindex
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchOption configures Search.
+type SearchOption func(*searchConfig)
+
+// searchConfig holds search configuration.
+type searchConfig struct {
+	project  string
+	provider string
+	kinds    map[Kind]bool
+	since    time.Time
+	until    time.Time
+	limit    int
+}
+
+// defaultSearchConfig returns the default search configuration.
+func defaultSearchConfig() searchConfig {
+	return searchConfig{limit: 50}
+}
+
+// WithProject restricts hits to sessions whose project path is dir or is
+// inside it.
+func WithProject(dir string) SearchOption {
+	return func(c *searchConfig) { c.project = strings.TrimRight(dir, "/") }
+}
+
+// WithProvider restricts hits to ProviderClaude or ProviderCodex sessions.
+func WithProvider(provider string) SearchOption {
+	return func(c *searchConfig) { c.provider = provider }
+}
+
+// WithKinds restricts hits to prompts, responses or tool inputs.
+func WithKinds(kinds ...Kind) SearchOption {
+	return func(c *searchConfig) {
+		c.kinds = make(map[Kind]bool, len(kinds))
+		for _, k := range kinds {
+			c.kinds[k] = true
+		}
+	}
+}
+
+// WithTimeRange restricts hits to messages written in [since, until). A
+// zero bound is open.
+func WithTimeRange(since, until time.Time) SearchOption {
+	return func(c *searchConfig) {
+		c.since = since
+		c.until = until
+	}
+}
+
+// WithLimit caps the number of hits. The default is 50; 0 means no limit.
+func WithLimit(n int) SearchOption {
+	return func(c *searchConfig) { c.limit = n }
+}
+
+// Hit is one matching message.
+type Hit struct {
+	SessionID string    `json:"session_id"`
+	Provider  string    `json:"provider"`
+	Project   string    `json:"project,omitempty"`
+	Path      string    `json:"path"`
+	Kind      Kind      `json:"kind"`
+	Timestamp time.Time `json:"timestamp"`
+	// UUID is the Claude message UUID. Codex records have no message IDs, so
+	// Codex tool input hits carry the call ID and other Codex hits none.
+	UUID string `json:"uuid,omitempty"`
+	// Offset is the byte offset of the source line in Path.
+	Offset int64 `json:"offset"`
+	// Snippet is the matched text around the first query term.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Search returns messages containing every term of query, newest first.
+// Terms match whole words case-insensitively; a trailing * matches a
+// prefix ("migrat*").
+func (ix *Index) Search(query string, opts ...SearchOption) ([]Hit, error) {
+	cfg := defaultSearchConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	ix.mu.RLock()
+	var hits []Hit
+	var parts []int
+	for _, seg := range ix.segments {
+		if cfg.provider != "" && seg.Provider != cfg.provider {
+			continue
+		}
+		for _, id := range seg.match(terms) {
+			d := seg.Docs[id]
+			session := seg.Sessions[d.SessionID]
+			if !cfg.accepts(d, session) {
+				continue
+			}
+			hits = append(hits, Hit{
+				SessionID: d.SessionID,
+				Provider:  seg.Provider,
+				Project:   session.Project,
+				Path:      seg.Path,
+				Kind:      d.Kind,
+				Timestamp: d.Timestamp,
+				UUID:      d.UUID,
+				Offset:    d.Offset,
+			})
+			parts = append(parts, d.Part)
+		}
+	}
+	ix.mu.RUnlock()
+
+	order := make([]int, len(hits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := hits[order[i]], hits[order[j]]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Offset < b.Offset
+	})
+	if cfg.limit > 0 && len(order) > cfg.limit {
+		order = order[:cfg.limit]
+	}
+
+	result := make([]Hit, len(order))
+	for i, idx := range order {
+		hit := hits[idx]
+		hit.Snippet = snippet(hit, parts[idx], terms)
+		result[i] = hit
+	}
+	return result, nil
+}
+
+func (c searchConfig) accepts(d doc, s *Session) bool {
+	if len(c.kinds) > 0 && !c.kinds[d.Kind] {
+		return false
+	}
+	if !c.since.IsZero() && d.Timestamp.Before(c.since) {
+		return false
+	}
+	if !c.until.IsZero() && !d.Timestamp.Before(c.until) {
+		return false
+	}
+	if c.project != "" {
+		if s == nil || (s.Project != c.project && !strings.HasPrefix(s.Project, c.project+"/")) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryTerm is one parsed query word.
+type queryTerm struct {
+	text   string
+	prefix bool
+}
+
+func parseQuery(query string) []queryTerm {
+	var terms []queryTerm
+	for _, field := range strings.Fields(query) {
+		prefix := strings.HasSuffix(field, "*")
+		for _, word := range tokenize(field) {
+			terms = append(terms, queryTerm{text: word})
+		}
+		if prefix && len(terms) > 0 {
+			terms[len(terms)-1].prefix = true
+		}
+	}
+	return terms
+}
+
+// match returns the IDs of docs containing every term, in ascending order.
+func (seg *segment) match(terms []queryTerm) []int {
+	var result []int
+	for i, term := range terms {
+		ids := seg.postings(term)
+		if i == 0 {
+			result = ids
+		} else {
+			result = intersect(result, ids)
+		}
+		if len(result) == 0 {
+			return nil
+		}
+	}
+	return result
+}
+
+func (seg *segment) postings(term queryTerm) []int {
+	if !term.prefix {
+		return seg.Terms[term.text]
+	}
+	seen := make(map[int]bool)
+	var ids []int
+	for word, list := range seg.Terms {
+		if !strings.HasPrefix(word, term.text) {
+			continue
+		}
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// intersect merges two ascending ID lists.
+func intersect(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+// Words shorter or longer than these bounds are not indexed.
+const (
+	minTermLen = 2
+	maxTermLen = 64
+)
+
+// tokenize lowercases text and splits it into letter and digit runs, so
+// "db/0042_add_users.sql" yields db, 0042, add, users and sql.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if n := utf8.RuneCountInString(f); n >= minTermLen && n <= maxTermLen {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+func uniqueTerms(text string) []string {
+	words := tokenize(text)
+	seen := make(map[string]bool, len(words))
+	unique := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+// snippetRadius is how many bytes of context a snippet keeps on each side
+// of the first matched term.
+const snippetRadius = 80
+
+// snippet re-reads the hit's source line and cuts the matched text around
+// the first query term. It returns "" if the source changed.
+func snippet(hit Hit, part int, terms []queryTerm) string {
+	line, ok := readLine(hit.Path, hit.Offset)
+	if !ok {
+		return ""
+	}
+	rec, ok := extractLine(hit.Provider, line)
+	if !ok || part >= len(rec.Docs) {
+		return ""
+	}
+	text := rec.Docs[part].Text
+
+	at := strings.Index(strings.ToLower(text), terms[0].text)
+	if at < 0 {
+		at = 0
+	}
+	start := max(0, at-snippetRadius)
+	end := min(len(text), at+len(terms[0].text)+snippetRadius)
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	s := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(text) {
+		s += "…"
+	}
+	return s
+}
+
+// readLine reads the line starting at offset in path.
+func readLine(path string, offset int64) ([]byte, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, false
+	}
+	line, err := bufio.NewReaderSize(f, 64*1024).ReadBytes('\n')
+	if err != nil {
+		return nil, false
+	}
+	return line[:len(line)-1], true
+}
@@ -0,0 +1,17 @@
+// Package sessionstore persists session manager state so long-running
+// services can find and resume Claude sessions and Codex threads after a
+// restart.
+//
+// The claude/session and codex/session managers write a Record when a session
+// is created, after each completed turn, and when it ends. A session closed
+// with SessionManager.Close is recorded as closed; one whose process ended any
+// other way (CloseAll on shutdown, idle TTL, a crash) is recorded as
+// suspended. The managers implement session.Restorer, whose Restore loads
+// active and suspended records. Pass a store with the WithSessionStore manager
+// option and call Restore on startup:
+//
+//	store, err := sessionstore.NewFileStore(filepath.Join(stateDir, "sessions"))
+//	mgr := session.NewManager(session.WithSessionStore(store))
+//	if err := mgr.(session.Restorer).Restore(); err != nil { ... }
+//	s, err := mgr.Resume(ctx, sessionID) // resumes with the stored options
+package sessionstore
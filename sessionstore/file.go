@@ -0,0 +1,139 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileStore keeps one JSON file per session in a directory. Writes are
+// atomic and files are created with mode 0600 because options can include
+// prompts and paths.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates the directory if needed and returns a store backed by it.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("session store dir is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create session store dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Dir returns the directory holding the record files.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+// Save implements Store.
+func (s *FileStore) Save(rec Record) error {
+	path, err := s.path(rec.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal session record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, ".session-*.json")
+	if err != nil {
+		return fmt.Errorf("create session record temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write session record: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("close session record: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("replace session record: %w", err)
+	}
+	return nil
+}
+
+// Load implements Store.
+func (s *FileStore) Load(id string) (Record, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Record{}, err
+	}
+	return readRecord(path, id)
+}
+
+// List implements Store. Files that fail to parse are skipped so one corrupt
+// record does not hide the rest.
+func (s *FileStore) List() ([]Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read session store dir: %w", err)
+	}
+	var records []Record
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		rec, err := readRecord(filepath.Join(s.dir, name), strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete session record: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session record ID %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func readRecord(path, id string) (Record, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("read session record: %w", err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, fmt.Errorf("parse session record %s: %w", id, err)
+	}
+	return rec, nil
+}
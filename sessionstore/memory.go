@@ -0,0 +1,61 @@
+package sessionstore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps records in memory. It is useful in tests and for sharing
+// state between managers in one process.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(rec Record) error {
+	if rec.ID == "" {
+		return fmt.Errorf("save session record: empty ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[rec.ID] = cloneRecord(rec)
+	return nil
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(id string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return cloneRecord(rec), nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List() ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]Record, 0, len(s.records))
+	for _, rec := range s.records {
+		records = append(records, cloneRecord(rec))
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
@@ -0,0 +1,72 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Load when no record exists for an ID.
+var ErrNotFound = errors.New("session record not found")
+
+// Record statuses written by the session managers.
+const (
+	StatusActive = "active"
+	// StatusSuspended marks a session whose process ended without an
+	// explicit Close. It can still be resumed.
+	StatusSuspended = "suspended"
+	StatusClosed    = "closed"
+)
+
+// Record is the persisted state of one managed session.
+type Record struct {
+	// ID is the Claude session ID or Codex thread ID.
+	ID string `json:"id"`
+	// Provider is "claude" or "codex".
+	Provider string `json:"provider"`
+	Workdir  string `json:"workdir,omitempty"`
+	// Options is the provider package's serialized session options. It is
+	// opaque to the store.
+	Options json.RawMessage `json:"options,omitempty"`
+	Status  string          `json:"status"`
+	Usage   Usage           `json:"usage"`
+	// Metadata carries caller-defined keys such as a task ID.
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	LastActivity time.Time         `json:"last_activity"`
+}
+
+// Usage is the cumulative usage of a session across process restarts.
+type Usage struct {
+	Turns        int     `json:"turns"`
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+}
+
+// Store persists session records. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Save creates or replaces the record with rec.ID.
+	Save(rec Record) error
+	// Load returns the record for id or ErrNotFound.
+	Load(id string) (Record, error)
+	// List returns every record, ordered by ID.
+	List() ([]Record, error)
+	// Delete removes the record for id. Deleting a missing record is not an error.
+	Delete(id string) error
+}
+
+func cloneRecord(rec Record) Record {
+	if rec.Options != nil {
+		rec.Options = append(json.RawMessage(nil), rec.Options...)
+	}
+	if rec.Metadata != nil {
+		metadata := make(map[string]string, len(rec.Metadata))
+		for k, v := range rec.Metadata {
+			metadata[k] = v
+		}
+		rec.Metadata = metadata
+	}
+	return rec
+}
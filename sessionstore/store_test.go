@@ -0,0 +1,92 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStores_RoundTrip(t *testing.T) {
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+	if err != nil {
+		t.Fatalf("NewFileStore returned error: %v", err)
+	}
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			rec := Record{
+				ID:           "sess-2",
+				Provider:     "claude",
+				Workdir:      "/repo",
+				Options:      json.RawMessage(`{"model":"opus"}`),
+				Status:       StatusActive,
+				Usage:        Usage{Turns: 2, InputTokens: 10, OutputTokens: 4, CostUSD: 0.5},
+				Metadata:     map[string]string{"task": "T-1"},
+				CreatedAt:    time.Now().UTC().Truncate(time.Second),
+				LastActivity: time.Now().UTC().Truncate(time.Second),
+			}
+			if err := store.Save(rec); err != nil {
+				t.Fatalf("Save returned error: %v", err)
+			}
+			if err := store.Save(Record{ID: "sess-1", Provider: "codex", Status: StatusClosed}); err != nil {
+				t.Fatalf("Save returned error: %v", err)
+			}
+
+			got, err := store.Load("sess-2")
+			if err != nil {
+				t.Fatalf("Load returned error: %v", err)
+			}
+			var options map[string]string
+			if err := json.Unmarshal(got.Options, &options); err != nil || options["model"] != "opus" {
+				t.Fatalf("Load options = %s, %v", got.Options, err)
+			}
+			if got.Metadata["task"] != "T-1" || got.Usage != rec.Usage || !got.CreatedAt.Equal(rec.CreatedAt) {
+				t.Fatalf("Load = %+v, want %+v", got, rec)
+			}
+
+			records, err := store.List()
+			if err != nil || len(records) != 2 || records[0].ID != "sess-1" {
+				t.Fatalf("List = %+v, %v", records, err)
+			}
+
+			if err := store.Delete("sess-2"); err != nil {
+				t.Fatalf("Delete returned error: %v", err)
+			}
+			if err := store.Delete("sess-2"); err != nil {
+				t.Fatalf("second Delete returned error: %v", err)
+			}
+			if _, err := store.Load("sess-2"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Load after delete = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestFileStore_RejectsPathIDsAndSkipsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore returned error: %v", err)
+	}
+	if err := store.Save(Record{ID: "../escape"}); err == nil {
+		t.Fatal("Save accepted an ID containing a path separator")
+	}
+	if err := store.Save(Record{ID: "ok", Status: StatusActive}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "ok.json"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("record file mode = %v, %v", info, err)
+	}
+	records, err := store.List()
+	if err != nil || len(records) != 1 || records[0].ID != "ok" {
+		t.Fatalf("List = %+v, %v", records, err)
+	}
+}
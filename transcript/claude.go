@@ -0,0 +1,173 @@
+package transcript
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/claude/jsonl"
+	"github.com/randalmurphal/llmkit/v2/claude/session"
+)
+
+// claudeProvider is the Transcript.Provider value for Claude sessions.
+const claudeProvider = "claude"
+
+// claudeLine holds the JSONL fields that session.JSONLMessage does not
+// decode.
+type claudeLine struct {
+	CWD         string `json:"cwd"`
+	IsSidechain bool   `json:"isSidechain"`
+	IsMeta      bool   `json:"isMeta"`
+	Message     struct {
+		ID string `json:"id"`
+	} `json:"message"`
+}
+
+// FromClaudeJSONL reads a Claude Code session file and builds its transcript.
+func FromClaudeJSONL(path string) (*Transcript, error) {
+	messages, err := jsonl.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromClaudeMessages(messages), nil
+}
+
+// FromClaudeMessages builds a transcript from parsed Claude JSONL messages.
+// Sidechain (subagent) and meta messages are skipped. Claude writes one line
+// per content block with the same usage repeated, so usage is counted once
+// per API message ID.
+func FromClaudeMessages(messages []session.JSONLMessage) *Transcript {
+	b := newBuilder(claudeProvider)
+	counted := make(map[string]bool)
+
+	for i := range messages {
+		msg := &messages[i]
+		var line claudeLine
+		if len(msg.Raw) > 0 {
+			_ = json.Unmarshal(msg.Raw, &line) // Best effort; the fields are optional
+		}
+		if line.IsSidechain || line.IsMeta {
+			continue
+		}
+		if b.t.SessionID == "" {
+			b.t.SessionID = msg.SessionID
+		}
+		if b.t.WorkDir == "" {
+			b.t.WorkDir = line.CWD
+		}
+		at, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+		b.seen(at)
+
+		switch {
+		case msg.IsUser():
+			addClaudeUser(b, msg, at)
+		case msg.IsAssistant():
+			if model := msg.GetModel(); model != "" {
+				if b.t.Model == "" {
+					b.t.Model = model
+				}
+				if turn := b.turn(); turn.Model == "" {
+					turn.Model = model
+				}
+			}
+			for _, block := range msg.GetContentBlocks() {
+				switch block.Type {
+				case "text":
+					b.addText(EntryText, block.Text)
+				case "thinking":
+					b.addText(EntryThinking, block.Thinking)
+				case "tool_use":
+					b.addTool(ToolCall{ID: block.ID, Name: block.Name, Input: block.Input})
+					if block.Name == "TodoWrite" {
+						if todos := todoWriteInput(block.Input); todos != nil {
+							b.turn().Todos = todos
+						}
+					}
+				}
+			}
+			if usage := msg.GetUsage(); usage != nil {
+				id := line.Message.ID
+				if id == "" || !counted[id] {
+					counted[id] = id != ""
+					b.addUsage(Usage{
+						InputTokens:         usage.InputTokens,
+						OutputTokens:        usage.OutputTokens,
+						CacheReadTokens:     usage.CacheReadInputTokens,
+						CacheCreationTokens: usage.CacheCreationInputTokens,
+					})
+				}
+			}
+		}
+	}
+	return b.t
+}
+
+// addClaudeUser handles a user line: either a new prompt or tool results.
+func addClaudeUser(b *builder, msg *session.JSONLMessage, at time.Time) {
+	if msg.Message == nil {
+		return
+	}
+	// Typed prompts are stored as a plain string.
+	var prompt string
+	if json.Unmarshal(msg.Message.Content, &prompt) == nil {
+		b.startTurn(prompt, at)
+		return
+	}
+
+	var texts []string
+	for _, block := range msg.GetContentBlocks() {
+		switch block.Type {
+		case "tool_result":
+			b.setOutput(block.ToolUseID, toolResultText(block.Content), block.IsError)
+		case "text":
+			texts = append(texts, block.Text)
+		}
+	}
+	if len(texts) > 0 {
+		b.startTurn(strings.Join(texts, "\n"), at)
+	}
+	if todos := msg.GetTodos(); len(todos) > 0 {
+		b.turn().Todos = convertTodos(todos)
+	}
+}
+
+// toolResultText flattens tool_result content, which is either a string or
+// an array of text blocks.
+func toolResultText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var blocks []session.JSONLContentBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return string(raw)
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func todoWriteInput(input json.RawMessage) []Todo {
+	var in struct {
+		Todos []session.TodoItem `json:"todos"`
+	}
+	if json.Unmarshal(input, &in) != nil || len(in.Todos) == 0 {
+		return nil
+	}
+	return convertTodos(in.Todos)
+}
+
+func convertTodos(items []session.TodoItem) []Todo {
+	todos := make([]Todo, len(items))
+	for i, item := range items {
+		todos[i] = Todo{Content: item.Content, Status: item.Status}
+	}
+	return todos
+}
@@ -0,0 +1,75 @@
+package transcript
+
+import (
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// codexProvider is the Transcript.Provider value for Codex sessions.
+const codexProvider = "codex"
+
+// FromCodexRollout reads a Codex rollout file
+// (CODEX_HOME/sessions/YYYY/MM/DD/rollout-*.jsonl) and builds its transcript.
+func FromCodexRollout(path string) (*Transcript, error) {
+	messages, err := rollout.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromCodexMessages(messages), nil
+}
+
+// FromCodexMessages builds a transcript from parsed rollout messages.
+// Context Codex injects as user messages is not treated as a prompt.
+func FromCodexMessages(messages []rollout.Message) *Transcript {
+	b := newBuilder(codexProvider)
+
+	for i := range messages {
+		msg := &messages[i]
+		at, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+		b.seen(at)
+
+		switch {
+		case msg.SessionMeta != nil:
+			b.t.SessionID = msg.SessionMeta.ID
+			b.t.WorkDir = msg.SessionMeta.CWD
+		case msg.TurnContext != nil:
+			if model := msg.GetModel(); model != "" {
+				b.t.Model = model
+				if b.turnOpen && b.turn().Model == "" {
+					b.turn().Model = model
+				}
+			}
+		case msg.IsUser():
+			if !msg.IsInjectedContext() {
+				b.startTurn(msg.GetText(), at)
+			}
+		case msg.IsAssistant():
+			b.addText(EntryText, msg.GetText())
+		case msg.Item != nil && msg.Item.Type == rollout.ItemReasoning:
+			b.addText(EntryThinking, msg.GetText())
+		case msg.IsToolCall():
+			call := msg.GetToolCall()
+			b.addTool(ToolCall{ID: call.CallID, Name: call.Name, Input: call.Arguments})
+			if plan := msg.GetPlan(); len(plan) > 0 {
+				todos := make([]Todo, len(plan))
+				for j, step := range plan {
+					todos[j] = Todo{Content: step.Step, Status: step.Status}
+				}
+				b.turn().Todos = todos
+			}
+		case msg.IsToolOutput():
+			output, isError := msg.GetToolOutput()
+			b.setOutput(msg.Item.CallID, output, isError)
+		default:
+			if u := msg.GetUsage(); u != nil {
+				b.addUsage(Usage{
+					InputTokens:     u.InputTokens,
+					OutputTokens:    u.OutputTokens,
+					CacheReadTokens: u.CachedInputTokens,
+				})
+			}
+		}
+	}
+	return b.t
+}
@@ -0,0 +1,27 @@
+// Package transcript builds readable, provider-neutral transcripts of agent
+// sessions and renders them for PRs and tickets.
+//
+// A Transcript is a list of turns. Each turn holds the user's prompt and the
+// agent's text, thinking and tool calls in order, with tool outputs attached
+// to their calls, the last todo or plan snapshot, and the turn's usage.
+//
+// Transcripts are built from three sources:
+//
+//	t, err := transcript.FromClaudeJSONL("~/.claude/projects/.../<id>.jsonl")
+//	t, err := transcript.FromCodexRollout("~/.codex/sessions/2025/01/02/rollout-....jsonl")
+//	t := transcript.FromEvents("claude", prompts, chunks) // collected from Session.Events
+//
+// # Rendering
+//
+// Render writes Markdown, a self-contained HTML page, or the normalized JSON
+// model:
+//
+//	err := t.Render(os.Stdout, transcript.Markdown,
+//	    transcript.WithCollapse(40),
+//	    transcript.WithRedaction(),
+//	)
+//
+// WithCollapse cuts long tool outputs; WithRedaction masks API keys, bearer
+// tokens, private keys and password-like values before anything is written.
+// Rendering never modifies the Transcript.
+package transcript
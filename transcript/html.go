@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"title":    capitalize,
+	"usage":    formatUsage,
+	"json":     prettyJSON,
+	"checkbox": todoCheckbox,
+	"time":     func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{title .T.Provider}} transcript{{with .T.SessionID}} {{.}}{{end}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;max-width:960px;margin:2em auto;padding:0 1em;color:#1f2328;line-height:1.5}
+h1{font-size:1.6em}h2{font-size:1.2em;border-bottom:1px solid #d0d7de;padding-bottom:.3em;margin-top:2em}
+dl.meta{display:grid;grid-template-columns:max-content 1fr;gap:.2em 1em}dl.meta dt{font-weight:600}dl.meta dd{margin:0}
+.prompt{background:#ddf4ff;border-left:4px solid #0969da;padding:.5em 1em;white-space:pre-wrap}
+.text{white-space:pre-wrap}
+.thinking{color:#656d76;font-style:italic;white-space:pre-wrap}
+.tool{border:1px solid #d0d7de;border-radius:6px;margin:1em 0;padding:.5em 1em}
+.tool.error{border-color:#cf222e}
+.tool-name{font-family:monospace;font-weight:600}
+pre{background:#f6f8fa;padding:.75em;overflow-x:auto;white-space:pre-wrap;word-break:break-word}
+.usage{color:#656d76;font-size:.9em}
+ul.todos{list-style:none;padding-left:1em}
+</style>
+</head>
+<body>
+<h1>{{title .T.Provider}} transcript</h1>
+<dl class="meta">
+{{with .T.SessionID}}<dt>Session</dt><dd>{{.}}</dd>{{end}}
+{{with .T.Model}}<dt>Model</dt><dd>{{.}}</dd>{{end}}
+{{with .T.WorkDir}}<dt>Working directory</dt><dd>{{.}}</dd>{{end}}
+{{if not .T.StartedAt.IsZero}}<dt>Started</dt><dd>{{time .T.StartedAt}}</dd>{{end}}
+{{if not .T.EndedAt.IsZero}}<dt>Ended</dt><dd>{{time .T.EndedAt}}</dd>{{end}}
+{{with usage .T.Usage}}<dt>Usage</dt><dd>{{.}}</dd>{{end}}
+</dl>
+{{range .T.Turns}}
+<section class="turn">
+<h2>Turn {{.Index}}</h2>
+{{with .Prompt}}<div class="prompt">{{.}}</div>{{end}}
+{{range .Entries}}
+{{if eq .Kind "text"}}<div class="text">{{.Text}}</div>
+{{else if eq .Kind "thinking"}}<details><summary>Thinking</summary><div class="thinking">{{.Text}}</div></details>
+{{else if .Tool}}{{with .Tool}}<div class="tool{{if .IsError}} error{{end}}">
+<div class="tool-name">{{.Name}}{{if .IsError}} (error){{end}}</div>
+{{with json .Input}}<pre>{{.}}</pre>{{end}}
+{{if .Output}}{{if $.Collapse}}<details><summary>Output</summary><pre>{{.Output}}</pre></details>{{else}}<pre>{{.Output}}</pre>{{end}}{{end}}
+</div>{{end}}
+{{end}}
+{{end}}
+{{with .Todos}}<ul class="todos">{{range .}}<li>{{checkbox .Status}} {{.Content}}</li>{{end}}</ul>{{end}}
+{{with usage .Usage}}<p class="usage">{{.}}</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, t *Transcript, cfg renderConfig) error {
+	return htmlTemplate.Execute(w, struct {
+		T        *Transcript
+		Collapse bool
+	}{T: t, Collapse: cfg.collapseLines > 0})
+}
@@ -0,0 +1,127 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+func renderMarkdown(w io.Writer, t *Transcript, cfg renderConfig) error {
+	bw := bufio.NewWriter(w)
+
+	title := "Transcript"
+	if t.Provider != "" {
+		title = fmt.Sprintf("%s transcript", capitalize(t.Provider))
+	}
+	fmt.Fprintf(bw, "# %s\n\n", title)
+	writeMarkdownField(bw, "Session", t.SessionID)
+	writeMarkdownField(bw, "Model", t.Model)
+	writeMarkdownField(bw, "Working directory", t.WorkDir)
+	if !t.StartedAt.IsZero() {
+		writeMarkdownField(bw, "Started", t.StartedAt.UTC().Format(time.RFC3339))
+	}
+	if !t.EndedAt.IsZero() {
+		writeMarkdownField(bw, "Ended", t.EndedAt.UTC().Format(time.RFC3339))
+	}
+	writeMarkdownField(bw, "Usage", formatUsage(t.Usage))
+
+	for _, turn := range t.Turns {
+		fmt.Fprintf(bw, "\n## Turn %d\n", turn.Index)
+		if turn.Prompt != "" {
+			fmt.Fprintf(bw, "\n**User**\n\n%s\n", quote(turn.Prompt))
+		}
+		for _, e := range turn.Entries {
+			switch e.Kind {
+			case EntryText:
+				fmt.Fprintf(bw, "\n%s\n", e.Text)
+			case EntryThinking:
+				fmt.Fprintf(bw, "\n<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n", e.Text)
+			case EntryToolCall:
+				writeMarkdownTool(bw, e.Tool, cfg)
+			}
+		}
+		if len(turn.Todos) > 0 {
+			bw.WriteString("\n**Todos**\n\n")
+			for _, todo := range turn.Todos {
+				fmt.Fprintf(bw, "- %s %s\n", todoCheckbox(todo.Status), todo.Content)
+			}
+		}
+		if usage := formatUsage(turn.Usage); usage != "" {
+			fmt.Fprintf(bw, "\n_%s_\n", usage)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeMarkdownField(w *bufio.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "- **%s:** %s\n", name, value)
+	}
+}
+
+func writeMarkdownTool(w *bufio.Writer, call *ToolCall, cfg renderConfig) {
+	status := ""
+	if call.IsError {
+		status = " (error)"
+	}
+	fmt.Fprintf(w, "\n**Tool: `%s`**%s\n", call.Name, status)
+	if input := prettyJSON(call.Input); input != "" {
+		fmt.Fprintf(w, "\n%s\n", fenced(input, "json"))
+	}
+	if call.Output == "" {
+		return
+	}
+	if cfg.collapseLines > 0 {
+		fmt.Fprintf(w, "\n<details>\n<summary>Output</summary>\n\n%s\n\n</details>\n", fenced(call.Output, ""))
+		return
+	}
+	fmt.Fprintf(w, "\nOutput:\n\n%s\n", fenced(call.Output, ""))
+}
+
+// fenced wraps s in a code fence longer than any backtick run inside it.
+func fenced(s, lang string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + s + "\n" + fence
+}
+
+func quote(s string) string {
+	return "> " + strings.ReplaceAll(s, "\n", "\n> ")
+}
+
+func todoCheckbox(status string) string {
+	switch status {
+	case "completed":
+		return "[x]"
+	case "in_progress":
+		return "[ ] _(in progress)_"
+	default:
+		return "[ ]"
+	}
+}
+
+// formatUsage summarizes u, or returns "" when it is empty.
+func formatUsage(u Usage) string {
+	if u == (Usage{}) {
+		return ""
+	}
+	s := fmt.Sprintf("%d input / %d output tokens", u.InputTokens, u.OutputTokens)
+	if u.CacheReadTokens > 0 || u.CacheCreationTokens > 0 {
+		s += fmt.Sprintf(" (%d cache read, %d cache write)", u.CacheReadTokens, u.CacheCreationTokens)
+	}
+	if u.CostUSD > 0 {
+		s += fmt.Sprintf(", $%.4f", u.CostUSD)
+	}
+	return s
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,196 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format selects a rendering.
+type Format string
+
+const (
+	// Markdown renders GitHub-flavored Markdown, suitable for PR comments.
+	Markdown Format = "markdown"
+	// HTML renders a self-contained page with inline styles.
+	HTML Format = "html"
+	// JSON renders the normalized Transcript model.
+	JSON Format = "json"
+)
+
+// RenderOption configures Render.
+type RenderOption func(*renderConfig)
+
+// renderConfig holds render configuration.
+type renderConfig struct {
+	// Tool outputs longer than this many lines are cut (0 = never)
+	collapseLines int
+
+	// Secret patterns to replace (nil = no redaction)
+	redact []redactRule
+
+	// Whether thinking entries are rendered
+	thinking bool
+}
+
+// defaultRenderConfig returns the default render configuration.
+func defaultRenderConfig() renderConfig {
+	return renderConfig{thinking: true}
+}
+
+// WithCollapse cuts tool outputs longer than maxLines to their first
+// maxLines lines and notes how many were dropped. Markdown and HTML also
+// fold every tool output into a <details> element.
+func WithCollapse(maxLines int) RenderOption {
+	return func(c *renderConfig) { c.collapseLines = maxLines }
+}
+
+// WithRedaction replaces secrets with [REDACTED] in prompts, text, thinking
+// and tool inputs and outputs. The built-in patterns cover API keys for
+// common providers, bearer tokens, private key blocks and password-like
+// key=value pairs; extra adds patterns whose whole match is replaced.
+func WithRedaction(extra ...*regexp.Regexp) RenderOption {
+	return func(c *renderConfig) {
+		c.redact = append([]redactRule(nil), defaultRedactRules...)
+		for _, re := range extra {
+			c.redact = append(c.redact, redactRule{re: re, repl: redacted})
+		}
+	}
+}
+
+// WithThinking controls whether thinking entries are rendered. It defaults
+// to true.
+func WithThinking(include bool) RenderOption {
+	return func(c *renderConfig) { c.thinking = include }
+}
+
+const redacted = "[REDACTED]"
+
+type redactRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+var defaultRedactRules = []redactRule{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), redacted},
+	{regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{16,}`), redacted},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), redacted},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), redacted},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), redacted},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), redacted},
+	{regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]{16,}`), "${1}" + redacted},
+	// key=value, key: value and JSON "key": "value" forms.
+	{regexp.MustCompile(`(?i)(\b(?:password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)"?\s*[=:]\s*"?)[^\s"'&,;]+`), "${1}" + redacted},
+}
+
+// Render writes t in format. The transcript itself is not modified.
+func (t *Transcript) Render(w io.Writer, format Format, opts ...RenderOption) error {
+	cfg := defaultRenderConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	prepared := prepare(t, cfg)
+
+	switch format {
+	case Markdown:
+		return renderMarkdown(w, prepared, cfg)
+	case HTML:
+		return renderHTML(w, prepared, cfg)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(prepared)
+	default:
+		return fmt.Errorf("unknown transcript format %q", format)
+	}
+}
+
+// prepare returns a copy of t with redaction, collapsing and thinking
+// filtering applied.
+func prepare(t *Transcript, cfg renderConfig) *Transcript {
+	out := *t
+	out.Turns = make([]Turn, len(t.Turns))
+	for i, turn := range t.Turns {
+		turn.Prompt = cfg.redactString(turn.Prompt)
+		entries := make([]Entry, 0, len(turn.Entries))
+		for _, e := range turn.Entries {
+			if e.Kind == EntryThinking && !cfg.thinking {
+				continue
+			}
+			e.Text = cfg.redactString(e.Text)
+			if e.Tool != nil {
+				call := *e.Tool
+				call.Input = cfg.redactJSON(call.Input)
+				call.Output, call.OutputTruncated = collapse(cfg.redactString(call.Output), cfg.collapseLines)
+				e.Tool = &call
+			}
+			entries = append(entries, e)
+		}
+		turn.Entries = entries
+		if turn.Todos != nil {
+			todos := make([]Todo, len(turn.Todos))
+			for j, todo := range turn.Todos {
+				todo.Content = cfg.redactString(todo.Content)
+				todos[j] = todo
+			}
+			turn.Todos = todos
+		}
+		out.Turns[i] = turn
+	}
+	return &out
+}
+
+func (c renderConfig) redactString(s string) string {
+	for _, rule := range c.redact {
+		s = rule.re.ReplaceAllString(s, rule.repl)
+	}
+	return s
+}
+
+// redactJSON redacts a tool input. If a pattern broke the JSON, the
+// redacted text is kept as a JSON string.
+func (c renderConfig) redactJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || len(c.redact) == 0 {
+		return raw
+	}
+	s := c.redactString(string(raw))
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	quoted, _ := json.Marshal(s)
+	return quoted
+}
+
+// collapse cuts s to maxLines lines and reports whether it did.
+func collapse(s string, maxLines int) (string, bool) {
+	if maxLines <= 0 {
+		return s, false
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) <= maxLines {
+		return s, false
+	}
+	kept := strings.Join(lines[:maxLines], "\n")
+	return fmt.Sprintf("%s\n… (%d more lines)", kept, len(lines)-maxLines), true
+}
+
+// prettyJSON indents raw for display, falling back to the raw text.
+func prettyJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v any
+	if json.Unmarshal(raw, &v) != nil {
+		return string(raw)
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(raw)
+	}
+	return string(b)
+}
@@ -0,0 +1,239 @@
+package transcript
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2"
+)
+
+// EntryKind identifies what an Entry holds.
+type EntryKind string
+
+const (
+	EntryText     EntryKind = "text"
+	EntryThinking EntryKind = "thinking"
+	EntryToolCall EntryKind = "tool_call"
+)
+
+// Transcript is a provider-neutral record of an agent session.
+type Transcript struct {
+	Provider  string    `json:"provider"`
+	SessionID string    `json:"session_id,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	WorkDir   string    `json:"work_dir,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Turns     []Turn    `json:"turns"`
+	Usage     Usage     `json:"usage"`
+}
+
+// Turn is one user prompt and everything the agent did in response.
+type Turn struct {
+	Index     int       `json:"index"`
+	Prompt    string    `json:"prompt,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Entries   []Entry   `json:"entries"`
+	// Todos is the last todo or plan snapshot written during the turn.
+	Todos []Todo `json:"todos,omitempty"`
+	Usage Usage  `json:"usage"`
+}
+
+// Entry is one piece of agent output, in order.
+type Entry struct {
+	Kind EntryKind `json:"kind"`
+	Text string    `json:"text,omitempty"`
+	Tool *ToolCall `json:"tool,omitempty"`
+}
+
+// ToolCall is a tool invocation and its result.
+type ToolCall struct {
+	ID      string          `json:"id,omitempty"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Output  string          `json:"output,omitempty"`
+	IsError bool            `json:"is_error,omitempty"`
+	// OutputTruncated is set when rendering collapsed the output.
+	OutputTruncated bool `json:"output_truncated,omitempty"`
+}
+
+// Todo is one item of a Claude TodoWrite list or Codex plan.
+type Todo struct {
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// Usage is token usage and cost for a turn or a whole transcript.
+type Usage struct {
+	InputTokens         int     `json:"input_tokens"`
+	OutputTokens        int     `json:"output_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int     `json:"cache_creation_tokens,omitempty"`
+	CostUSD             float64 `json:"cost_usd,omitempty"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CostUSD += other.CostUSD
+}
+
+// builder accumulates turns for the provider-specific constructors.
+type builder struct {
+	t        *Transcript
+	tools    map[string]*ToolCall
+	turnOpen bool
+
+	// mergeText joins consecutive text entries, for streamed fragments.
+	mergeText bool
+}
+
+func newBuilder(provider string) *builder {
+	return &builder{
+		t:     &Transcript{Provider: provider},
+		tools: make(map[string]*ToolCall),
+	}
+}
+
+// startTurn begins a turn for prompt.
+func (b *builder) startTurn(prompt string, at time.Time) {
+	b.t.Turns = append(b.t.Turns, Turn{Index: len(b.t.Turns) + 1, Prompt: prompt, StartedAt: at, Model: b.t.Model})
+	b.turnOpen = true
+}
+
+// turn returns the current turn, opening one without a prompt when the
+// agent speaks first.
+func (b *builder) turn() *Turn {
+	if !b.turnOpen {
+		b.startTurn("", time.Time{})
+	}
+	return &b.t.Turns[len(b.t.Turns)-1]
+}
+
+func (b *builder) addText(kind EntryKind, text string) {
+	if text == "" {
+		return
+	}
+	turn := b.turn()
+	if n := len(turn.Entries); b.mergeText && n > 0 && turn.Entries[n-1].Kind == kind {
+		turn.Entries[n-1].Text += text
+		return
+	}
+	turn.Entries = append(turn.Entries, Entry{Kind: kind, Text: text})
+}
+
+func (b *builder) addTool(call ToolCall) {
+	turn := b.turn()
+	turn.Entries = append(turn.Entries, Entry{Kind: EntryToolCall, Tool: &call})
+	if call.ID != "" {
+		b.tools[call.ID] = turn.Entries[len(turn.Entries)-1].Tool
+	}
+}
+
+// setOutput attaches a tool result to the call with id.
+func (b *builder) setOutput(id, output string, isError bool) {
+	if call, ok := b.tools[id]; ok {
+		call.Output = output
+		call.IsError = isError
+	}
+}
+
+func (b *builder) addUsage(u Usage) {
+	b.turn().Usage.add(u)
+	b.t.Usage.add(u)
+}
+
+func (b *builder) seen(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if b.t.StartedAt.IsZero() || at.Before(b.t.StartedAt) {
+		b.t.StartedAt = at
+	}
+	if at.After(b.t.EndedAt) {
+		b.t.EndedAt = at
+	}
+}
+
+// FromEvents builds a transcript from a live session's events. prompts
+// holds the text sent for each turn in order; turns end at each Done chunk.
+func FromEvents(provider string, prompts []string, chunks []llmkit.StreamChunk) *Transcript {
+	b := newBuilder(provider)
+	b.mergeText = true
+	next := 0
+	for _, chunk := range chunks {
+		if !b.turnOpen && next < len(prompts) {
+			b.startTurn(prompts[next], time.Time{})
+			next++
+		}
+		if b.t.SessionID == "" && chunk.SessionID != "" {
+			b.t.SessionID = chunk.SessionID
+		}
+		if chunk.Model != "" {
+			if b.t.Model == "" {
+				b.t.Model = chunk.Model
+			}
+			if b.turnOpen && b.turn().Model == "" {
+				b.turn().Model = chunk.Model
+			}
+		}
+
+		switch chunk.Type {
+		case "session":
+			if dir, ok := chunk.Metadata["cwd"].(string); ok && b.t.WorkDir == "" {
+				b.t.WorkDir = dir
+			}
+		case "assistant":
+			b.addText(EntryText, chunk.Content)
+		case "tool_call":
+			for _, call := range chunk.ToolCalls {
+				b.addTool(ToolCall{ID: call.ID, Name: call.Name, Input: call.Arguments})
+			}
+		case "tool_result":
+			for _, result := range chunk.ToolResults {
+				if _, ok := b.tools[result.ID]; !ok {
+					b.addTool(ToolCall{ID: result.ID, Name: result.Name})
+				}
+				isError := result.Status == "failed" || (result.ExitCode != nil && *result.ExitCode != 0)
+				b.setOutput(result.ID, result.Output, isError)
+			}
+		case "final":
+			if chunk.FinalContent != "" && !b.hasText() {
+				b.addText(EntryText, chunk.FinalContent)
+			}
+			if chunk.Usage != nil || chunk.CostUSD > 0 {
+				u := Usage{CostUSD: chunk.CostUSD}
+				if chunk.Usage != nil {
+					u.InputTokens = chunk.Usage.InputTokens
+					u.OutputTokens = chunk.Usage.OutputTokens
+					u.CacheReadTokens = chunk.Usage.CacheReadInputTokens
+					u.CacheCreationTokens = chunk.Usage.CacheCreationInputTokens
+				}
+				b.addUsage(u)
+			}
+		}
+		if chunk.Done {
+			b.turnOpen = false
+		}
+	}
+	for ; next < len(prompts); next++ {
+		b.startTurn(prompts[next], time.Time{})
+	}
+	return b.t
+}
+
+// hasText reports whether the open turn already has agent text.
+func (b *builder) hasText() bool {
+	if !b.turnOpen {
+		return false
+	}
+	for _, e := range b.turn().Entries {
+		if e.Kind == EntryText {
+			return true
+		}
+	}
+	return false
+}
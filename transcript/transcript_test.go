@@ -0,0 +1,182 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/randalmurphal/llmkit/v2"
+)
+
+func TestFromClaudeJSONL(t *testing.T) {
+	tr, err := FromClaudeJSONL("testdata/claude.jsonl")
+	if err != nil {
+		t.Fatalf("FromClaudeJSONL returned error: %v", err)
+	}
+	if tr.Provider != "claude" || tr.SessionID != "sess-1" || tr.WorkDir != "/repo" || tr.Model != "claude-sonnet-4" {
+		t.Fatalf("header = %+v", tr)
+	}
+	if len(tr.Turns) != 2 {
+		t.Fatalf("turns = %d, want 2", len(tr.Turns))
+	}
+
+	first := tr.Turns[0]
+	if first.Prompt != "Fix the failing test" {
+		t.Fatalf("prompt = %q", first.Prompt)
+	}
+	kinds := []EntryKind{EntryThinking, EntryToolCall, EntryToolCall, EntryText}
+	if len(first.Entries) != len(kinds) {
+		t.Fatalf("entries = %+v", first.Entries)
+	}
+	for i, kind := range kinds {
+		if first.Entries[i].Kind != kind {
+			t.Fatalf("entry %d kind = %s, want %s", i, first.Entries[i].Kind, kind)
+		}
+	}
+	bash := first.Entries[1].Tool
+	if bash.Name != "Bash" || !bash.IsError || !strings.HasPrefix(bash.Output, "FAIL") {
+		t.Fatalf("bash call = %+v", bash)
+	}
+	if len(first.Todos) != 1 || first.Todos[0].Status != "completed" {
+		t.Fatalf("todos = %+v, want the completed snapshot from the tool result", first.Todos)
+	}
+	// msg_1 repeats its usage on two lines; the sidechain is excluded.
+	if first.Usage.InputTokens != 170 || first.Usage.OutputTokens != 35 || first.Usage.CacheReadTokens != 50 {
+		t.Fatalf("turn usage = %+v", first.Usage)
+	}
+	if tr.Usage.InputTokens != 180 {
+		t.Fatalf("total input tokens = %d, want 180", tr.Usage.InputTokens)
+	}
+	if tr.Turns[1].Prompt != "Thanks" || tr.StartedAt.IsZero() || !tr.EndedAt.After(tr.StartedAt) {
+		t.Fatalf("second turn or times wrong: %+v", tr)
+	}
+}
+
+func TestFromCodexRollout(t *testing.T) {
+	tr, err := FromCodexRollout("testdata/codex-rollout.jsonl")
+	if err != nil {
+		t.Fatalf("FromCodexRollout returned error: %v", err)
+	}
+	if tr.SessionID != "thread-1" || tr.Model != "gpt-5-codex" || tr.WorkDir != "/repo" {
+		t.Fatalf("header = %+v", tr)
+	}
+	if len(tr.Turns) != 1 || tr.Turns[0].Prompt != "List the files" {
+		t.Fatalf("turns = %+v, want one turn without the environment context", tr.Turns)
+	}
+	turn := tr.Turns[0]
+	if len(turn.Entries) != 4 || turn.Entries[0].Kind != EntryThinking || turn.Entries[3].Text != "Two files: a.go and b.go." {
+		t.Fatalf("entries = %+v", turn.Entries)
+	}
+	shell := turn.Entries[2].Tool
+	if shell.Name != "shell" || shell.Output != "a.go\nb.go\n" || shell.IsError {
+		t.Fatalf("shell call = %+v", shell)
+	}
+	if len(turn.Todos) != 1 || turn.Todos[0].Content != "List files" {
+		t.Fatalf("todos = %+v", turn.Todos)
+	}
+	if turn.Usage.InputTokens != 500 || turn.Usage.CacheReadTokens != 100 || turn.Model != "gpt-5-codex" {
+		t.Fatalf("turn = %+v", turn)
+	}
+}
+
+func TestFromEvents(t *testing.T) {
+	chunks := []llmkit.StreamChunk{
+		{Type: "session", SessionID: "s1", Model: "m1", Metadata: map[string]any{"cwd": "/w"}},
+		{Type: "assistant", Content: "Running"},
+		{Type: "tool_call", ToolCalls: []llmkit.ToolCall{{ID: "t1", Name: "Read", Arguments: json.RawMessage(`{"path":"x"}`)}}},
+		{Type: "tool_result", ToolResults: []llmkit.ToolResult{{ID: "t1", Output: "contents"}}},
+		{Type: "final", FinalContent: "Done", Usage: &llmkit.TokenUsage{InputTokens: 7, OutputTokens: 3}, CostUSD: 0.01, Done: true},
+		{Type: "final", FinalContent: "Second answer", Done: true},
+	}
+	tr := FromEvents("claude", []string{"first", "second"}, chunks)
+	if tr.SessionID != "s1" || tr.WorkDir != "/w" || len(tr.Turns) != 2 {
+		t.Fatalf("transcript = %+v", tr)
+	}
+	first := tr.Turns[0]
+	if first.Prompt != "first" || len(first.Entries) != 2 || first.Entries[1].Tool.Output != "contents" {
+		t.Fatalf("first turn = %+v", first)
+	}
+	if first.Usage.InputTokens != 7 || first.Usage.CostUSD != 0.01 {
+		t.Fatalf("first turn usage = %+v", first.Usage)
+	}
+	if second := tr.Turns[1]; second.Prompt != "second" || second.Entries[0].Text != "Second answer" {
+		t.Fatalf("second turn = %+v", second)
+	}
+}
+
+func TestRenderCollapsesAndRedacts(t *testing.T) {
+	tr, err := FromClaudeJSONL("testdata/claude.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := regexp.MustCompile(`line4`)
+	opts := []RenderOption{WithCollapse(2), WithRedaction(extra), WithThinking(false)}
+
+	for _, format := range []Format{Markdown, HTML, JSON} {
+		var buf bytes.Buffer
+		if err := tr.Render(&buf, format, opts...); err != nil {
+			t.Fatalf("Render(%s) returned error: %v", format, err)
+		}
+		out := buf.String()
+		if strings.Contains(out, "sk-abcdefghijklmnopqrstuvwxyz") {
+			t.Fatalf("%s output leaks the API key:\n%s", format, out)
+		}
+		if !strings.Contains(out, "2 more lines") {
+			t.Fatalf("%s output is not collapsed:\n%s", format, out)
+		}
+		if strings.Contains(out, "Look at the test first.") {
+			t.Fatalf("%s output includes thinking", format)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tr.Render(&buf, HTML); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "<b>test</b>") {
+		t.Fatal("HTML output does not escape agent text")
+	}
+
+	buf.Reset()
+	if err := tr.Render(&buf, JSON, WithRedaction()); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Transcript
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON output does not decode: %v", err)
+	}
+	if !strings.Contains(decoded.Turns[0].Entries[1].Tool.Output, "OPENAI_API_KEY=[REDACTED]") {
+		t.Fatalf("redacted output = %q", decoded.Turns[0].Entries[1].Tool.Output)
+	}
+	if !strings.Contains(tr.Turns[0].Entries[1].Tool.Output, "sk-") {
+		t.Fatal("Render modified the transcript")
+	}
+
+	if err := tr.Render(&buf, Format("pdf")); err == nil {
+		t.Fatal("Render should reject unknown formats")
+	}
+}
+
+func TestDefaultRedactionKeyValueForms(t *testing.T) {
+	cfg := defaultRenderConfig()
+	WithRedaction()(&cfg)
+
+	for in, want := range map[string]string{
+		"password=hunter2hunter2":                 "password=[REDACTED]",
+		"export API_KEY: abc123secretvalue":       "export API_KEY: [REDACTED]",
+		`{"api_key":"abc123secretvalue"}`:         `{"api_key":"[REDACTED]"}`,
+		`{"auth_token": "tok123", "user": "bob"}`: `{"auth_token": "[REDACTED]", "user": "bob"}`,
+		"no secrets here":                         "no secrets here",
+	} {
+		if got := cfg.redactString(in); got != want {
+			t.Errorf("redactString(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	input := cfg.redactJSON(json.RawMessage(`{"command":"deploy","secret":"s3cr3tvalue"}`))
+	if string(input) != `{"command":"deploy","secret":"[REDACTED]"}` {
+		t.Fatalf("redactJSON = %s", input)
+	}
+}
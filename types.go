@@ -60,6 +60,17 @@ func NewImageBase64Message(role Role, text, base64Data, mediaType string) Messag
 	}
 }
 
+// NewDocumentMessage creates a message with text and a PDF or plain-text document read from path.
+func NewDocumentMessage(role Role, text, path string) Message {
+	return Message{
+		Role: role,
+		ContentParts: []ContentPart{
+			{Type: "text", Text: text},
+			{Type: "document", FilePath: path},
+		},
+	}
+}
+
 func (m Message) IsMultimodal() bool {
 	return len(m.ContentParts) > 0
 }
@@ -95,6 +106,21 @@ type Tool struct {
 // MCPServerConfig defines an MCP server using the shared llmkit contract.
 type MCPServerConfig = contract.MCPServerConfig
 
+// HookDefinition is a provider-neutral command hook; see contract.HookDefinition.
+type HookDefinition = contract.HookDefinition
+
+// HookEvent is a provider-neutral hook event.
+type HookEvent = contract.HookEvent
+
+// Provider-neutral hook events.
+const (
+	HookBeforeTool   = contract.HookBeforeTool
+	HookAfterTool    = contract.HookAfterTool
+	HookPromptSubmit = contract.HookPromptSubmit
+	HookSessionStart = contract.HookSessionStart
+	HookStop         = contract.HookStop
+)
+
 type SessionMetadata struct {
 	Provider string          `json:"provider"`
 	Data     json.RawMessage `json:"data"`
@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"time"
+)
+
+// BlockDuration is the length of a subscription billing window.
+const BlockDuration = 5 * time.Hour
+
+// Block is a billing window: it opens at the hour of the first request after
+// a previous window ended and lasts BlockDuration.
+type Block struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	LastActivity time.Time `json:"last_activity"`
+	// Active is true when now falls inside the window.
+	Active bool `json:"active"`
+	Row
+	// TokensPerMinute is the burn rate between the window's first and last
+	// request.
+	TokensPerMinute float64 `json:"tokens_per_minute"`
+	// ProjectedCostUSD extends the current cost rate to End. It is only set
+	// for the active block.
+	ProjectedCostUSD float64 `json:"projected_cost_usd,omitempty"`
+}
+
+// Blocks splits entries into 5-hour billing windows, oldest first. A window
+// starts at the first request's hour (UTC) and ends BlockDuration later, or
+// early when BlockDuration passes without a request. Pass entries from one
+// provider; Claude and Codex subscriptions meter separately.
+func Blocks(entries []Entry, now time.Time) []Block {
+	var blocks []Block
+	var cur *Block
+	for _, e := range entries {
+		if cur == nil || !e.Timestamp.Before(cur.End) || e.Timestamp.Sub(cur.LastActivity) >= BlockDuration {
+			start := e.Timestamp.UTC().Truncate(time.Hour)
+			blocks = append(blocks, Block{Start: start, End: start.Add(BlockDuration)})
+			cur = &blocks[len(blocks)-1]
+		}
+		cur.Row.add(e)
+		cur.LastActivity = cur.Row.Last
+	}
+
+	for i := range blocks {
+		b := &blocks[i]
+		b.Key = b.Start.Format(time.RFC3339)
+		if minutes := b.Last.Sub(b.First).Minutes(); minutes > 0 {
+			b.TokensPerMinute = float64(b.TotalTokens) / minutes
+		}
+		b.Active = !now.Before(b.Start) && now.Before(b.End) && now.Sub(b.LastActivity) < BlockDuration
+		if b.Active {
+			b.ProjectedCostUSD = b.CostUSD
+			if elapsed := now.Sub(b.Start).Minutes(); elapsed > 0 {
+				b.ProjectedCostUSD += b.CostUSD / elapsed * b.End.Sub(now).Minutes()
+			}
+		}
+	}
+	return blocks
+}
+
+// ActiveBlock returns the block containing now, if any.
+func ActiveBlock(entries []Entry, now time.Time) (Block, bool) {
+	blocks := Blocks(entries, now)
+	if n := len(blocks); n > 0 && blocks[n-1].Active {
+		return blocks[n-1], true
+	}
+	return Block{}, false
+}
@@ -0,0 +1,323 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/randalmurphal/llmkit/v2"
+	"github.com/randalmurphal/llmkit/v2/claude/jsonl"
+	"github.com/randalmurphal/llmkit/v2/codex/rollout"
+)
+
+// Providers whose session files are read.
+const (
+	ProviderClaude = "claude"
+	ProviderCodex  = "codex"
+)
+
+// Option configures Collect and Aggregate.
+type Option func(*config)
+
+// config holds collection and reporting configuration.
+type config struct {
+	// Claude projects directory ("" = skipped)
+	claudeDir string
+
+	// Codex sessions directory ("" = skipped)
+	codexDir string
+
+	// Entry filters (zero = unfiltered)
+	since    time.Time
+	until    time.Time
+	project  string
+	provider string
+
+	// Time zone for ByDay grouping
+	location *time.Location
+}
+
+// defaultConfig returns the default configuration.
+func defaultConfig() config {
+	cfg := config{location: time.Local}
+	if dir, err := jsonl.DefaultProjectsDir(); err == nil {
+		cfg.claudeDir = dir
+	}
+	if dir, err := rollout.DefaultSessionsDir(); err == nil {
+		cfg.codexDir = dir
+	}
+	return cfg
+}
+
+// WithClaudeProjectsDir sets the Claude projects directory. The default is
+// jsonl.DefaultProjectsDir; "" skips Claude.
+func WithClaudeProjectsDir(dir string) Option {
+	return func(c *config) { c.claudeDir = dir }
+}
+
+// WithCodexSessionsDir sets the Codex sessions directory. The default is
+// rollout.DefaultSessionsDir; "" skips Codex.
+func WithCodexSessionsDir(dir string) Option {
+	return func(c *config) { c.codexDir = dir }
+}
+
+// WithTimeRange keeps entries in [since, until). A zero bound is open.
+func WithTimeRange(since, until time.Time) Option {
+	return func(c *config) {
+		c.since = since
+		c.until = until
+	}
+}
+
+// WithProject keeps entries whose project path is dir or is inside it.
+func WithProject(dir string) Option {
+	return func(c *config) { c.project = strings.TrimRight(dir, "/") }
+}
+
+// WithProvider keeps entries from ProviderClaude or ProviderCodex only.
+func WithProvider(provider string) Option {
+	return func(c *config) { c.provider = provider }
+}
+
+// WithLocation sets the time zone that ByDay groups by. The default is
+// time.Local.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		if loc != nil {
+			c.location = loc
+		}
+	}
+}
+
+// Entry is the usage of one model request.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Provider  string    `json:"provider"`
+	Project   string    `json:"project,omitempty"`
+	SessionID string    `json:"session_id"`
+	Model     string    `json:"model,omitempty"`
+	// InputTokens excludes cached input for both providers.
+	InputTokens         int     `json:"input_tokens"`
+	OutputTokens        int     `json:"output_tokens"`
+	CacheCreationTokens int     `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int     `json:"cache_read_tokens,omitempty"`
+	CostUSD             float64 `json:"cost_usd"`
+	// Priced is false when the model has no entry in llmkit.ModelPrices.
+	Priced bool `json:"priced"`
+}
+
+// TotalTokens returns all tokens of the entry, including cache tokens.
+func (e Entry) TotalTokens() int {
+	return e.InputTokens + e.OutputTokens + e.CacheCreationTokens + e.CacheReadTokens
+}
+
+// Collect reads every Claude and Codex session file and returns the
+// filtered usage entries, oldest first. Claude repeats a request's usage on
+// each content block and copies history into resumed sessions, so entries
+// are deduplicated by message and request ID.
+func Collect(ctx context.Context, opts ...Option) ([]Entry, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var entries []Entry
+	if cfg.claudeDir != "" && cfg.provider != ProviderCodex && dirExists(cfg.claudeDir) {
+		files, err := jsonl.FindSessionFiles(cfg.claudeDir)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, path := range files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if !cfg.mayContain(path) {
+				continue
+			}
+			found, err := claudeEntries(path, seen)
+			if err != nil {
+				continue // Unreadable files are skipped, as in jsonl.FindSessionFiles
+			}
+			entries = append(entries, cfg.filter(found)...)
+		}
+	}
+	if cfg.codexDir != "" && cfg.provider != ProviderClaude && dirExists(cfg.codexDir) {
+		files, err := rollout.FindRolloutFiles(cfg.codexDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if !cfg.mayContain(path) {
+				continue
+			}
+			found, err := codexEntries(path)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, cfg.filter(found)...)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// mayContain reports whether a file modified at its mtime can hold entries
+// after since.
+func (c config) mayContain(path string) bool {
+	if c.since.IsZero() {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.ModTime().Before(c.since)
+}
+
+func (c config) filter(entries []Entry) []Entry {
+	kept := entries[:0]
+	for _, e := range entries {
+		if !c.since.IsZero() && e.Timestamp.Before(c.since) {
+			continue
+		}
+		if !c.until.IsZero() && !e.Timestamp.Before(c.until) {
+			continue
+		}
+		if c.project != "" && e.Project != c.project && !strings.HasPrefix(e.Project, c.project+"/") {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// claudeLine holds the JSONL fields used for deduplication and projects.
+type claudeLine struct {
+	CWD       string `json:"cwd"`
+	RequestID string `json:"requestId"`
+	Message   struct {
+		ID string `json:"id"`
+	} `json:"message"`
+}
+
+func claudeEntries(path string, seen map[string]bool) ([]Entry, error) {
+	messages, err := jsonl.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Claude names project dirs after the normalized working directory.
+	project := filepath.Base(filepath.Dir(path))
+
+	var entries []Entry
+	for _, msg := range messages {
+		u := msg.GetUsage()
+		if !msg.IsAssistant() || u == nil {
+			continue
+		}
+		var line claudeLine
+		_ = json.Unmarshal(msg.Raw, &line) // Optional fields
+		if line.CWD != "" {
+			project = line.CWD
+		}
+		if line.Message.ID != "" {
+			key := line.Message.ID + ":" + line.RequestID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		ts, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+		entries = append(entries, priced(Entry{
+			Timestamp:           ts,
+			Provider:            ProviderClaude,
+			Project:             project,
+			SessionID:           msg.SessionID,
+			Model:               msg.GetModel(),
+			InputTokens:         u.InputTokens,
+			OutputTokens:        u.OutputTokens,
+			CacheCreationTokens: u.CacheCreationInputTokens,
+			CacheReadTokens:     u.CacheReadInputTokens,
+		}))
+	}
+	return entries, nil
+}
+
+// codexEntries turns token_count events into entries. Codex reports running
+// totals and may repeat an event, so each entry is the growth of the total
+// since the previous event.
+func codexEntries(path string) ([]Entry, error) {
+	messages, err := rollout.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sessionID, project, model string
+	if info, ok := rollout.ParseFileName(path); ok {
+		sessionID = info.ThreadID
+	}
+
+	var entries []Entry
+	var prev rollout.TokenUsage
+	for _, msg := range messages {
+		switch {
+		case msg.SessionMeta != nil:
+			sessionID = msg.SessionMeta.ID
+			project = msg.SessionMeta.CWD
+		case msg.TurnContext != nil:
+			if msg.TurnContext.CWD != "" {
+				project = msg.TurnContext.CWD
+			}
+			if m := msg.GetModel(); m != "" {
+				model = m
+			}
+		case msg.Event != nil && msg.Event.Info != nil:
+			total := msg.Event.Info.TotalTokenUsage
+			delta := total
+			if total.InputTokens >= prev.InputTokens && total.OutputTokens >= prev.OutputTokens {
+				delta.InputTokens -= prev.InputTokens
+				delta.CachedInputTokens -= prev.CachedInputTokens
+				delta.OutputTokens -= prev.OutputTokens
+			}
+			prev = total
+			if delta.InputTokens == 0 && delta.OutputTokens == 0 {
+				continue
+			}
+			ts, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+			// OpenAI counts cached tokens inside input_tokens.
+			entries = append(entries, priced(Entry{
+				Timestamp:       ts,
+				Provider:        ProviderCodex,
+				Project:         project,
+				SessionID:       sessionID,
+				Model:           model,
+				InputTokens:     delta.InputTokens - delta.CachedInputTokens,
+				OutputTokens:    delta.OutputTokens,
+				CacheReadTokens: delta.CachedInputTokens,
+			}))
+		}
+	}
+	return entries, nil
+}
+
+// priced fills in the entry's cost from llmkit.ModelPrices.
+func priced(e Entry) Entry {
+	e.CostUSD, e.Priced = llmkit.EstimateCost(e.Model, llmkit.Usage{
+		InputTokens:              e.InputTokens,
+		OutputTokens:             e.OutputTokens,
+		CacheCreationInputTokens: e.CacheCreationTokens,
+		CacheReadInputTokens:     e.CacheReadTokens,
+	})
+	return e
+}
+
+func dirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,30 @@
+// Package usage reports token usage and estimated cost across every local
+// Claude Code and Codex session on a workstation.
+//
+// Collect walks ~/.claude/projects and CODEX_HOME/sessions and returns one
+// Entry per model request, including cache creation and cache read tokens,
+// priced through llmkit.ModelPrices. Aggregate groups entries by day,
+// project, model or session, and a Report renders as a table or JSON:
+//
+//	entries, err := usage.Collect(ctx,
+//	    usage.WithTimeRange(time.Now().AddDate(0, 0, -7), time.Time{}),
+//	    usage.WithProject("/work/api"),
+//	)
+//	if err != nil {
+//	    return err
+//	}
+//	usage.Aggregate(entries, usage.ByDay).WriteTable(os.Stdout)
+//
+// # Billing Blocks
+//
+// Subscription plans meter usage in 5-hour windows. Blocks splits entries
+// into those windows and ActiveBlock reports the current one with its burn
+// rate and projected cost:
+//
+//	claude, _ := usage.Collect(ctx, usage.WithProvider(usage.ProviderClaude))
+//	if block, ok := usage.ActiveBlock(claude, time.Now()); ok {
+//	    fmt.Printf("resets at %s, $%.2f so far\n", block.End, block.CostUSD)
+//	}
+//
+// Costs are API-price estimates; subscription users are not billed per token.
+package usage
@@ -0,0 +1,155 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// GroupBy selects how Aggregate groups entries.
+type GroupBy string
+
+const (
+	ByDay     GroupBy = "day"
+	ByProject GroupBy = "project"
+	ByModel   GroupBy = "model"
+	BySession GroupBy = "session"
+)
+
+// Row is the aggregated usage of one group.
+type Row struct {
+	Key                 string    `json:"key"`
+	Models              []string  `json:"models,omitempty"`
+	Requests            int       `json:"requests"`
+	InputTokens         int       `json:"input_tokens"`
+	OutputTokens        int       `json:"output_tokens"`
+	CacheCreationTokens int       `json:"cache_creation_tokens"`
+	CacheReadTokens     int       `json:"cache_read_tokens"`
+	TotalTokens         int       `json:"total_tokens"`
+	CostUSD             float64   `json:"cost_usd"`
+	First               time.Time `json:"first"`
+	Last                time.Time `json:"last"`
+}
+
+func (r *Row) add(e Entry) {
+	r.Requests++
+	r.InputTokens += e.InputTokens
+	r.OutputTokens += e.OutputTokens
+	r.CacheCreationTokens += e.CacheCreationTokens
+	r.CacheReadTokens += e.CacheReadTokens
+	r.TotalTokens += e.TotalTokens()
+	r.CostUSD += e.CostUSD
+	if e.Model != "" {
+		r.Models = addUnique(r.Models, e.Model)
+	}
+	if r.First.IsZero() || e.Timestamp.Before(r.First) {
+		r.First = e.Timestamp
+	}
+	if e.Timestamp.After(r.Last) {
+		r.Last = e.Timestamp
+	}
+}
+
+// Report is usage grouped one way, with a total.
+type Report struct {
+	GroupBy GroupBy `json:"group_by"`
+	Rows    []Row   `json:"rows"`
+	Total   Row     `json:"total"`
+	// UnpricedModels lists models without pricing; their cost counts as zero.
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+}
+
+// Aggregate groups entries. Day rows are in date order; other rows are
+// sorted by cost, highest first. Only WithLocation applies here.
+func Aggregate(entries []Entry, by GroupBy, opts ...Option) Report {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	report := Report{GroupBy: by, Total: Row{Key: "total"}}
+	rows := make(map[string]*Row)
+	for _, e := range entries {
+		key := groupKey(e, by, cfg.location)
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key}
+			rows[key] = row
+		}
+		row.add(e)
+		report.Total.add(e)
+		if !e.Priced && e.Model != "" {
+			report.UnpricedModels = addUnique(report.UnpricedModels, e.Model)
+		}
+	}
+
+	report.Rows = make([]Row, 0, len(rows))
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if by != ByDay && a.CostUSD != b.CostUSD {
+			return a.CostUSD > b.CostUSD
+		}
+		return a.Key < b.Key
+	})
+	return report
+}
+
+func groupKey(e Entry, by GroupBy, loc *time.Location) string {
+	switch by {
+	case ByDay:
+		return e.Timestamp.In(loc).Format("2006-01-02")
+	case ByProject:
+		return e.Project
+	case ByModel:
+		if e.Model == "" {
+			return "unknown"
+		}
+		return e.Model
+	default:
+		return e.Provider + "/" + e.SessionID
+	}
+}
+
+// WriteTable writes the report as an aligned text table.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tRequests\tInput\tOutput\tCache write\tCache read\tTotal\tCost (USD)\t\n", r.GroupBy)
+	for _, row := range append(r.Rows, r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t\n",
+			row.Key, row.Requests, row.InputTokens, row.OutputTokens,
+			row.CacheCreationTokens, row.CacheReadTokens, row.TotalTokens, row.CostUSD)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.UnpricedModels) > 0 {
+		_, err := fmt.Fprintf(w, "unpriced models: %v\n", r.UnpricedModels)
+		return err
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// addUnique inserts v into the sorted slice s if it is missing.
+func addUnique(s []string, v string) []string {
+	i := sort.SearchStrings(s, v)
+	if i < len(s) && s[i] == v {
+		return s
+	}
+	s = append(s, "")
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
@@ -0,0 +1,173 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The second and third lines repeat one request's usage per content block;
+// the resumed session file copies the first request again.
+const claudeSession = `{"type":"assistant","timestamp":"2025-06-01T10:00:00Z","sessionId":"c1","cwd":"/work/api","requestId":"req_1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":1000,"output_tokens":100,"cache_creation_input_tokens":2000,"cache_read_input_tokens":10000}}}
+{"type":"assistant","timestamp":"2025-06-01T10:30:00Z","sessionId":"c1","cwd":"/work/api","requestId":"req_2","message":{"id":"msg_2","role":"assistant","model":"claude-opus-4-5","content":[],"usage":{"input_tokens":10,"output_tokens":1000}}}
+{"type":"assistant","timestamp":"2025-06-01T10:30:01Z","sessionId":"c1","cwd":"/work/api","requestId":"req_2","message":{"id":"msg_2","role":"assistant","model":"claude-opus-4-5","content":[],"usage":{"input_tokens":10,"output_tokens":1000}}}
+{"type":"user","timestamp":"2025-06-01T10:31:00Z","sessionId":"c1","cwd":"/work/api","message":{"role":"user","content":"thanks"}}
+`
+
+const claudeResumed = `{"type":"assistant","timestamp":"2025-06-01T10:00:00Z","sessionId":"c1","cwd":"/work/api","requestId":"req_1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":1000,"output_tokens":100,"cache_creation_input_tokens":2000,"cache_read_input_tokens":10000}}}
+{"type":"assistant","timestamp":"2025-06-02T18:00:00Z","sessionId":"c2","cwd":"/work/api/sub","requestId":"req_3","message":{"id":"msg_3","role":"assistant","model":"claude-haiku-4-5","content":[],"usage":{"input_tokens":500,"output_tokens":50}}}
+`
+
+// Codex totals are cumulative; the repeated event adds nothing.
+const codexRollout = `{"timestamp":"2025-06-02T09:00:00Z","type":"session_meta","payload":{"id":"thread-1","cwd":"/work/web"}}
+{"timestamp":"2025-06-02T09:00:01Z","type":"turn_context","payload":{"cwd":"/work/web","model":"gpt-5-codex"}}
+{"timestamp":"2025-06-02T09:00:05Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100}}}}
+{"timestamp":"2025-06-02T09:00:06Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":100}}}}
+{"timestamp":"2025-06-02T09:01:00Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":3000,"cached_input_tokens":1400,"output_tokens":300},"last_token_usage":{"input_tokens":2000,"cached_input_tokens":1000,"output_tokens":200}}}}
+`
+
+func writeFixtures(t *testing.T) []Option {
+	t.Helper()
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, "projects")
+	codexDir := filepath.Join(root, "sessions")
+	files := map[string]string{
+		filepath.Join(claudeDir, "-work-api", "c1.jsonl"):                                         claudeSession,
+		filepath.Join(claudeDir, "-work-api", "c2.jsonl"):                                         claudeResumed,
+		filepath.Join(codexDir, "2025", "06", "02", "rollout-2025-06-02T09-00-00-thread-1.jsonl"): codexRollout,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []Option{WithClaudeProjectsDir(claudeDir), WithCodexSessionsDir(codexDir)}
+}
+
+func TestCollectDeduplicatesAndPrices(t *testing.T) {
+	opts := writeFixtures(t)
+	entries, err := Collect(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("entries = %d, want 3 Claude and 2 Codex requests: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	// Sonnet: 1000*3 + 100*15 + 2000*3.75 + 10000*0.30 per million.
+	if want := 0.015; math.Abs(first.CostUSD-want) > 1e-9 || !first.Priced {
+		t.Fatalf("first entry cost = %v, want %v", first.CostUSD, want)
+	}
+
+	var codex []Entry
+	for _, e := range entries {
+		if e.Provider == ProviderCodex {
+			codex = append(codex, e)
+		}
+	}
+	if len(codex) != 2 || codex[1].InputTokens != 1000 || codex[1].CacheReadTokens != 1000 || codex[1].OutputTokens != 200 {
+		t.Fatalf("codex entries = %+v", codex)
+	}
+	if codex[0].Project != "/work/web" || codex[0].SessionID != "thread-1" || codex[0].Model != "gpt-5-codex" {
+		t.Fatalf("codex entry = %+v", codex[0])
+	}
+
+	filtered, err := Collect(context.Background(), append(opts,
+		WithProject("/work/api"),
+		WithTimeRange(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), time.Time{}),
+	)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 1 || filtered[0].SessionID != "c2" {
+		t.Fatalf("filtered entries = %+v", filtered)
+	}
+}
+
+func TestAggregateAndRender(t *testing.T) {
+	entries, err := Collect(context.Background(), writeFixtures(t)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	days := Aggregate(entries, ByDay, WithLocation(time.UTC))
+	if len(days.Rows) != 2 || days.Rows[0].Key != "2025-06-01" || days.Rows[0].Requests != 2 {
+		t.Fatalf("day rows = %+v", days.Rows)
+	}
+	if days.Total.Requests != 5 {
+		t.Fatalf("total = %+v", days.Total)
+	}
+
+	models := Aggregate(entries, ByModel)
+	if models.Rows[0].Key != "claude-opus-4-5" {
+		t.Fatalf("most expensive model = %s, want opus", models.Rows[0].Key)
+	}
+
+	sessions := Aggregate(entries, BySession)
+	if len(sessions.Rows) != 3 {
+		t.Fatalf("session rows = %+v", sessions.Rows)
+	}
+
+	var table bytes.Buffer
+	if err := days.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "2025-06-02") || !strings.Contains(table.String(), "total") {
+		t.Fatalf("table:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := Aggregate(entries, ByProject).WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON report does not decode: %v", err)
+	}
+	if decoded.GroupBy != ByProject || len(decoded.Rows) != 3 {
+		t.Fatalf("decoded report = %+v", decoded)
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 6, 1, h, m, 0, 0, time.UTC) }
+	entries := []Entry{
+		{Timestamp: at(9, 20), OutputTokens: 100, CostUSD: 1},
+		{Timestamp: at(11, 20), OutputTokens: 100, CostUSD: 1},
+		{Timestamp: at(14, 10), OutputTokens: 100, CostUSD: 1}, // Past 09:00+5h
+		{Timestamp: at(14, 30), OutputTokens: 100, CostUSD: 1},
+	}
+
+	blocks := Blocks(entries, at(15, 0))
+	if len(blocks) != 2 {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if !blocks[0].Start.Equal(at(9, 0)) || blocks[0].Requests != 2 || blocks[0].Active {
+		t.Fatalf("first block = %+v", blocks[0])
+	}
+	second := blocks[1]
+	if !second.Start.Equal(at(14, 0)) || !second.End.Equal(at(19, 0)) || !second.Active {
+		t.Fatalf("second block = %+v", second)
+	}
+	if second.TokensPerMinute != 10 {
+		t.Fatalf("burn rate = %v, want 10 tokens/min", second.TokensPerMinute)
+	}
+	// $2 in the first hour projects to $10 over five hours.
+	if math.Abs(second.ProjectedCostUSD-10) > 1e-9 {
+		t.Fatalf("projected cost = %v, want 10", second.ProjectedCostUSD)
+	}
+
+	if _, ok := ActiveBlock(entries, at(20, 0)); ok {
+		t.Fatal("no block should be active after the last one ends")
+	}
+}